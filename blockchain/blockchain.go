@@ -21,6 +21,7 @@ import (
 const (
 	BlockGasTargetDivisor uint64 = 1024 // The bound divisor of the gas limit, used in update calculations
 	defaultCacheSize      int    = 100  // The default size for Blockchain LRU cache structures
+	minBaseFee            uint64 = 1    // The floor of the base fee once the London fork is active
 )
 
 var (
@@ -35,6 +36,9 @@ var (
 	ErrInvalidStateRoot     = errors.New("invalid block state root")
 	ErrInvalidGasUsed       = errors.New("invalid block gas used")
 	ErrInvalidReceiptsRoot  = errors.New("invalid block receipts root")
+	ErrInvalidBaseFee       = errors.New("invalid block base fee")
+	ErrInvalidHeadNumber    = errors.New("invalid head number")
+	ErrHeadStateNotFound    = errors.New("state of the head not found")
 )
 
 // Blockchain is a blockchain reference
@@ -70,6 +74,11 @@ type Blockchain struct {
 
 	gpAverage *gasPriceAverage // A reference to the average gas price
 
+	logIndex *logIndex // The index of the logs of the canonical chain
+
+	// stateRetention is the number of the most recent blocks whose state is kept, 0 if every state is kept
+	stateRetention uint64
+
 	writeLock sync.Mutex
 }
 
@@ -90,6 +99,8 @@ type Verifier interface {
 
 type Executor interface {
 	ProcessBlock(parentRoot types.Hash, block *types.Block, blockCreator types.Address) (*state.Transition, error)
+	// StateAt returns the snapshot of the state with the given root, an error if it's not available
+	StateAt(root types.Hash) (state.Snapshot, error)
 }
 
 type TxSigner interface {
@@ -208,6 +219,8 @@ func NewBlockchain(
 		return nil, err
 	}
 
+	b.logIndex = newLogIndex(b.logger, db, LogIndexSectionSize)
+
 	// Push the initial event to the stream
 	b.stream.push(&Event{})
 
@@ -390,6 +403,63 @@ func (b *Blockchain) calculateGasLimit(parentGasLimit uint64) uint64 {
 	return common.Max(blockGasTarget, common.Max(parentGasLimit-delta, 0))
 }
 
+// CalculateBaseFee calculates the EIP-1559 base fee of the block following the parent.
+// The base fee is zero unless the London fork is active and the genesis defines an initial base fee
+func (b *Blockchain) CalculateBaseFee(parent *types.Header) uint64 {
+	if !b.config.Params.Forks.IsLondon(parent.Number+1) || b.config.Genesis.BaseFee == 0 {
+		return 0
+	}
+
+	// The first London block starts with the initial base fee
+	if parent.BaseFee == 0 {
+		return b.config.Genesis.BaseFee
+	}
+
+	elasticityMultiplier := b.config.Genesis.BaseFeeEM
+	if elasticityMultiplier == 0 {
+		elasticityMultiplier = chain.GenesisBaseFeeEM
+	}
+
+	parentGasTarget := parent.GasLimit / elasticityMultiplier
+
+	// If the parent gas used is the same as the target, the base fee remains unchanged
+	if parent.GasUsed == parentGasTarget {
+		return parent.BaseFee
+	}
+
+	// If the parent block used more gas than its target, the base fee should increase
+	if parent.GasUsed > parentGasTarget {
+		gasUsedDelta := parent.GasUsed - parentGasTarget
+		baseFeeDelta := calcBaseFeeDelta(gasUsedDelta, parentGasTarget, parent.BaseFee)
+
+		return parent.BaseFee + common.Max(baseFeeDelta, 1)
+	}
+
+	// Otherwise, the parent block used less gas than its target, and the base fee should decrease
+	gasUsedDelta := parentGasTarget - parent.GasUsed
+	baseFeeDelta := calcBaseFeeDelta(gasUsedDelta, parentGasTarget, parent.BaseFee)
+
+	// the base fee doesn't drop to 0, which would be taken for a chain without base fee
+	if baseFeeDelta >= parent.BaseFee {
+		return minBaseFee
+	}
+
+	return common.Max(parent.BaseFee-baseFeeDelta, minBaseFee)
+}
+
+// calcBaseFeeDelta calculates the base fee change, bounded by chain.BaseFeeChangeDenom
+func calcBaseFeeDelta(gasUsedDelta, parentGasTarget, baseFee uint64) uint64 {
+	if parentGasTarget == 0 {
+		return 0
+	}
+
+	y := new(big.Int).Mul(new(big.Int).SetUint64(baseFee), new(big.Int).SetUint64(gasUsedDelta))
+	y.Div(y, new(big.Int).SetUint64(parentGasTarget))
+	y.Div(y, new(big.Int).SetUint64(chain.BaseFeeChangeDenom))
+
+	return y.Uint64()
+}
+
 // writeGenesis wrapper for the genesis write function
 func (b *Blockchain) writeGenesis(genesis *chain.Genesis) error {
 	header := genesis.GenesisHeader()
@@ -707,6 +777,7 @@ func (b *Blockchain) verifyBlock(block *types.Block) ([]*types.Receipt, error) {
 // - The hashes match up
 // - The block numbers match up
 // - The block gas limit / used matches up
+// - The block base fee matches up
 func (b *Blockchain) verifyBlockParent(childBlock *types.Block) error {
 	// Grab the parent block
 	parentHash := childBlock.ParentHash()
@@ -749,14 +820,95 @@ func (b *Blockchain) verifyBlockParent(childBlock *types.Block) error {
 		return fmt.Errorf("invalid gas limit, %w", gasLimitErr)
 	}
 
+	// Make sure the base fee follows the EIP-1559 rules
+	if expected := b.CalculateBaseFee(parent); childBlock.Header.BaseFee != expected {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidBaseFee, childBlock.Header.BaseFee, expected)
+	}
+
 	return nil
 }
 
+// VerifySyncedBlock verifies the block synced along with its receipts, without executing it.
+// The receipts are checked against the header, whose state root can't be verified
+// until the state is synced
+func (b *Blockchain) VerifySyncedBlock(block *types.Block, receipts []*types.Receipt) (*types.FullBlock, error) {
+	if block == nil {
+		return nil, ErrNoBlock
+	}
+
+	// Make sure the consensus layer verifies this block header
+	if err := b.consensus.VerifyHeader(block.Header); err != nil {
+		return nil, fmt.Errorf("failed to verify the header: %w", err)
+	}
+
+	if err := b.verifyBlockParent(block); err != nil {
+		return nil, err
+	}
+
+	if err := b.verifyBlockRoots(block); err != nil {
+		return nil, err
+	}
+
+	if len(receipts) != len(block.Transactions) {
+		return nil, ErrInvalidReceiptsSize
+	}
+
+	// the context fields of the receipts are derived from the block
+	var prevGasUsed uint64
+
+	for i, receipt := range receipts {
+		tx := block.Transactions[i]
+
+		if receipt.CumulativeGasUsed < prevGasUsed {
+			return nil, ErrInvalidGasUsed
+		}
+
+		receipt.TxHash = tx.Hash
+		receipt.TransactionType = tx.Type
+		receipt.GasUsed = receipt.CumulativeGasUsed - prevGasUsed
+		prevGasUsed = receipt.CumulativeGasUsed
+
+		if tx.To != nil {
+			receipt.ContractAddress = nil
+		}
+	}
+
+	if prevGasUsed != block.Header.GasUsed {
+		return nil, ErrInvalidGasUsed
+	}
+
+	if buildroot.CalculateReceiptsRoot(receipts) != block.Header.ReceiptsRoot {
+		return nil, ErrInvalidReceiptsRoot
+	}
+
+	return &types.FullBlock{Block: block, Receipts: receipts}, nil
+}
+
 // verifyBlockBody verifies that the block body is valid. This means checking:
 // - The trie roots match up (state, transactions, receipts, uncles)
 // - The receipts match up
 // - The execution result matches up
 func (b *Blockchain) verifyBlockBody(block *types.Block) ([]*types.Receipt, error) {
+	if err := b.verifyBlockRoots(block); err != nil {
+		return nil, err
+	}
+
+	// Execute the transactions in the block and grab the result
+	blockResult, executeErr := b.executeBlockTransactions(block)
+	if executeErr != nil {
+		return nil, fmt.Errorf("unable to execute block transactions, %w", executeErr)
+	}
+
+	// Verify the local execution result with the proposed block data
+	if err := blockResult.verifyBlockResult(block); err != nil {
+		return nil, fmt.Errorf("unable to verify block execution result, %w", err)
+	}
+
+	return blockResult.Receipts, nil
+}
+
+// verifyBlockRoots verifies that the uncles and the transactions of the block match up its header
+func (b *Blockchain) verifyBlockRoots(block *types.Block) error {
 	// Make sure the Uncles root matches up
 	if hash := buildroot.CalculateUncleRoot(block.Uncles); hash != block.Header.Sha3Uncles {
 		b.logger.Error(fmt.Sprintf(
@@ -765,7 +917,7 @@ func (b *Blockchain) verifyBlockBody(block *types.Block) ([]*types.Receipt, erro
 			block.Header.Sha3Uncles,
 		))
 
-		return nil, ErrInvalidSha3Uncles
+		return ErrInvalidSha3Uncles
 	}
 
 	// Make sure the transactions root matches up
@@ -776,21 +928,10 @@ func (b *Blockchain) verifyBlockBody(block *types.Block) ([]*types.Receipt, erro
 			block.Header.TxRoot,
 		))
 
-		return nil, ErrInvalidTxRoot
-	}
-
-	// Execute the transactions in the block and grab the result
-	blockResult, executeErr := b.executeBlockTransactions(block)
-	if executeErr != nil {
-		return nil, fmt.Errorf("unable to execute block transactions, %w", executeErr)
-	}
-
-	// Verify the local execution result with the proposed block data
-	if err := blockResult.verifyBlockResult(block); err != nil {
-		return nil, fmt.Errorf("unable to verify block execution result, %w", err)
+		return ErrInvalidTxRoot
 	}
 
-	return blockResult.Receipts, nil
+	return nil
 }
 
 // verifyBlockResult verifies that the block transaction execution result
@@ -1031,9 +1172,11 @@ func (b *Blockchain) updateGasPriceAvgWithBlock(block *types.Block) {
 		return
 	}
 
+	baseFee := new(big.Int).SetUint64(block.Header.BaseFee)
+
 	gasPrices := make([]*big.Int, len(block.Transactions))
 	for i, transaction := range block.Transactions {
-		gasPrices[i] = transaction.GasPrice
+		gasPrices[i] = transaction.EffectiveGasPrice(baseFee)
 	}
 
 	b.updateGasPriceAvg(gasPrices)
@@ -1362,6 +1505,97 @@ func (b *Blockchain) handleReorg(
 	return nil
 }
 
+// SetStateRetention sets the number of the most recent blocks whose state is kept,
+// the chain can't be rewound past them
+func (b *Blockchain) SetStateRetention(retention uint64) {
+	b.stateRetention = retention
+}
+
+// SetHead rewinds the canonical chain to the block of the given number, the canonical hashes
+// and the transaction lookups of the following blocks are removed (their data is kept as forks).
+// The state of the new head must be available. The rewind is dispatched as a reorg event
+func (b *Blockchain) SetHead(number uint64) error {
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	currentHeader := b.Header()
+	if number > currentHeader.Number {
+		return fmt.Errorf("%w: %d is above the head %d", ErrInvalidHeadNumber, number, currentHeader.Number)
+	}
+
+	if number == currentHeader.Number {
+		return nil
+	}
+
+	// the state of the new head must be kept, to execute the next blocks on top of it
+	if b.stateRetention > 0 && number+b.stateRetention <= currentHeader.Number {
+		return fmt.Errorf(
+			"%w: %d is outside the state retention window of %d blocks",
+			ErrHeadStateNotFound, number, b.stateRetention,
+		)
+	}
+
+	header, ok := b.GetHeaderByNumber(number)
+	if !ok {
+		return fmt.Errorf("header %d not found", number)
+	}
+
+	if _, err := b.executor.StateAt(header.StateRoot); err != nil {
+		return fmt.Errorf("%w: block %d, %v", ErrHeadStateNotFound, number, err)
+	}
+
+	td, ok := b.readTotalDifficulty(header.Hash)
+	if !ok {
+		return fmt.Errorf("difficulty of header %d not found", number)
+	}
+
+	evnt := &Event{}
+
+	for n := currentHeader.Number; n > number; n-- {
+		oldHeader, ok := b.GetHeaderByNumber(n)
+		if !ok {
+			return fmt.Errorf("header %d not found", n)
+		}
+
+		if body, ok := b.readBody(oldHeader.Hash); ok {
+			for _, txn := range body.Transactions {
+				if err := b.db.DeleteTxLookup(txn.Hash); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := b.db.DeleteCanonicalHash(n); err != nil {
+			return err
+		}
+
+		evnt.AddOldHeader(oldHeader)
+	}
+
+	if err := b.writeFork(currentHeader); err != nil {
+		return fmt.Errorf("failed to write the old header as fork: %w", err)
+	}
+
+	if err := b.db.WriteHeadHash(header.Hash); err != nil {
+		return err
+	}
+
+	if err := b.db.WriteHeadNumber(header.Number); err != nil {
+		return err
+	}
+
+	b.setCurrentHeader(header, td)
+
+	b.logger.Info("rewound the chain", "number", number, "hash", header.Hash, "removed", len(evnt.OldChain))
+
+	evnt.AddNewHeader(header)
+	evnt.Type = EventReorg
+	evnt.SetDifficulty(td)
+	b.dispatchEvent(evnt)
+
+	return nil
+}
+
 // GetForks returns the forks
 func (b *Blockchain) GetForks() ([]types.Hash, error) {
 	return b.db.ReadForks()
@@ -1412,5 +1646,9 @@ func (b *Blockchain) GetBlockByNumber(blockNumber uint64, full bool) (*types.Blo
 
 // Close closes the DB connection
 func (b *Blockchain) Close() error {
+	if b.logIndex != nil {
+		b.logIndex.close()
+	}
+
 	return b.db.Close()
 }
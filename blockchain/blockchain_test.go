@@ -16,6 +16,7 @@ import (
 	"github.com/newton2049/favo-chain/blockchain/storage"
 	"github.com/newton2049/favo-chain/blockchain/storage/memory"
 	"github.com/newton2049/favo-chain/types"
+	"github.com/newton2049/favo-chain/types/buildroot"
 )
 
 func TestGenesis(t *testing.T) {
@@ -1335,3 +1336,302 @@ func TestBlockchain_VerifyBlockBody(t *testing.T) {
 		assert.ErrorIs(t, err, errUnableToExecute)
 	})
 }
+
+// TestBlockchain_VerifySyncedBlock makes sure that the synced block is verified against its receipts
+func TestBlockchain_VerifySyncedBlock(t *testing.T) {
+	t.Parallel()
+
+	to := types.StringToAddress("1")
+
+	newBlock := func(t *testing.T) (*Blockchain, *types.Block, []*types.Receipt) {
+		t.Helper()
+
+		headers := NewTestHeadersWithSeed(nil, 2, 10000000)
+		blockchain := NewTestBlockchain(t, headers)
+
+		txs := []*types.Transaction{
+			{Nonce: 0, To: &to, Value: big.NewInt(1), Gas: 21000, GasPrice: big.NewInt(1)},
+			{Nonce: 1, Value: big.NewInt(1), Gas: 50000, GasPrice: big.NewInt(1)},
+		}
+
+		contract := types.StringToAddress("2")
+		receipts := []*types.Receipt{
+			{CumulativeGasUsed: 21000},
+			{CumulativeGasUsed: 61000, ContractAddress: &contract},
+		}
+
+		for i, tx := range txs {
+			tx.ComputeHash()
+			receipts[i].SetStatus(types.ReceiptSuccess)
+		}
+
+		block := &types.Block{
+			Header: &types.Header{
+				Number:       2,
+				ParentHash:   headers[1].Hash,
+				GasLimit:     10000000,
+				GasUsed:      61000,
+				Sha3Uncles:   types.EmptyUncleHash,
+				TxRoot:       buildroot.CalculateTransactionsRoot(txs),
+				ReceiptsRoot: buildroot.CalculateReceiptsRoot(receipts),
+			},
+			Transactions: txs,
+		}
+		block.Header.ComputeHash()
+
+		return blockchain, block, receipts
+	}
+
+	t.Run("Valid block", func(t *testing.T) {
+		t.Parallel()
+
+		blockchain, block, receipts := newBlock(t)
+
+		fullBlock, err := blockchain.VerifySyncedBlock(block, receipts)
+		assert.NoError(t, err)
+
+		// the context fields are derived from the block
+		assert.Equal(t, block.Transactions[1].Hash, fullBlock.Receipts[1].TxHash)
+		assert.Equal(t, uint64(40000), fullBlock.Receipts[1].GasUsed)
+		assert.NotNil(t, fullBlock.Receipts[1].ContractAddress)
+	})
+
+	t.Run("Invalid receipts size", func(t *testing.T) {
+		t.Parallel()
+
+		blockchain, block, receipts := newBlock(t)
+
+		_, err := blockchain.VerifySyncedBlock(block, receipts[:1])
+		assert.ErrorIs(t, err, ErrInvalidReceiptsSize)
+	})
+
+	t.Run("Invalid gas used", func(t *testing.T) {
+		t.Parallel()
+
+		blockchain, block, receipts := newBlock(t)
+		receipts[1].CumulativeGasUsed = 60000
+
+		_, err := blockchain.VerifySyncedBlock(block, receipts)
+		assert.ErrorIs(t, err, ErrInvalidGasUsed)
+	})
+
+	t.Run("Invalid receipts root", func(t *testing.T) {
+		t.Parallel()
+
+		blockchain, block, receipts := newBlock(t)
+		receipts[0].SetStatus(types.ReceiptFailed)
+
+		_, err := blockchain.VerifySyncedBlock(block, receipts)
+		assert.ErrorIs(t, err, ErrInvalidReceiptsRoot)
+	})
+
+	t.Run("Invalid transactions root", func(t *testing.T) {
+		t.Parallel()
+
+		blockchain, block, receipts := newBlock(t)
+		block.Transactions = block.Transactions[:1]
+
+		_, err := blockchain.VerifySyncedBlock(block, receipts)
+		assert.ErrorIs(t, err, ErrInvalidTxRoot)
+	})
+}
+
+func TestCalculateBaseFee(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		genesisBaseFee  uint64
+		parentBaseFee   uint64
+		parentGasLimit  uint64
+		parentGasUsed   uint64
+		expectedBaseFee uint64
+	}{
+		{
+			name:            "should be disabled without genesis base fee",
+			genesisBaseFee:  0,
+			parentBaseFee:   0,
+			parentGasLimit:  20000000,
+			parentGasUsed:   10000000,
+			expectedBaseFee: 0,
+		},
+		{
+			name:            "should start from the genesis base fee",
+			genesisBaseFee:  1000000000,
+			parentBaseFee:   0,
+			parentGasLimit:  20000000,
+			parentGasUsed:   0,
+			expectedBaseFee: 1000000000,
+		},
+		{
+			name:            "should not change when the gas target is reached",
+			genesisBaseFee:  1000000000,
+			parentBaseFee:   1000000000,
+			parentGasLimit:  20000000,
+			parentGasUsed:   10000000,
+			expectedBaseFee: 1000000000,
+		},
+		{
+			name:            "should increase when the gas target is exceeded",
+			genesisBaseFee:  1000000000,
+			parentBaseFee:   1000000000,
+			parentGasLimit:  20000000,
+			parentGasUsed:   20000000,
+			expectedBaseFee: 1125000000,
+		},
+		{
+			name:            "should decrease when the gas target is not reached",
+			genesisBaseFee:  1000000000,
+			parentBaseFee:   1000000000,
+			parentGasLimit:  20000000,
+			parentGasUsed:   0,
+			expectedBaseFee: 875000000,
+		},
+		{
+			name:            "should not decrease below one",
+			genesisBaseFee:  1000000000,
+			parentBaseFee:   1,
+			parentGasLimit:  20000000,
+			parentGasUsed:   0,
+			expectedBaseFee: 1,
+		},
+		{
+			name:            "should increase by at least one",
+			genesisBaseFee:  1,
+			parentBaseFee:   1,
+			parentGasLimit:  20000000,
+			parentGasUsed:   10000001,
+			expectedBaseFee: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := &Blockchain{
+				config: &chain.Chain{
+					Genesis: &chain.Genesis{
+						BaseFee: tt.genesisBaseFee,
+					},
+					Params: &chain.Params{
+						Forks: chain.AllForksEnabled,
+					},
+				},
+			}
+
+			parent := &types.Header{
+				Number:   1,
+				GasLimit: tt.parentGasLimit,
+				GasUsed:  tt.parentGasUsed,
+				BaseFee:  tt.parentBaseFee,
+			}
+
+			assert.Equal(t, tt.expectedBaseFee, b.CalculateBaseFee(parent))
+		})
+	}
+}
+
+func TestBlockchain_SetHead(t *testing.T) {
+	t.Parallel()
+
+	headers := NewTestHeaders(5)
+	b := NewTestBlockchain(t, headers)
+	b.executor = &mockExecutor{}
+
+	// the transaction of block 4
+	txn := &types.Transaction{Nonce: 1, GasPrice: big.NewInt(1), Value: big.NewInt(1)}
+	txn.ComputeHash()
+
+	assert.NoError(t, b.db.WriteBody(headers[4].Hash, &types.Body{Transactions: []*types.Transaction{txn}}))
+	assert.NoError(t, b.db.WriteTxLookup(txn.Hash, headers[4].Hash))
+
+	sub := b.SubscribeEvents()
+	defer sub.Close()
+
+	// the head can't be above the current head
+	assert.ErrorIs(t, b.SetHead(5), ErrInvalidHeadNumber)
+
+	assert.NoError(t, b.SetHead(2))
+
+	assert.Equal(t, headers[2].Hash, b.Header().Hash)
+
+	headHash, _ := b.db.ReadHeadHash()
+	headNumber, _ := b.db.ReadHeadNumber()
+
+	assert.Equal(t, headers[2].Hash, headHash)
+	assert.Equal(t, uint64(2), headNumber)
+
+	for n := uint64(3); n <= 4; n++ {
+		_, ok := b.db.ReadCanonicalHash(n)
+		assert.False(t, ok)
+	}
+
+	_, ok := b.db.ReadTxLookup(txn.Hash)
+	assert.False(t, ok)
+
+	// the removed blocks are kept as a fork
+	forks, err := b.GetForks()
+	assert.NoError(t, err)
+	assert.Contains(t, forks, headers[4].Hash)
+
+	evnt := sub.GetEvent()
+	assert.Equal(t, EventReorg, evnt.Type)
+	assert.Len(t, evnt.OldChain, 2)
+	assert.Equal(t, headers[2].Hash, evnt.NewChain[0].Hash)
+
+	// the chain is extended again from the new head
+	assert.NoError(t, b.WriteHeaders(AppendNewTestheadersWithSeed(headers[:3], 1, 1)[3:]))
+	assert.Equal(t, uint64(3), b.Header().Number)
+}
+
+func TestBlockchain_SetHead_StateNotFound(t *testing.T) {
+	t.Parallel()
+
+	// the state root of each block is its number
+	headers := NewTestHeaders(10)
+	for i := 1; i < len(headers); i++ {
+		headers[i].StateRoot = types.BytesToHash([]byte{byte(i)})
+		headers[i].ParentHash = headers[i-1].Hash
+		headers[i].ComputeHash()
+	}
+
+	b := NewTestBlockchain(t, headers)
+
+	executor := &mockExecutor{}
+	executor.HookStateAt(func(root types.Hash) (state.Snapshot, error) {
+		// the state of the blocks up to 3 has been pruned
+		for _, header := range headers[:4] {
+			if header.StateRoot == root {
+				return nil, errors.New("state not found")
+			}
+		}
+
+		return nil, nil
+	})
+
+	b.executor = executor
+
+	// the state of the new head is pruned
+	assert.ErrorIs(t, b.SetHead(3), ErrHeadStateNotFound)
+
+	// the new head is outside the retention window, its state is about to be pruned
+	b.SetStateRetention(4)
+
+	assert.ErrorIs(t, b.SetHead(5), ErrHeadStateNotFound)
+
+	// the chain is left untouched
+	assert.Equal(t, headers[9].Hash, b.Header().Hash)
+
+	for n := uint64(6); n <= 9; n++ {
+		hash, ok := b.db.ReadCanonicalHash(n)
+		assert.True(t, ok)
+		assert.Equal(t, headers[n].Hash, hash)
+	}
+
+	// the state of the new head is retained
+	assert.NoError(t, b.SetHead(7))
+	assert.Equal(t, headers[7].Hash, b.Header().Hash)
+}
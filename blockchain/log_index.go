@@ -0,0 +1,309 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/newton2049/favo-chain/blockchain/storage"
+	"github.com/newton2049/favo-chain/types"
+)
+
+// LogIndexSectionSize is the number of blocks of a section of the log index
+const LogIndexSectionSize = 4096
+
+// logIndex is the index of the logs of the canonical chain. For each bit of the logs bloom,
+// it keeps the vector of the blocks of a section whose logs set the bit,
+// so that the blocks which may contain the logs of a filter are found without reading their receipts.
+// The complete sections are written to the storage, the current section is kept in memory
+type logIndex struct {
+	logger      hclog.Logger
+	db          storage.Storage
+	sectionSize uint64
+
+	lock     sync.RWMutex
+	sections uint64                       // the number of the stored sections
+	next     uint64                       // the number of the next block to index
+	bits     [types.BloomBitLength][]byte // the bit vectors of the current section
+
+	closeCh chan struct{}
+	doneCh  chan struct{}
+}
+
+func newLogIndex(logger hclog.Logger, db storage.Storage, sectionSize uint64) *logIndex {
+	l := &logIndex{
+		logger:      logger.Named("log-index"),
+		db:          db,
+		sectionSize: sectionSize,
+	}
+
+	// the stored sections are kept, and the current section is backfilled
+	l.sections, _ = db.ReadBloomSections()
+	l.next = l.sections * sectionSize
+
+	return l
+}
+
+// run indexes the blocks of the canonical chain as they are written, until the index is closed
+func (l *logIndex) run(subscription Subscription, head func() uint64) {
+	defer close(l.doneCh)
+	defer subscription.Close()
+
+	eventCh := subscription.GetEventCh()
+
+	for {
+		if err := l.indexTo(head()); err != nil {
+			l.logger.Error("failed to index logs", "err", err)
+		}
+
+		select {
+		case evnt := <-eventCh:
+			if evnt.Type == EventReorg {
+				// the blocks of the old chain are indexed again
+				for _, header := range evnt.OldChain {
+					l.rewind(header.Number)
+				}
+			}
+		case <-l.closeCh:
+			return
+		}
+	}
+}
+
+// close stops the indexing, if it's running
+func (l *logIndex) close() {
+	if l.closeCh == nil {
+		return
+	}
+
+	close(l.closeCh)
+	<-l.doneCh
+}
+
+// indexTo indexes the blocks up to the given number
+func (l *logIndex) indexTo(number uint64) error {
+	for l.nextBlock() <= number {
+		select {
+		case <-l.closeCh:
+			return nil
+		default:
+		}
+
+		if err := l.indexBlock(l.nextBlock()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *logIndex) nextBlock() uint64 {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.next
+}
+
+// indexBlock adds the logs of the canonical block to the current section,
+// and writes the section once complete
+func (l *logIndex) indexBlock(number uint64) error {
+	hash, ok := l.db.ReadCanonicalHash(number)
+	if !ok {
+		return fmt.Errorf("canonical hash of block %d not found", number)
+	}
+
+	receipts, err := l.db.ReadReceipts(hash)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	bloom := types.CreateBloom(receipts)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if number != l.next {
+		// the index was rewound in the meantime
+		return nil
+	}
+
+	position := number % l.sectionSize
+
+	for bit := uint(0); bit < types.BloomBitLength; bit++ {
+		if !bloom.IsBitSet(bit) {
+			continue
+		}
+
+		if l.bits[bit] == nil {
+			l.bits[bit] = make([]byte, l.sectionSize/8)
+		}
+
+		l.bits[bit][position/8] |= 1 << (7 - position%8)
+	}
+
+	l.next++
+
+	if l.next%l.sectionSize != 0 {
+		return nil
+	}
+
+	// the section is complete, the vectors without any block are not written
+	for bit, vector := range l.bits {
+		if vector == nil {
+			continue
+		}
+
+		if err := l.db.WriteBloomBits(uint(bit), l.sections, vector); err != nil {
+			return err
+		}
+	}
+
+	if err := l.db.WriteBloomSections(l.sections + 1); err != nil {
+		return err
+	}
+
+	l.sections++
+	l.bits = [types.BloomBitLength][]byte{}
+
+	return nil
+}
+
+// rewind removes the blocks from the given number from the index
+func (l *logIndex) rewind(number uint64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if number >= l.next {
+		return
+	}
+
+	section := number / l.sectionSize
+
+	if section < l.sections {
+		if err := l.db.WriteBloomSections(section); err != nil {
+			l.logger.Error("failed to rewind log index", "err", err)
+		}
+
+		l.sections = section
+	}
+
+	// the current section is indexed again from its first block
+	l.next = section * l.sectionSize
+	l.bits = [types.BloomBitLength][]byte{}
+}
+
+// filterBlocks returns the numbers of the indexed blocks from the range whose logs may match the criteria,
+// and the number of the first block of the range which isn't indexed
+func (l *logIndex) filterBlocks(from, to uint64, criteria [][][]byte) ([]uint64, uint64) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if from >= l.next {
+		return nil, from
+	}
+
+	if to >= l.next {
+		to = l.next - 1
+	}
+
+	blocks := make([]uint64, 0)
+
+	for section := from / l.sectionSize; section*l.sectionSize <= to; section++ {
+		vector := l.matchSection(section, criteria)
+
+		for position := uint64(0); position < l.sectionSize; position++ {
+			number := section*l.sectionSize + position
+			if number < from || number > to {
+				continue
+			}
+
+			if vector[position/8]&(1<<(7-position%8)) != 0 {
+				blocks = append(blocks, number)
+			}
+		}
+	}
+
+	return blocks, to + 1
+}
+
+// matchSection returns the vector of the blocks of the section matching all the criteria
+func (l *logIndex) matchSection(section uint64, criteria [][][]byte) []byte {
+	result := l.filledVector()
+
+	for _, criterion := range criteria {
+		if len(criterion) == 0 {
+			// an empty criterion matches any block
+			continue
+		}
+
+		// the blocks matching any of the values of the criterion
+		matches := make([]byte, l.sectionSize/8)
+
+		for _, value := range criterion {
+			valueMatches := l.filledVector()
+
+			for _, bit := range types.BloomBits(value) {
+				andVector(valueMatches, l.bitVector(section, bit))
+			}
+
+			for i := range matches {
+				matches[i] |= valueMatches[i]
+			}
+		}
+
+		andVector(result, matches)
+	}
+
+	return result
+}
+
+// bitVector returns the vector of the blocks of the section having the bit set [NOT Thread Safe]
+func (l *logIndex) bitVector(section uint64, bit uint) []byte {
+	if section >= l.sections {
+		return l.bits[bit]
+	}
+
+	vector, _ := l.db.ReadBloomBits(bit, section)
+
+	return vector
+}
+
+func (l *logIndex) filledVector() []byte {
+	vector := make([]byte, l.sectionSize/8)
+	for i := range vector {
+		vector[i] = 0xff
+	}
+
+	return vector
+}
+
+// andVector intersects the vector with the other vector, a missing vector has no block set
+func andVector(vector, other []byte) {
+	for i := range vector {
+		if i < len(other) {
+			vector[i] &= other[i]
+		} else {
+			vector[i] = 0
+		}
+	}
+}
+
+// StartLogIndex starts indexing the logs of the canonical chain,
+// beginning with the blocks written before the index was started
+func (b *Blockchain) StartLogIndex() {
+	b.logIndex.closeCh = make(chan struct{})
+	b.logIndex.doneCh = make(chan struct{})
+
+	go b.logIndex.run(b.SubscribeEvents(), func() uint64 {
+		return b.Header().Number
+	})
+}
+
+// FilterLogBlocks returns the numbers of the indexed blocks from the range whose logs may match the criteria,
+// and the number of the first block of the range which isn't indexed yet.
+// A block matches when its logs match any of the values of each of the criteria,
+// which are the log addresses or topics, and an empty criterion matches any block
+func (b *Blockchain) FilterLogBlocks(from, to uint64, criteria [][][]byte) ([]uint64, uint64) {
+	return b.logIndex.filterBlocks(from, to, criteria)
+}
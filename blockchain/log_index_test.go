@@ -0,0 +1,110 @@
+package blockchain
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newton2049/favo-chain/blockchain/storage/memory"
+	"github.com/newton2049/favo-chain/types"
+)
+
+func TestLogIndex(t *testing.T) {
+	t.Parallel()
+
+	var (
+		addr1  = types.StringToAddress("1")
+		addr2  = types.StringToAddress("2")
+		topic1 = types.StringToHash("1")
+		topic2 = types.StringToHash("2")
+	)
+
+	db, err := memory.NewMemoryStorage(nil)
+	require.NoError(t, err)
+
+	writeBlock := func(number uint64, logs ...*types.Log) {
+		hash := types.StringToHash(strconv.FormatUint(number+1, 10))
+
+		require.NoError(t, db.WriteCanonicalHash(number, hash))
+		require.NoError(t, db.WriteReceipts(hash, []*types.Receipt{{Logs: logs}}))
+	}
+
+	// 20 blocks, which are 2 sections of 8 blocks and 4 blocks of the current section
+	for i := uint64(0); i < 20; i++ {
+		switch {
+		case i%5 == 0:
+			writeBlock(i, &types.Log{Address: addr1, Topics: []types.Hash{topic1}})
+		case i%7 == 0:
+			writeBlock(i, &types.Log{Address: addr2, Topics: []types.Hash{topic2}})
+		default:
+			writeBlock(i)
+		}
+	}
+
+	index := newLogIndex(hclog.NewNullLogger(), db, 8)
+	require.NoError(t, index.indexTo(19))
+
+	sections, ok := db.ReadBloomSections()
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), sections)
+
+	filter := func(index *logIndex, from, to uint64, criteria ...[][]byte) ([]uint64, uint64) {
+		t.Helper()
+
+		return index.filterBlocks(from, to, criteria)
+	}
+
+	blocks, next := filter(index, 0, 19, [][]byte{addr1.Bytes()})
+	assert.Equal(t, []uint64{0, 5, 10, 15}, blocks)
+	assert.Equal(t, uint64(20), next)
+
+	blocks, _ = filter(index, 3, 12, [][]byte{addr1.Bytes(), addr2.Bytes()})
+	assert.Equal(t, []uint64{5, 7, 10}, blocks)
+
+	blocks, _ = filter(index, 0, 19, [][]byte{addr2.Bytes()}, [][]byte{topic2.Bytes()})
+	assert.Equal(t, []uint64{7, 14}, blocks)
+
+	blocks, _ = filter(index, 0, 19, [][]byte{addr2.Bytes()}, [][]byte{topic1.Bytes()})
+	assert.Empty(t, blocks)
+
+	// the blocks after the index aren't filtered
+	blocks, next = filter(index, 18, 30, [][]byte{addr1.Bytes()})
+	assert.Empty(t, blocks)
+	assert.Equal(t, uint64(20), next)
+
+	t.Run("reopened index", func(t *testing.T) {
+		// the stored sections are kept, and the current section is indexed again
+		reopened := newLogIndex(hclog.NewNullLogger(), db, 8)
+
+		blocks, next := filter(reopened, 0, 19, [][]byte{addr1.Bytes()})
+		assert.Equal(t, []uint64{0, 5, 10, 15}, blocks)
+		assert.Equal(t, uint64(16), next)
+
+		require.NoError(t, reopened.indexTo(19))
+
+		blocks, next = filter(reopened, 0, 19, [][]byte{addr1.Bytes()})
+		assert.Equal(t, []uint64{0, 5, 10, 15}, blocks)
+		assert.Equal(t, uint64(20), next)
+	})
+
+	t.Run("rewound index", func(t *testing.T) {
+		rewound := newLogIndex(hclog.NewNullLogger(), db, 8)
+		require.NoError(t, rewound.indexTo(19))
+
+		rewound.rewind(10)
+
+		assert.Equal(t, uint64(1), rewound.sections)
+		assert.Equal(t, uint64(8), rewound.next)
+
+		blocks, next := filter(rewound, 0, 19, [][]byte{addr1.Bytes()})
+		assert.Equal(t, []uint64{0, 5}, blocks)
+		assert.Equal(t, uint64(8), next)
+
+		sections, ok := db.ReadBloomSections()
+		require.True(t, ok)
+		assert.Equal(t, uint64(1), sections)
+	})
+}
@@ -39,6 +39,9 @@ var (
 
 	// TX_LOOKUP_PREFIX is the prefix for transaction lookups
 	TX_LOOKUP_PREFIX = []byte("l")
+
+	// BLOOM_BITS is the prefix for the bloom bits of the log index
+	BLOOM_BITS = []byte("m")
 )
 
 // Sub-prefixes
@@ -46,6 +49,7 @@ var (
 	HASH   = []byte("hash")
 	NUMBER = []byte("number")
 	EMPTY  = []byte("empty")
+	BLOOM  = []byte("bloom")
 )
 
 // KV is a key value storage interface.
@@ -55,6 +59,7 @@ type KV interface {
 	Close() error
 	Set(p []byte, v []byte) error
 	Get(p []byte) ([]byte, bool, error)
+	Delete(p []byte) error
 }
 
 // KeyValueStorage is a generic storage for kv databases
@@ -96,6 +101,11 @@ func (s *KeyValueStorage) WriteCanonicalHash(n uint64, hash types.Hash) error {
 	return s.set(CANONICAL, s.encodeUint(n), hash.Bytes())
 }
 
+// DeleteCanonicalHash removes the number block from the canonical chain
+func (s *KeyValueStorage) DeleteCanonicalHash(n uint64) error {
+	return s.delete(CANONICAL, s.encodeUint(n))
+}
+
 // HEAD //
 
 // ReadHeadHash returns the hash of the head
@@ -267,6 +277,45 @@ func (s *KeyValueStorage) ReadTxLookup(hash types.Hash) (types.Hash, bool) {
 	return types.BytesToHash(blockHash), true
 }
 
+// DeleteTxLookup removes the block hash of the transaction hash
+func (s *KeyValueStorage) DeleteTxLookup(hash types.Hash) error {
+	return s.delete(TX_LOOKUP_PREFIX, hash.Bytes())
+}
+
+// BLOOM BITS //
+
+func (s *KeyValueStorage) bloomBitsKey(bit uint, section uint64) []byte {
+	key := make([]byte, 2, 10)
+	binary.BigEndian.PutUint16(key, uint16(bit))
+
+	return append(key, s.encodeUint(section)...)
+}
+
+// WriteBloomBits writes the vector of the blocks of the section having the bloom bit set
+func (s *KeyValueStorage) WriteBloomBits(bit uint, section uint64, bits []byte) error {
+	return s.set(BLOOM_BITS, s.bloomBitsKey(bit, section), bits)
+}
+
+// ReadBloomBits reads the vector of the blocks of the section having the bloom bit set
+func (s *KeyValueStorage) ReadBloomBits(bit uint, section uint64) ([]byte, bool) {
+	return s.get(BLOOM_BITS, s.bloomBitsKey(bit, section))
+}
+
+// WriteBloomSections writes the number of the sections of the log index
+func (s *KeyValueStorage) WriteBloomSections(sections uint64) error {
+	return s.set(HEAD, BLOOM, s.encodeUint(sections))
+}
+
+// ReadBloomSections reads the number of the sections of the log index
+func (s *KeyValueStorage) ReadBloomSections() (uint64, bool) {
+	data, ok := s.get(HEAD, BLOOM)
+	if !ok || len(data) != 8 {
+		return 0, false
+	}
+
+	return s.decodeUint(data), true
+}
+
 // WRITE OPERATIONS //
 
 func (s *KeyValueStorage) writeRLP(p, k []byte, raw types.RLPMarshaler) error {
@@ -335,6 +384,12 @@ func (s *KeyValueStorage) set(p []byte, k []byte, v []byte) error {
 	return s.db.Set(p, v)
 }
 
+func (s *KeyValueStorage) delete(p []byte, k []byte) error {
+	p = append(p, k...)
+
+	return s.db.Delete(p)
+}
+
 func (s *KeyValueStorage) get(p []byte, k []byte) ([]byte, bool) {
 	p = append(p, k...)
 	data, ok, err := s.db.Get(p)
@@ -11,6 +11,7 @@ import (
 type Storage interface {
 	ReadCanonicalHash(n uint64) (types.Hash, bool)
 	WriteCanonicalHash(n uint64, hash types.Hash) error
+	DeleteCanonicalHash(n uint64) error
 
 	ReadHeadHash() (types.Hash, bool)
 	ReadHeadNumber() (uint64, bool)
@@ -36,6 +37,12 @@ type Storage interface {
 
 	WriteTxLookup(hash types.Hash, blockHash types.Hash) error
 	ReadTxLookup(hash types.Hash) (types.Hash, bool)
+	DeleteTxLookup(hash types.Hash) error
+
+	WriteBloomBits(bit uint, section uint64, bits []byte) error
+	ReadBloomBits(bit uint, section uint64) ([]byte, bool)
+	WriteBloomSections(sections uint64) error
+	ReadBloomSections() (uint64, bool)
 
 	Close() error
 }
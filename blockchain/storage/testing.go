@@ -48,6 +48,9 @@ func TestStorage(t *testing.T, m PlaceholderStorage) {
 	t.Run("testReceipts", func(t *testing.T) {
 		testReceipts(t, m)
 	})
+	t.Run("testBloomBits", func(t *testing.T) {
+		testBloomBits(t, m)
+	})
 }
 
 func testCanonicalChain(t *testing.T, m PlaceholderStorage) {
@@ -101,6 +104,14 @@ func testCanonicalChain(t *testing.T, m PlaceholderStorage) {
 			t.Fatal("not match")
 		}
 	}
+
+	if err := s.DeleteCanonicalHash(2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.ReadCanonicalHash(2); ok {
+		t.Fatal("canonical hash not deleted")
+	}
 }
 
 func testDifficulty(t *testing.T, m PlaceholderStorage) {
@@ -389,6 +400,30 @@ func testReceipts(t *testing.T, m PlaceholderStorage) {
 	assert.True(t, reflect.DeepEqual(receipts, found))
 }
 
+func testBloomBits(t *testing.T, m PlaceholderStorage) {
+	t.Helper()
+
+	s, closeFn := m(t)
+	defer closeFn()
+
+	_, ok := s.ReadBloomSections()
+	assert.False(t, ok)
+
+	assert.NoError(t, s.WriteBloomBits(2047, 1, []byte{0x1, 0x2}))
+	assert.NoError(t, s.WriteBloomSections(2))
+
+	bits, ok := s.ReadBloomBits(2047, 1)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0x1, 0x2}, bits)
+
+	_, ok = s.ReadBloomBits(2047, 0)
+	assert.False(t, ok)
+
+	sections, ok := s.ReadBloomSections()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), sections)
+}
+
 func testWriteCanonicalHeader(t *testing.T, m PlaceholderStorage) {
 	t.Helper()
 
@@ -446,6 +481,7 @@ func testWriteCanonicalHeader(t *testing.T, m PlaceholderStorage) {
 
 type readCanonicalHashDelegate func(uint64) (types.Hash, bool)
 type writeCanonicalHashDelegate func(uint64, types.Hash) error
+type deleteCanonicalHashDelegate func(uint64) error
 type readHeadHashDelegate func() (types.Hash, bool)
 type readHeadNumberDelegate func() (uint64, bool)
 type writeHeadHashDelegate func(types.Hash) error
@@ -465,11 +501,17 @@ type writeReceiptsDelegate func(types.Hash, []*types.Receipt) error
 type readReceiptsDelegate func(types.Hash) ([]*types.Receipt, error)
 type writeTxLookupDelegate func(types.Hash, types.Hash) error
 type readTxLookupDelegate func(types.Hash) (types.Hash, bool)
+type deleteTxLookupDelegate func(types.Hash) error
+type writeBloomBitsDelegate func(uint, uint64, []byte) error
+type readBloomBitsDelegate func(uint, uint64) ([]byte, bool)
+type writeBloomSectionsDelegate func(uint64) error
+type readBloomSectionsDelegate func() (uint64, bool)
 type closeDelegate func() error
 
 type MockStorage struct {
 	readCanonicalHashFn    readCanonicalHashDelegate
 	writeCanonicalHashFn   writeCanonicalHashDelegate
+	deleteCanonicalHashFn  deleteCanonicalHashDelegate
 	readHeadHashFn         readHeadHashDelegate
 	readHeadNumberFn       readHeadNumberDelegate
 	writeHeadHashFn        writeHeadHashDelegate
@@ -487,6 +529,11 @@ type MockStorage struct {
 	readReceiptsFn         readReceiptsDelegate
 	writeTxLookupFn        writeTxLookupDelegate
 	readTxLookupFn         readTxLookupDelegate
+	deleteTxLookupFn       deleteTxLookupDelegate
+	writeBloomBitsFn       writeBloomBitsDelegate
+	readBloomBitsFn        readBloomBitsDelegate
+	writeBloomSectionsFn   writeBloomSectionsDelegate
+	readBloomSectionsFn    readBloomSectionsDelegate
 	closeFn                closeDelegate
 }
 
@@ -518,6 +565,18 @@ func (m *MockStorage) HookWriteCanonicalHash(fn writeCanonicalHashDelegate) {
 	m.writeCanonicalHashFn = fn
 }
 
+func (m *MockStorage) DeleteCanonicalHash(n uint64) error {
+	if m.deleteCanonicalHashFn != nil {
+		return m.deleteCanonicalHashFn(n)
+	}
+
+	return nil
+}
+
+func (m *MockStorage) HookDeleteCanonicalHash(fn deleteCanonicalHashDelegate) {
+	m.deleteCanonicalHashFn = fn
+}
+
 func (m *MockStorage) ReadHeadHash() (types.Hash, bool) {
 	if m.readHeadHashFn != nil {
 		return m.readHeadHashFn()
@@ -722,6 +781,66 @@ func (m *MockStorage) HookReadTxLookup(fn readTxLookupDelegate) {
 	m.readTxLookupFn = fn
 }
 
+func (m *MockStorage) DeleteTxLookup(hash types.Hash) error {
+	if m.deleteTxLookupFn != nil {
+		return m.deleteTxLookupFn(hash)
+	}
+
+	return nil
+}
+
+func (m *MockStorage) HookDeleteTxLookup(fn deleteTxLookupDelegate) {
+	m.deleteTxLookupFn = fn
+}
+
+func (m *MockStorage) WriteBloomBits(bit uint, section uint64, bits []byte) error {
+	if m.writeBloomBitsFn != nil {
+		return m.writeBloomBitsFn(bit, section, bits)
+	}
+
+	return nil
+}
+
+func (m *MockStorage) HookWriteBloomBits(fn writeBloomBitsDelegate) {
+	m.writeBloomBitsFn = fn
+}
+
+func (m *MockStorage) ReadBloomBits(bit uint, section uint64) ([]byte, bool) {
+	if m.readBloomBitsFn != nil {
+		return m.readBloomBitsFn(bit, section)
+	}
+
+	return nil, false
+}
+
+func (m *MockStorage) HookReadBloomBits(fn readBloomBitsDelegate) {
+	m.readBloomBitsFn = fn
+}
+
+func (m *MockStorage) WriteBloomSections(sections uint64) error {
+	if m.writeBloomSectionsFn != nil {
+		return m.writeBloomSectionsFn(sections)
+	}
+
+	return nil
+}
+
+func (m *MockStorage) HookWriteBloomSections(fn writeBloomSectionsDelegate) {
+	m.writeBloomSectionsFn = fn
+}
+
+func (m *MockStorage) ReadBloomSections() (uint64, bool) {
+	if m.readBloomSectionsFn != nil {
+		return m.readBloomSectionsFn()
+	}
+
+	return 0, false
+}
+
+func (m *MockStorage) HookReadBloomSections(fn readBloomSectionsDelegate) {
+	m.readBloomSectionsFn = fn
+}
+
 func (m *MockStorage) Close() error {
 	if m.closeFn != nil {
 		return m.closeFn()
@@ -216,6 +216,8 @@ func NewMockBlockchain(
 		return nil, err
 	}
 
+	blockchain.logIndex = newLogIndex(blockchain.logger, mockStorage, LogIndexSectionSize)
+
 	return blockchain, nil
 }
 
@@ -285,8 +287,11 @@ func (m *MockVerifier) HookPreCommitState(fn preStateCommitDelegate) {
 
 type processBlockDelegate func(types.Hash, *types.Block, types.Address) (*state.Transition, error)
 
+type stateAtDelegate func(types.Hash) (state.Snapshot, error)
+
 type mockExecutor struct {
 	processBlockFn processBlockDelegate
+	stateAtFn      stateAtDelegate
 }
 
 func (m *mockExecutor) ProcessBlock(
@@ -305,6 +310,18 @@ func (m *mockExecutor) HookProcessBlock(fn processBlockDelegate) {
 	m.processBlockFn = fn
 }
 
+func (m *mockExecutor) StateAt(root types.Hash) (state.Snapshot, error) {
+	if m.stateAtFn != nil {
+		return m.stateAtFn(root)
+	}
+
+	return nil, nil
+}
+
+func (m *mockExecutor) HookStateAt(fn stateAtDelegate) {
+	m.stateAtFn = fn
+}
+
 type mockSigner struct {
 	txFromByTxHash map[types.Hash]types.Address
 }
@@ -18,6 +18,13 @@ var (
 
 	// GenesisDifficulty is the default difficulty of the Genesis block.
 	GenesisDifficulty = big.NewInt(131072)
+
+	// GenesisBaseFeeEM is the default EIP-1559 elasticity multiplier
+	// (ratio between the block gas limit and the block gas target).
+	GenesisBaseFeeEM uint64 = 2
+
+	// BaseFeeChangeDenom bounds the amount the base fee can change between blocks.
+	BaseFeeChangeDenom uint64 = 8
 )
 
 // Chain is the blockchain chain configuration
@@ -41,6 +48,12 @@ type Genesis struct {
 	Coinbase   types.Address                     `json:"coinbase"`
 	Alloc      map[types.Address]*GenesisAccount `json:"alloc,omitempty"`
 
+	// BaseFee is the initial EIP-1559 base fee. It is applied to the genesis header and
+	// to the first London block. Zero keeps the base fee pricing disabled
+	BaseFee uint64 `json:"baseFee"`
+	// BaseFeeEM is the EIP-1559 elasticity multiplier (GenesisBaseFeeEM if not set)
+	BaseFeeEM uint64 `json:"baseFeeEM"`
+
 	// Override
 	StateRoot types.Hash
 
@@ -73,6 +86,7 @@ func (g *Genesis) GenesisHeader() *types.Header {
 		Sha3Uncles:   types.EmptyUncleHash,
 		ReceiptsRoot: types.EmptyRootHash,
 		TxRoot:       types.EmptyRootHash,
+		BaseFee:      g.BaseFee,
 	}
 
 	// Set default values if none are passed in
@@ -106,6 +120,8 @@ func (g *Genesis) MarshalJSON() ([]byte, error) {
 		Mixhash    types.Hash                  `json:"mixHash"`
 		Coinbase   types.Address               `json:"coinbase"`
 		Alloc      *map[string]*GenesisAccount `json:"alloc,omitempty"`
+		BaseFee    *string                     `json:"baseFee,omitempty"`
+		BaseFeeEM  *string                     `json:"baseFeeEM,omitempty"`
 		Number     *string                     `json:"number,omitempty"`
 		GasUsed    *string                     `json:"gasUsed,omitempty"`
 		ParentHash types.Hash                  `json:"parentHash"`
@@ -132,6 +148,14 @@ func (g *Genesis) MarshalJSON() ([]byte, error) {
 		enc.Alloc = &alloc
 	}
 
+	if g.BaseFee != 0 {
+		enc.BaseFee = types.EncodeUint64(g.BaseFee)
+	}
+
+	if g.BaseFeeEM != 0 {
+		enc.BaseFeeEM = types.EncodeUint64(g.BaseFeeEM)
+	}
+
 	enc.Number = types.EncodeUint64(g.Number)
 	enc.GasUsed = types.EncodeUint64(g.GasUsed)
 	enc.ParentHash = g.ParentHash
@@ -150,6 +174,8 @@ func (g *Genesis) UnmarshalJSON(data []byte) error {
 		Mixhash    *types.Hash                `json:"mixHash"`
 		Coinbase   *types.Address             `json:"coinbase"`
 		Alloc      map[string]*GenesisAccount `json:"alloc"`
+		BaseFee    *string                    `json:"baseFee"`
+		BaseFeeEM  *string                    `json:"baseFeeEM"`
 		Number     *string                    `json:"number"`
 		GasUsed    *string                    `json:"gasUsed"`
 		ParentHash *types.Hash                `json:"parentHash"`
@@ -214,6 +240,16 @@ func (g *Genesis) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	g.BaseFee, subErr = types.ParseUint64orHex(dec.BaseFee)
+	if subErr != nil {
+		parseError("basefee", subErr)
+	}
+
+	g.BaseFeeEM, subErr = types.ParseUint64orHex(dec.BaseFeeEM)
+	if subErr != nil {
+		parseError("basefeeem", subErr)
+	}
+
 	g.Number, subErr = types.ParseUint64orHex(dec.Number)
 	if subErr != nil {
 		parseError("number", subErr)
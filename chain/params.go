@@ -16,6 +16,9 @@ type Params struct {
 
 	// AllowList configuration
 	ContractDeployerAllowList *AllowListConfig `json:"contractDeployerAllowListConfig,omitempty"`
+
+	// TxOrdering is the ordering policy of the transactions of the built blocks
+	TxOrdering *TxOrdering `json:"txOrdering,omitempty"`
 }
 
 type AllowListConfig struct {
@@ -26,6 +29,18 @@ type AllowListConfig struct {
 	EnabledAddresses []types.Address `json:"enabledAddresses,omitempty"`
 }
 
+// TxOrdering specifies the order in which the sealers include the pooled transactions in blocks
+type TxOrdering struct {
+	// Policy is the base order of the transactions, by gas price (the default) or by arrival in the pool
+	Policy string `json:"policy,omitempty"`
+
+	// PrioritySenders are the accounts whose transactions are included before all the others
+	PrioritySenders []types.Address `json:"prioritySenders,omitempty"`
+
+	// MaxTxsPerSender is the maximum number of transactions of an account per block, unlimited if 0
+	MaxTxsPerSender uint64 `json:"maxTxsPerSender,omitempty"`
+}
+
 func (p *Params) GetEngine() string {
 	// We know there is already one
 	for k := range p.Engine {
@@ -47,7 +62,10 @@ type Forks struct {
 	Constantinople *Fork `json:"constantinople,omitempty"`
 	Petersburg     *Fork `json:"petersburg,omitempty"`
 	Istanbul       *Fork `json:"istanbul,omitempty"`
+	Berlin         *Fork `json:"berlin,omitempty"`
 	London         *Fork `json:"london,omitempty"`
+	Shanghai       *Fork `json:"shanghai,omitempty"`
+	Cancun         *Fork `json:"cancun,omitempty"`
 	EIP150         *Fork `json:"EIP150,omitempty"`
 	EIP158         *Fork `json:"EIP158,omitempty"`
 	EIP155         *Fork `json:"EIP155,omitempty"`
@@ -77,10 +95,22 @@ func (f *Forks) IsPetersburg(block uint64) bool {
 	return f.active(f.Petersburg, block)
 }
 
+func (f *Forks) IsBerlin(block uint64) bool {
+	return f.active(f.Berlin, block)
+}
+
 func (f *Forks) IsLondon(block uint64) bool {
 	return f.active(f.London, block)
 }
 
+func (f *Forks) IsShanghai(block uint64) bool {
+	return f.active(f.Shanghai, block)
+}
+
+func (f *Forks) IsCancun(block uint64) bool {
+	return f.active(f.Cancun, block)
+}
+
 func (f *Forks) IsEIP150(block uint64) bool {
 	return f.active(f.EIP150, block)
 }
@@ -100,7 +130,10 @@ func (f *Forks) At(block uint64) ForksInTime {
 		Constantinople: f.active(f.Constantinople, block),
 		Petersburg:     f.active(f.Petersburg, block),
 		Istanbul:       f.active(f.Istanbul, block),
+		Berlin:         f.active(f.Berlin, block),
 		London:         f.active(f.London, block),
+		Shanghai:       f.active(f.Shanghai, block),
+		Cancun:         f.active(f.Cancun, block),
 		EIP150:         f.active(f.EIP150, block),
 		EIP158:         f.active(f.EIP158, block),
 		EIP155:         f.active(f.EIP155, block),
@@ -129,7 +162,10 @@ type ForksInTime struct {
 	Constantinople,
 	Petersburg,
 	Istanbul,
+	Berlin,
 	London,
+	Shanghai,
+	Cancun,
 	EIP150,
 	EIP158,
 	EIP155 bool
@@ -144,5 +180,8 @@ var AllForksEnabled = &Forks{
 	Constantinople: NewFork(0),
 	Petersburg:     NewFork(0),
 	Istanbul:       NewFork(0),
+	Berlin:         NewFork(0),
 	London:         NewFork(0),
+	Shanghai:       NewFork(0),
+	Cancun:         NewFork(0),
 }
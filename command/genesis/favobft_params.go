@@ -105,6 +105,7 @@ func (p *genesisParams) generateFavoBftChainConfig(o command.OutputFormatter) er
 			Engine: map[string]interface{}{
 				string(server.FavoBFTConsensus): favoBftConfig,
 			},
+			TxOrdering: p.getTxOrdering(),
 		},
 		Bootnodes: p.bootnodes,
 	}
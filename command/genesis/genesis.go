@@ -8,6 +8,7 @@ import (
 	"github.com/newton2049/favo-chain/command/helper"
 	"github.com/newton2049/favo-chain/consensus/ibft"
 	"github.com/newton2049/favo-chain/helper/common"
+	"github.com/newton2049/favo-chain/txpool"
 	"github.com/newton2049/favo-chain/validators"
 	"github.com/spf13/cobra"
 )
@@ -231,6 +232,35 @@ func setFlags(cmd *cobra.Command) {
 			"list of addresses to enable by default in the contract deployer allow list",
 		)
 	}
+
+	// Transaction ordering
+	{
+		cmd.Flags().StringVar(
+			&params.txOrdering,
+			txOrderingFlag,
+			"",
+			fmt.Sprintf(
+				"the order in which the sealers include the transactions in blocks (%s or %s), %s if empty",
+				txpool.GasPriceOrdering,
+				txpool.FIFOOrdering,
+				txpool.GasPriceOrdering,
+			),
+		)
+
+		cmd.Flags().StringArrayVar(
+			&params.prioritySenders,
+			prioritySendersFlag,
+			[]string{},
+			"list of addresses whose transactions are included in blocks before all the others",
+		)
+
+		cmd.Flags().Uint64Var(
+			&params.maxTxsPerSender,
+			maxTxsPerSenderFlag,
+			0,
+			"the maximum number of transactions of an account per block, unlimited if 0",
+		)
+	}
 }
 
 // setLegacyFlags sets the legacy flags to preserve backwards compatibility
@@ -14,6 +14,7 @@ import (
 	"github.com/newton2049/favo-chain/contracts/staking"
 	stakingHelper "github.com/newton2049/favo-chain/helper/staking"
 	"github.com/newton2049/favo-chain/server"
+	"github.com/newton2049/favo-chain/txpool"
 	"github.com/newton2049/favo-chain/types"
 	"github.com/newton2049/favo-chain/validators"
 )
@@ -30,6 +31,10 @@ const (
 	minValidatorCount = "min-validator-count"
 	maxValidatorCount = "max-validator-count"
 	mintableTokenFlag = "mintable-native-token"
+
+	txOrderingFlag      = "tx-ordering"
+	prioritySendersFlag = "tx-priority-senders"
+	maxTxsPerSenderFlag = "max-txs-per-sender"
 )
 
 // Legacy flags that need to be preserved for running clients
@@ -92,6 +97,11 @@ type genesisParams struct {
 	contractDeployerAllowListAdmin   []string
 	contractDeployerAllowListEnabled []string
 	mintableNativeToken              bool
+
+	// transaction ordering
+	txOrdering      string
+	prioritySenders []string
+	maxTxsPerSender uint64
 }
 
 func (p *genesisParams) validateFlags() error {
@@ -125,9 +135,27 @@ func (p *genesisParams) validateFlags() error {
 		return err
 	}
 
+	// Validate the transaction ordering policy
+	if _, err := txpool.NewOrdering(p.getTxOrdering()); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// getTxOrdering returns the transaction ordering of the chain, nil if the default one is used
+func (p *genesisParams) getTxOrdering() *chain.TxOrdering {
+	if p.txOrdering == "" && len(p.prioritySenders) == 0 && p.maxTxsPerSender == 0 {
+		return nil
+	}
+
+	return &chain.TxOrdering{
+		Policy:          p.txOrdering,
+		PrioritySenders: stringSliceToAddressSlice(p.prioritySenders),
+		MaxTxsPerSender: p.maxTxsPerSender,
+	}
+}
+
 func (p *genesisParams) isIBFTConsensus() bool {
 	return server.ConsensusType(p.consensusRaw) == server.IBFTConsensus
 }
@@ -327,9 +355,10 @@ func (p *genesisParams) initGenesisConfig() error {
 			GasUsed:    command.DefaultGenesisGasUsed,
 		},
 		Params: &chain.Params{
-			ChainID: int64(p.chainID),
-			Forks:   chain.AllForksEnabled,
-			Engine:  p.consensusEngineConfig,
+			ChainID:    int64(p.chainID),
+			Forks:      chain.AllForksEnabled,
+			Engine:     p.consensusEngineConfig,
+			TxOrdering: p.getTxOrdering(),
 		},
 		Bootnodes: p.bootnodes,
 	}
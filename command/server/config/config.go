@@ -13,27 +13,42 @@ import (
 
 // Config defines the server configuration params
 type Config struct {
-	GenesisPath              string     `json:"chain_config" yaml:"chain_config"`
-	SecretsConfigPath        string     `json:"secrets_config" yaml:"secrets_config"`
-	DataDir                  string     `json:"data_dir" yaml:"data_dir"`
-	BlockGasTarget           string     `json:"block_gas_target" yaml:"block_gas_target"`
-	GRPCAddr                 string     `json:"grpc_addr" yaml:"grpc_addr"`
-	JSONRPCAddr              string     `json:"jsonrpc_addr" yaml:"jsonrpc_addr"`
-	Telemetry                *Telemetry `json:"telemetry" yaml:"telemetry"`
-	Network                  *Network   `json:"network" yaml:"network"`
-	ShouldSeal               bool       `json:"seal" yaml:"seal"`
-	TxPool                   *TxPool    `json:"tx_pool" yaml:"tx_pool"`
-	LogLevel                 string     `json:"log_level" yaml:"log_level"`
-	RestoreFile              string     `json:"restore_file" yaml:"restore_file"`
-	BlockTime                uint64     `json:"block_time_s" yaml:"block_time_s"`
-	Headers                  *Headers   `json:"headers" yaml:"headers"`
-	LogFilePath              string     `json:"log_to" yaml:"log_to"`
-	JSONRPCBatchRequestLimit uint64     `json:"json_rpc_batch_request_limit" yaml:"json_rpc_batch_request_limit"`
-	JSONRPCBlockRangeLimit   uint64     `json:"json_rpc_block_range_limit" yaml:"json_rpc_block_range_limit"`
-	JSONLogFormat            bool       `json:"json_log_format" yaml:"json_log_format"`
+	GenesisPath                  string     `json:"chain_config" yaml:"chain_config"`
+	SecretsConfigPath            string     `json:"secrets_config" yaml:"secrets_config"`
+	DataDir                      string     `json:"data_dir" yaml:"data_dir"`
+	BlockGasTarget               string     `json:"block_gas_target" yaml:"block_gas_target"`
+	GRPCAddr                     string     `json:"grpc_addr" yaml:"grpc_addr"`
+	JSONRPCAddr                  string     `json:"jsonrpc_addr" yaml:"jsonrpc_addr"`
+	Telemetry                    *Telemetry `json:"telemetry" yaml:"telemetry"`
+	Network                      *Network   `json:"network" yaml:"network"`
+	ShouldSeal                   bool       `json:"seal" yaml:"seal"`
+	TxPool                       *TxPool    `json:"tx_pool" yaml:"tx_pool"`
+	LogLevel                     string     `json:"log_level" yaml:"log_level"`
+	RestoreFile                  string     `json:"restore_file" yaml:"restore_file"`
+	BlockTime                    uint64     `json:"block_time_s" yaml:"block_time_s"`
+	Headers                      *Headers   `json:"headers" yaml:"headers"`
+	LogFilePath                  string     `json:"log_to" yaml:"log_to"`
+	JSONRPCBatchRequestLimit     uint64     `json:"json_rpc_batch_request_limit" yaml:"json_rpc_batch_request_limit"`
+	JSONRPCBlockRangeLimit       uint64     `json:"json_rpc_block_range_limit" yaml:"json_rpc_block_range_limit"`
+	JSONRPCIPC                   bool       `json:"json_rpc_ipc" yaml:"json_rpc_ipc"`
+	JSONRPCNamespaces            []string   `json:"json_rpc_namespaces" yaml:"json_rpc_namespaces"`
+	JSONRPCAuthAddr              string     `json:"json_rpc_auth_addr" yaml:"json_rpc_auth_addr"`
+	JSONRPCAuthNamespaces        []string   `json:"json_rpc_auth_namespaces" yaml:"json_rpc_auth_namespaces"`
+	JSONRPCJWTSecretPath         string     `json:"json_rpc_jwt_secret_path" yaml:"json_rpc_jwt_secret_path"`
+	JSONRPCRateLimit             uint64     `json:"json_rpc_rate_limit" yaml:"json_rpc_rate_limit"`
+	JSONRPCGraphQL               bool       `json:"json_rpc_graphql" yaml:"json_rpc_graphql"`
+	JSONRPCGraphQLMaxDepth       uint64     `json:"json_rpc_graphql_max_depth" yaml:"json_rpc_graphql_max_depth"`
+	JSONRPCGraphQLMaxParallelism uint64     `json:"json_rpc_graphql_max_parallelism" yaml:"json_rpc_graphql_max_parallelism"`
+	JSONLogFormat                bool       `json:"json_log_format" yaml:"json_log_format"`
 
 	Relayer               bool   `json:"relayer" yaml:"relayer"`
 	NumBlockConfirmations uint64 `json:"num_block_confirmations" yaml:"num_block_confirmations"`
+
+	StatePruning            string `json:"state_pruning" yaml:"state_pruning"`
+	StateRetention          uint64 `json:"state_retention" yaml:"state_retention"`
+	StateCheckpointInterval uint64 `json:"state_checkpoint_interval" yaml:"state_checkpoint_interval"`
+
+	SnapSync bool `json:"snap_sync" yaml:"snap_sync"`
 }
 
 // Telemetry holds the config details for metric services.
@@ -54,9 +69,16 @@ type Network struct {
 
 // TxPool defines the TxPool configuration params
 type TxPool struct {
-	PriceLimit         uint64 `json:"price_limit" yaml:"price_limit"`
-	MaxSlots           uint64 `json:"max_slots" yaml:"max_slots"`
-	MaxAccountEnqueued uint64 `json:"max_account_enqueued" yaml:"max_account_enqueued"`
+	PriceLimit         uint64   `json:"price_limit" yaml:"price_limit"`
+	MaxSlots           uint64   `json:"max_slots" yaml:"max_slots"`
+	MaxAccountEnqueued uint64   `json:"max_account_enqueued" yaml:"max_account_enqueued"`
+	PriceBump          uint64   `json:"price_bump" yaml:"price_bump"`
+	AccountSlots       uint64   `json:"account_slots" yaml:"account_slots"`
+	NoJournal          bool     `json:"no_journal" yaml:"no_journal"`
+	JournalRotate      uint64   `json:"journal_rotate_s" yaml:"journal_rotate_s"`
+	Ordering           string   `json:"ordering,omitempty" yaml:"ordering,omitempty"`
+	PrioritySenders    []string `json:"priority_senders,omitempty" yaml:"priority_senders,omitempty"`
+	MaxTxsPerSender    uint64   `json:"max_txs_per_sender,omitempty" yaml:"max_txs_per_sender,omitempty"`
 }
 
 // Headers defines the HTTP response headers required to enable CORS.
@@ -79,9 +101,27 @@ const (
 	// requests with fromBlock/toBlock values (e.g. eth_getLogs)
 	DefaultJSONRPCBlockRangeLimit uint64 = 1000
 
+	// DefaultJSONRPCGraphQLMaxDepth maximum field nesting depth of the graphql queries
+	DefaultJSONRPCGraphQLMaxDepth uint64 = 16
+
+	// DefaultJSONRPCGraphQLMaxParallelism maximum number of the resolvers of a graphql query running in parallel
+	DefaultJSONRPCGraphQLMaxParallelism uint64 = 10
+
 	// DefaultNumBlockConfirmations minimal number of child blocks required for the parent block to be considered final
 	// on ethereum epoch lasts for 32 blocks. more details: https://www.alchemy.com/overviews/ethereum-commitment-levels
 	DefaultNumBlockConfirmations uint64 = 64
+
+	// ArchiveStatePruning keeps the state of every block
+	ArchiveStatePruning = "archive"
+
+	// FullStatePruning keeps the state of the recent blocks and of the checkpoints only
+	FullStatePruning = "full"
+
+	// DefaultStateRetention number of the most recent blocks whose state is kept in full pruning mode
+	DefaultStateRetention uint64 = 128
+
+	// DefaultStateCheckpointInterval interval of the blocks whose state is kept forever in full pruning mode
+	DefaultStateCheckpointInterval uint64 = 10000
 )
 
 // DefaultConfig returns the default server configuration
@@ -108,6 +148,9 @@ func DefaultConfig() *Config {
 			PriceLimit:         0,
 			MaxSlots:           4096,
 			MaxAccountEnqueued: 128,
+			PriceBump:          10,
+			AccountSlots:       16,
+			JournalRotate:      3600,
 		},
 		LogLevel:    "INFO",
 		RestoreFile: "",
@@ -115,11 +158,16 @@ func DefaultConfig() *Config {
 		Headers: &Headers{
 			AccessControlAllowOrigins: []string{"*"},
 		},
-		LogFilePath:              "",
-		JSONRPCBatchRequestLimit: DefaultJSONRPCBatchRequestLimit,
-		JSONRPCBlockRangeLimit:   DefaultJSONRPCBlockRangeLimit,
-		Relayer:                  false,
-		NumBlockConfirmations:    DefaultNumBlockConfirmations,
+		LogFilePath:                  "",
+		JSONRPCBatchRequestLimit:     DefaultJSONRPCBatchRequestLimit,
+		JSONRPCBlockRangeLimit:       DefaultJSONRPCBlockRangeLimit,
+		JSONRPCGraphQLMaxDepth:       DefaultJSONRPCGraphQLMaxDepth,
+		JSONRPCGraphQLMaxParallelism: DefaultJSONRPCGraphQLMaxParallelism,
+		Relayer:                      false,
+		NumBlockConfirmations:        DefaultNumBlockConfirmations,
+		StatePruning:                 ArchiveStatePruning,
+		StateRetention:               DefaultStateRetention,
+		StateCheckpointInterval:      DefaultStateCheckpointInterval,
 	}
 }
 
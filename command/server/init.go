@@ -12,15 +12,20 @@ import (
 
 	"github.com/newton2049/favo-chain/chain"
 	"github.com/newton2049/favo-chain/command/helper"
+	"github.com/newton2049/favo-chain/helper/hex"
 	"github.com/newton2049/favo-chain/network"
 	"github.com/newton2049/favo-chain/secrets"
 	"github.com/newton2049/favo-chain/server"
+	itrie "github.com/newton2049/favo-chain/state/immutable-trie"
 	"github.com/newton2049/favo-chain/types"
 )
 
 var (
 	errInvalidBlockTime       = errors.New("invalid block time specified")
 	errDataDirectoryUndefined = errors.New("data directory not defined")
+	errInvalidStatePruning    = errors.New("invalid state pruning mode")
+	errInvalidStateRetention  = errors.New("state retention must be greater than 0")
+	errInvalidPrioritySender  = errors.New("invalid txpool priority sender address")
 )
 
 func (p *serverParams) initConfigFromFile() error {
@@ -54,6 +59,10 @@ func (p *serverParams) initRawParams() error {
 		return err
 	}
 
+	if err := p.initStatePruning(); err != nil {
+		return err
+	}
+
 	if p.isDevMode {
 		p.initDevMode()
 	}
@@ -74,6 +83,28 @@ func (p *serverParams) initBlockTime() error {
 	return nil
 }
 
+func (p *serverParams) initStatePruning() error {
+	switch p.rawConfig.StatePruning {
+	case "", config.ArchiveStatePruning:
+		p.statePruning = nil
+
+	case config.FullStatePruning:
+		if p.rawConfig.StateRetention == 0 {
+			return errInvalidStateRetention
+		}
+
+		p.statePruning = &itrie.PrunerConfig{
+			Retention:          p.rawConfig.StateRetention,
+			CheckpointInterval: p.rawConfig.StateCheckpointInterval,
+		}
+
+	default:
+		return fmt.Errorf("%w: %s", errInvalidStatePruning, p.rawConfig.StatePruning)
+	}
+
+	return nil
+}
+
 func (p *serverParams) initDataDirLocation() error {
 	if p.rawConfig.DataDir == "" {
 		return errDataDirectoryUndefined
@@ -130,6 +161,43 @@ func (p *serverParams) initGenesisConfig() error {
 		p.genesisConfig.Params.BlockGasTarget = p.blockGasTarget
 	}
 
+	return p.initTxOrdering()
+}
+
+// initTxOrdering overrides the genesis.json transaction ordering with the one set in the txpool config
+func (p *serverParams) initTxOrdering() error {
+	txPool := p.rawConfig.TxPool
+	if txPool.Ordering == "" && len(txPool.PrioritySenders) == 0 && txPool.MaxTxsPerSender == 0 {
+		return nil
+	}
+
+	ordering := &chain.TxOrdering{}
+	if p.genesisConfig.Params.TxOrdering != nil {
+		*ordering = *p.genesisConfig.Params.TxOrdering
+	}
+
+	if txPool.Ordering != "" {
+		ordering.Policy = txPool.Ordering
+	}
+
+	if len(txPool.PrioritySenders) != 0 {
+		ordering.PrioritySenders = make([]types.Address, len(txPool.PrioritySenders))
+		for i, sender := range txPool.PrioritySenders {
+			buf, err := hex.DecodeHex(sender)
+			if err != nil || len(buf) != types.AddressLength {
+				return fmt.Errorf("%w: %s", errInvalidPrioritySender, sender)
+			}
+
+			ordering.PrioritySenders[i] = types.BytesToAddress(buf)
+		}
+	}
+
+	if txPool.MaxTxsPerSender != 0 {
+		ordering.MaxTxsPerSender = txPool.MaxTxsPerSender
+	}
+
+	p.genesisConfig.Params.TxOrdering = ordering
+
 	return nil
 }
 
@@ -233,6 +301,10 @@ func (p *serverParams) initAddresses() error {
 		return err
 	}
 
+	if err := p.initJSONRPCAuthAddress(); err != nil {
+		return err
+	}
+
 	return p.initGRPCAddress()
 }
 
@@ -309,6 +381,23 @@ func (p *serverParams) initJSONRPCAddress() error {
 	return nil
 }
 
+func (p *serverParams) initJSONRPCAuthAddress() error {
+	if !p.isJSONRPCAuthAddressSet() {
+		return nil
+	}
+
+	var parseErr error
+
+	if p.jsonRPCAuthAddress, parseErr = helper.ResolveAddr(
+		p.rawConfig.JSONRPCAuthAddr,
+		helper.LocalHostBinding,
+	); parseErr != nil {
+		return parseErr
+	}
+
+	return nil
+}
+
 func (p *serverParams) initGRPCAddress() error {
 	var parseErr error
 
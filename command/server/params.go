@@ -3,6 +3,7 @@ package server
 import (
 	"errors"
 	"net"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/multiformats/go-multiaddr"
@@ -11,36 +12,59 @@ import (
 	"github.com/newton2049/favo-chain/network"
 	"github.com/newton2049/favo-chain/secrets"
 	"github.com/newton2049/favo-chain/server"
+	itrie "github.com/newton2049/favo-chain/state/immutable-trie"
 )
 
 const (
-	configFlag                   = "config"
-	genesisPathFlag              = "chain"
-	dataDirFlag                  = "data-dir"
-	libp2pAddressFlag            = "libp2p"
-	prometheusAddressFlag        = "prometheus"
-	natFlag                      = "nat"
-	dnsFlag                      = "dns"
-	sealFlag                     = "seal"
-	maxPeersFlag                 = "max-peers"
-	maxInboundPeersFlag          = "max-inbound-peers"
-	maxOutboundPeersFlag         = "max-outbound-peers"
-	priceLimitFlag               = "price-limit"
-	jsonRPCBatchRequestLimitFlag = "json-rpc-batch-request-limit"
-	jsonRPCBlockRangeLimitFlag   = "json-rpc-block-range-limit"
-	maxSlotsFlag                 = "max-slots"
-	maxEnqueuedFlag              = "max-enqueued"
-	blockGasTargetFlag           = "block-gas-target"
-	secretsConfigFlag            = "secrets-config"
-	restoreFlag                  = "restore"
-	blockTimeFlag                = "block-time"
-	devIntervalFlag              = "dev-interval"
-	devFlag                      = "dev"
-	corsOriginFlag               = "access-control-allow-origins"
-	logFileLocationFlag          = "log-to"
+	configFlag                       = "config"
+	genesisPathFlag                  = "chain"
+	dataDirFlag                      = "data-dir"
+	libp2pAddressFlag                = "libp2p"
+	prometheusAddressFlag            = "prometheus"
+	natFlag                          = "nat"
+	dnsFlag                          = "dns"
+	sealFlag                         = "seal"
+	maxPeersFlag                     = "max-peers"
+	maxInboundPeersFlag              = "max-inbound-peers"
+	maxOutboundPeersFlag             = "max-outbound-peers"
+	priceLimitFlag                   = "price-limit"
+	jsonRPCBatchRequestLimitFlag     = "json-rpc-batch-request-limit"
+	jsonRPCBlockRangeLimitFlag       = "json-rpc-block-range-limit"
+	jsonRPCIPCFlag                   = "json-rpc-ipc"
+	jsonRPCNamespacesFlag            = "json-rpc-namespaces"
+	jsonRPCAuthAddrFlag              = "json-rpc-auth-addr"
+	jsonRPCAuthNamespacesFlag        = "json-rpc-auth-namespaces"
+	jsonRPCJWTSecretPathFlag         = "json-rpc-jwt-secret-path"
+	jsonRPCRateLimitFlag             = "json-rpc-rate-limit"
+	jsonRPCGraphQLFlag               = "json-rpc-graphql"
+	jsonRPCGraphQLMaxDepthFlag       = "json-rpc-graphql-max-depth"
+	jsonRPCGraphQLMaxParallelismFlag = "json-rpc-graphql-max-parallelism"
+	maxSlotsFlag                     = "max-slots"
+	maxEnqueuedFlag                  = "max-enqueued"
+	priceBumpFlag                    = "price-bump"
+	accountSlotsFlag                 = "account-slots"
+	noJournalFlag                    = "txpool-no-journal"
+	journalRotateFlag                = "txpool-journal-rotate"
+	txOrderingFlag                   = "txpool-ordering"
+	prioritySendersFlag              = "txpool-priority-senders"
+	maxTxsPerSenderFlag              = "txpool-max-txs-per-sender"
+	blockGasTargetFlag               = "block-gas-target"
+	secretsConfigFlag                = "secrets-config"
+	restoreFlag                      = "restore"
+	blockTimeFlag                    = "block-time"
+	devIntervalFlag                  = "dev-interval"
+	devFlag                          = "dev"
+	corsOriginFlag                   = "access-control-allow-origins"
+	logFileLocationFlag              = "log-to"
 
 	relayerFlag               = "relayer"
 	numBlockConfirmationsFlag = "num-block-confirmations"
+
+	statePruningFlag            = "state-pruning"
+	stateRetentionFlag          = "state-retention"
+	stateCheckpointIntervalFlag = "state-checkpoint-interval"
+
+	snapSyncFlag = "snap-sync"
 )
 
 // Flags that are deprecated, but need to be preserved for
@@ -71,12 +95,13 @@ type serverParams struct {
 	rawConfig  *config.Config
 	configPath string
 
-	libp2pAddress     *net.TCPAddr
-	prometheusAddress *net.TCPAddr
-	natAddress        net.IP
-	dnsAddress        multiaddr.Multiaddr
-	grpcAddress       *net.TCPAddr
-	jsonRPCAddress    *net.TCPAddr
+	libp2pAddress      *net.TCPAddr
+	prometheusAddress  *net.TCPAddr
+	natAddress         net.IP
+	dnsAddress         multiaddr.Multiaddr
+	grpcAddress        *net.TCPAddr
+	jsonRPCAddress     *net.TCPAddr
+	jsonRPCAuthAddress *net.TCPAddr
 
 	blockGasTarget uint64
 	devInterval    uint64
@@ -92,6 +117,8 @@ type serverParams struct {
 	logFileLocation string
 
 	relayer bool
+
+	statePruning *itrie.PrunerConfig
 }
 
 func (p *serverParams) isMaxPeersSet() bool {
@@ -111,6 +138,10 @@ func (p *serverParams) isPrometheusAddressSet() bool {
 	return p.rawConfig.Telemetry.PrometheusAddr != ""
 }
 
+func (p *serverParams) isJSONRPCAuthAddressSet() bool {
+	return p.rawConfig.JSONRPCAuthAddr != ""
+}
+
 func (p *serverParams) isNATAddressSet() bool {
 	return p.rawConfig.Network.NatAddr != ""
 }
@@ -155,6 +186,15 @@ func (p *serverParams) generateConfig() *server.Config {
 			AccessControlAllowOrigin: p.corsAllowedOrigins,
 			BatchLengthLimit:         p.rawConfig.JSONRPCBatchRequestLimit,
 			BlockRangeLimit:          p.rawConfig.JSONRPCBlockRangeLimit,
+			IPC:                      p.rawConfig.JSONRPCIPC,
+			Namespaces:               p.rawConfig.JSONRPCNamespaces,
+			AuthAddr:                 p.jsonRPCAuthAddress,
+			AuthNamespaces:           p.rawConfig.JSONRPCAuthNamespaces,
+			JWTSecretPath:            p.rawConfig.JSONRPCJWTSecretPath,
+			RateLimit:                p.rawConfig.JSONRPCRateLimit,
+			GraphQL:                  p.rawConfig.JSONRPCGraphQL,
+			GraphQLMaxDepth:          p.rawConfig.JSONRPCGraphQLMaxDepth,
+			GraphQLMaxParallelism:    p.rawConfig.JSONRPCGraphQLMaxParallelism,
 		},
 		GRPCAddr:   p.grpcAddress,
 		LibP2PAddr: p.libp2pAddress,
@@ -177,6 +217,10 @@ func (p *serverParams) generateConfig() *server.Config {
 		PriceLimit:         p.rawConfig.TxPool.PriceLimit,
 		MaxSlots:           p.rawConfig.TxPool.MaxSlots,
 		MaxAccountEnqueued: p.rawConfig.TxPool.MaxAccountEnqueued,
+		PriceBump:          p.rawConfig.TxPool.PriceBump,
+		AccountSlots:       p.rawConfig.TxPool.AccountSlots,
+		NoJournal:          p.rawConfig.TxPool.NoJournal,
+		JournalRotate:      time.Duration(p.rawConfig.TxPool.JournalRotate) * time.Second,
 		SecretsManager:     p.secretsConfig,
 		RestoreFile:        p.getRestoreFilePath(),
 		BlockTime:          p.rawConfig.BlockTime,
@@ -186,5 +230,9 @@ func (p *serverParams) generateConfig() *server.Config {
 
 		Relayer:               p.relayer,
 		NumBlockConfirmations: p.rawConfig.NumBlockConfirmations,
+
+		StatePruning: p.statePruning,
+
+		SnapSync: p.rawConfig.SnapSync,
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/newton2049/favo-chain/command/server/config"
 	"github.com/newton2049/favo-chain/command/server/export"
 	"github.com/newton2049/favo-chain/server"
+	"github.com/newton2049/favo-chain/txpool"
 	"github.com/spf13/cobra"
 )
 
@@ -185,6 +186,62 @@ func setFlags(cmd *cobra.Command) {
 		"maximum number of enqueued transactions per account",
 	)
 
+	cmd.Flags().Uint64Var(
+		&params.rawConfig.TxPool.PriceBump,
+		priceBumpFlag,
+		defaultConfig.TxPool.PriceBump,
+		"the minimum gas price increase, in percent, for replacing a pooled transaction of the same nonce",
+	)
+
+	cmd.Flags().Uint64Var(
+		&params.rawConfig.TxPool.AccountSlots,
+		accountSlotsFlag,
+		defaultConfig.TxPool.AccountSlots,
+		"the number of slots per account which aren't evicted for better paying transactions when the pool is full",
+	)
+
+	cmd.Flags().BoolVar(
+		&params.rawConfig.TxPool.NoJournal,
+		noJournalFlag,
+		defaultConfig.TxPool.NoJournal,
+		"disables the on-disk journal of the local transactions, which are then lost on restart",
+	)
+
+	cmd.Flags().Uint64Var(
+		&params.rawConfig.TxPool.JournalRotate,
+		journalRotateFlag,
+		defaultConfig.TxPool.JournalRotate,
+		"the interval of the rotations of the local transactions journal, in seconds",
+	)
+
+	cmd.Flags().StringVar(
+		&params.rawConfig.TxPool.Ordering,
+		txOrderingFlag,
+		defaultConfig.TxPool.Ordering,
+		fmt.Sprintf(
+			"the order in which the transactions are included in the sealed blocks (%s or %s), "+
+				"overrides the genesis ordering if set",
+			txpool.GasPriceOrdering,
+			txpool.FIFOOrdering,
+		),
+	)
+
+	cmd.Flags().StringSliceVar(
+		&params.rawConfig.TxPool.PrioritySenders,
+		prioritySendersFlag,
+		defaultConfig.TxPool.PrioritySenders,
+		"the addresses whose transactions are included in the sealed blocks before all the others, "+
+			"overrides the genesis priority senders if set",
+	)
+
+	cmd.Flags().Uint64Var(
+		&params.rawConfig.TxPool.MaxTxsPerSender,
+		maxTxsPerSenderFlag,
+		defaultConfig.TxPool.MaxTxsPerSender,
+		"the maximum number of transactions of an account per sealed block, "+
+			"overrides the genesis cap if set",
+	)
+
 	cmd.Flags().Uint64Var(
 		&params.rawConfig.BlockTime,
 		blockTimeFlag,
@@ -211,7 +268,72 @@ func setFlags(cmd *cobra.Command) {
 		jsonRPCBlockRangeLimitFlag,
 		defaultConfig.JSONRPCBlockRangeLimit,
 		"max block range to be considered when executing json-rpc requests "+
-			"that consider fromBlock/toBlock values (e.g. eth_getLogs), value of 0 disables it",
+			"that consider fromBlock/toBlock values (e.g. eth_getLogs), value of 0 disables it. "+
+			"eth_getLogs with addresses or topics only counts the blocks which aren't in the log index yet",
+	)
+
+	cmd.Flags().BoolVar(
+		&params.rawConfig.JSONRPCIPC,
+		jsonRPCIPCFlag,
+		defaultConfig.JSONRPCIPC,
+		"serve the json-rpc requests on the jsonrpc.ipc unix domain socket in the data directory",
+	)
+
+	cmd.Flags().StringSliceVar(
+		&params.rawConfig.JSONRPCNamespaces,
+		jsonRPCNamespacesFlag,
+		defaultConfig.JSONRPCNamespaces,
+		"the json-rpc namespaces served on the json-rpc address (e.g. eth,net,web3), all the namespaces if empty",
+	)
+
+	cmd.Flags().StringVar(
+		&params.rawConfig.JSONRPCAuthAddr,
+		jsonRPCAuthAddrFlag,
+		defaultConfig.JSONRPCAuthAddr,
+		"the address of the json-rpc listener authenticated with JWT bearer tokens, disabled if empty",
+	)
+
+	cmd.Flags().StringSliceVar(
+		&params.rawConfig.JSONRPCAuthNamespaces,
+		jsonRPCAuthNamespacesFlag,
+		defaultConfig.JSONRPCAuthNamespaces,
+		"the json-rpc namespaces served on the authenticated json-rpc address, all the namespaces if empty",
+	)
+
+	cmd.Flags().StringVar(
+		&params.rawConfig.JSONRPCJWTSecretPath,
+		jsonRPCJWTSecretPathFlag,
+		defaultConfig.JSONRPCJWTSecretPath,
+		"the path of the hex encoded secret of the HS256 tokens of the authenticated json-rpc listener, "+
+			"the jwtsecret file in the data directory is generated and used if empty",
+	)
+
+	cmd.Flags().Uint64Var(
+		&params.rawConfig.JSONRPCRateLimit,
+		jsonRPCRateLimitFlag,
+		defaultConfig.JSONRPCRateLimit,
+		"max number of json-rpc requests per second of each client on the json-rpc address, value of 0 disables it",
+	)
+
+	cmd.Flags().BoolVar(
+		&params.rawConfig.JSONRPCGraphQL,
+		jsonRPCGraphQLFlag,
+		defaultConfig.JSONRPCGraphQL,
+		"serve the EIP-1767 graphql queries on the /graphql path of the json-rpc address",
+	)
+
+	cmd.Flags().Uint64Var(
+		&params.rawConfig.JSONRPCGraphQLMaxDepth,
+		jsonRPCGraphQLMaxDepthFlag,
+		defaultConfig.JSONRPCGraphQLMaxDepth,
+		"max field nesting depth of the graphql queries, value of 0 disables it",
+	)
+
+	cmd.Flags().Uint64Var(
+		&params.rawConfig.JSONRPCGraphQLMaxParallelism,
+		jsonRPCGraphQLMaxParallelismFlag,
+		defaultConfig.JSONRPCGraphQLMaxParallelism,
+		"max number of the resolvers of a graphql query running in parallel",
 	)
 
 	cmd.Flags().StringVar(
@@ -235,6 +357,39 @@ func setFlags(cmd *cobra.Command) {
 		"minimal number of child blocks required for the parent block to be considered final",
 	)
 
+	cmd.Flags().StringVar(
+		&params.rawConfig.StatePruning,
+		statePruningFlag,
+		defaultConfig.StatePruning,
+		fmt.Sprintf(
+			"the state pruning mode, %q keeps the state of every block, "+
+				"%q keeps the state of the recent blocks and of the checkpoints only",
+			config.ArchiveStatePruning,
+			config.FullStatePruning,
+		),
+	)
+
+	cmd.Flags().Uint64Var(
+		&params.rawConfig.StateRetention,
+		stateRetentionFlag,
+		defaultConfig.StateRetention,
+		"number of the most recent blocks whose state is kept in full pruning mode",
+	)
+
+	cmd.Flags().Uint64Var(
+		&params.rawConfig.StateCheckpointInterval,
+		stateCheckpointIntervalFlag,
+		defaultConfig.StateCheckpointInterval,
+		"interval of the blocks whose state is kept forever in full pruning mode, value of 0 disables it",
+	)
+
+	cmd.Flags().BoolVar(
+		&params.rawConfig.SnapSync,
+		snapSyncFlag,
+		defaultConfig.SnapSync,
+		"sync the state of a recent block from the peers instead of executing all the blocks (FavoBFT only)",
+	)
+
 	setLegacyFlags(cmd)
 
 	setDevFlags(cmd)
@@ -11,6 +11,7 @@ import (
 	"github.com/newton2049/favo-chain/network"
 	"github.com/newton2049/favo-chain/secrets"
 	"github.com/newton2049/favo-chain/state"
+	itrie "github.com/newton2049/favo-chain/state/immutable-trie"
 	"github.com/newton2049/favo-chain/txpool"
 	"github.com/newton2049/favo-chain/types"
 	"google.golang.org/grpc"
@@ -69,12 +70,15 @@ type Params struct {
 	Network        *network.Server
 	Blockchain     *blockchain.Blockchain
 	Executor       *state.Executor
+	State          *itrie.State
 	Grpc           *grpc.Server
 	Logger         hclog.Logger
 	SecretsManager secrets.SecretsManager
 	BlockTime      uint64
 
 	NumBlockConfirmations uint64
+	// SnapSync syncs the state of a recent block instead of executing all the blocks
+	SnapSync bool
 }
 
 // Factory is the factory function to create a discovery consensus
@@ -27,6 +27,9 @@ type BlockBuilderParams struct {
 	// GasLimit is the gas limit for the block
 	GasLimit uint64
 
+	// BaseFee is the EIP-1559 base fee for the block
+	BaseFee uint64
+
 	// duration for one block
 	BlockTime time.Duration
 
@@ -82,6 +85,7 @@ func (b *BlockBuilder) Reset() error {
 		ReceiptsRoot: types.EmptyRootHash, // this avoids needing state for now
 		Sha3Uncles:   types.EmptyUncleHash,
 		GasLimit:     b.params.GasLimit,
+		BaseFee:      b.params.BaseFee,
 		Timestamp:    uint64(headerTime.Unix()),
 	}
 
@@ -165,6 +165,7 @@ func (p *blockchainWrapper) NewBlockBuilder(
 		Coinbase:  coinbase,
 		Executor:  p.executor,
 		GasLimit:  gasLimit,
+		BaseFee:   p.blockchain.CalculateBaseFee(parent),
 		TxPool:    txPool,
 		Logger:    logger,
 	}), nil
@@ -635,6 +635,33 @@ func (c *consensusRuntime) IsProposer(id []byte, height, round uint64) bool {
 	return bytes.Equal(id, nextProposer[:])
 }
 
+// nextProposers returns the proposers of the first two rounds of the next block,
+// which are the current and the next proposers if the next block is sealed in the first round
+func (c *consensusRuntime) nextProposers() []types.Address {
+	c.lock.RLock()
+	snapshot, ok := c.proposerCalculator.GetSnapshot()
+	c.lock.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	proposers := make([]types.Address, 0, 2)
+
+	for round := uint64(0); round < 2; round++ {
+		proposer, err := snapshot.CalcProposer(round, snapshot.Height)
+		if err != nil {
+			c.logger.Error("cannot calculate proposer", "height", snapshot.Height, "round", round, "error", err)
+
+			break
+		}
+
+		proposers = append(proposers, proposer)
+	}
+
+	return proposers
+}
+
 func (c *consensusRuntime) IsValidProposalHash(proposal *proto.Proposal, hash []byte) bool {
 	if len(proposal.RawProposal) == 0 {
 		c.logger.Error("proposal hash is not valid because proposal is empty")
@@ -204,7 +204,9 @@ func (p *Favobft) Initialize() error {
 		p.config.Logger.Named("syncer"),
 		p.config.Network,
 		p.config.Blockchain,
+		p.config.State,
 		time.Duration(p.config.BlockTime)*3*time.Second,
+		p.config.SnapSync,
 	)
 
 	// set blockchain backend
@@ -243,6 +245,11 @@ func (p *Favobft) Initialize() error {
 
 	p.ibft = newIBFTConsensusWrapper(p.logger, p.runtime, p)
 
+	// the private transactions are forwarded to the next proposers
+	if p.config.TxPool != nil {
+		p.config.TxPool.SetProposers(p.runtime.nextProposers)
+	}
+
 	if err = p.subscribeToIbftTopic(); err != nil {
 		return fmt.Errorf("IBFT topic subscription failed: %w", err)
 	}
@@ -196,6 +196,7 @@ func (i *backendIBFT) buildBlock(parent *types.Header) (*types.Block, error) {
 	}
 
 	header.GasLimit = gasLimit
+	header.BaseFee = i.blockchain.CalculateBaseFee(parent)
 
 	if err := i.currentHooks.ModifyHeader(header, i.currentSigner.Address()); err != nil {
 		return nil, err
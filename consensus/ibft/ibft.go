@@ -149,7 +149,10 @@ func Factory(params *consensus.Params) (consensus.Consensus, error) {
 			params.Logger,
 			params.Network,
 			params.Blockchain,
+			params.State,
 			time.Duration(params.BlockTime)*3*time.Second,
+			// the validators might be read from the state of each block, which isn't available with snap sync
+			false,
 		),
 		secretsManager: params.SecretsManager,
 		Grpc:           params.Grpc,
@@ -168,6 +171,11 @@ func Factory(params *consensus.Params) (consensus.Consensus, error) {
 	// Istanbul requires a different header hash function
 	p.SetHeaderHash()
 
+	// the private transactions are forwarded to the next proposers
+	if params.TxPool != nil {
+		params.TxPool.SetProposers(p.nextProposers)
+	}
+
 	return p, nil
 }
 
@@ -182,6 +182,35 @@ func (i *backendIBFT) IsProposer(id []byte, height, round uint64) bool {
 	return types.BytesToAddress(id) == nextProposer.Addr()
 }
 
+// nextProposers returns the proposers of the first two rounds of the next block,
+// which are the current and the next proposers if the next block is sealed in the first round
+func (i *backendIBFT) nextProposers() []types.Address {
+	header := i.blockchain.Header()
+
+	validators, err := i.forkManager.GetValidators(header.Number + 1)
+	if err != nil {
+		i.logger.Error("failed to get the validators", "height", header.Number+1, "err", err)
+
+		return nil
+	}
+
+	if validators.Len() == 0 {
+		return nil
+	}
+
+	lastProposer, err := i.extractProposer(header)
+	if err != nil {
+		i.logger.Error("failed to extract the last proposer", "height", header.Number, "err", err)
+
+		return nil
+	}
+
+	return []types.Address{
+		CalcProposer(validators, 0, lastProposer).Addr(),
+		CalcProposer(validators, 1, lastProposer).Addr(),
+	}
+}
+
 func (i *backendIBFT) IsValidProposalHash(proposal *protoIBFT.Proposal, hash []byte) bool {
 	proposalHash, err := i.calculateProposalHashFromBlockBytes(proposal.RawProposal, &proposal.Round)
 	if err != nil {
@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"math/big"
 	"math/bits"
@@ -12,6 +13,8 @@ import (
 	"github.com/umbracle/fastrlp"
 )
 
+var errMissingChainID = errors.New("missing chain id of the typed transaction")
+
 // TxSigner is a utility interface used to recover data from a transaction
 type TxSigner interface {
 	// Hash returns the hash of the transaction
@@ -27,7 +30,7 @@ type TxSigner interface {
 	CalculateV(parity byte) []byte
 }
 
-// NewSigner creates a new signer object (EIP155 or FrontierSigner)
+// NewSigner creates a new signer object (London, Berlin, EIP155 or FrontierSigner)
 func NewSigner(forks chain.ForksInTime, chainID uint64) TxSigner {
 	var signer TxSigner
 
@@ -37,6 +40,15 @@ func NewSigner(forks chain.ForksInTime, chainID uint64) TxSigner {
 		signer = &FrontierSigner{forks.Homestead}
 	}
 
+	// Berlin and London signers require a fallback signer for the older transaction types
+	if forks.Berlin {
+		signer = NewBerlinSigner(chainID, forks.Homestead, signer)
+	}
+
+	if forks.London {
+		signer = NewLondonSigner(chainID, forks.Homestead, signer)
+	}
+
 	return signer
 }
 
@@ -229,6 +241,203 @@ func (e *EIP155Signer) CalculateV(parity byte) []byte {
 	return reference.Bytes()
 }
 
+// NewBerlinSigner returns a new BerlinSigner object
+func NewBerlinSigner(chainID uint64, isHomestead bool, fallbackSigner TxSigner) *BerlinSigner {
+	return &BerlinSigner{
+		chainID:        chainID,
+		isHomestead:    isHomestead,
+		fallbackSigner: fallbackSigner,
+	}
+}
+
+// BerlinSigner signs EIP-2930 access list transactions.
+// Any other transaction type is handled by the fallback signer
+type BerlinSigner struct {
+	chainID        uint64
+	isHomestead    bool
+	fallbackSigner TxSigner
+}
+
+// Hash returns the keccak256 hash of the signing payload, prefixed with the transaction type
+func (b *BerlinSigner) Hash(tx *types.Transaction) types.Hash {
+	if tx.Type != types.AccessListTx {
+		return b.fallbackSigner.Hash(tx)
+	}
+
+	return calcTypedTxHash(tx, b.chainID)
+}
+
+// Sender returns the transaction sender
+func (b *BerlinSigner) Sender(tx *types.Transaction) (types.Address, error) {
+	if tx.Type != types.AccessListTx {
+		return b.fallbackSigner.Sender(tx)
+	}
+
+	return typedTxSender(tx, b.Hash(tx), b.chainID, b.isHomestead)
+}
+
+// SignTx signs the transaction using the passed in private key
+func (b *BerlinSigner) SignTx(
+	tx *types.Transaction,
+	privateKey *ecdsa.PrivateKey,
+) (*types.Transaction, error) {
+	if tx.Type != types.AccessListTx {
+		return b.fallbackSigner.SignTx(tx, privateKey)
+	}
+
+	return signTypedTx(b, tx, b.chainID, privateKey)
+}
+
+// CalculateV returns the V value for the access list transaction signatures,
+// which is the signature parity itself
+func (b *BerlinSigner) CalculateV(parity byte) []byte {
+	return big.NewInt(int64(parity)).Bytes()
+}
+
+// NewLondonSigner returns a new LondonSigner object
+func NewLondonSigner(chainID uint64, isHomestead bool, fallbackSigner TxSigner) *LondonSigner {
+	return &LondonSigner{
+		chainID:        chainID,
+		isHomestead:    isHomestead,
+		fallbackSigner: fallbackSigner,
+	}
+}
+
+// LondonSigner signs EIP-1559 dynamic fee transactions.
+// Any other transaction type is handled by the fallback signer
+type LondonSigner struct {
+	chainID        uint64
+	isHomestead    bool
+	fallbackSigner TxSigner
+}
+
+// Hash returns the keccak256 hash of the signing payload, prefixed with the transaction type
+func (l *LondonSigner) Hash(tx *types.Transaction) types.Hash {
+	if tx.Type != types.DynamicFeeTx {
+		return l.fallbackSigner.Hash(tx)
+	}
+
+	return calcTypedTxHash(tx, l.chainID)
+}
+
+// Sender returns the transaction sender
+func (l *LondonSigner) Sender(tx *types.Transaction) (types.Address, error) {
+	if tx.Type != types.DynamicFeeTx {
+		return l.fallbackSigner.Sender(tx)
+	}
+
+	return typedTxSender(tx, l.Hash(tx), l.chainID, l.isHomestead)
+}
+
+// SignTx signs the transaction using the passed in private key
+func (l *LondonSigner) SignTx(
+	tx *types.Transaction,
+	privateKey *ecdsa.PrivateKey,
+) (*types.Transaction, error) {
+	if tx.Type != types.DynamicFeeTx {
+		return l.fallbackSigner.SignTx(tx, privateKey)
+	}
+
+	return signTypedTx(l, tx, l.chainID, privateKey)
+}
+
+// CalculateV returns the V value for the dynamic fee transaction signatures,
+// which is the signature parity itself
+func (l *LondonSigner) CalculateV(parity byte) []byte {
+	return big.NewInt(int64(parity)).Bytes()
+}
+
+// calcTypedTxHash calculates the signing hash of the EIP-2718 typed transactions:
+// keccak256(type || rlp(payload without the signature values))
+func calcTypedTxHash(tx *types.Transaction, chainID uint64) types.Hash {
+	a := signerPool.Get()
+
+	v := a.NewArray()
+	v.Set(a.NewUint(chainID))
+	v.Set(a.NewUint(tx.Nonce))
+
+	if tx.Type == types.DynamicFeeTx {
+		v.Set(a.NewBigInt(tx.GasTipCap))
+		v.Set(a.NewBigInt(tx.GasFeeCap))
+	} else {
+		v.Set(a.NewBigInt(tx.GasPrice))
+	}
+
+	v.Set(a.NewUint(tx.Gas))
+
+	if tx.To == nil {
+		v.Set(a.NewNull())
+	} else {
+		v.Set(a.NewCopyBytes((*tx.To).Bytes()))
+	}
+
+	v.Set(a.NewBigInt(tx.Value))
+	v.Set(a.NewCopyBytes(tx.Input))
+	v.Set(tx.AccessList.MarshalRLPWith(a))
+
+	hash := keccak.Keccak256(nil, v.MarshalTo([]byte{byte(tx.Type)}))
+
+	signerPool.Put(a)
+
+	return types.BytesToHash(hash)
+}
+
+// typedTxSender recovers the sender of the EIP-2718 typed transactions
+func typedTxSender(tx *types.Transaction, hash types.Hash, chainID uint64, isHomestead bool) (types.Address, error) {
+	// the chain id is part of the signing payload of the typed transactions, it can't be left out
+	if tx.ChainID == nil {
+		return types.Address{}, errMissingChainID
+	}
+
+	if tx.ChainID.Cmp(new(big.Int).SetUint64(chainID)) != 0 {
+		return types.Address{}, fmt.Errorf("invalid chain id: have %d want %d", tx.ChainID, chainID)
+	}
+
+	// the V value of the typed transactions is the parity of the signature
+	refV := big.NewInt(0)
+	if tx.V != nil {
+		refV.SetBytes(tx.V.Bytes())
+	}
+
+	sig, err := encodeSignature(tx.R, tx.S, refV, isHomestead)
+	if err != nil {
+		return types.Address{}, err
+	}
+
+	pub, err := Ecrecover(hash.Bytes(), sig)
+	if err != nil {
+		return types.Address{}, err
+	}
+
+	buf := Keccak256(pub[1:])[12:]
+
+	return types.BytesToAddress(buf), nil
+}
+
+// signTypedTx signs the EIP-2718 typed transactions using the passed in private key
+func signTypedTx(
+	signer TxSigner,
+	tx *types.Transaction,
+	chainID uint64,
+	privateKey *ecdsa.PrivateKey,
+) (*types.Transaction, error) {
+	tx = tx.Copy()
+	tx.ChainID = new(big.Int).SetUint64(chainID)
+
+	h := signer.Hash(tx)
+
+	sig, err := Sign(privateKey, h[:])
+	if err != nil {
+		return nil, err
+	}
+
+	tx.R = new(big.Int).SetBytes(sig[:32])
+	tx.S = new(big.Int).SetBytes(sig[32:64])
+	tx.V = new(big.Int).SetBytes(signer.CalculateV(sig[64]))
+
+	return tx, nil
+}
+
 // encodeSignature generates a signature value based on the R, S and V value
 func encodeSignature(R, S, V *big.Int, isHomestead bool) ([]byte, error) {
 	if !ValidateSignatureValues(V, R, S, isHomestead) {
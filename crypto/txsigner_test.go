@@ -144,3 +144,98 @@ func TestEIP155Signer_ChainIDMismatch(t *testing.T) {
 		}
 	}
 }
+
+func TestLondonSigner(t *testing.T) {
+	t.Parallel()
+
+	toAddress := types.StringToAddress("1")
+	key, err := GenerateECDSAKey()
+	assert.NoError(t, err)
+
+	signer := NewLondonSigner(100, true, NewEIP155Signer(chain.AllForksEnabled.At(0), 100))
+
+	t.Run("dynamic fee transaction", func(t *testing.T) {
+		t.Parallel()
+
+		txn := &types.Transaction{
+			Type:      types.DynamicFeeTx,
+			To:        &toAddress,
+			Value:     big.NewInt(10),
+			GasPrice:  big.NewInt(0),
+			GasTipCap: big.NewInt(2),
+			GasFeeCap: big.NewInt(20),
+		}
+
+		signedTx, err := signer.SignTx(txn, key)
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(100), signedTx.ChainID)
+
+		from, err := signer.Sender(signedTx)
+		assert.NoError(t, err)
+		assert.Equal(t, PubKeyToAddress(&key.PublicKey), from)
+
+		// a signer for another chain should reject the transaction
+		_, err = NewLondonSigner(1, true, &FrontierSigner{}).Sender(signedTx)
+		assert.Error(t, err)
+
+		// the transaction without chain id should be rejected
+		signedTx.ChainID = nil
+
+		_, err = signer.Sender(signedTx)
+		assert.ErrorIs(t, err, errMissingChainID)
+	})
+
+	t.Run("legacy transaction", func(t *testing.T) {
+		t.Parallel()
+
+		txn := &types.Transaction{
+			To:       &toAddress,
+			Value:    big.NewInt(10),
+			GasPrice: big.NewInt(1),
+		}
+
+		signedTx, err := signer.SignTx(txn, key)
+		assert.NoError(t, err)
+
+		from, err := NewEIP155Signer(chain.AllForksEnabled.At(0), 100).Sender(signedTx)
+		assert.NoError(t, err)
+		assert.Equal(t, PubKeyToAddress(&key.PublicKey), from)
+	})
+}
+
+func TestBerlinSigner(t *testing.T) {
+	t.Parallel()
+
+	toAddress := types.StringToAddress("1")
+	key, err := GenerateECDSAKey()
+	assert.NoError(t, err)
+
+	signer := NewSigner(chain.AllForksEnabled.At(0), 100)
+
+	txn := &types.Transaction{
+		Type:     types.AccessListTx,
+		To:       &toAddress,
+		Value:    big.NewInt(10),
+		GasPrice: big.NewInt(1),
+		AccessList: types.TxAccessList{
+			{
+				Address:     toAddress,
+				StorageKeys: []types.Hash{types.StringToHash("1")},
+			},
+		},
+	}
+
+	signedTx, err := signer.SignTx(txn, key)
+	assert.NoError(t, err)
+
+	from, err := signer.Sender(signedTx)
+	assert.NoError(t, err)
+	assert.Equal(t, PubKeyToAddress(&key.PublicKey), from)
+
+	// the access list is part of the signed payload
+	signedTx.AccessList[0].StorageKeys[0] = types.StringToHash("2")
+
+	from, err = signer.Sender(signedTx)
+	assert.NoError(t, err)
+	assert.NotEqual(t, PubKeyToAddress(&key.PublicKey), from)
+}
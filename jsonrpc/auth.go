@@ -0,0 +1,109 @@
+package jsonrpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// jwtMaxClockSkew is the maximum difference between the issuance time of a token and the local time
+const jwtMaxClockSkew = 60 * time.Second
+
+var (
+	errMissingToken     = errors.New("missing bearer token")
+	errMalformedToken   = errors.New("malformed token")
+	errUnsupportedAlg   = errors.New("unsupported token signing algorithm")
+	errInvalidSignature = errors.New("invalid token signature")
+	errStaleToken       = errors.New("token issued at is too far from the current time")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+type jwtClaims struct {
+	IssuedAt int64 `json:"iat"`
+}
+
+// verifyJWT verifies the token is signed with the secret using HS256,
+// and that it was issued within the allowed clock skew from now
+func verifyJWT(token string, secret []byte, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errMalformedToken
+	}
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return err
+	}
+
+	if header.Alg != "HS256" {
+		return errUnsupportedAlg
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errMalformedToken
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errInvalidSignature
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return err
+	}
+
+	issuedAt := time.Unix(claims.IssuedAt, 0)
+	if issuedAt.Before(now.Add(-jwtMaxClockSkew)) || issuedAt.After(now.Add(jwtMaxClockSkew)) {
+		return errStaleToken
+	}
+
+	return nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errMalformedToken
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return errMalformedToken
+	}
+
+	return nil
+}
+
+// authMiddleware rejects the requests without a bearer token signed with the secret
+func authMiddleware(secret []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+			err := errMissingToken
+			if token != "" && token != r.Header.Get("Authorization") {
+				err = verifyJWT(token, secret, time.Now())
+			}
+
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
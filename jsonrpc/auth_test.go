@@ -0,0 +1,83 @@
+package jsonrpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestJWT(alg string, issuedAt int64, secret []byte) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"alg":"%s","typ":"JWT"}`, alg)))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"iat":%d}`, issuedAt)))
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(header + "." + claims))
+
+	return header + "." + claims + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyJWT(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	now := time.Now()
+
+	cases := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"valid", newTestJWT("HS256", now.Unix(), secret), nil},
+		{"skewed within the limit", newTestJWT("HS256", now.Add(-30*time.Second).Unix(), secret), nil},
+		{"stale", newTestJWT("HS256", now.Add(-2*time.Minute).Unix(), secret), errStaleToken},
+		{"future", newTestJWT("HS256", now.Add(2*time.Minute).Unix(), secret), errStaleToken},
+		{"other secret", newTestJWT("HS256", now.Unix(), []byte("other")), errInvalidSignature},
+		{"other algorithm", newTestJWT("none", now.Unix(), secret), errUnsupportedAlg},
+		{"malformed", "token", errMalformedToken},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, c.err, verifyJWT(c.token, secret, now))
+		})
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	handler := authMiddleware(secret)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(authorization string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	token := newTestJWT("HS256", time.Now().Unix(), secret)
+
+	assert.Equal(t, http.StatusOK, serve("Bearer "+token))
+	assert.Equal(t, http.StatusUnauthorized, serve(""))
+	assert.Equal(t, http.StatusUnauthorized, serve(token))
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer "+newTestJWT("HS256", time.Now().Unix(), []byte("other"))))
+}
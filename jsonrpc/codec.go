@@ -94,9 +94,11 @@ func (e *ObjectError) Error() string {
 }
 
 const (
-	pending  = "pending"
-	latest   = "latest"
-	earliest = "earliest"
+	pending   = "pending"
+	latest    = "latest"
+	earliest  = "earliest"
+	safe      = "safe"
+	finalized = "finalized"
 )
 
 const (
@@ -156,6 +158,10 @@ func stringToBlockNumber(str string) (BlockNumber, error) {
 	switch str {
 	case pending, latest:
 		return LatestBlockNumber, nil
+	case safe, finalized:
+		// the blocks are final as soon as they are written with the BFT consensus,
+		// so the safe and the finalized blocks are the latest block
+		return LatestBlockNumber, nil
 	case earliest:
 		return EarliestBlockNumber, nil
 	}
@@ -2,12 +2,15 @@ package jsonrpc
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/newton2049/favo-chain/helper/hex"
 	"github.com/newton2049/favo-chain/state/runtime/tracer"
+	"github.com/newton2049/favo-chain/state/runtime/tracer/calltracer"
+	"github.com/newton2049/favo-chain/state/runtime/tracer/prestatetracer"
 	"github.com/newton2049/favo-chain/state/runtime/tracer/structtracer"
 	"github.com/newton2049/favo-chain/types"
 )
@@ -21,6 +24,13 @@ var (
 	ErrTraceGenesisBlock = errors.New("genesis is not traceable")
 	// ErrNoConfig is an error returns when config is empty
 	ErrNoConfig = errors.New("missing config object")
+	// ErrUnknownTracer is an error returned when the requested tracer doesn't exist
+	ErrUnknownTracer = errors.New("unknown tracer")
+)
+
+const (
+	callTracerName     = "callTracer"
+	prestateTracerName = "prestateTracer"
 )
 
 type debugBlockchainStore interface {
@@ -39,6 +49,12 @@ type debugBlockchainStore interface {
 	// GetBlockByNumber gets a block using the provided height
 	GetBlockByNumber(num uint64, full bool) (*types.Block, bool)
 
+	// GetReceiptsByHash returns the receipts of the block with the given hash
+	GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error)
+
+	// SetHead rewinds the canonical chain to the block with the given number
+	SetHead(number uint64) error
+
 	// TraceBlock traces all transactions in the given block
 	TraceBlock(*types.Block, tracer.Tracer) ([]interface{}, error)
 
@@ -69,11 +85,21 @@ type Debug struct {
 }
 
 type TraceConfig struct {
-	EnableMemory     bool    `json:"enableMemory"`
-	DisableStack     bool    `json:"disableStack"`
-	DisableStorage   bool    `json:"disableStorage"`
-	EnableReturnData bool    `json:"enableReturnData"`
-	Timeout          *string `json:"timeout"`
+	EnableMemory     bool            `json:"enableMemory"`
+	DisableStack     bool            `json:"disableStack"`
+	DisableStorage   bool            `json:"disableStorage"`
+	EnableReturnData bool            `json:"enableReturnData"`
+	Timeout          *string         `json:"timeout"`
+	Tracer           string          `json:"tracer"`
+	TracerConfig     json.RawMessage `json:"tracerConfig"`
+}
+
+type callTracerConfig struct {
+	OnlyTopCall bool `json:"onlyTopCall"`
+}
+
+type prestateTracerConfig struct {
+	DiffMode bool `json:"diffMode"`
 }
 
 func (d *Debug) TraceBlockByNumber(
@@ -166,13 +192,78 @@ func (d *Debug) TraceCall(
 	}
 
 	tracer, cancel, err := newTracer(config)
+	if err != nil {
+		return nil, err
+	}
+
 	defer cancel()
 
+	return d.store.TraceCall(tx, header, tracer)
+}
+
+// GetRawHeader returns the RLP encoding of the header of the given block
+func (d *Debug) GetRawHeader(filter BlockNumberOrHash) (interface{}, error) {
+	header, err := GetHeaderFromBlockNumberOrHash(filter, d.store)
 	if err != nil {
 		return nil, err
 	}
 
-	return d.store.TraceCall(tx, header, tracer)
+	return argBytesPtr(header.MarshalRLP()), nil
+}
+
+// GetRawBlock returns the RLP encoding of the given block
+func (d *Debug) GetRawBlock(filter BlockNumberOrHash) (interface{}, error) {
+	header, err := GetHeaderFromBlockNumberOrHash(filter, d.store)
+	if err != nil {
+		return nil, err
+	}
+
+	block, ok := d.store.GetBlockByHash(header.Hash, true)
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", header.Hash)
+	}
+
+	return argBytesPtr(block.MarshalRLP()), nil
+}
+
+// GetRawReceipts returns the consensus encodings of the receipts of the given block
+func (d *Debug) GetRawReceipts(filter BlockNumberOrHash) (interface{}, error) {
+	header, err := GetHeaderFromBlockNumberOrHash(filter, d.store)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts, err := d.store.GetReceiptsByHash(header.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	rawReceipts := make([]argBytes, len(receipts))
+	for i, receipt := range receipts {
+		rawReceipts[i] = receipt.MarshalRLP()
+	}
+
+	return rawReceipts, nil
+}
+
+// GetRawTransaction returns the RLP encoding of the mined transaction with the given hash
+func (d *Debug) GetRawTransaction(txHash types.Hash) (interface{}, error) {
+	tx, _ := GetTxAndBlockByTxHash(txHash, d.store)
+	if tx == nil {
+		return nil, nil
+	}
+
+	return argBytesPtr(tx.MarshalRLP()), nil
+}
+
+// SetHead rewinds the canonical chain to the block with the given number,
+// it's only served on the admin listeners
+func (d *Debug) SetHead(number argUint64) (interface{}, error) {
+	if err := d.store.SetHead(uint64(number)); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
 }
 
 func (d *Debug) traceBlock(
@@ -184,12 +275,12 @@ func (d *Debug) traceBlock(
 	}
 
 	tracer, cancel, err := newTracer(config)
-	defer cancel()
-
 	if err != nil {
 		return nil, err
 	}
 
+	defer cancel()
+
 	return d.store.TraceBlock(block, tracer)
 }
 
@@ -214,12 +305,10 @@ func newTracer(config *TraceConfig) (
 		}
 	}
 
-	tracer := structtracer.NewStructTracer(structtracer.Config{
-		EnableMemory:     config.EnableMemory,
-		EnableStack:      !config.DisableStack,
-		EnableStorage:    !config.DisableStorage,
-		EnableReturnData: config.EnableReturnData,
-	})
+	tracer, err := newTracerByName(config)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), timeout)
 
@@ -234,3 +323,52 @@ func newTracer(config *TraceConfig) (
 	// cancellation of context is done by caller
 	return tracer, cancel, nil
 }
+
+// newTracerByName creates the tracer selected by the tracer option of the config,
+// the opcode-level struct tracer is used by default
+func newTracerByName(config *TraceConfig) (tracer.Tracer, error) {
+	switch config.Tracer {
+	case "":
+		return structtracer.NewStructTracer(structtracer.Config{
+			EnableMemory:     config.EnableMemory,
+			EnableStack:      !config.DisableStack,
+			EnableStorage:    !config.DisableStorage,
+			EnableReturnData: config.EnableReturnData,
+		}), nil
+
+	case callTracerName:
+		var tracerConfig callTracerConfig
+		if err := unmarshalTracerConfig(config.TracerConfig, &tracerConfig); err != nil {
+			return nil, err
+		}
+
+		return calltracer.NewCallTracer(calltracer.Config{
+			OnlyTopCall: tracerConfig.OnlyTopCall,
+		}), nil
+
+	case prestateTracerName:
+		var tracerConfig prestateTracerConfig
+		if err := unmarshalTracerConfig(config.TracerConfig, &tracerConfig); err != nil {
+			return nil, err
+		}
+
+		return prestatetracer.NewPrestateTracer(prestatetracer.Config{
+			DiffMode: tracerConfig.DiffMode,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTracer, config.Tracer)
+	}
+}
+
+func unmarshalTracerConfig(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid tracer config: %w", err)
+	}
+
+	return nil
+}
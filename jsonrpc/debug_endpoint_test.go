@@ -2,12 +2,16 @@ package jsonrpc
 
 import (
 	"encoding/json"
+	"errors"
 	"math/big"
 	"testing"
 	"time"
 
 	"github.com/newton2049/favo-chain/helper/hex"
 	"github.com/newton2049/favo-chain/state/runtime/tracer"
+	"github.com/newton2049/favo-chain/state/runtime/tracer/calltracer"
+	"github.com/newton2049/favo-chain/state/runtime/tracer/prestatetracer"
+	"github.com/newton2049/favo-chain/state/runtime/tracer/structtracer"
 	"github.com/newton2049/favo-chain/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -18,6 +22,8 @@ type debugEndpointMockStore struct {
 	readTxLookupFn      func(types.Hash) (types.Hash, bool)
 	getBlockByHashFn    func(types.Hash, bool) (*types.Block, bool)
 	getBlockByNumberFn  func(uint64, bool) (*types.Block, bool)
+	getReceiptsByHashFn func(types.Hash) ([]*types.Receipt, error)
+	setHeadFn           func(uint64) error
 	traceBlockFn        func(*types.Block, tracer.Tracer) ([]interface{}, error)
 	traceTxnFn          func(*types.Block, types.Hash, tracer.Tracer) (interface{}, error)
 	traceCallFn         func(*types.Transaction, *types.Header, tracer.Tracer) (interface{}, error)
@@ -45,6 +51,14 @@ func (s *debugEndpointMockStore) GetBlockByNumber(num uint64, full bool) (*types
 	return s.getBlockByNumberFn(num, full)
 }
 
+func (s *debugEndpointMockStore) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
+	return s.getReceiptsByHashFn(hash)
+}
+
+func (s *debugEndpointMockStore) SetHead(number uint64) error {
+	return s.setHeadFn(number)
+}
+
 func (s *debugEndpointMockStore) TraceBlock(block *types.Block, tracer tracer.Tracer) ([]interface{}, error) {
 	return s.traceBlockFn(block, tracer)
 }
@@ -138,6 +152,16 @@ func TestDebugTraceConfigDecode(t *testing.T) {
 				Timeout:          &timeout15s,
 			},
 		},
+		{
+			input: `{
+				"tracer": "callTracer",
+				"tracerConfig": {"onlyTopCall": true}
+			}`,
+			expected: TraceConfig{
+				Tracer:       callTracerName,
+				TracerConfig: json.RawMessage(`{"onlyTopCall": true}`),
+			},
+		},
 		{
 			input: `{
 				"enableMemory": true,
@@ -688,6 +712,141 @@ func TestTraceCall(t *testing.T) {
 	}
 }
 
+func TestDebugGetRawHeaderAndBlock(t *testing.T) {
+	t.Parallel()
+
+	block := &types.Block{
+		Header:       testHeader10,
+		Transactions: []*types.Transaction{testTx1},
+	}
+
+	store := &debugEndpointMockStore{
+		getHeaderByNumberFn: func(num uint64) (*types.Header, bool) {
+			if num != testHeader10.Number {
+				return nil, false
+			}
+
+			return testHeader10, true
+		},
+		getBlockByHashFn: func(hash types.Hash, full bool) (*types.Block, bool) {
+			assert.Equal(t, testHeader10.Hash, hash)
+			assert.True(t, full)
+
+			return block, true
+		},
+	}
+
+	number := BlockNumber(testHeader10.Number)
+	endpoint := &Debug{store}
+	filter := BlockNumberOrHash{BlockNumber: &number}
+
+	res, err := endpoint.GetRawHeader(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, argBytesPtr(testHeader10.MarshalRLP()), res)
+
+	res, err = endpoint.GetRawBlock(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, argBytesPtr(block.MarshalRLP()), res)
+
+	missing := BlockNumber(11)
+
+	_, err = endpoint.GetRawHeader(BlockNumberOrHash{BlockNumber: &missing})
+	assert.Error(t, err)
+
+	_, err = endpoint.GetRawBlock(BlockNumberOrHash{BlockNumber: &missing})
+	assert.Error(t, err)
+}
+
+func TestDebugGetRawReceipts(t *testing.T) {
+	t.Parallel()
+
+	receipts := []*types.Receipt{
+		{
+			CumulativeGasUsed: 21000,
+			TxHash:            testTxHash1,
+		},
+		{
+			TransactionType:   types.DynamicFeeTx,
+			CumulativeGasUsed: 42000,
+		},
+	}
+
+	receipts[0].SetStatus(types.ReceiptSuccess)
+	receipts[1].SetStatus(types.ReceiptFailed)
+
+	endpoint := &Debug{&debugEndpointMockStore{
+		getHeaderByNumberFn: func(num uint64) (*types.Header, bool) {
+			return testHeader10, true
+		},
+		getReceiptsByHashFn: func(hash types.Hash) ([]*types.Receipt, error) {
+			assert.Equal(t, testHeader10.Hash, hash)
+
+			return receipts, nil
+		},
+	}}
+
+	number := BlockNumber(testHeader10.Number)
+
+	res, err := endpoint.GetRawReceipts(BlockNumberOrHash{BlockNumber: &number})
+	assert.NoError(t, err)
+	assert.Equal(t, []argBytes{receipts[0].MarshalRLP(), receipts[1].MarshalRLP()}, res)
+}
+
+func TestDebugGetRawTransaction(t *testing.T) {
+	t.Parallel()
+
+	endpoint := &Debug{&debugEndpointMockStore{
+		readTxLookupFn: func(hash types.Hash) (types.Hash, bool) {
+			if hash != testTxHash1 {
+				return types.ZeroHash, false
+			}
+
+			return testBlock10.Hash(), true
+		},
+		getBlockByHashFn: func(hash types.Hash, full bool) (*types.Block, bool) {
+			return &types.Block{
+				Header:       testHeader10,
+				Transactions: []*types.Transaction{testTx1},
+			}, true
+		},
+	}}
+
+	res, err := endpoint.GetRawTransaction(testTxHash1)
+	assert.NoError(t, err)
+	assert.Equal(t, argBytesPtr(testTx1.MarshalRLP()), res)
+
+	// the unknown transactions are null
+	res, err = endpoint.GetRawTransaction(testHash11)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestDebugSetHead(t *testing.T) {
+	t.Parallel()
+
+	var head uint64
+
+	endpoint := &Debug{&debugEndpointMockStore{
+		setHeadFn: func(number uint64) error {
+			if number > 100 {
+				return errors.New("invalid head number")
+			}
+
+			head = number
+
+			return nil
+		},
+	}}
+
+	res, err := endpoint.SetHead(argUint64(10))
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.Equal(t, uint64(10), head)
+
+	_, err = endpoint.SetHead(argUint64(101))
+	assert.Error(t, err)
+}
+
 func Test_newTracer(t *testing.T) {
 	t.Parallel()
 
@@ -709,6 +868,38 @@ func Test_newTracer(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("should create tracer by name", func(t *testing.T) {
+		t.Parallel()
+
+		for name, expected := range map[string]tracer.Tracer{
+			"":                 &structtracer.StructTracer{},
+			callTracerName:     &calltracer.CallTracer{},
+			prestateTracerName: &prestatetracer.PrestateTracer{},
+		} {
+			tracer, cancel, err := newTracer(&TraceConfig{
+				Tracer:       name,
+				TracerConfig: json.RawMessage(`{"onlyTopCall": true, "diffMode": true}`),
+			})
+
+			assert.NoError(t, err)
+			assert.IsType(t, expected, tracer)
+
+			cancel()
+		}
+	})
+
+	t.Run("should return error if tracer is unknown", func(t *testing.T) {
+		t.Parallel()
+
+		tracer, cancel, err := newTracer(&TraceConfig{
+			Tracer: "unknownTracer",
+		})
+
+		assert.Nil(t, tracer)
+		assert.Nil(t, cancel)
+		assert.ErrorIs(t, err, ErrUnknownTracer)
+	})
+
 	t.Run("should return error if arg is nil", func(t *testing.T) {
 		t.Parallel()
 
@@ -8,6 +8,7 @@ import (
 	"math"
 	"reflect"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/hashicorp/go-hclog"
@@ -36,6 +37,7 @@ type endpoints struct {
 	TxPool *TxPool
 	Bridge *Bridge
 	Debug  *Debug
+	Trace  *Trace
 }
 
 // Dispatcher handles all json rpc requests by delegating
@@ -47,6 +49,17 @@ type Dispatcher struct {
 	endpoints     endpoints
 
 	params *dispatcherParams
+
+	// namespaces are the enabled namespaces, all the namespaces are enabled if nil
+	namespaces map[string]struct{}
+
+	// admin is whether the admin methods are served
+	admin bool
+}
+
+// adminMethods are the methods which are only served on the authenticated and ipc listeners
+var adminMethods = map[string]struct{}{
+	"debug_setHead": {},
 }
 
 type dispatcherParams struct {
@@ -105,6 +118,10 @@ func (d *Dispatcher) registerEndpoints(store JSONRPCStore) error {
 	d.endpoints.Debug = &Debug{
 		store,
 	}
+	d.endpoints.Trace = &Trace{
+		store,
+		d.params.blockRangeLimit,
+	}
 
 	var err error
 
@@ -128,7 +145,53 @@ func (d *Dispatcher) registerEndpoints(store JSONRPCStore) error {
 		return err
 	}
 
-	return d.registerService("debug", d.endpoints.Debug)
+	if err = d.registerService("debug", d.endpoints.Debug); err != nil {
+		return err
+	}
+
+	return d.registerService("trace", d.endpoints.Trace)
+}
+
+// withNamespaces returns the dispatcher of the same services which only serves the given namespaces,
+// or all the namespaces if none is given
+func (d *Dispatcher) withNamespaces(namespaces []string) (*Dispatcher, error) {
+	if len(namespaces) == 0 {
+		return d, nil
+	}
+
+	enabled := make(map[string]struct{}, len(namespaces))
+
+	for _, namespace := range namespaces {
+		if _, ok := d.serviceMap[namespace]; !ok {
+			return nil, fmt.Errorf("unknown json-rpc namespace %s", namespace)
+		}
+
+		enabled[namespace] = struct{}{}
+	}
+
+	nd := *d
+	nd.namespaces = enabled
+
+	return &nd, nil
+}
+
+// withAdmin returns the dispatcher of the same services which also serves the admin methods
+func (d *Dispatcher) withAdmin() *Dispatcher {
+	nd := *d
+	nd.admin = true
+
+	return &nd
+}
+
+// isNamespaceEnabled returns whether the methods of the namespace are served
+func (d *Dispatcher) isNamespaceEnabled(namespace string) bool {
+	if d.namespaces == nil {
+		return true
+	}
+
+	_, ok := d.namespaces[namespace]
+
+	return ok
 }
 
 func (d *Dispatcher) getFnHandler(req Request) (*serviceData, *funcData, Error) {
@@ -139,6 +202,14 @@ func (d *Dispatcher) getFnHandler(req Request) (*serviceData, *funcData, Error)
 
 	serviceName, funcName := callName[0], callName[1]
 
+	if !d.isNamespaceEnabled(serviceName) {
+		return nil, nil, NewMethodNotFoundError(req.Method)
+	}
+
+	if _, ok := adminMethods[req.Method]; ok && !d.admin {
+		return nil, nil, NewMethodNotFoundError(req.Method)
+	}
+
 	service, ok := d.serviceMap[serviceName]
 	if !ok {
 		return nil, nil, NewMethodNotFoundError(req.Method)
@@ -193,15 +264,34 @@ func (d *Dispatcher) handleSubscribe(req Request, conn wsConn) (string, Error) {
 	}
 
 	var filterID string
-	if subscribeMethod == "newHeads" {
+
+	switch subscribeMethod {
+	case "newHeads":
 		filterID = d.filterManager.NewBlockFilter(conn)
-	} else if subscribeMethod == "logs" {
+	case "logs":
+		if len(params) < 2 {
+			return "", NewInvalidParamsError("Invalid params")
+		}
+
 		logQuery, err := decodeLogQueryFromInterface(params[1])
 		if err != nil {
 			return "", NewInternalError(err.Error())
 		}
 		filterID = d.filterManager.NewLogFilter(logQuery, conn)
-	} else {
+	case "newPendingTransactions":
+		// the optional second param requests the whole transactions instead of the hashes
+		full := false
+
+		if len(params) > 1 {
+			if full, ok = params[1].(bool); !ok {
+				return "", NewInvalidParamsError("Invalid params")
+			}
+		}
+
+		filterID = d.filterManager.NewPendingTxFilter(full, conn)
+	case "syncing":
+		filterID = d.filterManager.NewSyncingFilter(conn)
+	default:
 		return "", NewSubscriptionNotFoundError(subscribeMethod)
 	}
 
@@ -236,6 +326,10 @@ func (d *Dispatcher) HandleWs(reqBody []byte, conn wsConn) ([]byte, error) {
 		return NewRPCResponse(req.ID, "2.0", nil, NewInvalidRequestError("Invalid json request")).Bytes()
 	}
 
+	if (req.Method == "eth_subscribe" || req.Method == "eth_unsubscribe") && !d.isNamespaceEnabled("eth") {
+		return NewRPCResponse(req.ID, "2.0", nil, NewMethodNotFoundError(req.Method)).Bytes()
+	}
+
 	// if the request method is eth_subscribe we need to create a
 	// new filter with ws connection
 	if req.Method == "eth_subscribe" {
@@ -323,6 +417,8 @@ func (d *Dispatcher) Handle(reqBody []byte) ([]byte, error) {
 		).Bytes()
 	}
 
+	updateBatchMetrics(len(requests))
+
 	responses := make([]Response, 0)
 
 	for _, req := range requests {
@@ -349,11 +445,23 @@ func (d *Dispatcher) Handle(reqBody []byte) ([]byte, error) {
 func (d *Dispatcher) handleReq(req Request) ([]byte, Error) {
 	d.logger.Debug("request", "method", req.Method, "id", req.ID)
 
+	start := time.Now()
+
 	service, fd, ferr := d.getFnHandler(req)
 	if ferr != nil {
+		updateRequestMetrics(unknownMethod, start, ferr)
+
 		return nil, ferr
 	}
 
+	data, ferr := d.callFn(req, service, fd)
+	updateRequestMetrics(req.Method, start, ferr)
+
+	return data, ferr
+}
+
+// callFn calls the function of the service with the params of the request
+func (d *Dispatcher) callFn(req Request, service *serviceData, fd *funcData) ([]byte, Error) {
 	inArgs := make([]reflect.Value, fd.inNum)
 	inArgs[0] = service.sv
 
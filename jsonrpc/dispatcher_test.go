@@ -99,6 +99,71 @@ func TestDispatcher_HandleWebsocketConnection_EthSubscribe(t *testing.T) {
 			t.Fatal("\"newHeads\" event not received in 2 seconds")
 		}
 	})
+
+	t.Run("clients should be able to receive \"newPendingTransactions\" event thru eth_subscribe", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMockStore()
+		dispatcher := newTestDispatcher(t,
+			hclog.NewNullLogger(),
+			store,
+			&dispatcherParams{
+				chainID:                 0,
+				priceLimit:              0,
+				jsonRPCBatchLengthLimit: 20,
+				blockRangeLimit:         1000,
+			},
+		)
+		mockConnection, msgCh := newMockWsConnWithMsgCh()
+
+		req := []byte(`{
+		"method": "eth_subscribe",
+		"params": ["newPendingTransactions"]
+	}`)
+		if _, err := dispatcher.HandleWs(req, mockConnection); err != nil {
+			t.Fatal(err)
+		}
+
+		store.emitPendingTx(&types.Transaction{
+			Hash: types.StringToHash("1"),
+		})
+
+		select {
+		case msg := <-msgCh:
+			assert.Contains(t, string(msg), types.StringToHash("1").String())
+		case <-time.After(2 * time.Second):
+			t.Fatal("\"newPendingTransactions\" event not received in 2 seconds")
+		}
+	})
+
+	t.Run("clients should be able to subscribe to \"syncing\" event thru eth_subscribe", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMockStore()
+		dispatcher := newTestDispatcher(t,
+			hclog.NewNullLogger(),
+			store,
+			&dispatcherParams{
+				chainID:                 0,
+				priceLimit:              0,
+				jsonRPCBatchLengthLimit: 20,
+				blockRangeLimit:         1000,
+			},
+		)
+		mockConnection, _ := newMockWsConnWithMsgCh()
+
+		req := []byte(`{
+		"id": 1,
+		"method": "eth_subscribe",
+		"params": ["syncing"]
+	}`)
+		resp, err := dispatcher.HandleWs(req, mockConnection)
+		require.NoError(t, err)
+
+		var filterID string
+		require.NoError(t, expectJSONResult(resp, &filterID))
+		assert.True(t, dispatcher.filterManager.Exists(filterID))
+	})
 }
 
 func TestDispatcher_WebsocketConnection_RequestFormats(t *testing.T) {
@@ -465,3 +530,88 @@ func newTestDispatcher(t *testing.T, logger hclog.Logger, store JSONRPCStore, pa
 
 	return d
 }
+
+func TestDispatcherNamespaces(t *testing.T) {
+	t.Parallel()
+
+	dispatcher := newTestDispatcher(t,
+		hclog.NewNullLogger(),
+		newMockStore(),
+		&dispatcherParams{
+			jsonRPCBatchLengthLimit: 20,
+		},
+	)
+
+	_, err := dispatcher.withNamespaces([]string{"eth", "unknown"})
+	require.Error(t, err)
+
+	restricted, err := dispatcher.withNamespaces([]string{"web3", "net"})
+	require.NoError(t, err)
+
+	handle := func(d *Dispatcher, method string) *ObjectError {
+		t.Helper()
+
+		res, err := d.Handle([]byte(`{"id":1,"jsonrpc":"2.0","method":"` + method + `","params":[]}`))
+		require.NoError(t, err)
+
+		var resp SuccessResponse
+		require.NoError(t, json.Unmarshal(res, &resp))
+
+		return resp.Error
+	}
+
+	assert.Nil(t, handle(restricted, "web3_clientVersion"))
+	assert.Nil(t, handle(dispatcher, "eth_chainId"))
+
+	// the disabled namespaces aren't found
+	resp := handle(restricted, "eth_chainId")
+	require.NotNil(t, resp)
+	assert.Equal(t, -32601, resp.Code)
+
+	resp = handle(restricted, "debug_traceTransaction")
+	require.NotNil(t, resp)
+	assert.Equal(t, -32601, resp.Code)
+
+	// the subscriptions are in the eth namespace
+	res, err := restricted.HandleWs(
+		[]byte(`{"id":1,"jsonrpc":"2.0","method":"eth_subscribe","params":["newHeads"]}`),
+		&mockWsConn{},
+	)
+	require.NoError(t, err)
+
+	var wsResp SuccessResponse
+	require.NoError(t, json.Unmarshal(res, &wsResp))
+	require.NotNil(t, wsResp.Error)
+	assert.Equal(t, -32601, wsResp.Error.Code)
+}
+
+func TestDispatcherAdminMethods(t *testing.T) {
+	t.Parallel()
+
+	dispatcher := newTestDispatcher(t,
+		hclog.NewNullLogger(),
+		newMockStore(),
+		&dispatcherParams{
+			jsonRPCBatchLengthLimit: 20,
+		},
+	)
+
+	handle := func(d *Dispatcher) *ObjectError {
+		t.Helper()
+
+		res, err := d.Handle([]byte(`{"id":1,"jsonrpc":"2.0","method":"debug_setHead","params":["0x0"]}`))
+		require.NoError(t, err)
+
+		var resp SuccessResponse
+		require.NoError(t, json.Unmarshal(res, &resp))
+
+		return resp.Error
+	}
+
+	// the admin methods aren't found on the public listeners
+	resp := handle(dispatcher)
+	require.NotNil(t, resp)
+	assert.Equal(t, -32601, resp.Code)
+
+	assert.Nil(t, handle(dispatcher.withAdmin()))
+}
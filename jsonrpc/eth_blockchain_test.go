@@ -1,15 +1,18 @@
 package jsonrpc
 
 import (
+	"encoding/json"
 	"errors"
 	"math/big"
 	"testing"
 
 	"github.com/newton2049/favo-chain/blockchain"
+	"github.com/newton2049/favo-chain/chain"
 	"github.com/newton2049/favo-chain/helper/progress"
 	"github.com/newton2049/favo-chain/state/runtime"
 	"github.com/newton2049/favo-chain/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEth_Block_GetBlockByNumber(t *testing.T) {
@@ -98,6 +101,106 @@ func TestEth_Block_GetBlockTransactionCountByNumber(t *testing.T) {
 	assert.Equal(t, res, 10)
 }
 
+func TestEth_Block_GetBlockTransactionCountByHash(t *testing.T) {
+	store := &mockBlockStore{}
+	block := newTestBlock(1, hash1)
+
+	for i := 0; i < 10; i++ {
+		block.Transactions = append(block.Transactions, []*types.Transaction{{Nonce: 0, From: addr0}}...)
+	}
+	store.add(block)
+
+	eth := newTestEthEndpoint(store)
+
+	res, err := eth.GetBlockTransactionCountByHash(hash1)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, res)
+
+	res, err = eth.GetBlockTransactionCountByHash(hash2)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestEth_Block_GetUncleCount(t *testing.T) {
+	store := &mockBlockStore{}
+	store.add(newTestBlock(1, hash1))
+
+	eth := newTestEthEndpoint(store)
+
+	res, err := eth.GetUncleCountByBlockNumber(BlockNumber(1))
+	assert.NoError(t, err)
+	assert.Equal(t, argUint64(0), res)
+
+	res, err = eth.GetUncleCountByBlockHash(hash1)
+	assert.NoError(t, err)
+	assert.Equal(t, argUint64(0), res)
+
+	res, err = eth.GetUncleCountByBlockHash(hash2)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestEth_Block_GetHeader(t *testing.T) {
+	store := &mockBlockStore{}
+	block := newTestBlock(1, hash1)
+	block.Header.GasLimit = 100
+	store.add(block)
+
+	eth := newTestEthEndpoint(store)
+
+	res, err := eth.GetHeaderByNumber(BlockNumber(1))
+	assert.NoError(t, err)
+
+	//nolint:forcetypeassert
+	h := res.(*header)
+	assert.Equal(t, hash1, h.Hash)
+	assert.Equal(t, argUint64(100), h.GasLimit)
+
+	res, err = eth.GetHeaderByHash(hash1)
+	assert.NoError(t, err)
+	assert.Equal(t, h, res)
+
+	res, err = eth.GetHeaderByNumber(BlockNumber(2))
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestEth_GetTransactionByBlockAndIndex(t *testing.T) {
+	store := &mockBlockStore{}
+	block := newTestBlock(1, hash1)
+	txn := newTestTransaction(uint64(0), addr0)
+	block.Transactions = []*types.Transaction{newTestTransaction(uint64(1), addr0), txn}
+	store.add(block)
+
+	eth := newTestEthEndpoint(store)
+
+	res, err := eth.GetTransactionByBlockNumberAndIndex(BlockNumber(1), argUint64(1))
+	assert.NoError(t, err)
+
+	//nolint:forcetypeassert
+	resTxn := res.(*transaction)
+	assert.Equal(t, txn.Hash, resTxn.Hash)
+	assert.Equal(t, argUintPtr(1), resTxn.TxIndex)
+	assert.Equal(t, argHashPtr(hash1), resTxn.BlockHash)
+
+	res, err = eth.GetTransactionByBlockHashAndIndex(hash1, argUint64(1))
+	assert.NoError(t, err)
+	assert.Equal(t, resTxn, res)
+
+	// the index is out of the block
+	res, err = eth.GetTransactionByBlockHashAndIndex(hash1, argUint64(2))
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestEth_Accounts(t *testing.T) {
+	eth := newTestEthEndpoint(&mockBlockStore{})
+
+	res, err := eth.Accounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Address{}, res)
+}
+
 func TestEth_GetTransactionByHash(t *testing.T) {
 	t.Parallel()
 
@@ -129,6 +232,32 @@ func TestEth_GetTransactionByHash(t *testing.T) {
 		assert.Equal(t, argUint64(testTxnIndex), *foundTxn.TxIndex)
 	})
 
+	t.Run("returns the price paid by the dynamic fee transactions found in a sealed block", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mockBlockStore{}
+		eth := newTestEthEndpoint(store)
+		block := newTestBlock(1, hash1)
+		block.Header.BaseFee = 10
+		store.add(block)
+
+		// the first transaction pays the base fee and its tip cap, the second one its fee cap
+		tipped := newTestDynamicFeeTransaction(0, big.NewInt(30), big.NewInt(5))
+		capped := newTestDynamicFeeTransaction(1, big.NewInt(12), big.NewInt(5))
+		block.Transactions = []*types.Transaction{tipped, capped}
+
+		for txn, price := range map[*types.Transaction]int64{tipped: 15, capped: 12} {
+			res, err := eth.GetTransactionByHash(txn.Hash)
+			require.NoError(t, err)
+
+			//nolint:forcetypeassert
+			foundTxn := res.(*transaction)
+			assert.Equal(t, argBig(*big.NewInt(price)), foundTxn.GasPrice)
+			assert.Equal(t, argBigPtr(txn.GasFeeCap), foundTxn.GasFeeCap)
+			assert.Equal(t, argBigPtr(txn.GasTipCap), foundTxn.GasTipCap)
+		}
+	})
+
 	t.Run("returns correct transaction data if transaction is found in tx pool (pending)", func(t *testing.T) {
 		t.Parallel()
 
@@ -213,6 +342,94 @@ func TestEth_GetTransactionReceipt(t *testing.T) {
 		assert.Equal(t, block.Hash(), response.BlockHash)
 		assert.NotNil(t, response.Logs)
 	})
+	t.Run("returns the price paid by the transaction", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMockBlockStore()
+		eth := newTestEthEndpoint(store)
+		block := newTestBlock(1, hash4)
+		block.Header.BaseFee = 10
+		store.add(block)
+		txn := newTestDynamicFeeTransaction(0, big.NewInt(30), big.NewInt(5))
+		block.Transactions = append(block.Transactions, txn)
+		rec := &types.Receipt{}
+		rec.SetStatus(types.ReceiptSuccess)
+		store.receipts[hash4] = []*types.Receipt{rec}
+
+		res, err := eth.GetTransactionReceipt(txn.Hash)
+		require.NoError(t, err)
+
+		//nolint:forcetypeassert
+		response := res.(*receipt)
+		assert.Equal(t, argBig(*big.NewInt(15)), response.EffectiveGasPrice)
+	})
+}
+
+func TestEth_GetBlockReceipts(t *testing.T) {
+	t.Parallel()
+
+	store := newMockBlockStore()
+	eth := newTestEthEndpoint(store)
+	block := newTestBlock(1, hash4)
+	store.add(block)
+
+	receipts := make([]*types.Receipt, 3)
+
+	for i := range receipts {
+		block.Transactions = append(block.Transactions, newTestTransaction(uint64(i), addr0))
+
+		receipts[i] = &types.Receipt{GasUsed: uint64(i + 1)}
+		receipts[i].SetStatus(types.ReceiptSuccess)
+	}
+
+	store.receipts[hash4] = receipts
+
+	res, err := eth.GetBlockReceipts(BlockNumberOrHash{BlockHash: &hash4})
+	require.NoError(t, err)
+
+	//nolint:forcetypeassert
+	response := res.([]*receipt)
+	require.Len(t, response, 3)
+
+	for i, rec := range response {
+		assert.Equal(t, block.Transactions[i].Hash, rec.TxHash)
+		assert.Equal(t, argUint64(i), rec.TxIndex)
+		assert.Equal(t, argUint64(i+1), rec.GasUsed)
+		assert.Equal(t, hash4, rec.BlockHash)
+	}
+}
+
+func TestEth_CreateAccessList(t *testing.T) {
+	t.Parallel()
+
+	store := newMockBlockStore()
+	store.add(newTestBlock(100, hash1))
+
+	// the access list of the execution depends on the access list of the transaction
+	store.accessListHook = func(txn *types.Transaction) types.TxAccessList {
+		if len(txn.AccessList) == 0 {
+			return types.TxAccessList{{Address: addr1, StorageKeys: []types.Hash{}}}
+		}
+
+		return types.TxAccessList{{Address: addr1, StorageKeys: []types.Hash{hash1}}}
+	}
+
+	eth := newTestEthEndpoint(store)
+
+	res, err := eth.CreateAccessList(&txnArgs{
+		From:  &addr0,
+		To:    &addr1,
+		Gas:   argUintPtr(100000),
+		Nonce: argUintPtr(0),
+	}, BlockNumberOrHash{})
+	require.NoError(t, err)
+
+	//nolint:forcetypeassert
+	result := res.(*accessListResult)
+	assert.Equal(t, types.TxAccessList{{Address: addr1, StorageKeys: []types.Hash{hash1}}}, result.AccessList)
+	assert.Equal(t, argUint64(21000), result.GasUsed)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, 3, store.accessListCalls)
 }
 
 func TestEth_Syncing(t *testing.T) {
@@ -284,6 +501,108 @@ func TestEth_GasPrice(t *testing.T) {
 	assert.Equal(t, argUint64(store.averageGasPrice), res)
 }
 
+func newTestFeeBlock(number, baseFee uint64, tips ...int64) (*types.Block, []*types.Receipt) {
+	block := &types.Block{
+		Header: &types.Header{
+			Number:   number,
+			Hash:     types.BytesToHash([]byte{byte(number + 1)}),
+			BaseFee:  baseFee,
+			GasLimit: 100000,
+		},
+	}
+
+	receipts := make([]*types.Receipt, 0, len(tips))
+
+	for _, tip := range tips {
+		block.Transactions = append(block.Transactions, &types.Transaction{
+			Type:      types.DynamicFeeTx,
+			GasTipCap: big.NewInt(tip),
+			GasFeeCap: new(big.Int).Add(big.NewInt(tip), new(big.Int).SetUint64(baseFee)),
+		})
+		receipts = append(receipts, &types.Receipt{GasUsed: 21000})
+		block.Header.GasUsed += 21000
+	}
+
+	return block, receipts
+}
+
+func TestEth_FeeHistory(t *testing.T) {
+	t.Parallel()
+
+	store := newMockBlockStore()
+
+	for i, tips := range [][]int64{{}, {3, 1, 2}, {10}} {
+		block, receipts := newTestFeeBlock(uint64(i), 100, tips...)
+		store.add(block)
+		store.receipts[block.Hash()] = receipts
+	}
+
+	eth := newTestEthEndpoint(store)
+
+	t.Run("returns the history of the requested blocks", func(t *testing.T) {
+		t.Parallel()
+
+		res, err := eth.FeeHistory(2, LatestBlockNumber, []float64{0, 50, 100})
+		assert.NoError(t, err)
+
+		history, ok := res.(*feeHistory)
+		assert.True(t, ok)
+
+		assert.Equal(t, argUint64(1), history.OldestBlock)
+		assert.Equal(t, []argUint64{100, 100, 100}, history.BaseFee)
+		assert.Equal(t, []float64{0.63, 0.21}, history.GasUsedRatio)
+		assert.Equal(t, [][]argBig{
+			{*argBigPtr(big.NewInt(1)), *argBigPtr(big.NewInt(2)), *argBigPtr(big.NewInt(3))},
+			{*argBigPtr(big.NewInt(10)), *argBigPtr(big.NewInt(10)), *argBigPtr(big.NewInt(10))},
+		}, history.Reward)
+	})
+
+	t.Run("caps the block count to the available blocks", func(t *testing.T) {
+		t.Parallel()
+
+		res, err := eth.FeeHistory(10, BlockNumber(1), nil)
+		assert.NoError(t, err)
+
+		history, ok := res.(*feeHistory)
+		assert.True(t, ok)
+
+		assert.Equal(t, argUint64(0), history.OldestBlock)
+		assert.Len(t, history.BaseFee, 3)
+		assert.Len(t, history.GasUsedRatio, 2)
+		assert.Nil(t, history.Reward)
+	})
+
+	t.Run("rejects invalid reward percentiles", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := eth.FeeHistory(1, LatestBlockNumber, []float64{50, 10})
+		assert.ErrorIs(t, err, ErrInvalidRewardPercentile)
+
+		_, err = eth.FeeHistory(1, LatestBlockNumber, []float64{101})
+		assert.ErrorIs(t, err, ErrInvalidRewardPercentile)
+	})
+}
+
+func TestEth_MaxPriorityFeePerGas(t *testing.T) {
+	t.Parallel()
+
+	store := newMockBlockStore()
+
+	for i, tips := range [][]int64{{}, {3, 1, 2}, {10, 4}} {
+		block, _ := newTestFeeBlock(uint64(i), 100, tips...)
+		store.add(block)
+	}
+
+	res, err := newTestEthEndpoint(store).MaxPriorityFeePerGas()
+	assert.NoError(t, err)
+	assert.Equal(t, argBigPtr(big.NewInt(3)), res)
+
+	// the price limit is the lower bound of the suggestion
+	res, err = newTestEthEndpointWithPriceLimit(store, 50).MaxPriorityFeePerGas()
+	assert.NoError(t, err)
+	assert.Equal(t, argBigPtr(big.NewInt(50)), res)
+}
+
 func TestEth_Call(t *testing.T) {
 	t.Parallel()
 
@@ -304,7 +623,7 @@ func TestEth_Call(t *testing.T) {
 			Nonce:    argUintPtr(0),
 		}
 
-		res, err := eth.Call(contractCall, BlockNumberOrHash{})
+		res, err := eth.Call(contractCall, BlockNumberOrHash{}, nil, nil)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), store.ethCallError.Error())
@@ -328,11 +647,51 @@ func TestEth_Call(t *testing.T) {
 			Nonce:    argUintPtr(0),
 		}
 
-		res, err := eth.Call(contractCall, BlockNumberOrHash{})
+		res, err := eth.Call(contractCall, BlockNumberOrHash{}, nil, nil)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, res)
 	})
+
+	t.Run("passes the state and block overrides", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMockBlockStore()
+		store.add(newTestBlock(100, hash1))
+		eth := newTestEthEndpoint(store)
+		contractCall := &txnArgs{
+			From: &addr0,
+			To:   &addr1,
+		}
+
+		var (
+			override      stateOverride
+			blockOverride *blockOverride
+		)
+
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"`+addr0.String()+`": {"nonce": "0x5", "balance": "0x64"},
+			"`+addr1.String()+`": {"code": "0x01", "stateDiff": {"`+hash1.String()+`": "`+hash2.String()+`"}}
+		}`), &override))
+		require.NoError(t, json.Unmarshal([]byte(`{"number": "0x200", "gasLimit": "0x1000"}`), &blockOverride))
+
+		_, err := eth.Call(contractCall, BlockNumberOrHash{}, override, blockOverride)
+		require.NoError(t, err)
+
+		// the nonce and the gas limit of the call are overridden
+		assert.Equal(t, uint64(5), store.callTxn.Nonce)
+		assert.Equal(t, uint64(0x1000), store.callTxn.Gas)
+
+		require.NotNil(t, store.callStateOverride)
+		assert.Equal(t, big.NewInt(100), store.callStateOverride[addr0].Balance)
+		assert.Equal(t, []byte{0x1}, store.callStateOverride[addr1].Code)
+		assert.Equal(t, map[types.Hash]types.Hash{hash1: hash2}, store.callStateOverride[addr1].StateDiff)
+		assert.Nil(t, store.callStateOverride[addr1].State)
+
+		require.NotNil(t, store.callBlockOverride)
+		assert.Equal(t, uint64(0x200), *store.callBlockOverride.Number)
+		assert.Nil(t, store.callBlockOverride.Time)
+	})
 }
 
 type testStore interface {
@@ -348,6 +707,22 @@ type mockBlockStore struct {
 	isSyncing       bool
 	averageGasPrice int64
 	ethCallError    error
+
+	// indexedBlocks is the number of the blocks covered by the log index
+	indexedBlocks uint64
+
+	// the arguments of the last call
+	callTxn           *types.Transaction
+	callStateOverride types.StateOverride
+	callBlockOverride *types.BlockOverride
+
+	// the transactions and the results of the last simulation
+	simulatedTxns   []*types.Transaction
+	simulateResults []*SimulatedTxn
+
+	// the access lists of the executions of eth_createAccessList
+	accessListHook  func(*types.Transaction) types.TxAccessList
+	accessListCalls int
 }
 
 func newMockBlockStore() *mockBlockStore {
@@ -517,14 +892,89 @@ func (m *mockBlockStore) GetAvgGasPrice() *big.Int {
 	return big.NewInt(m.averageGasPrice)
 }
 
-func (m *mockBlockStore) ApplyTxn(header *types.Header, txn *types.Transaction) (*runtime.ExecutionResult, error) {
+func (m *mockBlockStore) CalculateBaseFee(parent *types.Header) uint64 {
+	return parent.BaseFee
+}
+
+func (m *mockBlockStore) ApplyTxn(
+	header *types.Header,
+	txn *types.Transaction,
+	stateOverride types.StateOverride,
+	blockOverride *types.BlockOverride,
+) (*runtime.ExecutionResult, error) {
+	m.callTxn = txn
+	m.callStateOverride = stateOverride
+	m.callBlockOverride = blockOverride
+
 	return &runtime.ExecutionResult{Err: m.ethCallError}, nil
 }
 
+func (m *mockBlockStore) SimulateTxns(
+	header *types.Header,
+	txns []*types.Transaction,
+	validate bool,
+	stateOverride types.StateOverride,
+	blockOverride *types.BlockOverride,
+) ([]*SimulatedTxn, error) {
+	m.simulatedTxns = txns
+
+	return m.simulateResults, nil
+}
+
+func (m *mockBlockStore) CreateAccessList(
+	header *types.Header,
+	txn *types.Transaction,
+) (types.TxAccessList, *runtime.ExecutionResult, error) {
+	m.accessListCalls++
+
+	return m.accessListHook(txn), &runtime.ExecutionResult{GasUsed: 21000}, nil
+}
+
+func (m *mockBlockStore) GetHeaderByNumber(blockNumber uint64) (*types.Header, bool) {
+	block, ok := m.GetBlockByNumber(blockNumber, false)
+	if !ok {
+		return nil, false
+	}
+
+	return block.Header, true
+}
+
+func (m *mockBlockStore) GetForksInTime(blockNumber uint64) chain.ForksInTime {
+	return chain.AllForksEnabled.At(blockNumber)
+}
+
 func (m *mockBlockStore) SubscribeEvents() blockchain.Subscription {
 	return nil
 }
 
+func (m *mockBlockStore) FilterLogBlocks(from, to uint64, criteria [][][]byte) ([]uint64, uint64) {
+	blockNumbers := make([]uint64, 0)
+
+	for ; from <= to && from < m.indexedBlocks; from++ {
+		if block, ok := m.GetBlockByNumber(from, false); ok && len(m.receipts[block.Hash()]) > 0 {
+			blockNumbers = append(blockNumbers, from)
+		}
+	}
+
+	return blockNumbers, from
+}
+
+func (m *mockBlockStore) SubscribePendingTxs() (<-chan types.Hash, func()) {
+	return nil, func() {}
+}
+
+func newTestDynamicFeeTransaction(nonce uint64, gasFeeCap, gasTipCap *big.Int) *types.Transaction {
+	txn := newTestTransaction(nonce, addr0)
+	txn.Type = types.DynamicFeeTx
+	txn.GasPrice = nil
+	txn.GasFeeCap = gasFeeCap
+	txn.GasTipCap = gasTipCap
+
+	txn.ComputeHash()
+
+	return txn
+}
+
 func newTestBlock(number uint64, hash types.Hash) *types.Block {
 	return &types.Block{
 		Header: &types.Header{
@@ -0,0 +1,250 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/newton2049/favo-chain/crypto"
+	"github.com/newton2049/favo-chain/state"
+	"github.com/newton2049/favo-chain/state/runtime"
+	"github.com/newton2049/favo-chain/types"
+)
+
+var (
+	errEmptyBundle         = errors.New("the bundle has no transactions")
+	errUnknownBundleSender = errors.New("the sender of the transactions of the bundle is not set")
+)
+
+// SimulatedTxn is the outcome of a transaction applied in a simulation
+type SimulatedTxn struct {
+	// Result is the result of the execution, nil if the transaction couldn't be applied
+	Result *runtime.ExecutionResult
+
+	// Err is the reason the transaction couldn't be applied
+	Err error
+
+	// Logs are the logs emitted by the transaction
+	Logs []*types.Log
+
+	// Changes are the accounts changed by the transaction, with only their changed storage slots
+	Changes []*state.Object
+}
+
+// callBundleArgs are the transactions applied one after another by eth_callBundle
+type callBundleArgs struct {
+	Transactions []*bundleTransaction `json:"transactions"`
+
+	// Validation enforces the nonces and the base fee of the transactions,
+	// and the signatures of the signed transactions
+	Validation bool `json:"validation"`
+
+	// From is the sender of the signed transactions when they are not validated,
+	// and of the calls without sender
+	From *types.Address `json:"from"`
+}
+
+// bundleTransaction is either a call object or a signed raw transaction
+type bundleTransaction struct {
+	call *txnArgs
+	raw  argBytes
+}
+
+func (b *bundleTransaction) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &b.raw)
+	}
+
+	b.call = &txnArgs{}
+
+	return json.Unmarshal(data, b.call)
+}
+
+type callBundleResult struct {
+	Results []*callBundleTxResult `json:"results"`
+	GasUsed argUint64             `json:"gasUsed"`
+}
+
+type callBundleTxResult struct {
+	TxHash       *types.Hash                      `json:"transactionHash,omitempty"`
+	Status       argUint64                        `json:"status"`
+	GasUsed      argUint64                        `json:"gasUsed"`
+	ReturnValue  argBytes                         `json:"returnValue"`
+	Logs         []*Log                           `json:"logs"`
+	StateChanges map[types.Address]*accountChange `json:"stateChanges"`
+	Error        string                           `json:"error,omitempty"`
+}
+
+// accountChange is the state of an account changed by a transaction, with only its changed storage slots
+type accountChange struct {
+	Balance  *argBig                   `json:"balance"`
+	Nonce    argUint64                 `json:"nonce"`
+	CodeHash types.Hash                `json:"codeHash"`
+	Storage  map[types.Hash]types.Hash `json:"storage,omitempty"`
+	Deleted  bool                      `json:"deleted,omitempty"`
+}
+
+// CallBundle executes the calls and the signed transactions of the bundle one after another
+// on the state of the block, and returns the results, the logs and the state changes of each of them.
+// Like in eth_call, the state and the block can be overridden
+func (e *Eth) CallBundle(
+	bundle *callBundleArgs,
+	filter BlockNumberOrHash,
+	stateOverride stateOverride,
+	blockOverride *blockOverride,
+) (interface{}, error) {
+	if bundle == nil || len(bundle.Transactions) == 0 {
+		return nil, errEmptyBundle
+	}
+
+	header, err := GetHeaderFromBlockNumberOrHash(filter, e.store)
+	if err != nil {
+		return nil, err
+	}
+
+	blockOverrideType := blockOverride.toType()
+	signer := crypto.NewSigner(
+		e.store.GetForksInTime(overriddenHeader(header, blockOverrideType).Number),
+		e.chainID,
+	)
+
+	var (
+		txns       = make([]*types.Transaction, len(bundle.Transactions))
+		nextNonces = make(map[types.Address]uint64)
+	)
+
+	for i, bundleTxn := range bundle.Transactions {
+		if bundleTxn.call == nil {
+			txn := &types.Transaction{}
+			if err := txn.UnmarshalRLP(bundleTxn.raw); err != nil {
+				return nil, fmt.Errorf("invalid transaction %d: %w", i, err)
+			}
+
+			txn.ComputeHash()
+
+			if txn.From, err = signer.Sender(txn); err != nil {
+				if bundle.Validation {
+					return nil, fmt.Errorf("invalid signature of transaction %d: %w", i, err)
+				}
+
+				// without validation the unsigned transactions are sent by the sender of the bundle
+				if bundle.From == nil {
+					return nil, fmt.Errorf("transaction %d: %w", i, errUnknownBundleSender)
+				}
+
+				txn.From = *bundle.From
+			}
+
+			txns[i] = txn
+			nextNonces[txn.From] = txn.Nonce + 1
+
+			continue
+		}
+
+		call := bundleTxn.call
+
+		if call.From == nil {
+			call.From = bundle.From
+		}
+
+		if !bundle.Validation {
+			// the nonce is taken from the state of the simulation
+			call.Nonce = argUintPtr(0)
+		} else if call.Nonce == nil && call.From != nil {
+			// the nonce follows the previous transactions of the sender in the bundle
+			if nonce, ok := nextNonces[*call.From]; ok {
+				call.Nonce = argUintPtr(nonce)
+			}
+		}
+
+		if txns[i], err = DecodeTxn(call, e.store); err != nil {
+			return nil, fmt.Errorf("invalid call %d: %w", i, err)
+		}
+
+		nextNonces[txns[i].From] = txns[i].Nonce + 1
+	}
+
+	simulated, err := e.store.SimulateTxns(
+		header,
+		txns,
+		bundle.Validation,
+		stateOverride.toType(),
+		blockOverrideType,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &callBundleResult{
+		Results: make([]*callBundleTxResult, len(simulated)),
+	}
+
+	for i, simulatedTxn := range simulated {
+		txResult := toCallBundleTxResult(txns[i], simulatedTxn)
+		res.GasUsed += txResult.GasUsed
+		res.Results[i] = txResult
+	}
+
+	return res, nil
+}
+
+func toCallBundleTxResult(txn *types.Transaction, simulated *SimulatedTxn) *callBundleTxResult {
+	res := &callBundleTxResult{
+		Logs:         make([]*Log, len(simulated.Logs)),
+		StateChanges: make(map[types.Address]*accountChange, len(simulated.Changes)),
+	}
+
+	if txn.Hash != types.ZeroHash {
+		res.TxHash = &txn.Hash
+	}
+
+	if simulated.Result == nil {
+		res.Error = simulated.Err.Error()
+
+		return res
+	}
+
+	res.GasUsed = argUint64(simulated.Result.GasUsed)
+	res.ReturnValue = simulated.Result.ReturnValue
+
+	switch {
+	case simulated.Result.Reverted():
+		res.Error = constructErrorFromRevert(simulated.Result).Error()
+	case simulated.Result.Failed():
+		res.Error = simulated.Result.Err.Error()
+	default:
+		res.Status = argUint64(types.ReceiptSuccess)
+	}
+
+	for i, log := range simulated.Logs {
+		res.Logs[i] = &Log{
+			Address:  log.Address,
+			Topics:   log.Topics,
+			Data:     log.Data,
+			TxHash:   txn.Hash,
+			LogIndex: argUint64(i),
+		}
+	}
+
+	for _, object := range simulated.Changes {
+		change := &accountChange{
+			Balance:  argBigPtr(object.Balance),
+			Nonce:    argUint64(object.Nonce),
+			CodeHash: object.CodeHash,
+			Deleted:  object.Deleted,
+		}
+
+		if len(object.Storage) > 0 {
+			change.Storage = make(map[types.Hash]types.Hash, len(object.Storage))
+
+			for _, slot := range object.Storage {
+				// the deleted slots are zero
+				change.Storage[types.BytesToHash(slot.Key)] = types.BytesToHash(slot.Val)
+			}
+		}
+
+		res.StateChanges[object.Address] = change
+	}
+
+	return res
+}
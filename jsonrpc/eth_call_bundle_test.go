@@ -0,0 +1,213 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newton2049/favo-chain/chain"
+	"github.com/newton2049/favo-chain/crypto"
+	"github.com/newton2049/favo-chain/helper/hex"
+	"github.com/newton2049/favo-chain/state"
+	"github.com/newton2049/favo-chain/state/runtime"
+	"github.com/newton2049/favo-chain/types"
+)
+
+func TestEth_CallBundle(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateECDSAKey()
+	require.NoError(t, err)
+
+	sender := crypto.PubKeyToAddress(&key.PublicKey)
+
+	signedTxn, err := crypto.NewSigner(chain.AllForksEnabled.At(100), 100).SignTx(&types.Transaction{
+		Nonce:    7,
+		To:       &addr1,
+		Gas:      21000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(1),
+	}, key)
+	require.NoError(t, err)
+
+	signedTxn.ComputeHash()
+
+	t.Run("simulates the calls and the signed transactions", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMockBlockStore()
+		store.add(newTestBlock(100, hash1))
+		store.simulateResults = []*SimulatedTxn{
+			{
+				Result: &runtime.ExecutionResult{ReturnValue: []byte{0x1}, GasUsed: 30000},
+				Logs:   []*types.Log{{Address: addr1, Topics: []types.Hash{hash2}}},
+				Changes: []*state.Object{
+					{
+						Address: addr1,
+						Balance: big.NewInt(10),
+						Nonce:   1,
+						Storage: []*state.StorageObject{{Key: hash1.Bytes(), Val: hash2.Bytes()}},
+					},
+				},
+			},
+			{
+				Err: errors.New("insufficient funds"),
+			},
+		}
+
+		eth := newTestEthEndpoint(store)
+
+		var bundle callBundleArgs
+
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"validation": true,
+			"transactions": [
+				{"from": "`+addr0.String()+`", "to": "`+addr1.String()+`", "data": "0x01", "nonce": "0x0"},
+				"`+hex.EncodeToHex(signedTxn.MarshalRLP())+`"
+			]
+		}`), &bundle))
+
+		res, err := eth.CallBundle(&bundle, BlockNumberOrHash{}, nil, nil)
+		require.NoError(t, err)
+
+		// the call and the signed transaction are passed in order
+		require.Len(t, store.simulatedTxns, 2)
+		assert.Equal(t, addr0, store.simulatedTxns[0].From)
+		assert.Equal(t, sender, store.simulatedTxns[1].From)
+		assert.Equal(t, uint64(7), store.simulatedTxns[1].Nonce)
+
+		result, ok := res.(*callBundleResult)
+		require.True(t, ok)
+		require.Len(t, result.Results, 2)
+		assert.Equal(t, argUint64(30000), result.GasUsed)
+
+		first := result.Results[0]
+		assert.Equal(t, argUint64(types.ReceiptSuccess), first.Status)
+		assert.Equal(t, argBytes{0x1}, first.ReturnValue)
+		require.Len(t, first.Logs, 1)
+		assert.Equal(t, addr1, first.Logs[0].Address)
+		require.Contains(t, first.StateChanges, addr1)
+		assert.Equal(t, argUint64(1), first.StateChanges[addr1].Nonce)
+		assert.Equal(t, map[types.Hash]types.Hash{hash1: hash2}, first.StateChanges[addr1].Storage)
+
+		second := result.Results[1]
+		assert.Equal(t, argUint64(types.ReceiptFailed), second.Status)
+		assert.Equal(t, "insufficient funds", second.Error)
+		require.NotNil(t, second.TxHash)
+		assert.Equal(t, signedTxn.Hash, *second.TxHash)
+	})
+
+	t.Run("follows the nonces of the senders when validating", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMockBlockStore()
+		store.add(newTestBlock(100, hash1))
+		store.simulateResults = []*SimulatedTxn{{Err: errors.New("a")}, {Err: errors.New("b")}}
+
+		eth := newTestEthEndpoint(store)
+
+		var bundle callBundleArgs
+
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"validation": true,
+			"transactions": [
+				{"from": "`+addr0.String()+`", "to": "`+addr1.String()+`", "nonce": "0x3"},
+				{"from": "`+addr0.String()+`", "to": "`+addr1.String()+`"}
+			]
+		}`), &bundle))
+
+		_, err := eth.CallBundle(&bundle, BlockNumberOrHash{}, nil, nil)
+		require.NoError(t, err)
+
+		require.Len(t, store.simulatedTxns, 2)
+		assert.Equal(t, uint64(3), store.simulatedTxns[0].Nonce)
+		assert.Equal(t, uint64(4), store.simulatedTxns[1].Nonce)
+	})
+
+	t.Run("takes the sender of the bundle for the unsigned transactions without validation", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMockBlockStore()
+		store.add(newTestBlock(100, hash1))
+		store.simulateResults = []*SimulatedTxn{{Err: errors.New("a")}, {Err: errors.New("b")}}
+
+		eth := newTestEthEndpoint(store)
+
+		// the sender of the transaction can't be recovered
+		unsignedTxn := signedTxn.Copy()
+		unsignedTxn.R, unsignedTxn.S = big.NewInt(0), big.NewInt(0)
+
+		var bundle callBundleArgs
+
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"from": "`+addr2.String()+`",
+			"transactions": [
+				{"to": "`+addr1.String()+`"},
+				"`+hex.EncodeToHex(unsignedTxn.MarshalRLP())+`"
+			]
+		}`), &bundle))
+
+		_, err := eth.CallBundle(&bundle, BlockNumberOrHash{}, nil, nil)
+		require.NoError(t, err)
+
+		require.Len(t, store.simulatedTxns, 2)
+		assert.Equal(t, addr2, store.simulatedTxns[0].From)
+		assert.Equal(t, addr2, store.simulatedTxns[1].From)
+
+		// the unsigned transactions need a sender
+		bundle.From = nil
+
+		_, err = eth.CallBundle(&bundle, BlockNumberOrHash{}, nil, nil)
+		assert.ErrorIs(t, err, errUnknownBundleSender)
+	})
+
+	t.Run("takes the signers of the transactions without validation", func(t *testing.T) {
+		t.Parallel()
+
+		otherKey, err := crypto.GenerateECDSAKey()
+		require.NoError(t, err)
+
+		otherTxn, err := crypto.NewSigner(chain.AllForksEnabled.At(100), 100).SignTx(&types.Transaction{
+			Nonce:    1,
+			To:       &addr1,
+			Gas:      21000,
+			GasPrice: big.NewInt(1),
+		}, otherKey)
+		require.NoError(t, err)
+
+		store := newMockBlockStore()
+		store.add(newTestBlock(100, hash1))
+		store.simulateResults = []*SimulatedTxn{{Err: errors.New("a")}, {Err: errors.New("b")}}
+
+		eth := newTestEthEndpoint(store)
+
+		var bundle callBundleArgs
+
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"transactions": [
+				"`+hex.EncodeToHex(signedTxn.MarshalRLP())+`",
+				"`+hex.EncodeToHex(otherTxn.MarshalRLP())+`"
+			]
+		}`), &bundle))
+
+		_, err = eth.CallBundle(&bundle, BlockNumberOrHash{}, nil, nil)
+		require.NoError(t, err)
+
+		require.Len(t, store.simulatedTxns, 2)
+		assert.Equal(t, sender, store.simulatedTxns[0].From)
+		assert.Equal(t, crypto.PubKeyToAddress(&otherKey.PublicKey), store.simulatedTxns[1].From)
+	})
+
+	t.Run("rejects an empty bundle", func(t *testing.T) {
+		t.Parallel()
+
+		eth := newTestEthEndpoint(newMockBlockStore())
+
+		_, err := eth.CallBundle(&callBundleArgs{}, BlockNumberOrHash{}, nil, nil)
+		assert.ErrorIs(t, err, errEmptyBundle)
+	})
+}
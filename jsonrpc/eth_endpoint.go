@@ -4,14 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"reflect"
+	"sort"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/umbracle/fastrlp"
 
 	"github.com/newton2049/favo-chain/chain"
+	"github.com/newton2049/favo-chain/crypto"
 	"github.com/newton2049/favo-chain/helper/common"
 	"github.com/newton2049/favo-chain/helper/progress"
 	"github.com/newton2049/favo-chain/state"
+	itrie "github.com/newton2049/favo-chain/state/immutable-trie"
 	"github.com/newton2049/favo-chain/state/runtime"
 	"github.com/newton2049/favo-chain/types"
 )
@@ -20,6 +24,10 @@ type ethTxPoolStore interface {
 	// AddTx adds a new transaction to the tx pool
 	AddTx(tx *types.Transaction) error
 
+	// AddPrivateTx adds a new transaction to the tx pool without gossiping it,
+	// it's forwarded directly to the proposers
+	AddPrivateTx(tx *types.Transaction) error
+
 	// GetPendingTx gets the pending transaction from the transaction pool, if it's present
 	GetPendingTx(txHash types.Hash) (*types.Transaction, bool)
 
@@ -37,6 +45,9 @@ type ethStateStore interface {
 	GetStorage(root types.Hash, addr types.Address, slot types.Hash) ([]byte, error)
 	GetForksInTime(blockNumber uint64) chain.ForksInTime
 	GetCode(root types.Hash, addr types.Address) ([]byte, error)
+
+	// GetProof returns the merkle proof of the account and of its storage slots at the given state root
+	GetProof(root types.Hash, addr types.Address, keys []types.Hash) (*itrie.AccountProof, error)
 }
 
 type ethBlockchainStore interface {
@@ -61,8 +72,35 @@ type ethBlockchainStore interface {
 	// GetAvgGasPrice returns the average gas price
 	GetAvgGasPrice() *big.Int
 
-	// ApplyTxn applies a transaction object to the blockchain
-	ApplyTxn(header *types.Header, txn *types.Transaction) (*runtime.ExecutionResult, error)
+	// CalculateBaseFee calculates the base fee of the block following the parent
+	CalculateBaseFee(parent *types.Header) uint64
+
+	// ApplyTxn applies a transaction object to the blockchain,
+	// with the accounts of the state and the fields of the header replaced by the overrides (if any)
+	ApplyTxn(
+		header *types.Header,
+		txn *types.Transaction,
+		stateOverride types.StateOverride,
+		blockOverride *types.BlockOverride,
+	) (*runtime.ExecutionResult, error)
+
+	// CreateAccessList applies the transaction on the state of the header,
+	// and returns the access list of the addresses and the slots it accessed
+	CreateAccessList(
+		header *types.Header,
+		txn *types.Transaction,
+	) (types.TxAccessList, *runtime.ExecutionResult, error)
+
+	// SimulateTxns applies the transactions one after another on the state of the header,
+	// with the accounts of the state and the fields of the header replaced by the overrides (if any).
+	// The nonces and the base fee are only enforced if validate is set
+	SimulateTxns(
+		header *types.Header,
+		txns []*types.Transaction,
+		validate bool,
+		stateOverride types.StateOverride,
+		blockOverride *types.BlockOverride,
+	) ([]*SimulatedTxn, error)
 
 	// GetSyncProgression retrieves the current sync progression, if any
 	GetSyncProgression() *progress.Progression
@@ -85,7 +123,20 @@ type Eth struct {
 }
 
 var (
-	ErrInsufficientFunds = errors.New("insufficient funds for execution")
+	ErrInsufficientFunds       = errors.New("insufficient funds for execution")
+	ErrInvalidRewardPercentile = errors.New("invalid reward percentile")
+	ErrInvalidStorageKey       = errors.New("invalid storage key, expected at most 32 bytes")
+)
+
+const (
+	// maxFeeHistoryBlockCount is the max number of blocks returned by eth_feeHistory
+	maxFeeHistoryBlockCount = 1024
+
+	// priorityFeeBlockCount is the number of recent blocks sampled by eth_maxPriorityFeePerGas
+	priorityFeeBlockCount = 20
+
+	// priorityFeePercentile is the percentile of the sampled tips suggested by eth_maxPriorityFeePerGas
+	priorityFeePercentile = 60
 )
 
 // ChainId returns the chain id of the client
@@ -150,6 +201,104 @@ func (e *Eth) GetBlockTransactionCountByNumber(number BlockNumber) (interface{},
 	return len(block.Transactions), nil
 }
 
+// GetBlockTransactionCountByHash returns the number of transactions in the block with the given hash
+func (e *Eth) GetBlockTransactionCountByHash(hash types.Hash) (interface{}, error) {
+	block, ok := e.store.GetBlockByHash(hash, true)
+	if !ok {
+		return nil, nil
+	}
+
+	return len(block.Transactions), nil
+}
+
+// GetUncleCountByBlockNumber returns the number of uncles in the block with the given block number,
+// which is always 0 with the BFT consensus
+func (e *Eth) GetUncleCountByBlockNumber(number BlockNumber) (interface{}, error) {
+	num, err := GetNumericBlockNumber(number, e.store)
+	if err != nil {
+		return nil, err
+	}
+
+	block, ok := e.store.GetBlockByNumber(num, false)
+	if !ok {
+		return nil, nil
+	}
+
+	return argUint64(len(block.Uncles)), nil
+}
+
+// GetUncleCountByBlockHash returns the number of uncles in the block with the given hash,
+// which is always 0 with the BFT consensus
+func (e *Eth) GetUncleCountByBlockHash(hash types.Hash) (interface{}, error) {
+	block, ok := e.store.GetBlockByHash(hash, false)
+	if !ok {
+		return nil, nil
+	}
+
+	return argUint64(len(block.Uncles)), nil
+}
+
+// GetHeaderByNumber returns the header of the block with the given block number
+func (e *Eth) GetHeaderByNumber(number BlockNumber) (interface{}, error) {
+	num, err := GetNumericBlockNumber(number, e.store)
+	if err != nil {
+		return nil, err
+	}
+
+	header, ok := e.store.GetHeaderByNumber(num)
+	if !ok {
+		return nil, nil
+	}
+
+	return toHeader(header), nil
+}
+
+// GetHeaderByHash returns the header of the block with the given hash
+func (e *Eth) GetHeaderByHash(hash types.Hash) (interface{}, error) {
+	block, ok := e.store.GetBlockByHash(hash, false)
+	if !ok {
+		return nil, nil
+	}
+
+	return toHeader(block.Header), nil
+}
+
+// GetTransactionByBlockNumberAndIndex returns the transaction at the index of the block with the given block number
+func (e *Eth) GetTransactionByBlockNumberAndIndex(number BlockNumber, index argUint64) (interface{}, error) {
+	num, err := GetNumericBlockNumber(number, e.store)
+	if err != nil {
+		return nil, err
+	}
+
+	block, ok := e.store.GetBlockByNumber(num, true)
+	if !ok {
+		return nil, nil
+	}
+
+	return toBlockTransaction(block, index), nil
+}
+
+// GetTransactionByBlockHashAndIndex returns the transaction at the index of the block with the given hash
+func (e *Eth) GetTransactionByBlockHashAndIndex(hash types.Hash, index argUint64) (interface{}, error) {
+	block, ok := e.store.GetBlockByHash(hash, true)
+	if !ok {
+		return nil, nil
+	}
+
+	return toBlockTransaction(block, index), nil
+}
+
+// toBlockTransaction returns the transaction at the index of the block, or nil if there is none
+func toBlockTransaction(block *types.Block, index argUint64) *transaction {
+	if uint64(index) >= uint64(len(block.Transactions)) {
+		return nil
+	}
+
+	txIndex := int(index)
+
+	return toTransaction(block.Transactions[txIndex], block.Header, &txIndex)
+}
+
 // BlockNumber returns current block number
 func (e *Eth) BlockNumber() (interface{}, error) {
 	h := e.store.Header()
@@ -176,6 +325,28 @@ func (e *Eth) SendRawTransaction(buf argBytes) (interface{}, error) {
 	return tx.Hash.String(), nil
 }
 
+// SendPrivateRawTransaction sends a raw transaction which isn't gossiped,
+// it's forwarded directly to the current and next proposers
+func (e *Eth) SendPrivateRawTransaction(buf argBytes) (interface{}, error) {
+	tx := &types.Transaction{}
+	if err := tx.UnmarshalRLP(buf); err != nil {
+		return nil, err
+	}
+
+	tx.ComputeHash()
+
+	if err := e.store.AddPrivateTx(tx); err != nil {
+		return nil, err
+	}
+
+	return tx.Hash.String(), nil
+}
+
+// Accounts returns the accounts owned by the node, which are none as we don't support wallet management
+func (e *Eth) Accounts() (interface{}, error) {
+	return []types.Address{}, nil
+}
+
 // SendTransaction rejects eth_sendTransaction json-rpc call as we don't support wallet management
 func (e *Eth) SendTransaction(_ *txnArgs) (interface{}, error) {
 	return nil, fmt.Errorf("request calls to eth_sendTransaction method are not supported," +
@@ -206,12 +377,7 @@ func (e *Eth) GetTransactionByHash(hash types.Hash) (interface{}, error) {
 		// Find the transaction within the block
 		for idx, txn := range block.Transactions {
 			if txn.Hash == hash {
-				return toTransaction(
-					txn,
-					argUintPtr(block.Number()),
-					argHashPtr(block.Hash()),
-					&idx,
-				)
+				return toTransaction(txn, block.Header, &idx)
 			}
 		}
 
@@ -300,9 +466,40 @@ func (e *Eth) GetTransactionReceipt(hash types.Hash) (interface{}, error) {
 		return nil, nil
 	}
 
-	txn := block.Transactions[indx]
-	raw := receipts[indx]
+	return toReceipt(receipts[indx], block.Transactions[indx], indx, block), nil
+}
+
+// GetBlockReceipts returns the receipts of all the transactions of the block
+func (e *Eth) GetBlockReceipts(filter BlockNumberOrHash) (interface{}, error) {
+	header, err := GetHeaderFromBlockNumberOrHash(filter, e.store)
+	if err != nil {
+		return nil, err
+	}
+
+	block, ok := e.store.GetBlockByHash(header.Hash, true)
+	if !ok {
+		return nil, nil
+	}
+
+	receipts, err := e.store.GetReceiptsByHash(block.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(receipts) != len(block.Transactions) {
+		return nil, fmt.Errorf("receipts of block %d not found", block.Number())
+	}
 
+	res := make([]*receipt, len(receipts))
+	for i, raw := range receipts {
+		res[i] = toReceipt(raw, block.Transactions[i], i, block)
+	}
+
+	return res, nil
+}
+
+// toReceipt returns the receipt of the transaction at the index of the block
+func toReceipt(raw *types.Receipt, txn *types.Transaction, txIndex int, block *types.Block) *receipt {
 	logs := make([]*Log, len(raw.Logs))
 	for indx, elem := range raw.Logs {
 		logs[indx] = &Log{
@@ -324,17 +521,18 @@ func (e *Eth) GetTransactionReceipt(hash types.Hash) (interface{}, error) {
 		LogsBloom:         raw.LogsBloom,
 		Status:            argUint64(*raw.Status),
 		TxHash:            txn.Hash,
-		TxIndex:           argUint64(indx),
+		TxIndex:           argUint64(txIndex),
 		BlockHash:         block.Hash(),
 		BlockNumber:       argUint64(block.Number()),
 		GasUsed:           argUint64(raw.GasUsed),
+		EffectiveGasPrice: argBig(*txn.EffectiveGasPrice(new(big.Int).SetUint64(block.Header.BaseFee))),
 		ContractAddress:   raw.ContractAddress,
 		FromAddr:          txn.From,
 		ToAddr:            txn.To,
 		Logs:              logs,
 	}
 
-	return res, nil
+	return res
 }
 
 // GetStorageAt returns the contract storage at the index position
@@ -361,21 +559,84 @@ func (e *Eth) GetStorageAt(
 	//nolint:godox
 	// TODO: GetStorage should return the values already parsed (to be fixed in EVM-522)
 
-	// Parse the RLP value
+	// Pad to return 32 bytes data
+	return argBytesPtr(parseStorageValue(result).Bytes()), nil
+}
+
+// parseStorageValue parses the RLP encoded storage value, the zero hash is returned if it's invalid
+func parseStorageValue(value []byte) types.Hash {
 	p := &fastrlp.Parser{}
 
-	v, err := p.Parse(result)
+	v, err := p.Parse(value)
 	if err != nil {
-		return argBytesPtr(types.ZeroHash[:]), nil
+		return types.ZeroHash
 	}
 
 	data, err := v.Bytes()
 	if err != nil {
-		return argBytesPtr(types.ZeroHash[:]), nil
+		return types.ZeroHash
 	}
 
-	// Pad to return 32 bytes data
-	return argBytesPtr(types.BytesToHash(data).Bytes()), nil
+	return types.BytesToHash(data)
+}
+
+// GetProof returns the merkle proof of the account and of its storage slots (EIP-1186)
+func (e *Eth) GetProof(
+	address types.Address,
+	storageKeys []argBytes,
+	filter BlockNumberOrHash,
+) (interface{}, error) {
+	// the keys are decoded as bytes to accept the short ones, which are left-padded to 32 bytes
+	keys := make([]types.Hash, len(storageKeys))
+
+	for i, key := range storageKeys {
+		if len(key) > types.HashLength {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidStorageKey, encodeToHex(key))
+		}
+
+		keys[i] = types.BytesToHash(key)
+	}
+
+	header, err := GetHeaderFromBlockNumberOrHash(filter, e.store)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := e.store.GetProof(header.StateRoot, address, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &accountProof{
+		Address:      address,
+		AccountProof: toArgBytesList(proof.Proof),
+		Balance:      argBig(*big.NewInt(0)),
+		CodeHash:     types.BytesToHash(crypto.Keccak256(nil)),
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: make([]*storageProof, len(proof.StorageProofs)),
+	}
+
+	if proof.Account != nil {
+		res.Balance = argBig(*proof.Account.Balance)
+		res.Nonce = argUint64(proof.Account.Nonce)
+		res.CodeHash = types.BytesToHash(proof.Account.CodeHash)
+		res.StorageHash = proof.Account.Root
+	}
+
+	for i, slot := range proof.StorageProofs {
+		value := types.ZeroHash
+		if slot.Value != nil {
+			value = parseStorageValue(slot.Value)
+		}
+
+		res.StorageProof[i] = &storageProof{
+			Key:   slot.Key,
+			Value: argBig(*new(big.Int).SetBytes(value.Bytes())),
+			Proof: toArgBytesList(slot.Proof),
+		}
+	}
+
+	return res, nil
 }
 
 // GasPrice returns the average gas price based on the last x blocks
@@ -388,24 +649,212 @@ func (e *Eth) GasPrice() (interface{}, error) {
 	return argUint64(common.Max(e.priceLimit, avgGasPrice)), nil
 }
 
-// Call executes a smart contract call using the transaction object data
-func (e *Eth) Call(arg *txnArgs, filter BlockNumberOrHash) (interface{}, error) {
+// MaxPriorityFeePerGas returns a priority fee per gas suggestion based on the tips
+// paid in the last blocks, taking into consideration operator defined price limit
+func (e *Eth) MaxPriorityFeePerGas() (interface{}, error) {
+	header := e.store.Header()
+	tips := []*big.Int{}
+
+	for i := uint64(0); i < priorityFeeBlockCount && i <= header.Number; i++ {
+		block, ok := e.store.GetBlockByNumber(header.Number-i, true)
+		if !ok {
+			break
+		}
+
+		baseFee := new(big.Int).SetUint64(block.Header.BaseFee)
+
+		for _, txn := range block.Transactions {
+			if txn.Type == types.StateTx {
+				continue
+			}
+
+			tips = append(tips, txn.EffectiveTip(baseFee))
+		}
+	}
+
+	tip := new(big.Int).SetUint64(e.priceLimit)
+
+	if len(tips) > 0 {
+		sort.Slice(tips, func(i, j int) bool {
+			return tips[i].Cmp(tips[j]) < 0
+		})
+
+		if suggested := tips[(len(tips)-1)*priorityFeePercentile/100]; suggested.Cmp(tip) > 0 {
+			tip = suggested
+		}
+	}
+
+	return argBigPtr(tip), nil
+}
+
+// FeeHistory returns the base fees, the gas used ratios and the requested reward percentiles
+// of the blockCount blocks up to newestBlock. Before London the rewards are the gas price percentiles
+func (e *Eth) FeeHistory(
+	blockCount argUint64,
+	newestBlock BlockNumber,
+	rewardPercentiles []float64,
+) (interface{}, error) {
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 || (i > 0 && p < rewardPercentiles[i-1]) {
+			return nil, fmt.Errorf("%w: %f", ErrInvalidRewardPercentile, p)
+		}
+	}
+
+	newest, err := GetNumericBlockNumber(newestBlock, e.store)
+	if err != nil {
+		return nil, err
+	}
+
+	count := uint64(blockCount)
+	if count > maxFeeHistoryBlockCount {
+		count = maxFeeHistoryBlockCount
+	}
+
+	if count > newest+1 {
+		count = newest + 1
+	}
+
+	res := &feeHistory{
+		BaseFee:      []argUint64{},
+		GasUsedRatio: []float64{},
+	}
+
+	if count == 0 {
+		return res, nil
+	}
+
+	oldest := newest + 1 - count
+	res.OldestBlock = argUint64(oldest)
+
+	if len(rewardPercentiles) > 0 {
+		res.Reward = make([][]argBig, 0, count)
+	}
+
+	var header *types.Header
+
+	for number := oldest; number <= newest; number++ {
+		block, ok := e.store.GetBlockByNumber(number, true)
+		if !ok {
+			return nil, fmt.Errorf("error fetching block number %d", number)
+		}
+
+		header = block.Header
+
+		gasUsedRatio := float64(0)
+		if block.Header.GasLimit != 0 {
+			gasUsedRatio = float64(block.Header.GasUsed) / float64(block.Header.GasLimit)
+		}
+
+		res.BaseFee = append(res.BaseFee, argUint64(block.Header.BaseFee))
+		res.GasUsedRatio = append(res.GasUsedRatio, gasUsedRatio)
+
+		if len(rewardPercentiles) > 0 {
+			rewards, err := e.blockRewards(block, rewardPercentiles)
+			if err != nil {
+				return nil, err
+			}
+
+			res.Reward = append(res.Reward, rewards)
+		}
+	}
+
+	// the base fee of the block following the newest one
+	res.BaseFee = append(res.BaseFee, argUint64(e.store.CalculateBaseFee(header)))
+
+	return res, nil
+}
+
+// blockRewards returns the effective tips paid at the given percentiles of the block gas used,
+// with the transactions sorted by effective tip and weighted by their gas used
+func (e *Eth) blockRewards(block *types.Block, percentiles []float64) ([]argBig, error) {
+	rewards := make([]argBig, len(percentiles))
+
+	if len(block.Transactions) == 0 {
+		return rewards, nil
+	}
+
+	receipts, err := e.store.GetReceiptsByHash(block.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(receipts) != len(block.Transactions) {
+		return nil, fmt.Errorf("receipts for block %d not found", block.Number())
+	}
+
+	type txGasAndTip struct {
+		gasUsed uint64
+		tip     *big.Int
+	}
+
+	baseFee := new(big.Int).SetUint64(block.Header.BaseFee)
+	sorted := make([]txGasAndTip, 0, len(block.Transactions))
+
+	for i, txn := range block.Transactions {
+		if txn.Type == types.StateTx {
+			continue
+		}
+
+		sorted = append(sorted, txGasAndTip{
+			gasUsed: receipts[i].GasUsed,
+			tip:     txn.EffectiveTip(baseFee),
+		})
+	}
+
+	if len(sorted) == 0 {
+		return rewards, nil
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].tip.Cmp(sorted[j].tip) < 0
+	})
+
+	txIndex := 0
+	sumGasUsed := sorted[0].gasUsed
+
+	for i, p := range percentiles {
+		thresholdGasUsed := uint64(float64(block.Header.GasUsed) * p / 100)
+
+		for sumGasUsed < thresholdGasUsed && txIndex < len(sorted)-1 {
+			txIndex++
+			sumGasUsed += sorted[txIndex].gasUsed
+		}
+
+		rewards[i] = argBig(*sorted[txIndex].tip)
+	}
+
+	return rewards, nil
+}
+
+// Call executes a smart contract call using the transaction object data,
+// optionally against the state and the block with the overridden accounts and header fields
+func (e *Eth) Call(
+	arg *txnArgs,
+	filter BlockNumberOrHash,
+	stateOverride stateOverride,
+	blockOverride *blockOverride,
+) (interface{}, error) {
 	header, err := GetHeaderFromBlockNumberOrHash(filter, e.store)
 	if err != nil {
 		return nil, err
 	}
 
+	stateOverride.setSenderNonce(arg)
+
 	transaction, err := DecodeTxn(arg, e.store)
 	if err != nil {
 		return nil, err
 	}
+
+	blockOverrideType := blockOverride.toType()
+
 	// If the caller didn't supply the gas limit in the message, then we set it to maximum possible => block gas limit
 	if transaction.Gas == 0 {
-		transaction.Gas = header.GasLimit
+		transaction.Gas = overriddenHeader(header, blockOverrideType).GasLimit
 	}
 
 	// The return value of the execution is saved in the transition (returnValue field)
-	result, err := e.store.ApplyTxn(header, transaction)
+	result, err := e.store.ApplyTxn(header, transaction, stateOverride.toType(), blockOverrideType)
 	if err != nil {
 		return nil, err
 	}
@@ -422,8 +871,71 @@ func (e *Eth) Call(arg *txnArgs, filter BlockNumberOrHash) (interface{}, error)
 	return argBytesPtr(result.ReturnValue), nil
 }
 
-// EstimateGas estimates the gas needed to execute a transaction
-func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error) {
+// maxAccessListIterations is the maximum number of the executions of a transaction in eth_createAccessList
+const maxAccessListIterations = 10
+
+// CreateAccessList returns the EIP-2930 access list of the addresses and the slots accessed by the transaction,
+// and the gas used by the transaction with the access list
+func (e *Eth) CreateAccessList(arg *txnArgs, filter BlockNumberOrHash) (interface{}, error) {
+	header, err := GetHeaderFromBlockNumberOrHash(filter, e.store)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := DecodeTxn(arg, e.store)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.Gas == 0 {
+		transaction.Gas = header.GasLimit
+	}
+
+	accessList := transaction.AccessList
+	if accessList == nil {
+		accessList = types.TxAccessList{}
+	}
+
+	// the access list changes the gas of the execution, and so the accessed addresses and slots,
+	// so the transaction is executed until the access list doesn't change
+	for i := 0; i < maxAccessListIterations; i++ {
+		txn := transaction.Copy()
+		txn.AccessList = accessList
+
+		nextAccessList, result, err := e.store.CreateAccessList(header, txn)
+		if err != nil {
+			return nil, err
+		}
+
+		if reflect.DeepEqual(accessList, nextAccessList) {
+			res := &accessListResult{
+				AccessList: accessList,
+				GasUsed:    argUint64(result.GasUsed),
+			}
+
+			if result.Failed() {
+				res.Error = result.Err.Error()
+			}
+
+			return res, nil
+		}
+
+		accessList = nextAccessList
+	}
+
+	return nil, fmt.Errorf("access list didn't converge after %d executions", maxAccessListIterations)
+}
+
+// EstimateGas estimates the gas needed to execute a transaction,
+// optionally against the state and the block with the overridden accounts and header fields
+func (e *Eth) EstimateGas(
+	arg *txnArgs,
+	rawNum *BlockNumber,
+	stateOverride stateOverride,
+	blockOverride *blockOverride,
+) (interface{}, error) {
+	stateOverride.setSenderNonce(arg)
+
 	transaction, err := DecodeTxn(arg, e.store)
 	if err != nil {
 		return nil, err
@@ -440,6 +952,11 @@ func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error
 		return nil, err
 	}
 
+	var (
+		stateOverrideType = stateOverride.toType()
+		blockOverrideType = blockOverride.toType()
+	)
+
 	forksInTime := e.store.GetForksInTime(uint64(number))
 
 	var standardGas uint64
@@ -459,10 +976,10 @@ func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error
 		highEnd = transaction.Gas
 	} else {
 		// If not, use the referenced block number
-		highEnd = header.GasLimit
+		highEnd = overriddenHeader(header, blockOverrideType).GasLimit
 	}
 
-	gasPriceInt := new(big.Int).Set(transaction.GasPrice)
+	gasPriceInt := new(big.Int).Set(transaction.GetGasFeeCap())
 	valueInt := new(big.Int).Set(transaction.Value)
 
 	var availableBalance *big.Int
@@ -476,7 +993,10 @@ func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error
 		accountBalance := big.NewInt(0)
 		acc, err := e.store.GetAccount(header.StateRoot, transaction.From)
 
-		if err != nil && !errors.Is(err, ErrStateNotFound) {
+		if account, ok := stateOverrideType[transaction.From]; ok && account.Balance != nil {
+			// The balance is overridden
+			accountBalance = account.Balance
+		} else if err != nil && !errors.Is(err, ErrStateNotFound) {
 			// An unrelated error occurred, return it
 			return nil, err
 		} else if err == nil {
@@ -540,7 +1060,7 @@ func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error
 		txn := transaction.Copy()
 		txn.Gas = gas
 
-		result, applyErr := e.store.ApplyTxn(header, txn)
+		result, applyErr := e.store.ApplyTxn(header, txn, stateOverrideType, blockOverrideType)
 
 		if applyErr != nil {
 			// Check the application error.
@@ -712,6 +1232,11 @@ func (e *Eth) NewBlockFilter() (interface{}, error) {
 	return e.filterManager.NewBlockFilter(nil), nil
 }
 
+// NewPendingTransactionFilter creates a filter in the node, to notify when new pending transactions arrive
+func (e *Eth) NewPendingTransactionFilter() (interface{}, error) {
+	return e.filterManager.NewPendingTxFilter(false, nil), nil
+}
+
 // GetFilterChanges is a polling method for a filter, which returns an array of logs which occurred since last poll.
 func (e *Eth) GetFilterChanges(id string) (interface{}, error) {
 	return e.filterManager.GetFilterChanges(id)
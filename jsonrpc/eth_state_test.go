@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/newton2049/favo-chain/chain"
+	"github.com/newton2049/favo-chain/crypto"
 	"github.com/newton2049/favo-chain/helper/hex"
 	"github.com/newton2049/favo-chain/state"
+	itrie "github.com/newton2049/favo-chain/state/immutable-trie"
 	"github.com/newton2049/favo-chain/state/runtime"
 	"github.com/newton2049/favo-chain/types"
 	"github.com/stretchr/testify/assert"
@@ -615,6 +617,72 @@ func getExampleStore() *mockSpecialStore {
 // TestEth_EstimateGas_GasLimit tests eth_estimateGas, by using
 // the latest block gas limit for the upper bound, or the specified
 // gas limit in the transaction
+func TestEth_State_GetProof(t *testing.T) {
+	t.Parallel()
+
+	slotValue := types.BytesToHash(big.NewInt(5).Bytes())
+
+	store := &mockSpecialStore{
+		account: &mockAccount{
+			address: addr0,
+			account: &Account{
+				Balance: big.NewInt(100),
+				Nonce:   2,
+			},
+			storage: map[types.Hash][]byte{
+				hash1: (&fastrlp.Arena{}).NewBytes(slotValue.Bytes()).MarshalTo(nil),
+			},
+		},
+		block: &types.Block{
+			Header: &types.Header{
+				Hash:      types.ZeroHash,
+				Number:    0,
+				StateRoot: types.EmptyRootHash,
+			},
+		},
+	}
+
+	eth := newTestEthEndpoint(store)
+	latest := LatestBlockNumber
+
+	res, err := eth.GetProof(addr0, []argBytes{hash1.Bytes(), hash2.Bytes()}, BlockNumberOrHash{BlockNumber: &latest})
+	assert.NoError(t, err)
+
+	proof, ok := res.(*accountProof)
+	assert.True(t, ok)
+	assert.Equal(t, addr0, proof.Address)
+	assert.Equal(t, []argBytes{[]byte{0x1}}, proof.AccountProof)
+	assert.Equal(t, "100", (*big.Int)(&proof.Balance).String())
+	assert.Equal(t, argUint64(2), proof.Nonce)
+	assert.Len(t, proof.StorageProof, 2)
+	assert.Equal(t, hash1, proof.StorageProof[0].Key)
+	assert.Equal(t, "5", (*big.Int)(&proof.StorageProof[0].Value).String())
+	assert.Equal(t, "0", (*big.Int)(&proof.StorageProof[1].Value).String())
+
+	// the missing account is proven empty
+	res, err = eth.GetProof(uninitializedAddress, nil, BlockNumberOrHash{BlockNumber: &latest})
+	assert.NoError(t, err)
+
+	proof, ok = res.(*accountProof)
+	assert.True(t, ok)
+	assert.Equal(t, "0", (*big.Int)(&proof.Balance).String())
+	assert.Equal(t, types.EmptyRootHash, proof.StorageHash)
+	assert.Equal(t, types.BytesToHash(crypto.Keccak256(nil)), proof.CodeHash)
+
+	// the short storage keys are left-padded
+	res, err = eth.GetProof(addr0, []argBytes{{0x1}}, BlockNumberOrHash{BlockNumber: &latest})
+	assert.NoError(t, err)
+
+	proof, ok = res.(*accountProof)
+	assert.True(t, ok)
+	assert.Len(t, proof.StorageProof, 1)
+	assert.Equal(t, types.BytesToHash([]byte{0x1}), proof.StorageProof[0].Key)
+
+	// the storage keys can't be longer than 32 bytes
+	_, err = eth.GetProof(addr0, []argBytes{make([]byte, types.HashLength+1)}, BlockNumberOrHash{BlockNumber: &latest})
+	assert.ErrorIs(t, err, ErrInvalidStorageKey)
+}
+
 func TestEth_EstimateGas_GasLimit(t *testing.T) {
 	//nolint:godox
 	// TODO Make this test run in parallel when the race condition is fixed in gas estimation (to be fixed in EVM-523)
@@ -680,7 +748,7 @@ func TestEth_EstimateGas_GasLimit(t *testing.T) {
 			}
 
 			// Run the estimation
-			estimate, estimateErr := ethEndpoint.EstimateGas(testCase.transaction, nil)
+			estimate, estimateErr := ethEndpoint.EstimateGas(testCase.transaction, nil, nil, nil)
 
 			if testCase.expectedError != nil {
 				if estimateErr == nil {
@@ -731,6 +799,8 @@ func TestEth_EstimateGas_Reverts(t *testing.T) {
 	estimate, estimateErr := ethEndpoint.EstimateGas(
 		constructMockTx(nil, nil),
 		nil,
+		nil,
+		nil,
 	)
 
 	assert.Equal(t, 0, estimate)
@@ -757,6 +827,8 @@ func TestEth_EstimateGas_Errors(t *testing.T) {
 	estimate, estimateErr := ethEndpoint.EstimateGas(
 		mockTx,
 		nil,
+		nil,
+		nil,
 	)
 
 	assert.Equal(t, 0, estimate)
@@ -828,6 +900,35 @@ func (m *mockSpecialStore) GetStorage(root types.Hash, addr types.Address, slot
 	return val, nil
 }
 
+func (m *mockSpecialStore) GetProof(
+	root types.Hash,
+	addr types.Address,
+	keys []types.Hash,
+) (*itrie.AccountProof, error) {
+	proof := &itrie.AccountProof{
+		Proof:         [][]byte{{0x1}},
+		StorageProofs: make([]*itrie.StorageProof, len(keys)),
+	}
+
+	if m.account.address == addr {
+		proof.Account = &state.Account{
+			Balance: m.account.account.Balance,
+			Nonce:   m.account.account.Nonce,
+			Root:    types.EmptyRootHash,
+		}
+	}
+
+	for i, key := range keys {
+		proof.StorageProofs[i] = &itrie.StorageProof{
+			Key:   key,
+			Value: m.account.storage[key],
+			Proof: [][]byte{{0x2}},
+		}
+	}
+
+	return proof, nil
+}
+
 func (m *mockSpecialStore) GetCode(root types.Hash, addr types.Address) ([]byte, error) {
 	if m.account.address != addr {
 		return nil, ErrStateNotFound
@@ -840,7 +941,12 @@ func (m *mockSpecialStore) GetForksInTime(blockNumber uint64) chain.ForksInTime
 	return chain.ForksInTime{}
 }
 
-func (m *mockSpecialStore) ApplyTxn(header *types.Header, txn *types.Transaction) (*runtime.ExecutionResult, error) {
+func (m *mockSpecialStore) ApplyTxn(
+	header *types.Header,
+	txn *types.Transaction,
+	_ types.StateOverride,
+	_ *types.BlockOverride,
+) (*runtime.ExecutionResult, error) {
 	if m.applyTxnHook != nil {
 		return m.applyTxnHook(header, txn)
 	}
@@ -29,6 +29,25 @@ func TestEth_TxnPool_SendRawTransaction(t *testing.T) {
 	}
 }
 
+func TestEth_TxnPool_SendPrivateRawTransaction(t *testing.T) {
+	store := &mockStoreTxn{}
+	eth := newTestEthEndpoint(store)
+
+	txn := &types.Transaction{
+		From: addr0,
+		V:    big.NewInt(1),
+	}
+	txn.ComputeHash()
+
+	hash, err := eth.SendPrivateRawTransaction(txn.MarshalRLP())
+	assert.NoError(t, err)
+	assert.Equal(t, txn.Hash.String(), hash)
+
+	// the transaction isn't added as a public one
+	assert.Nil(t, store.txn)
+	assert.Equal(t, txn.Hash, store.privateTxn.Hash)
+}
+
 func TestEth_TxnPool_SendTransaction(t *testing.T) {
 	store := &mockStoreTxn{}
 	store.AddAccount(addr0)
@@ -48,8 +67,9 @@ func TestEth_TxnPool_SendTransaction(t *testing.T) {
 
 type mockStoreTxn struct {
 	ethStore
-	accounts map[types.Address]*mockAccount
-	txn      *types.Transaction
+	accounts   map[types.Address]*mockAccount
+	txn        *types.Transaction
+	privateTxn *types.Transaction
 }
 
 func (m *mockStoreTxn) AddTx(tx *types.Transaction) error {
@@ -58,6 +78,12 @@ func (m *mockStoreTxn) AddTx(tx *types.Transaction) error {
 	return nil
 }
 
+func (m *mockStoreTxn) AddPrivateTx(tx *types.Transaction) error {
+	m.privateTxn = tx
+
+	return nil
+}
+
 func (m *mockStoreTxn) GetNonce(addr types.Address) uint64 {
 	return 1
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/hashicorp/go-hclog"
 	"github.com/newton2049/favo-chain/blockchain"
+	"github.com/newton2049/favo-chain/helper/progress"
 	"github.com/newton2049/favo-chain/types"
 )
 
@@ -35,7 +36,7 @@ const (
 	NoIndexInHeap = -1
 )
 
-// filter is an interface that BlockFilter and LogFilter implement
+// filter is an interface that all the filters implement
 type filter interface {
 	// hasWSConn returns the flag indicating the filter has web socket stream
 	hasWSConn() bool
@@ -212,6 +213,113 @@ func (f *logFilter) sendUpdates() error {
 	return nil
 }
 
+// pendingTxFilter is a filter to store the new pending transactions
+type pendingTxFilter struct {
+	filterBase
+	sync.Mutex
+
+	// full is the flag indicating the filter stores the whole transactions instead of the hashes
+	full bool
+
+	// txs are the hashes of the transactions, or the transactions if the filter is full
+	txs []interface{}
+}
+
+// appendTx appends new transaction to txs
+func (f *pendingTxFilter) appendTx(tx interface{}) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.txs = append(f.txs, tx)
+}
+
+// takeTxUpdates returns all saved transactions in filter and set new transaction slice
+func (f *pendingTxFilter) takeTxUpdates() []interface{} {
+	f.Lock()
+	defer f.Unlock()
+
+	txs := f.txs
+	f.txs = []interface{}{}
+
+	return txs
+}
+
+// getUpdates returns stored transactions
+func (f *pendingTxFilter) getUpdates() (interface{}, error) {
+	return f.takeTxUpdates(), nil
+}
+
+// sendUpdates writes stored transactions to web socket stream
+func (f *pendingTxFilter) sendUpdates() error {
+	for _, tx := range f.takeTxUpdates() {
+		raw, err := json.Marshal(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := f.writeMessageToWs(string(raw)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncingStatus is the update of a syncing filter while the node is syncing
+type syncingStatus struct {
+	Syncing bool         `json:"syncing"`
+	Status  *progression `json:"status"`
+}
+
+// syncingFilter is a filter to store the changes of the sync status
+type syncingFilter struct {
+	filterBase
+	sync.Mutex
+
+	// updates are the sync statuses, or false once the sync is done
+	updates []interface{}
+}
+
+// appendUpdate appends new sync status to updates
+func (f *syncingFilter) appendUpdate(update interface{}) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.updates = append(f.updates, update)
+}
+
+// takeSyncingUpdates returns all saved sync statuses in filter and set new update slice
+func (f *syncingFilter) takeSyncingUpdates() []interface{} {
+	f.Lock()
+	defer f.Unlock()
+
+	updates := f.updates
+	f.updates = []interface{}{}
+
+	return updates
+}
+
+// getUpdates returns stored sync statuses
+func (f *syncingFilter) getUpdates() (interface{}, error) {
+	return f.takeSyncingUpdates(), nil
+}
+
+// sendUpdates writes stored sync statuses to web socket stream
+func (f *syncingFilter) sendUpdates() error {
+	for _, update := range f.takeSyncingUpdates() {
+		raw, err := json.Marshal(update)
+		if err != nil {
+			return err
+		}
+
+		if err := f.writeMessageToWs(string(raw)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // filterManagerStore provides methods required by FilterManager
 type filterManagerStore interface {
 	// Header returns the current header of the chain (genesis if empty)
@@ -228,6 +336,20 @@ type filterManagerStore interface {
 
 	// GetBlockByNumber returns a block using the provided number
 	GetBlockByNumber(num uint64, full bool) (*types.Block, bool)
+
+	// FilterLogBlocks returns the numbers of the indexed blocks from the range whose logs may match the criteria,
+	// and the number of the first block of the range which isn't indexed yet
+	FilterLogBlocks(from, to uint64, criteria [][][]byte) ([]uint64, uint64)
+
+	// GetPendingTx gets the pending transaction from the transaction pool, if it's present
+	GetPendingTx(txHash types.Hash) (*types.Transaction, bool)
+
+	// SubscribePendingTxs subscribes for the hashes of the transactions promoted in the transaction pool,
+	// and returns the function canceling the subscription
+	SubscribePendingTxs() (<-chan types.Hash, func())
+
+	// GetSyncProgression retrieves the current sync progression, if any
+	GetSyncProgression() *progress.Progression
 }
 
 // FilterManager manages all running filters
@@ -243,6 +365,13 @@ type FilterManager struct {
 	blockStream     *blockStream
 	blockRangeLimit uint64
 
+	pendingTxCh     <-chan types.Hash
+	pendingTxCancel func()
+
+	// syncProgression is the last sync progression sent to the syncing filters,
+	// it's only accessed by the worker process
+	syncProgression *progress.Progression
+
 	filters  map[string]filter
 	timeouts timeHeapImpl
 
@@ -273,6 +402,9 @@ func NewFilterManager(logger hclog.Logger, store filterManagerStore, blockRangeL
 	// start the head watcher
 	m.subscription = store.SubscribeEvents()
 
+	// start the pending transactions watcher
+	m.pendingTxCh, m.pendingTxCancel = store.SubscribePendingTxs()
+
 	return m
 }
 
@@ -309,6 +441,19 @@ func (f *FilterManager) Run() {
 				f.logger.Error("failed to dispatch event", "err", err)
 			}
 
+		case txHash, ok := <-f.pendingTxCh:
+			if !ok {
+				// the transaction pool is closed
+				f.pendingTxCh = nil
+
+				continue
+			}
+
+			// new pending transaction
+			if err := f.dispatchPendingTx(txHash); err != nil {
+				f.logger.Error("failed to dispatch pending transaction", "err", err)
+			}
+
 		case <-timeoutCh:
 			// timeout for filter
 			// if filter still exists
@@ -328,6 +473,7 @@ func (f *FilterManager) Run() {
 
 // Close closed closeCh so that terminate worker
 func (f *FilterManager) Close() {
+	f.pendingTxCancel()
 	close(f.closeCh)
 }
 
@@ -359,6 +505,33 @@ func (f *FilterManager) NewLogFilter(logQuery *LogQuery, ws wsConn) string {
 	return f.addFilter(filter)
 }
 
+// NewPendingTxFilter adds new PendingTxFilter, which stores the whole transactions if full is set
+func (f *FilterManager) NewPendingTxFilter(full bool, ws wsConn) string {
+	filter := &pendingTxFilter{
+		filterBase: newFilterBase(ws),
+		full:       full,
+	}
+
+	if filter.hasWSConn() {
+		ws.SetFilterID(filter.id)
+	}
+
+	return f.addFilter(filter)
+}
+
+// NewSyncingFilter adds new SyncingFilter
+func (f *FilterManager) NewSyncingFilter(ws wsConn) string {
+	filter := &syncingFilter{
+		filterBase: newFilterBase(ws),
+	}
+
+	if filter.hasWSConn() {
+		ws.SetFilterID(filter.id)
+	}
+
+	return f.addFilter(filter)
+}
+
 // Exists checks the filter with given ID exists
 func (f *FilterManager) Exists(id string) bool {
 	f.RLock()
@@ -418,14 +591,28 @@ func (f *FilterManager) getLogsFromBlocks(query *LogQuery) ([]*Log, error) {
 		from = 1
 	}
 
-	// if not disabled, avoid handling large block ranges
-	if f.blockRangeLimit != 0 && to-from > f.blockRangeLimit {
+	var (
+		blockNumbers []uint64
+		next         = from
+	)
+
+	// the log index only narrows down the blocks of a filter with addresses or topics
+	if criteria := query.bloomCriteria(); len(criteria) > 0 {
+		blockNumbers, next = f.store.FilterLogBlocks(from, to, criteria)
+	}
+
+	// if not disabled, avoid handling large block ranges which aren't indexed
+	if next <= to && f.blockRangeLimit != 0 && to-next > f.blockRangeLimit {
 		return nil, ErrBlockRangeTooHigh
 	}
 
+	for i := next; i <= to; i++ {
+		blockNumbers = append(blockNumbers, i)
+	}
+
 	logs := make([]*Log, 0)
 
-	for i := from; i <= to; i++ {
+	for _, i := range blockNumbers {
 		block, ok := f.store.GetBlockByNumber(i, true)
 		if !ok {
 			break
@@ -547,6 +734,7 @@ func (f *FilterManager) removeFilterByID(id string) bool {
 	}
 
 	delete(f.filters, id)
+	updateFiltersMetrics(len(f.filters))
 
 	if removed := f.timeouts.removeFilter(filter.getFilterBase()); removed {
 		f.emitSignalToUpdateCh()
@@ -584,6 +772,7 @@ func (f *FilterManager) addFilter(filter filter) string {
 	base := filter.getFilterBase()
 
 	f.filters[base.id] = filter
+	updateFiltersMetrics(len(f.filters))
 
 	// Set timeout and add to heap if filter doesn't have web socket connection
 	if !filter.hasWSConn() {
@@ -622,6 +811,9 @@ func (f *FilterManager) dispatchEvent(evnt *blockchain.Event) error {
 	// store new event in each filters
 	f.processEvent(evnt)
 
+	// the sync progression advances with the written blocks
+	f.processSyncProgression()
+
 	// send data to web socket stream
 	if err := f.flushWsFilters(); err != nil {
 		return err
@@ -648,6 +840,89 @@ func (f *FilterManager) processEvent(evnt *blockchain.Event) {
 	}
 }
 
+// dispatchPendingTx is an event handler for new pending transaction
+func (f *FilterManager) dispatchPendingTx(txHash types.Hash) error {
+	f.processPendingTx(txHash)
+
+	return f.flushWsFilters()
+}
+
+// processPendingTx makes each PendingTxFilter append the new pending transaction
+func (f *FilterManager) processPendingTx(txHash types.Hash) {
+	f.RLock()
+	defer f.RUnlock()
+
+	// the transaction is only fetched for the full filters
+	var tx *transaction
+
+	for _, filter := range f.filters {
+		pendingTxFilter, ok := filter.(*pendingTxFilter)
+		if !ok {
+			continue
+		}
+
+		if !pendingTxFilter.full {
+			pendingTxFilter.appendTx(txHash)
+
+			continue
+		}
+
+		if tx == nil {
+			pendingTx, found := f.store.GetPendingTx(txHash)
+			if !found {
+				// the transaction has already left the pool
+				continue
+			}
+
+			tx = toPendingTransaction(pendingTx)
+		}
+
+		pendingTxFilter.appendTx(tx)
+	}
+}
+
+// processSyncProgression makes each SyncingFilter append the sync status if it has changed
+func (f *FilterManager) processSyncProgression() {
+	f.RLock()
+	defer f.RUnlock()
+
+	syncProgression := f.store.GetSyncProgression()
+
+	var update interface{}
+
+	switch {
+	case syncProgression != nil:
+		if f.syncProgression != nil && *f.syncProgression == *syncProgression {
+			return
+		}
+
+		current := *syncProgression
+		f.syncProgression = &current
+
+		update = &syncingStatus{
+			Syncing: true,
+			Status: &progression{
+				Type:          string(current.SyncType),
+				StartingBlock: argUint64(current.StartingBlock),
+				CurrentBlock:  argUint64(current.CurrentBlock),
+				HighestBlock:  argUint64(current.HighestBlock),
+			},
+		}
+	case f.syncProgression != nil:
+		// the sync is done
+		f.syncProgression = nil
+		update = false
+	default:
+		return
+	}
+
+	for _, filter := range f.filters {
+		if syncingFilter, ok := filter.(*syncingFilter); ok {
+			syncingFilter.appendUpdate(update)
+		}
+	}
+}
+
 // appendLogsToFilters makes each LogFilters append logs in the header
 func (f *FilterManager) appendLogsToFilters(header *block) error {
 	receipts, err := f.store.GetReceiptsByHash(header.Hash)
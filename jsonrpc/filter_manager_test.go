@@ -14,8 +14,10 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/hashicorp/go-hclog"
 	"github.com/newton2049/favo-chain/blockchain"
+	"github.com/newton2049/favo-chain/helper/progress"
 	"github.com/newton2049/favo-chain/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_GetLogsForQuery(t *testing.T) {
@@ -154,6 +156,66 @@ func Test_GetLogsForQuery(t *testing.T) {
 	}
 }
 
+func Test_GetLogsForQuery_LogIndex(t *testing.T) {
+	t.Parallel()
+
+	topic := types.StringToHash("4")
+
+	store := &mockBlockStore{
+		topics: []types.Hash{topic},
+	}
+	store.setupLogs()
+
+	for i := 0; i < 3; i++ {
+		store.appendBlocksToStore([]*types.Block{
+			{
+				Header: &types.Header{
+					Number: uint64(i),
+					Hash:   types.StringToHash(strconv.Itoa(i)),
+				},
+				Transactions: []*types.Transaction{
+					{
+						Value: big.NewInt(10),
+					},
+					{
+						Value: big.NewInt(11),
+					},
+					{
+						Value: big.NewInt(12),
+					},
+				},
+			},
+		})
+	}
+
+	f := NewFilterManager(hclog.NewNullLogger(), store, 1000)
+	defer f.Close()
+
+	query := &LogQuery{
+		fromBlock: 1,
+		toBlock:   5000,
+		Topics:    [][]types.Hash{{topic}},
+	}
+
+	// the range isn't indexed
+	_, err := f.GetLogsForQuery(query)
+	assert.ErrorIs(t, err, ErrBlockRangeTooHigh)
+
+	// the range is indexed up to the last 1000 blocks
+	store.indexedBlocks = 4000
+
+	logs, err := f.GetLogsForQuery(query)
+	require.NoError(t, err)
+	assert.Len(t, logs, 2)
+
+	// the filter without addresses or topics isn't narrowed down by the index
+	_, err = f.GetLogsForQuery(&LogQuery{
+		fromBlock: 1,
+		toBlock:   5000,
+	})
+	assert.ErrorIs(t, err, ErrBlockRangeTooHigh)
+}
+
 func Test_GetLogFilterFromID(t *testing.T) {
 	t.Parallel()
 
@@ -469,6 +531,105 @@ func TestFilterWebsocket(t *testing.T) {
 	}
 }
 
+func TestFilterPendingTx(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+
+	m := NewFilterManager(hclog.NewNullLogger(), store, 1000)
+	defer m.Close()
+
+	go m.Run()
+
+	hashMock, hashMsgCh := newMockWsConnWithMsgCh()
+	fullMock, fullMsgCh := newMockWsConnWithMsgCh()
+
+	id := m.NewPendingTxFilter(false, nil)
+	m.NewPendingTxFilter(false, hashMock)
+	m.NewPendingTxFilter(true, fullMock)
+
+	tx := &types.Transaction{
+		Nonce:    1,
+		GasPrice: big.NewInt(10),
+		Gas:      21000,
+		Value:    big.NewInt(1),
+		V:        big.NewInt(1),
+		R:        big.NewInt(1),
+		S:        big.NewInt(1),
+		Hash:     types.StringToHash("1"),
+	}
+
+	store.emitPendingTx(tx)
+
+	for _, msgCh := range []<-chan []byte{hashMsgCh, fullMsgCh} {
+		select {
+		case msg := <-msgCh:
+			assert.Contains(t, string(msg), tx.Hash.String())
+		case <-time.After(2 * time.Second):
+			t.Fatal("pending transaction not received in 2 seconds")
+		}
+	}
+
+	// we need to wait for the manager to process the data
+	time.Sleep(500 * time.Millisecond)
+
+	changes, err := m.GetFilterChanges(id)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{tx.Hash}, changes)
+}
+
+func TestFilterSyncing(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+
+	m := NewFilterManager(hclog.NewNullLogger(), store, 1000)
+	defer m.Close()
+
+	go m.Run()
+
+	mock, msgCh := newMockWsConnWithMsgCh()
+
+	m.NewSyncingFilter(mock)
+
+	emitBlock := func(hash string) {
+		store.emitEvent(&mockEvent{
+			NewChain: []*mockHeader{
+				{
+					header: &types.Header{
+						Hash: types.StringToHash(hash),
+					},
+				},
+			},
+		})
+	}
+
+	expectMsg := func(expected string) {
+		select {
+		case msg := <-msgCh:
+			assert.Contains(t, string(msg), expected)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("sync status %s not received in 2 seconds", expected)
+		}
+	}
+
+	store.setSyncProgression(&progress.Progression{
+		SyncType:      progress.ChainSyncBulk,
+		StartingBlock: 1,
+		CurrentBlock:  1,
+		HighestBlock:  3,
+	})
+	emitBlock("1")
+	expectMsg(`"syncing":true`)
+
+	// the sync status is only sent when it changes
+	emitBlock("2")
+
+	store.setSyncProgression(nil)
+	emitBlock("3")
+	expectMsg(`"result": false`)
+}
+
 type mockWsConn struct {
 	SetFilterIDFn  func(string)
 	GetFilterIDFn  func() string
@@ -0,0 +1,991 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"github.com/newton2049/favo-chain/types"
+)
+
+var (
+	errInvalidGraphQLScalar = errors.New("invalid scalar value")
+	errNumberAndHash        = errors.New("only one of number and hash can be given")
+	errReceiptNotFound      = errors.New("receipt not found")
+)
+
+// graphQLAddress is the Address scalar of the GraphQL schema
+type graphQLAddress types.Address
+
+func (graphQLAddress) ImplementsGraphQLType(name string) bool {
+	return name == "Address"
+}
+
+func (a *graphQLAddress) UnmarshalGraphQL(input interface{}) error {
+	str, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("%w: %v", errInvalidGraphQLScalar, input)
+	}
+
+	return (*types.Address)(a).UnmarshalText([]byte(str))
+}
+
+func (a graphQLAddress) MarshalText() ([]byte, error) {
+	return types.Address(a).MarshalText()
+}
+
+// graphQLHash is the Bytes32 scalar of the GraphQL schema
+type graphQLHash types.Hash
+
+func (graphQLHash) ImplementsGraphQLType(name string) bool {
+	return name == "Bytes32"
+}
+
+func (h *graphQLHash) UnmarshalGraphQL(input interface{}) error {
+	str, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("%w: %v", errInvalidGraphQLScalar, input)
+	}
+
+	return (*types.Hash)(h).UnmarshalText([]byte(str))
+}
+
+func (h graphQLHash) MarshalText() ([]byte, error) {
+	return types.Hash(h).MarshalText()
+}
+
+// argUint64 is the Long scalar of the GraphQL schema
+func (argUint64) ImplementsGraphQLType(name string) bool {
+	return name == "Long"
+}
+
+func (u *argUint64) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case string:
+		return u.UnmarshalText([]byte(v))
+	case int32:
+		// the numbers of the query literals
+		if v < 0 {
+			return fmt.Errorf("%w: %v", errInvalidGraphQLScalar, input)
+		}
+
+		*u = argUint64(v)
+	case float64:
+		// the numbers of the json variables
+		if v < 0 || v != math.Trunc(v) {
+			return fmt.Errorf("%w: %v", errInvalidGraphQLScalar, input)
+		}
+
+		*u = argUint64(v)
+	default:
+		return fmt.Errorf("%w: %v", errInvalidGraphQLScalar, input)
+	}
+
+	return nil
+}
+
+// argBig is the BigInt scalar of the GraphQL schema
+func (argBig) ImplementsGraphQLType(name string) bool {
+	return name == "BigInt"
+}
+
+func (a *argBig) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case string:
+		return a.UnmarshalText([]byte(v))
+	case int32, float64:
+		var u argUint64
+		if err := u.UnmarshalGraphQL(v); err != nil {
+			return err
+		}
+
+		*a = argBig(*new(big.Int).SetUint64(uint64(u)))
+	default:
+		return fmt.Errorf("%w: %v", errInvalidGraphQLScalar, input)
+	}
+
+	return nil
+}
+
+// argBytes is the Bytes scalar of the GraphQL schema
+func (argBytes) ImplementsGraphQLType(name string) bool {
+	return name == "Bytes"
+}
+
+func (b *argBytes) UnmarshalGraphQL(input interface{}) error {
+	str, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("%w: %v", errInvalidGraphQLScalar, input)
+	}
+
+	return b.UnmarshalText([]byte(str))
+}
+
+// toArgBig converts a big integer to a BigInt scalar, nil is converted to zero
+func toArgBig(b *big.Int) argBig {
+	if b == nil {
+		return argBig{}
+	}
+
+	return argBig(*b)
+}
+
+// graphQLResolver is the root resolver of the GraphQL queries, which are resolved through the store.
+// The gas prices and the logs of the block ranges are resolved like the eth endpoint does
+type graphQLResolver struct {
+	store           ethStore
+	eth             *Eth
+	blockRangeLimit uint64
+}
+
+// newGraphQLSchema parses the GraphQL schema with the resolvers of the store.
+// The queries nested deeper than maxDepth are rejected (unless 0), and at most maxParallelism
+// resolvers of a query run in parallel (the library default if 0)
+func newGraphQLSchema(
+	store ethStore,
+	eth *Eth,
+	blockRangeLimit uint64,
+	maxDepth uint64,
+	maxParallelism uint64,
+) (*graphql.Schema, error) {
+	opts := []graphql.SchemaOpt{graphql.MaxDepth(int(maxDepth))}
+
+	if maxParallelism > 0 {
+		opts = append(opts, graphql.MaxParallelism(int(maxParallelism)))
+	}
+
+	return graphql.ParseSchema(graphQLSchema, &graphQLResolver{
+		store:           store,
+		eth:             eth,
+		blockRangeLimit: blockRangeLimit,
+	}, opts...)
+}
+
+type graphQLBlockArgs struct {
+	Number *argUint64
+	Hash   *graphQLHash
+}
+
+// Block returns a block by number or by hash, the latest block if neither is given
+func (r *graphQLResolver) Block(args graphQLBlockArgs) (*graphQLBlock, error) {
+	var (
+		block *types.Block
+		ok    bool
+	)
+
+	switch {
+	case args.Number != nil && args.Hash != nil:
+		return nil, errNumberAndHash
+	case args.Hash != nil:
+		block, ok = r.store.GetBlockByHash(types.Hash(*args.Hash), true)
+	case args.Number != nil:
+		block, ok = r.store.GetBlockByNumber(uint64(*args.Number), true)
+	default:
+		block, ok = r.store.GetBlockByNumber(r.store.Header().Number, true)
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &graphQLBlock{r: r, block: block}, nil
+}
+
+type graphQLBlocksArgs struct {
+	From argUint64
+	To   *argUint64
+}
+
+// Blocks returns the blocks of the range, up to the latest block if the end of the range isn't given
+func (r *graphQLResolver) Blocks(args graphQLBlocksArgs) ([]*graphQLBlock, error) {
+	from, to := uint64(args.From), r.store.Header().Number
+	if args.To != nil {
+		to = uint64(*args.To)
+	}
+
+	if to < from {
+		return nil, ErrIncorrectBlockRange
+	}
+
+	if r.blockRangeLimit != 0 && to-from > r.blockRangeLimit {
+		return nil, ErrBlockRangeTooHigh
+	}
+
+	blocks := make([]*graphQLBlock, 0)
+
+	for i := from; i <= to; i++ {
+		block, ok := r.store.GetBlockByNumber(i, true)
+		if !ok {
+			break
+		}
+
+		blocks = append(blocks, &graphQLBlock{r: r, block: block})
+	}
+
+	return blocks, nil
+}
+
+type graphQLTransactionArgs struct {
+	Hash graphQLHash
+}
+
+// Transaction returns a mined transaction or a pending transaction of the pool by hash
+func (r *graphQLResolver) Transaction(args graphQLTransactionArgs) *graphQLTransaction {
+	hash := types.Hash(args.Hash)
+
+	if blockHash, ok := r.store.ReadTxLookup(hash); ok {
+		if block, ok := r.store.GetBlockByHash(blockHash, true); ok {
+			for idx, txn := range block.Transactions {
+				if txn.Hash == hash {
+					return &graphQLTransaction{r: r, txn: txn, block: block, index: idx}
+				}
+			}
+		}
+	}
+
+	if txn, ok := r.store.GetPendingTx(hash); ok {
+		return &graphQLTransaction{r: r, txn: txn}
+	}
+
+	return nil
+}
+
+type graphQLFilterCriteria struct {
+	FromBlock *argUint64
+	ToBlock   *argUint64
+	Addresses *[]graphQLAddress
+	Topics    *[][]graphQLHash
+}
+
+type graphQLLogsArgs struct {
+	Filter graphQLFilterCriteria
+}
+
+// Logs returns the logs of the range matching the filter, the latest block if the range isn't given
+func (r *graphQLResolver) Logs(args graphQLLogsArgs) ([]*graphQLLog, error) {
+	query := toLogQuery(args.Filter.Addresses, args.Filter.Topics)
+
+	if args.Filter.FromBlock != nil {
+		query.fromBlock = BlockNumber(*args.Filter.FromBlock)
+	}
+
+	if args.Filter.ToBlock != nil {
+		query.toBlock = BlockNumber(*args.Filter.ToBlock)
+	}
+
+	logs, err := r.eth.filterManager.GetLogsForQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		blocks = map[types.Hash]*types.Block{}
+		result = make([]*graphQLLog, 0, len(logs))
+	)
+
+	for _, log := range logs {
+		block, ok := blocks[log.BlockHash]
+		if !ok {
+			if block, ok = r.store.GetBlockByHash(log.BlockHash, true); !ok {
+				return nil, ErrBlockNotFound
+			}
+
+			blocks[log.BlockHash] = block
+		}
+
+		result = append(result, &graphQLLog{
+			txn: &graphQLTransaction{
+				r:     r,
+				txn:   block.Transactions[log.TxIndex],
+				block: block,
+				index: int(log.TxIndex),
+			},
+			log: &types.Log{
+				Address: log.Address,
+				Topics:  log.Topics,
+				Data:    log.Data,
+			},
+			index: uint64(log.LogIndex),
+		})
+	}
+
+	return result, nil
+}
+
+// GasPrice returns the gas price suggestion of eth_gasPrice
+func (r *graphQLResolver) GasPrice() (argBig, error) {
+	price, err := r.eth.GasPrice()
+	if err != nil {
+		return argBig{}, err
+	}
+
+	return toArgBig(new(big.Int).SetUint64(uint64(price.(argUint64)))), nil //nolint:forcetypeassert
+}
+
+// MaxPriorityFeePerGas returns the priority fee suggestion of eth_maxPriorityFeePerGas
+func (r *graphQLResolver) MaxPriorityFeePerGas() (argBig, error) {
+	tip, err := r.eth.MaxPriorityFeePerGas()
+	if err != nil {
+		return argBig{}, err
+	}
+
+	return *tip.(*argBig), nil //nolint:forcetypeassert
+}
+
+// ChainID returns the chain id
+func (r *graphQLResolver) ChainID() argBig {
+	return toArgBig(new(big.Int).SetUint64(r.eth.chainID))
+}
+
+// toLogQuery returns the query of the logs matching the addresses and the topics,
+// the range of the query is the latest block
+func toLogQuery(addresses *[]graphQLAddress, topics *[][]graphQLHash) *LogQuery {
+	query := &LogQuery{
+		fromBlock: LatestBlockNumber,
+		toBlock:   LatestBlockNumber,
+	}
+
+	if addresses != nil {
+		for _, addr := range *addresses {
+			query.Addresses = append(query.Addresses, types.Address(addr))
+		}
+	}
+
+	if topics != nil {
+		query.Topics = make([][]types.Hash, len(*topics))
+
+		for i, set := range *topics {
+			query.Topics[i] = make([]types.Hash, len(set))
+
+			for j, topic := range set {
+				query.Topics[i][j] = types.Hash(topic)
+			}
+		}
+	}
+
+	return query
+}
+
+// graphQLBlock resolves the fields of a block
+type graphQLBlock struct {
+	r     *graphQLResolver
+	block *types.Block
+}
+
+func (b *graphQLBlock) Number() argUint64 {
+	return argUint64(b.block.Number())
+}
+
+func (b *graphQLBlock) Hash() graphQLHash {
+	return graphQLHash(b.block.Hash())
+}
+
+// Parent returns the parent block, nil for the genesis block
+func (b *graphQLBlock) Parent() *graphQLBlock {
+	if b.block.Number() == 0 {
+		return nil
+	}
+
+	parent, ok := b.r.store.GetBlockByHash(b.block.ParentHash(), true)
+	if !ok {
+		return nil
+	}
+
+	return &graphQLBlock{r: b.r, block: parent}
+}
+
+func (b *graphQLBlock) Nonce() argBytes {
+	return argBytes(b.block.Header.Nonce[:])
+}
+
+func (b *graphQLBlock) TransactionsRoot() graphQLHash {
+	return graphQLHash(b.block.Header.TxRoot)
+}
+
+func (b *graphQLBlock) TransactionCount() argUint64 {
+	return argUint64(len(b.block.Transactions))
+}
+
+func (b *graphQLBlock) StateRoot() graphQLHash {
+	return graphQLHash(b.block.Header.StateRoot)
+}
+
+func (b *graphQLBlock) ReceiptsRoot() graphQLHash {
+	return graphQLHash(b.block.Header.ReceiptsRoot)
+}
+
+func (b *graphQLBlock) Miner() *graphQLAccount {
+	return &graphQLAccount{
+		r:       b.r,
+		address: types.BytesToAddress(b.block.Header.Miner),
+		header:  b.block.Header,
+	}
+}
+
+func (b *graphQLBlock) ExtraData() argBytes {
+	return argBytes(b.block.Header.ExtraData)
+}
+
+func (b *graphQLBlock) GasLimit() argUint64 {
+	return argUint64(b.block.Header.GasLimit)
+}
+
+func (b *graphQLBlock) GasUsed() argUint64 {
+	return argUint64(b.block.Header.GasUsed)
+}
+
+// BaseFeePerGas returns the base fee of the block, nil before London
+func (b *graphQLBlock) BaseFeePerGas() *argBig {
+	if !b.r.store.GetForksInTime(b.block.Number()).London {
+		return nil
+	}
+
+	return argBigPtr(new(big.Int).SetUint64(b.block.Header.BaseFee))
+}
+
+func (b *graphQLBlock) Timestamp() argUint64 {
+	return argUint64(b.block.Header.Timestamp)
+}
+
+func (b *graphQLBlock) LogsBloom() argBytes {
+	return argBytes(b.block.Header.LogsBloom[:])
+}
+
+func (b *graphQLBlock) MixHash() graphQLHash {
+	return graphQLHash(b.block.Header.MixHash)
+}
+
+func (b *graphQLBlock) Difficulty() argBig {
+	return toArgBig(new(big.Int).SetUint64(b.block.Header.Difficulty))
+}
+
+func (b *graphQLBlock) OmmerCount() argUint64 {
+	return argUint64(len(b.block.Uncles))
+}
+
+func (b *graphQLBlock) Transactions() []*graphQLTransaction {
+	txns := make([]*graphQLTransaction, len(b.block.Transactions))
+
+	for idx, txn := range b.block.Transactions {
+		txns[idx] = &graphQLTransaction{r: b.r, txn: txn, block: b.block, index: idx}
+	}
+
+	return txns
+}
+
+type graphQLTransactionAtArgs struct {
+	Index argUint64
+}
+
+// TransactionAt returns the transaction at the index of the block, nil if it's out of the block
+func (b *graphQLBlock) TransactionAt(args graphQLTransactionAtArgs) *graphQLTransaction {
+	if uint64(args.Index) >= uint64(len(b.block.Transactions)) {
+		return nil
+	}
+
+	return &graphQLTransaction{
+		r:     b.r,
+		txn:   b.block.Transactions[args.Index],
+		block: b.block,
+		index: int(args.Index),
+	}
+}
+
+type graphQLBlockFilterCriteria struct {
+	Addresses *[]graphQLAddress
+	Topics    *[][]graphQLHash
+}
+
+type graphQLBlockLogsArgs struct {
+	Filter graphQLBlockFilterCriteria
+}
+
+// Logs returns the logs of the block matching the filter
+func (b *graphQLBlock) Logs(args graphQLBlockLogsArgs) ([]*graphQLLog, error) {
+	query := toLogQuery(args.Filter.Addresses, args.Filter.Topics)
+
+	receipts, err := b.r.store.GetReceiptsByHash(b.block.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]*graphQLLog, 0)
+
+	for idx, receipt := range receipts {
+		if idx >= len(b.block.Transactions) {
+			break
+		}
+
+		txn := &graphQLTransaction{
+			r:       b.r,
+			txn:     b.block.Transactions[idx],
+			block:   b.block,
+			index:   idx,
+			receipt: receipt,
+		}
+
+		for logIdx, log := range receipt.Logs {
+			if query.Match(log) {
+				logs = append(logs, &graphQLLog{txn: txn, log: log, index: uint64(logIdx)})
+			}
+		}
+	}
+
+	return logs, nil
+}
+
+type graphQLAccountArgs struct {
+	Address graphQLAddress
+}
+
+// Account returns the account at the state of the block
+func (b *graphQLBlock) Account(args graphQLAccountArgs) *graphQLAccount {
+	return &graphQLAccount{r: b.r, address: types.Address(args.Address), header: b.block.Header}
+}
+
+type graphQLCallData struct {
+	From                 *graphQLAddress
+	To                   *graphQLAddress
+	Gas                  *argUint64
+	GasPrice             *argBig
+	MaxFeePerGas         *argBig
+	MaxPriorityFeePerGas *argBig
+	Value                *argBig
+	Data                 *argBytes
+}
+
+// toTxnArgs converts the call data to the transaction object of eth_call
+func (c *graphQLCallData) toTxnArgs() *txnArgs {
+	toBytes := func(b *argBig) *argBytes {
+		if b == nil {
+			return nil
+		}
+
+		return argBytesPtr((*big.Int)(b).Bytes())
+	}
+
+	return &txnArgs{
+		From:      (*types.Address)(c.From),
+		To:        (*types.Address)(c.To),
+		Gas:       c.Gas,
+		GasPrice:  toBytes(c.GasPrice),
+		GasTipCap: toBytes(c.MaxPriorityFeePerGas),
+		GasFeeCap: toBytes(c.MaxFeePerGas),
+		Value:     toBytes(c.Value),
+		Data:      c.Data,
+	}
+}
+
+type graphQLCallArgs struct {
+	Data graphQLCallData
+}
+
+// Call executes the call at the state of the block, the failed calls are returned with the status 0
+func (b *graphQLBlock) Call(args graphQLCallArgs) (*graphQLCallResult, error) {
+	txn, err := DecodeTxn(args.Data.toTxnArgs(), b.r.store)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the caller didn't supply the gas limit in the message, then we set it to maximum possible => block gas limit
+	if txn.Gas == 0 {
+		txn.Gas = b.block.Header.GasLimit
+	}
+
+	result, err := b.r.store.ApplyTxn(b.block.Header, txn, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	status := uint64(types.ReceiptSuccess)
+	if result.Failed() {
+		status = uint64(types.ReceiptFailed)
+	}
+
+	return &graphQLCallResult{
+		data:    result.ReturnValue,
+		gasUsed: result.GasUsed,
+		status:  status,
+	}, nil
+}
+
+// EstimateGas estimates the gas of the call at the state of the block like eth_estimateGas does
+func (b *graphQLBlock) EstimateGas(args graphQLCallArgs) (argUint64, error) {
+	number := BlockNumber(b.block.Number())
+
+	gas, err := b.r.eth.EstimateGas(args.Data.toTxnArgs(), &number, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return gas.(argUint64), nil //nolint:forcetypeassert
+}
+
+// graphQLCallResult resolves the fields of the result of a call
+type graphQLCallResult struct {
+	data    []byte
+	gasUsed uint64
+	status  uint64
+}
+
+func (c *graphQLCallResult) Data() argBytes {
+	return argBytes(c.data)
+}
+
+func (c *graphQLCallResult) GasUsed() argUint64 {
+	return argUint64(c.gasUsed)
+}
+
+func (c *graphQLCallResult) Status() argUint64 {
+	return argUint64(c.status)
+}
+
+// graphQLTransaction resolves the fields of a mined or of a pending transaction
+type graphQLTransaction struct {
+	r   *graphQLResolver
+	txn *types.Transaction
+
+	// block is the block of the transaction and index is its index in the block, block is nil if it's pending
+	block *types.Block
+	index int
+
+	// receipt is the receipt of the transaction, loaded on the first access
+	receipt     *types.Receipt
+	receiptLock sync.Mutex
+}
+
+// header returns the header of the block of the transaction, the latest header if it's pending
+func (t *graphQLTransaction) header() *types.Header {
+	if t.block == nil {
+		return t.r.store.Header()
+	}
+
+	return t.block.Header
+}
+
+// getReceipt returns the receipt of the transaction, nil if it's pending
+func (t *graphQLTransaction) getReceipt() (*types.Receipt, error) {
+	if t.block == nil {
+		return nil, nil
+	}
+
+	t.receiptLock.Lock()
+	defer t.receiptLock.Unlock()
+
+	if t.receipt == nil {
+		receipts, err := t.r.store.GetReceiptsByHash(t.block.Hash())
+		if err != nil {
+			return nil, err
+		}
+
+		if t.index >= len(receipts) {
+			return nil, errReceiptNotFound
+		}
+
+		t.receipt = receipts[t.index]
+	}
+
+	return t.receipt, nil
+}
+
+func (t *graphQLTransaction) Hash() graphQLHash {
+	return graphQLHash(t.txn.Hash)
+}
+
+func (t *graphQLTransaction) Nonce() argUint64 {
+	return argUint64(t.txn.Nonce)
+}
+
+func (t *graphQLTransaction) Index() *argUint64 {
+	if t.block == nil {
+		return nil
+	}
+
+	return argUintPtr(uint64(t.index))
+}
+
+func (t *graphQLTransaction) From() *graphQLAccount {
+	return &graphQLAccount{r: t.r, address: t.txn.From, header: t.header()}
+}
+
+func (t *graphQLTransaction) To() *graphQLAccount {
+	if t.txn.To == nil {
+		return nil
+	}
+
+	return &graphQLAccount{r: t.r, address: *t.txn.To, header: t.header()}
+}
+
+func (t *graphQLTransaction) Value() argBig {
+	return toArgBig(t.txn.Value)
+}
+
+func (t *graphQLTransaction) GasPrice() argBig {
+	return toArgBig(t.txn.GetGasFeeCap())
+}
+
+func (t *graphQLTransaction) MaxFeePerGas() *argBig {
+	if t.txn.Type != types.DynamicFeeTx {
+		return nil
+	}
+
+	return argBigPtr(t.txn.GetGasFeeCap())
+}
+
+func (t *graphQLTransaction) MaxPriorityFeePerGas() *argBig {
+	if t.txn.Type != types.DynamicFeeTx {
+		return nil
+	}
+
+	return argBigPtr(t.txn.GetGasTipCap())
+}
+
+func (t *graphQLTransaction) Gas() argUint64 {
+	return argUint64(t.txn.Gas)
+}
+
+func (t *graphQLTransaction) InputData() argBytes {
+	return argBytes(t.txn.Input)
+}
+
+func (t *graphQLTransaction) Block() *graphQLBlock {
+	if t.block == nil {
+		return nil
+	}
+
+	return &graphQLBlock{r: t.r, block: t.block}
+}
+
+func (t *graphQLTransaction) Status() (*argUint64, error) {
+	receipt, err := t.getReceipt()
+	if receipt == nil || receipt.Status == nil {
+		return nil, err
+	}
+
+	return argUintPtr(uint64(*receipt.Status)), nil
+}
+
+func (t *graphQLTransaction) GasUsed() (*argUint64, error) {
+	receipt, err := t.getReceipt()
+	if receipt == nil {
+		return nil, err
+	}
+
+	return argUintPtr(receipt.GasUsed), nil
+}
+
+func (t *graphQLTransaction) CumulativeGasUsed() (*argUint64, error) {
+	receipt, err := t.getReceipt()
+	if receipt == nil {
+		return nil, err
+	}
+
+	return argUintPtr(receipt.CumulativeGasUsed), nil
+}
+
+// CreatedContract returns the contract created by the transaction, nil if it isn't a mined contract creation
+func (t *graphQLTransaction) CreatedContract() (*graphQLAccount, error) {
+	receipt, err := t.getReceipt()
+	if receipt == nil || receipt.ContractAddress == nil {
+		return nil, err
+	}
+
+	return &graphQLAccount{r: t.r, address: *receipt.ContractAddress, header: t.header()}, nil
+}
+
+func (t *graphQLTransaction) Logs() (*[]*graphQLLog, error) {
+	receipt, err := t.getReceipt()
+	if receipt == nil {
+		return nil, err
+	}
+
+	logs := make([]*graphQLLog, len(receipt.Logs))
+
+	for idx, log := range receipt.Logs {
+		logs[idx] = &graphQLLog{txn: t, log: log, index: uint64(idx)}
+	}
+
+	return &logs, nil
+}
+
+func (t *graphQLTransaction) Type() argUint64 {
+	return argUint64(t.txn.Type)
+}
+
+func (t *graphQLTransaction) Raw() argBytes {
+	return argBytes(t.txn.MarshalRLP())
+}
+
+// graphQLLog resolves the fields of a log
+type graphQLLog struct {
+	txn   *graphQLTransaction
+	log   *types.Log
+	index uint64
+}
+
+func (l *graphQLLog) Index() argUint64 {
+	return argUint64(l.index)
+}
+
+func (l *graphQLLog) Account() *graphQLAccount {
+	return &graphQLAccount{r: l.txn.r, address: l.log.Address, header: l.txn.header()}
+}
+
+func (l *graphQLLog) Topics() []graphQLHash {
+	topics := make([]graphQLHash, len(l.log.Topics))
+
+	for idx, topic := range l.log.Topics {
+		topics[idx] = graphQLHash(topic)
+	}
+
+	return topics
+}
+
+func (l *graphQLLog) Data() argBytes {
+	return argBytes(l.log.Data)
+}
+
+func (l *graphQLLog) Transaction() *graphQLTransaction {
+	return l.txn
+}
+
+// graphQLAccount resolves the fields of an account at the state of a block
+type graphQLAccount struct {
+	r       *graphQLResolver
+	address types.Address
+	header  *types.Header
+}
+
+func (a *graphQLAccount) Address() graphQLAddress {
+	return graphQLAddress(a.address)
+}
+
+func (a *graphQLAccount) Balance() (argBig, error) {
+	acc, err := a.r.store.GetAccount(a.header.StateRoot, a.address)
+	if errors.Is(err, ErrStateNotFound) {
+		return argBig{}, nil
+	} else if err != nil {
+		return argBig{}, err
+	}
+
+	return toArgBig(acc.Balance), nil
+}
+
+func (a *graphQLAccount) TransactionCount() (argUint64, error) {
+	acc, err := a.r.store.GetAccount(a.header.StateRoot, a.address)
+	if errors.Is(err, ErrStateNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return argUint64(acc.Nonce), nil
+}
+
+func (a *graphQLAccount) Code() (argBytes, error) {
+	code, err := a.r.store.GetCode(a.header.StateRoot, a.address)
+	if errors.Is(err, ErrStateNotFound) {
+		return argBytes{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return argBytes(code), nil
+}
+
+type graphQLStorageArgs struct {
+	Slot graphQLHash
+}
+
+func (a *graphQLAccount) Storage(args graphQLStorageArgs) (graphQLHash, error) {
+	value, err := a.r.store.GetStorage(a.header.StateRoot, a.address, types.Hash(args.Slot))
+	if errors.Is(err, ErrStateNotFound) {
+		return graphQLHash{}, nil
+	} else if err != nil {
+		return graphQLHash{}, err
+	}
+
+	return graphQLHash(parseStorageValue(value)), nil
+}
+
+// graphQLRequest is the body of a GraphQL POST request
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// handleGraphQL executes the GraphQL query of the body of a POST request or of the parameters of a GET request
+func (j *JSONRPC) handleGraphQL(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set(
+		"Access-Control-Allow-Headers",
+		"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization",
+	)
+
+	if !j.limiter.allow(clientAddress(req), 1) {
+		writeGraphQLError(w, http.StatusTooManyRequests, errRateLimitExceeded)
+
+		return
+	}
+
+	var request graphQLRequest
+
+	switch req.Method {
+	case http.MethodGet:
+		params := req.URL.Query()
+
+		request.Query = params.Get("query")
+		request.OperationName = params.Get("operationName")
+
+		if variables := params.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				writeGraphQLError(w, http.StatusBadRequest, err)
+
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			writeGraphQLError(w, http.StatusBadRequest, err)
+
+			return
+		}
+	case http.MethodOptions:
+		// nothing to return
+		return
+	default:
+		writeGraphQLError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", req.Method))
+
+		return
+	}
+
+	j.logger.Debug("handle graphql", "query", request.Query)
+
+	resp, err := json.Marshal(j.graphQL.Exec(req.Context(), request.Query, request.OperationName, request.Variables))
+	if err != nil {
+		writeGraphQLError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	_, _ = w.Write(resp)
+}
+
+// writeGraphQLError writes the error of a request which isn't executed as a GraphQL response
+func writeGraphQLError(w http.ResponseWriter, status int, err error) {
+	resp, _ := json.Marshal(&graphql.Response{
+		Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("%s", err)},
+	})
+
+	w.WriteHeader(status)
+	_, _ = w.Write(resp)
+}
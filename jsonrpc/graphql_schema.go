@@ -0,0 +1,143 @@
+package jsonrpc
+
+// graphQLSchema is the EIP-1767 schema served on the /graphql path,
+// the account fields are resolved at the state of the block they are queried from
+const graphQLSchema = `
+	# Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal
+	scalar Bytes32
+	# Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal
+	scalar Address
+	# Bytes is an arbitrary length binary string, represented as 0x-prefixed hexadecimal
+	scalar Bytes
+	# BigInt is a large integer, represented as 0x-prefixed hexadecimal
+	scalar BigInt
+	# Long is a 64 bit unsigned integer, represented as 0x-prefixed hexadecimal
+	scalar Long
+
+	schema {
+		query: Query
+	}
+
+	# Account is an Ethereum account at a particular block
+	type Account {
+		address: Address!
+		balance: BigInt!
+		transactionCount: Long!
+		code: Bytes!
+		storage(slot: Bytes32!): Bytes32!
+	}
+
+	# Log is an Ethereum event log
+	type Log {
+		# index is the index of the log in the receipt of the transaction
+		index: Long!
+		account: Account!
+		topics: [Bytes32!]!
+		data: Bytes!
+		transaction: Transaction!
+	}
+
+	# Transaction is an Ethereum transaction
+	type Transaction {
+		hash: Bytes32!
+		nonce: Long!
+		# index is the index of the transaction in the block, null if it's pending
+		index: Long
+		from: Account!
+		# to is the recipient of the transaction, null if it's a contract creation
+		to: Account
+		value: BigInt!
+		gasPrice: BigInt!
+		maxFeePerGas: BigInt
+		maxPriorityFeePerGas: BigInt
+		gas: Long!
+		inputData: Bytes!
+		# block is the block containing the transaction, null if it's pending
+		block: Block
+		# the receipt fields are null if the transaction is pending
+		status: Long
+		gasUsed: Long
+		cumulativeGasUsed: Long
+		createdContract: Account
+		logs: [Log!]
+		type: Long!
+		raw: Bytes!
+	}
+
+	# BlockFilterCriteria filters the logs of a single block
+	input BlockFilterCriteria {
+		addresses: [Address!]
+		topics: [[Bytes32!]!]
+	}
+
+	# Block is an Ethereum block
+	type Block {
+		number: Long!
+		hash: Bytes32!
+		# parent is the parent block, null for the genesis block
+		parent: Block
+		nonce: Bytes!
+		transactionsRoot: Bytes32!
+		transactionCount: Long!
+		stateRoot: Bytes32!
+		receiptsRoot: Bytes32!
+		miner: Account!
+		extraData: Bytes!
+		gasLimit: Long!
+		gasUsed: Long!
+		# baseFeePerGas is the EIP-1559 base fee, null before London
+		baseFeePerGas: BigInt
+		timestamp: Long!
+		logsBloom: Bytes!
+		mixHash: Bytes32!
+		difficulty: BigInt!
+		ommerCount: Long!
+		transactions: [Transaction!]!
+		transactionAt(index: Long!): Transaction
+		logs(filter: BlockFilterCriteria!): [Log!]!
+		account(address: Address!): Account!
+		call(data: CallData!): CallResult!
+		estimateGas(data: CallData!): Long!
+	}
+
+	# CallData is the message of a call or of a gas estimation
+	input CallData {
+		from: Address
+		to: Address
+		gas: Long
+		gasPrice: BigInt
+		maxFeePerGas: BigInt
+		maxPriorityFeePerGas: BigInt
+		value: BigInt
+		data: Bytes
+	}
+
+	# CallResult is the result of a call
+	type CallResult {
+		data: Bytes!
+		gasUsed: Long!
+		# status is 1 if the call succeeded and 0 if it failed
+		status: Long!
+	}
+
+	# FilterCriteria filters the logs of a range of blocks, the latest block by default
+	input FilterCriteria {
+		fromBlock: Long
+		toBlock: Long
+		addresses: [Address!]
+		topics: [[Bytes32!]!]
+	}
+
+	type Query {
+		# block returns a block by number or by hash, the latest block if neither is given
+		block(number: Long, hash: Bytes32): Block
+		# blocks returns the blocks from 'from' to 'to' (inclusive), up to the latest block by default
+		blocks(from: Long!, to: Long): [Block!]!
+		# transaction returns a mined or a pending transaction by hash
+		transaction(hash: Bytes32!): Transaction
+		logs(filter: FilterCriteria!): [Log!]!
+		gasPrice: BigInt!
+		maxPriorityFeePerGas: BigInt!
+		chainID: BigInt!
+	}
+`
@@ -0,0 +1,451 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newton2049/favo-chain/state/runtime"
+	"github.com/newton2049/favo-chain/types"
+)
+
+type mockGraphQLStore struct {
+	*mockBlockStore
+
+	accounts map[types.Address]*Account
+	code     map[types.Address][]byte
+	storage  map[types.Hash][]byte
+}
+
+func (m *mockGraphQLStore) GetAccount(root types.Hash, addr types.Address) (*Account, error) {
+	acc, ok := m.accounts[addr]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+
+	return acc, nil
+}
+
+func (m *mockGraphQLStore) GetCode(root types.Hash, addr types.Address) ([]byte, error) {
+	code, ok := m.code[addr]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+
+	return code, nil
+}
+
+func (m *mockGraphQLStore) GetStorage(root types.Hash, addr types.Address, slot types.Hash) ([]byte, error) {
+	value, ok := m.storage[slot]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+
+	return value, nil
+}
+
+// newTestGraphQLStore returns a store with the genesis block and a block of two transactions,
+// the first transaction has a log
+func newTestGraphQLStore() *mockGraphQLStore {
+	store := &mockGraphQLStore{
+		mockBlockStore: newMockBlockStore(),
+		accounts:       map[types.Address]*Account{},
+		code:           map[types.Address][]byte{},
+		storage:        map[types.Hash][]byte{},
+	}
+
+	genesis := newTestBlock(0, hash1)
+	block := newTestBlock(1, hash2)
+	block.Header.ParentHash = hash1
+	block.Header.GasLimit = 5000000
+	block.Transactions = []*types.Transaction{
+		newTestTransaction(0, addr0),
+		newTestTransaction(1, addr0),
+	}
+
+	store.add(genesis, block)
+
+	success := types.ReceiptSuccess
+	store.receipts[hash2] = []*types.Receipt{
+		{
+			Status:            &success,
+			GasUsed:           21000,
+			CumulativeGasUsed: 21000,
+			Logs: []*types.Log{
+				{Address: addr1, Topics: []types.Hash{hash3}, Data: []byte{0x1}},
+			},
+		},
+		{
+			Status:            &success,
+			GasUsed:           30000,
+			CumulativeGasUsed: 51000,
+		},
+	}
+
+	return store
+}
+
+func newTestGraphQLSchema(t *testing.T, store *mockGraphQLStore) *graphql.Schema {
+	t.Helper()
+
+	eth := newTestEthEndpoint(store)
+	eth.filterManager = NewFilterManager(hclog.NewNullLogger(), store, 1000)
+
+	schema, err := newGraphQLSchema(store, eth, 1000, 16, 10)
+	require.NoError(t, err)
+
+	return schema
+}
+
+func execGraphQL(t *testing.T, schema *graphql.Schema, query string) string {
+	t.Helper()
+
+	resp := schema.Exec(context.Background(), query, "", nil)
+	require.Empty(t, resp.Errors)
+
+	return string(resp.Data)
+}
+
+func TestGraphQL_MaxDepth(t *testing.T) {
+	t.Parallel()
+
+	store := newTestGraphQLStore()
+
+	eth := newTestEthEndpoint(store)
+	eth.filterManager = NewFilterManager(hclog.NewNullLogger(), store, 1000)
+
+	schema, err := newGraphQLSchema(store, eth, 1000, 3, 10)
+	require.NoError(t, err)
+
+	// the queries within the max depth are resolved
+	assert.JSONEq(
+		t,
+		`{"block": {"parent": {"number": "0x0"}}}`,
+		execGraphQL(t, schema, `{ block(number: 1) { parent { number } } }`),
+	)
+
+	// the deeper queries are rejected before being resolved
+	resp := schema.Exec(context.Background(), `{ block(number: 1) { parent { parent { number } } } }`, "", nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "exceeds max depth 3")
+	assert.Nil(t, resp.Data)
+}
+
+func TestGraphQL_Block(t *testing.T) {
+	t.Parallel()
+
+	store := newTestGraphQLStore()
+	schema := newTestGraphQLSchema(t, store)
+	block := store.blocks[1]
+
+	t.Run("by number with the nested transactions", func(t *testing.T) {
+		t.Parallel()
+
+		res := execGraphQL(t, schema, `{
+			block(number: 1) {
+				number
+				hash
+				gasLimit
+				transactionCount
+				parent { number }
+				transactions {
+					hash
+					index
+					from { address }
+					status
+					gasUsed
+					cumulativeGasUsed
+					createdContract { address }
+					logs { index topics data }
+				}
+			}
+		}`)
+
+		assert.JSONEq(t, fmt.Sprintf(`{
+			"block": {
+				"number": "0x1",
+				"hash": "%s",
+				"gasLimit": "0x4c4b40",
+				"transactionCount": "0x2",
+				"parent": {"number": "0x0"},
+				"transactions": [
+					{
+						"hash": "%s",
+						"index": "0x0",
+						"from": {"address": "%s"},
+						"status": "0x1",
+						"gasUsed": "0x5208",
+						"cumulativeGasUsed": "0x5208",
+						"createdContract": null,
+						"logs": [{"index": "0x0", "topics": ["%s"], "data": "0x01"}]
+					},
+					{
+						"hash": "%s",
+						"index": "0x1",
+						"from": {"address": "%s"},
+						"status": "0x1",
+						"gasUsed": "0x7530",
+						"cumulativeGasUsed": "0xc738",
+						"createdContract": null,
+						"logs": []
+					}
+				]
+			}
+		}`,
+			hash2,
+			block.Transactions[0].Hash, addr0, hash3,
+			block.Transactions[1].Hash, addr0,
+		), res)
+	})
+
+	t.Run("latest by default", func(t *testing.T) {
+		t.Parallel()
+
+		assert.JSONEq(t, `{"block": {"number": "0x1"}}`, execGraphQL(t, schema, `{ block { number } }`))
+	})
+
+	t.Run("by hash", func(t *testing.T) {
+		t.Parallel()
+
+		res := execGraphQL(t, schema, fmt.Sprintf(`{ block(hash: "%s") { number parent { number } } }`, hash1))
+		assert.JSONEq(t, `{"block": {"number": "0x0", "parent": null}}`, res)
+	})
+
+	t.Run("unknown block", func(t *testing.T) {
+		t.Parallel()
+
+		assert.JSONEq(t, `{"block": null}`, execGraphQL(t, schema, `{ block(number: 5) { number } }`))
+	})
+
+	t.Run("both number and hash", func(t *testing.T) {
+		t.Parallel()
+
+		resp := schema.Exec(
+			context.Background(),
+			fmt.Sprintf(`{ block(number: 1, hash: "%s") { number } }`, hash2),
+			"",
+			nil,
+		)
+		require.Len(t, resp.Errors, 1)
+		assert.Contains(t, resp.Errors[0].Message, errNumberAndHash.Error())
+	})
+}
+
+func TestGraphQL_Blocks(t *testing.T) {
+	t.Parallel()
+
+	schema := newTestGraphQLSchema(t, newTestGraphQLStore())
+
+	assert.JSONEq(
+		t,
+		`{"blocks": [{"number": "0x0"}, {"number": "0x1"}]}`,
+		execGraphQL(t, schema, `{ blocks(from: 0) { number } }`),
+	)
+
+	resp := schema.Exec(context.Background(), `{ blocks(from: 0, to: 2000) { number } }`, "", nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, ErrBlockRangeTooHigh.Error())
+}
+
+func TestGraphQL_Transaction(t *testing.T) {
+	t.Parallel()
+
+	store := newTestGraphQLStore()
+	pendingTxn := newTestTransaction(2, addr0)
+	store.pendingTxns = []*types.Transaction{pendingTxn}
+
+	schema := newTestGraphQLSchema(t, store)
+
+	res := execGraphQL(t, schema, fmt.Sprintf(
+		`{ transaction(hash: "%s") { nonce index gasUsed block { number } } }`,
+		store.blocks[1].Transactions[1].Hash,
+	))
+	assert.JSONEq(t, `{"transaction": {"nonce": "0x1", "index": "0x1", "gasUsed": "0x7530", "block": {"number": "0x1"}}}`, res)
+
+	// the pending transactions have no block and no receipt
+	res = execGraphQL(t, schema, fmt.Sprintf(
+		`{ transaction(hash: "%s") { nonce index gasUsed block { number } } }`,
+		pendingTxn.Hash,
+	))
+	assert.JSONEq(t, `{"transaction": {"nonce": "0x2", "index": null, "gasUsed": null, "block": null}}`, res)
+
+	res = execGraphQL(t, schema, fmt.Sprintf(`{ transaction(hash: "%s") { nonce } }`, hash4))
+	assert.JSONEq(t, `{"transaction": null}`, res)
+}
+
+func TestGraphQL_Logs(t *testing.T) {
+	t.Parallel()
+
+	store := newTestGraphQLStore()
+	schema := newTestGraphQLSchema(t, store)
+
+	res := execGraphQL(t, schema, fmt.Sprintf(
+		`{ logs(filter: {fromBlock: 0, addresses: ["%s"]}) { account { address } topics transaction { hash } } }`,
+		addr1,
+	))
+	assert.JSONEq(t, fmt.Sprintf(
+		`{"logs": [{"account": {"address": "%s"}, "topics": ["%s"], "transaction": {"hash": "%s"}}]}`,
+		addr1, hash3, store.blocks[1].Transactions[0].Hash,
+	), res)
+
+	// the logs of the block matching the topics
+	res = execGraphQL(t, schema, fmt.Sprintf(
+		`{ block { logs(filter: {topics: [["%s"]]}) { index } } }`,
+		hash4,
+	))
+	assert.JSONEq(t, `{"block": {"logs": []}}`, res)
+}
+
+func TestGraphQL_Account(t *testing.T) {
+	t.Parallel()
+
+	store := newTestGraphQLStore()
+	store.accounts[addr1] = &Account{Balance: big.NewInt(100), Nonce: 3}
+	store.code[addr1] = []byte{0x60, 0x80}
+	store.storage[hash1] = []byte{0x82, 0x01, 0x02}
+
+	schema := newTestGraphQLSchema(t, store)
+
+	res := execGraphQL(t, schema, fmt.Sprintf(
+		`{ block { account(address: "%s") { balance transactionCount code storage(slot: "%s") } } }`,
+		addr1, hash1,
+	))
+	assert.JSONEq(t, fmt.Sprintf(
+		`{"block": {"account": {"balance": "0x64", "transactionCount": "0x3", "code": "0x6080", "storage": "%s"}}}`,
+		types.BytesToHash([]byte{0x01, 0x02}),
+	), res)
+
+	// the accounts which don't exist are empty
+	res = execGraphQL(t, schema, fmt.Sprintf(
+		`{ block { account(address: "%s") { balance transactionCount code storage(slot: "%s") } } }`,
+		addr0, hash2,
+	))
+	assert.JSONEq(t, fmt.Sprintf(
+		`{"block": {"account": {"balance": "0x0", "transactionCount": "0x0", "code": "0x", "storage": "%s"}}}`,
+		types.ZeroHash,
+	), res)
+}
+
+func TestGraphQL_Call(t *testing.T) {
+	t.Parallel()
+
+	t.Run("succeeded call", func(t *testing.T) {
+		t.Parallel()
+
+		store := newTestGraphQLStore()
+		schema := newTestGraphQLSchema(t, store)
+
+		res := execGraphQL(t, schema, fmt.Sprintf(
+			`{ block(number: 1) { call(data: {to: "%s", data: "0x1234"}) { status } } }`,
+			addr1,
+		))
+		assert.JSONEq(t, `{"block": {"call": {"status": "0x1"}}}`, res)
+
+		require.NotNil(t, store.callTxn)
+		assert.Equal(t, &addr1, store.callTxn.To)
+		assert.Equal(t, []byte{0x12, 0x34}, store.callTxn.Input)
+		assert.Equal(t, uint64(5000000), store.callTxn.Gas)
+	})
+
+	t.Run("failed call", func(t *testing.T) {
+		t.Parallel()
+
+		store := newTestGraphQLStore()
+		store.ethCallError = runtime.ErrExecutionReverted
+		schema := newTestGraphQLSchema(t, store)
+
+		res := execGraphQL(t, schema, fmt.Sprintf(
+			`{ block(number: 1) { call(data: {to: "%s"}) { status } } }`,
+			addr1,
+		))
+		assert.JSONEq(t, `{"block": {"call": {"status": "0x0"}}}`, res)
+	})
+}
+
+func TestGraphQL_Handler(t *testing.T) {
+	t.Parallel()
+
+	schema := newTestGraphQLSchema(t, newTestGraphQLStore())
+	srv := httptest.NewServer(http.HandlerFunc((&JSONRPC{
+		logger:  hclog.NewNullLogger(),
+		graphQL: schema,
+	}).handleGraphQL))
+
+	t.Cleanup(srv.Close)
+
+	readBody := func(t *testing.T, resp *http.Response) string {
+		t.Helper()
+
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return string(body)
+	}
+
+	t.Run("POST", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := http.Post( //nolint:noctx
+			srv.URL,
+			"application/json",
+			strings.NewReader(`{"query": "query($n: Long) { block(number: $n) { number } }", "variables": {"n": 0}}`),
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"data": {"block": {"number": "0x0"}}}`, readBody(t, resp))
+	})
+
+	t.Run("GET", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := http.Get(srv.URL + "?query=" + url.QueryEscape("{ block { number } }")) //nolint:noctx
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"data": {"block": {"number": "0x1"}}}`, readBody(t, resp))
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader("{")) //nolint:noctx
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, readBody(t, resp), `"errors"`)
+	})
+}
+
+func TestGraphQL_ScalarUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	var u argUint64
+
+	require.NoError(t, u.UnmarshalGraphQL("0x10"))
+	assert.Equal(t, argUint64(16), u)
+
+	require.NoError(t, u.UnmarshalGraphQL(int32(5)))
+	assert.Equal(t, argUint64(5), u)
+
+	require.NoError(t, u.UnmarshalGraphQL(float64(7)))
+	assert.Equal(t, argUint64(7), u)
+
+	assert.True(t, errors.Is(u.UnmarshalGraphQL(int32(-1)), errInvalidGraphQLScalar))
+	assert.True(t, errors.Is(u.UnmarshalGraphQL(1.5), errInvalidGraphQLScalar))
+	assert.True(t, errors.Is(u.UnmarshalGraphQL(true), errInvalidGraphQLScalar))
+}
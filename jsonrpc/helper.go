@@ -14,6 +14,7 @@ var (
 	ErrNegativeBlockNumber      = errors.New("invalid argument 0: block number must not be negative")
 	ErrFailedFetchGenesis       = errors.New("error fetching genesis block header")
 	ErrNoDataInContractCreation = errors.New("contract creation without data provided")
+	ErrGasPriceAndDynamicFees   = errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
 )
 
 type latestHeaderGetter interface {
@@ -130,6 +131,15 @@ func GetHeaderFromBlockNumberOrHash(bnh BlockNumberOrHash, store blockGetter) (*
 	return block.Header, nil
 }
 
+// overriddenHeader returns the header with the fields replaced by the block override (if any)
+func overriddenHeader(header *types.Header, override *types.BlockOverride) *types.Header {
+	if override == nil {
+		return header
+	}
+
+	return override.Apply(header)
+}
+
 type nonceGetter interface {
 	Header() *types.Header
 	GetHeaderByNumber(uint64) (*types.Header, bool)
@@ -216,6 +226,35 @@ func DecodeTxn(arg *txnArgs, store nonceGetter) (*types.Transaction, error) {
 		Input:    input,
 		Nonce:    uint64(*arg.Nonce),
 	}
+
+	// EIP-1559 fee fields turn the call into a dynamic fee transaction
+	if arg.GasTipCap != nil || arg.GasFeeCap != nil {
+		if len(*arg.GasPrice) != 0 {
+			return nil, ErrGasPriceAndDynamicFees
+		}
+
+		txn.Type = types.DynamicFeeTx
+		txn.GasTipCap = new(big.Int)
+		txn.GasFeeCap = new(big.Int)
+
+		if arg.GasTipCap != nil {
+			txn.GasTipCap.SetBytes(*arg.GasTipCap)
+		}
+
+		if arg.GasFeeCap != nil {
+			txn.GasFeeCap.SetBytes(*arg.GasFeeCap)
+		}
+	}
+
+	// EIP-2930 access list turns the legacy call into an access list transaction
+	if arg.AccessList != nil {
+		txn.AccessList = *arg.AccessList
+
+		if txn.Type == types.LegacyTx {
+			txn.Type = types.AccessListTx
+		}
+	}
+
 	if arg.To != nil {
 		txn.To = arg.To
 	}
@@ -0,0 +1,125 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/newton2049/favo-chain/helper/ipc"
+)
+
+// setupIPC serves the JSON-RPC requests on the IPC endpoint.
+// The requests and the responses are consecutive JSON values on the connection,
+// and the subscriptions are served like on a web socket connection
+func (j *JSONRPC) setupIPC() error {
+	lis, err := ipc.Listen(j.config.IPCPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on IPC endpoint: %w", err)
+	}
+
+	j.logger.Info("ipc server started", "path", j.config.IPCPath)
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				j.logger.Error("closed ipc listener", "err", err)
+
+				return
+			}
+
+			go j.handleIPC(conn)
+		}
+	}()
+
+	return nil
+}
+
+// ipcWrapper is a wrapping object for the IPC connection and logger
+type ipcWrapper struct {
+	sync.Mutex
+
+	conn     net.Conn     // the actual IPC connection
+	logger   hclog.Logger // module logger
+	filterID string       // filter ID
+}
+
+func (w *ipcWrapper) SetFilterID(filterID string) {
+	w.filterID = filterID
+}
+
+func (w *ipcWrapper) GetFilterID() string {
+	return w.filterID
+}
+
+// WriteMessage writes out the message to the IPC peer, the message type is ignored
+func (w *ipcWrapper) WriteMessage(_ int, data []byte) error {
+	w.Lock()
+	defer w.Unlock()
+
+	_, writeErr := w.conn.Write(append(data, '\n'))
+	if writeErr != nil {
+		w.logger.Error(
+			fmt.Sprintf("Unable to write IPC message, %s", writeErr.Error()),
+		)
+	}
+
+	return writeErr
+}
+
+func (j *JSONRPC) handleIPC(conn net.Conn) {
+	defer func() {
+		if err := conn.Close(); err != nil {
+			j.logger.Error(fmt.Sprintf("Unable to gracefully close IPC connection, %s", err.Error()))
+		}
+	}()
+
+	wrapConn := &ipcWrapper{conn: conn, logger: j.logger}
+	decoder := json.NewDecoder(conn)
+
+	j.logger.Debug("IPC connection established")
+
+	for {
+		// Read the incoming request
+		var message json.RawMessage
+		if err := decoder.Decode(&message); err != nil {
+			if !errors.Is(err, io.EOF) {
+				j.logger.Error(fmt.Sprintf("Unable to read IPC message, %s", err.Error()))
+			}
+
+			j.dispatcher.RemoveFilterByWs(wrapConn)
+
+			return
+		}
+
+		go func() {
+			var (
+				resp      []byte
+				handleErr error
+			)
+
+			if bytes.HasPrefix(bytes.TrimLeft(message, " \t\r\n"), []byte("[")) {
+				// batch requests don't support subscriptions
+				resp, handleErr = j.dispatcher.Handle(message)
+			} else {
+				resp, handleErr = j.dispatcher.HandleWs(message, wrapConn)
+			}
+
+			if handleErr != nil {
+				j.logger.Error(fmt.Sprintf("Unable to handle IPC request, %s", handleErr.Error()))
+
+				resp, handleErr = NewRPCResponse(nil, "2.0", nil, NewInternalError(handleErr.Error())).Bytes()
+				if handleErr != nil {
+					return
+				}
+			}
+
+			_ = wrapConn.WriteMessage(0, resp)
+		}()
+	}
+}
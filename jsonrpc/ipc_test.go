@@ -0,0 +1,105 @@
+//go:build !windows
+// +build !windows
+
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newton2049/favo-chain/helper/ipc"
+	"github.com/newton2049/favo-chain/helper/tests"
+	"github.com/newton2049/favo-chain/types"
+)
+
+func TestIPCServer(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	port, err := tests.GetFreePort()
+	require.NoError(t, err)
+
+	config := &Config{
+		Store:            store,
+		Addr:             &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port},
+		BatchLengthLimit: 20,
+		IPCPath:          filepath.Join(t.TempDir(), "jsonrpc.ipc"),
+	}
+
+	_, err = NewJSONRPC(hclog.NewNullLogger(), config)
+	require.NoError(t, err)
+
+	conn, err := ipc.DialTimeout(config.IPCPath, 2*time.Second)
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	decoder := json.NewDecoder(conn)
+
+	read := func(v interface{}) {
+		t.Helper()
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		require.NoError(t, decoder.Decode(v))
+	}
+
+	// a request
+	_, err = conn.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"web3_clientVersion","params":[]}`))
+	require.NoError(t, err)
+
+	var resp SuccessResponse
+
+	read(&resp)
+	assert.Nil(t, resp.Error)
+	assert.NotEmpty(t, resp.Result)
+
+	// a batch of requests
+	_, err = conn.Write([]byte(`[
+		{"jsonrpc":"2.0","id":2,"method":"net_version","params":[]},
+		{"jsonrpc":"2.0","id":3,"method":"web3_clientVersion","params":[]}
+	]`))
+	require.NoError(t, err)
+
+	var batchResp []SuccessResponse
+
+	read(&batchResp)
+	assert.Len(t, batchResp, 2)
+
+	// a subscription
+	_, err = conn.Write([]byte(`{"jsonrpc":"2.0","id":4,"method":"eth_subscribe","params":["newHeads"]}`))
+	require.NoError(t, err)
+
+	read(&resp)
+	assert.Nil(t, resp.Error)
+
+	var filterID string
+	require.NoError(t, json.Unmarshal(resp.Result, &filterID))
+
+	store.emitEvent(&mockEvent{
+		NewChain: []*mockHeader{
+			{
+				header: &types.Header{
+					Hash: types.StringToHash("1"),
+				},
+			},
+		},
+	})
+
+	var notification struct {
+		Method string `json:"method"`
+		Params struct {
+			Subscription string `json:"subscription"`
+		} `json:"params"`
+	}
+
+	read(&notification)
+	assert.Equal(t, "eth_subscription", notification.Method)
+	assert.Equal(t, filterID, notification.Params.Subscription)
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
 	"github.com/hashicorp/go-hclog"
 	"github.com/newton2049/favo-chain/versioning"
 )
@@ -40,6 +41,12 @@ type JSONRPC struct {
 	logger     hclog.Logger
 	config     *Config
 	dispatcher dispatcher
+
+	// limiter limits the requests of each client, the requests are not limited if nil
+	limiter *rateLimiter
+
+	// graphQL is the schema served on the /graphql path, which is disabled if nil
+	graphQL *graphql.Schema
 }
 
 type dispatcher interface {
@@ -57,6 +64,7 @@ type JSONRPCStore interface {
 	filterManagerStore
 	bridgeStore
 	debugStore
+	traceStore
 }
 
 type Config struct {
@@ -68,6 +76,35 @@ type Config struct {
 	PriceLimit               uint64
 	BatchLengthLimit         uint64
 	BlockRangeLimit          uint64
+
+	// IPCPath is the path of the IPC endpoint, which is disabled if empty
+	IPCPath string
+
+	// Namespaces are the namespaces served on Addr, all the namespaces are served if empty
+	Namespaces []string
+
+	// AuthAddr is the address of the listener authenticated with the JWT secret, which is disabled if nil
+	AuthAddr *net.TCPAddr
+
+	// AuthNamespaces are the namespaces served on AuthAddr, all the namespaces are served if empty
+	AuthNamespaces []string
+
+	// JWTSecret is the secret of the HS256 tokens accepted on AuthAddr
+	JWTSecret []byte
+
+	// RateLimit is the number of the requests per second allowed for each client on Addr,
+	// the requests are not limited if 0
+	RateLimit uint64
+
+	// GraphQL enables the EIP-1767 GraphQL queries on the /graphql path of Addr
+	GraphQL bool
+
+	// GraphQLMaxDepth is the max field nesting depth of the GraphQL queries, the depth is not limited if 0
+	GraphQLMaxDepth uint64
+
+	// GraphQLMaxParallelism is the max number of the resolvers of a GraphQL query running in parallel,
+	// the default of the GraphQL library applies if 0
+	GraphQLMaxParallelism uint64
 }
 
 // NewJSONRPC returns the JSONRPC http server
@@ -88,24 +125,76 @@ func NewJSONRPC(logger hclog.Logger, config *Config) (*JSONRPC, error) {
 		return nil, err
 	}
 
+	public, err := d.withNamespaces(config.Namespaces)
+	if err != nil {
+		return nil, err
+	}
+
 	srv := &JSONRPC{
 		logger:     logger.Named("jsonrpc"),
 		config:     config,
-		dispatcher: d,
+		dispatcher: public,
+		limiter:    newRateLimiter(config.RateLimit),
+	}
+
+	if config.GraphQL {
+		srv.graphQL, err = newGraphQLSchema(
+			config.Store,
+			d.endpoints.Eth,
+			config.BlockRangeLimit,
+			config.GraphQLMaxDepth,
+			config.GraphQLMaxParallelism,
+		)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// start http server
-	if err := srv.setupHTTP(); err != nil {
+	if err := srv.setupHTTP(config.Addr, nil); err != nil {
 		return nil, err
 	}
 
+	// start authenticated http server
+	if config.AuthAddr != nil {
+		authDispatcher, err := d.withNamespaces(config.AuthNamespaces)
+		if err != nil {
+			return nil, err
+		}
+
+		authSrv := &JSONRPC{
+			logger:     logger.Named("jsonrpc-auth"),
+			config:     config,
+			dispatcher: authDispatcher.withAdmin(),
+		}
+
+		if err := authSrv.setupHTTP(config.AuthAddr, authMiddleware(config.JWTSecret)); err != nil {
+			return nil, err
+		}
+	}
+
+	// start ipc server, which is local and serves all the namespaces and the admin methods
+	if config.IPCPath != "" {
+		ipcSrv := &JSONRPC{
+			logger:     srv.logger,
+			config:     config,
+			dispatcher: d.withAdmin(),
+		}
+
+		if err := ipcSrv.setupIPC(); err != nil {
+			return nil, err
+		}
+	}
+
 	return srv, nil
 }
 
-func (j *JSONRPC) setupHTTP() error {
-	j.logger.Info("http server started", "addr", j.config.Addr.String())
+// setupHTTP serves the http and web socket requests on the address,
+// the requests are passed through the auth middleware first if it's given
+func (j *JSONRPC) setupHTTP(addr *net.TCPAddr, auth func(http.Handler) http.Handler) error {
+	j.logger.Info("http server started", "addr", addr.String())
 
-	lis, err := net.Listen("tcp", j.config.Addr.String())
+	lis, err := net.Listen("tcp", addr.String())
 	if err != nil {
 		return err
 	}
@@ -116,10 +205,26 @@ func (j *JSONRPC) setupHTTP() error {
 	mux := http.NewServeMux()
 
 	// The middleware factory returns a handler, so we need to wrap the handler function properly.
-	jsonRPCHandler := http.HandlerFunc(j.handle)
-	mux.Handle("/", middlewareFactory(j.config)(jsonRPCHandler))
+	jsonRPCHandler := middlewareFactory(j.config)(http.HandlerFunc(j.handle))
+	wsHandler := http.Handler(http.HandlerFunc(j.handleWs))
 
-	mux.HandleFunc("/ws", j.handleWs)
+	if auth != nil {
+		jsonRPCHandler = auth(jsonRPCHandler)
+		wsHandler = auth(wsHandler)
+	}
+
+	mux.Handle("/", jsonRPCHandler)
+	mux.Handle("/ws", wsHandler)
+
+	if j.graphQL != nil {
+		graphQLHandler := middlewareFactory(j.config)(http.HandlerFunc(j.handleGraphQL))
+
+		if auth != nil {
+			graphQLHandler = auth(graphQLHandler)
+		}
+
+		mux.Handle("/graphql", graphQLHandler)
+	}
 
 	srv := http.Server{
 		Handler:           mux,
@@ -230,8 +335,13 @@ func (j *JSONRPC) handleWs(w http.ResponseWriter, req *http.Request) {
 	}(ws)
 
 	wrapConn := &wsWrapper{ws: ws, logger: j.logger}
+	client := clientAddress(req)
 
 	j.logger.Info("Websocket connection established")
+
+	updateWsConnectionsMetrics(1)
+	defer updateWsConnectionsMetrics(-1)
+
 	// Run the listen loop
 	for {
 		// Read the incoming message
@@ -255,6 +365,13 @@ func (j *JSONRPC) handleWs(w http.ResponseWriter, req *http.Request) {
 		}
 
 		if isSupportedWSType(msgType) {
+			if !j.limiter.allow(client, 1) {
+				resp, _ := NewRPCResponse(nil, "2.0", nil, errRateLimitExceeded).Bytes()
+				_ = wrapConn.WriteMessage(msgType, resp)
+
+				continue
+			}
+
 			go func() {
 				resp, handleErr := j.dispatcher.HandleWs(message, wrapConn)
 				if handleErr != nil {
@@ -300,6 +417,16 @@ func (j *JSONRPC) handleJSONRPCRequest(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	// each request of a batch is charged to the client
+	if j.limiter != nil && !j.limiter.allow(clientAddress(req), requestCount(data)) {
+		resp, _ := NewRPCResponse(nil, "2.0", nil, errRateLimitExceeded).Bytes()
+
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write(resp)
+
+		return
+	}
+
 	// log request
 	j.logger.Debug("handle", "request", string(data))
 
@@ -0,0 +1,75 @@
+package jsonrpc
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// jsonRPCMetrics is a prefix used for json-rpc related metrics
+	jsonRPCMetrics = "jsonrpc"
+
+	// unknownMethod is the method label of the requests to methods which aren't served,
+	// so that the arbitrary method names don't create new series
+	unknownMethod = "unknown"
+
+	// successStatus is the status label of the requests which didn't fail,
+	// the status of the failed requests is their error code
+	successStatus = "success"
+)
+
+var (
+	// wsConnections is the number of the active websocket connections of all the listeners
+	wsConnections int64
+
+	// requestsMetric counts the requests by method and status
+	requestsMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "edge",
+		Subsystem: jsonRPCMetrics,
+		Name:      "requests_total",
+		Help:      "The number of the json-rpc requests by method and status",
+	}, []string{"method", "status"})
+
+	// requestDurationMetric is the latency distribution of the requests by method and status
+	requestDurationMetric = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "edge",
+		Subsystem: jsonRPCMetrics,
+		Name:      "request_duration_seconds",
+		Help:      "The duration of the json-rpc requests by method and status",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "status"})
+)
+
+func init() {
+	prometheus.MustRegister(requestsMetric, requestDurationMetric)
+}
+
+// updateRequestMetrics records the request to the method, its duration and its error if any
+func updateRequestMetrics(method string, start time.Time, err Error) {
+	status := successStatus
+	if err != nil {
+		status = strconv.Itoa(err.ErrorCode())
+	}
+
+	requestsMetric.WithLabelValues(method, status).Inc()
+	requestDurationMetric.WithLabelValues(method, status).Observe(time.Since(start).Seconds())
+}
+
+// updateBatchMetrics records the number of the requests of a batch
+func updateBatchMetrics(size int) {
+	metrics.AddSample([]string{jsonRPCMetrics, "batch_size"}, float32(size))
+}
+
+// updateWsConnectionsMetrics adds the delta to the number of the active websocket connections
+func updateWsConnectionsMetrics(delta int64) {
+	metrics.SetGauge([]string{jsonRPCMetrics, "ws_connections"}, float32(atomic.AddInt64(&wsConnections, delta)))
+}
+
+// updateFiltersMetrics records the number of the installed filters
+func updateFiltersMetrics(count int) {
+	metrics.SetGauge([]string{jsonRPCMetrics, "filters"}, float32(count))
+}
@@ -0,0 +1,58 @@
+package jsonrpc
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateRequestMetrics(t *testing.T) {
+	t.Parallel()
+
+	const method = "test_updateRequestMetrics"
+
+	notFound := NewMethodNotFoundError(method)
+	errorStatus := strconv.Itoa(notFound.ErrorCode())
+
+	updateRequestMetrics(method, time.Now().Add(-2*time.Second), nil)
+	updateRequestMetrics(method, time.Now(), nil)
+	updateRequestMetrics(method, time.Now(), notFound)
+
+	// the requests are counted by method and status
+	assert.Equal(t, 2.0, testutil.ToFloat64(requestsMetric.WithLabelValues(method, successStatus)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requestsMetric.WithLabelValues(method, errorStatus)))
+
+	// the latencies are observed by method and status
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(requestDurationMetric))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	assert.Equal(t, "edge_jsonrpc_request_duration_seconds", families[0].GetName())
+
+	samples := make(map[string]uint64)
+	sums := make(map[string]float64)
+
+	for _, metric := range families[0].GetMetric() {
+		labels := make(map[string]string)
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+
+		if labels["method"] != method {
+			continue
+		}
+
+		samples[labels["status"]] = metric.GetHistogram().GetSampleCount()
+		sums[labels["status"]] = metric.GetHistogram().GetSampleSum()
+	}
+
+	assert.Equal(t, map[string]uint64{successStatus: 2, errorStatus: 1}, samples)
+	assert.GreaterOrEqual(t, sums[successStatus], 2.0)
+}
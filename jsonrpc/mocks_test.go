@@ -1,10 +1,12 @@
 package jsonrpc
 
 import (
+	"errors"
 	"math/big"
 	"sync"
 
 	"github.com/newton2049/favo-chain/blockchain"
+	"github.com/newton2049/favo-chain/helper/progress"
 	"github.com/newton2049/favo-chain/types"
 )
 
@@ -50,6 +52,11 @@ type mockStore struct {
 	receipts     map[types.Hash][]*types.Receipt
 	accounts     map[types.Address]*Account
 
+	pendingTxCh     chan types.Hash
+	pendingTxs      map[types.Hash]*types.Transaction
+	syncLock        sync.Mutex
+	syncProgression *progress.Progression
+
 	// headers is the list of historical headers
 	historicalHeaders []*types.Header
 }
@@ -59,6 +66,8 @@ func newMockStore() *mockStore {
 		header:       &types.Header{Number: 0},
 		subscription: blockchain.NewMockSubscription(),
 		accounts:     map[types.Address]*Account{},
+		pendingTxCh:  make(chan types.Hash),
+		pendingTxs:   map[types.Hash]*types.Transaction{},
 	}
 	m.addHeader(m.header)
 
@@ -137,6 +146,46 @@ func (m *mockStore) SubscribeEvents() blockchain.Subscription {
 	return m.subscription
 }
 
+// emitPendingTx adds the transaction to the pool and notifies the subscriber
+func (m *mockStore) emitPendingTx(tx *types.Transaction) {
+	m.receiptsLock.Lock()
+	m.pendingTxs[tx.Hash] = tx
+	m.receiptsLock.Unlock()
+
+	m.pendingTxCh <- tx.Hash
+}
+
+func (m *mockStore) SubscribePendingTxs() (<-chan types.Hash, func()) {
+	return m.pendingTxCh, func() {}
+}
+
+func (m *mockStore) FilterLogBlocks(from, to uint64, criteria [][][]byte) ([]uint64, uint64) {
+	return nil, from
+}
+
+func (m *mockStore) GetPendingTx(txHash types.Hash) (*types.Transaction, bool) {
+	m.receiptsLock.Lock()
+	defer m.receiptsLock.Unlock()
+
+	tx, ok := m.pendingTxs[txHash]
+
+	return tx, ok
+}
+
+func (m *mockStore) setSyncProgression(syncProgression *progress.Progression) {
+	m.syncLock.Lock()
+	defer m.syncLock.Unlock()
+
+	m.syncProgression = syncProgression
+}
+
+func (m *mockStore) GetSyncProgression() *progress.Progression {
+	m.syncLock.Lock()
+	defer m.syncLock.Unlock()
+
+	return m.syncProgression
+}
+
 func (m *mockStore) GetHeaderByNumber(num uint64) (*types.Header, bool) {
 	header := m.headerLoop(func(header *types.Header) bool {
 		return header.Number == num
@@ -196,3 +245,11 @@ func (m *mockStore) GetStateSyncProof(stateSyncID uint64) (types.Proof, error) {
 
 	return ssp, nil
 }
+
+func (m *mockStore) SetHead(number uint64) error {
+	if number > m.header.Number {
+		return errors.New("invalid head number")
+	}
+
+	return nil
+}
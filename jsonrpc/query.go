@@ -174,6 +174,36 @@ func (q *LogQuery) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// bloomCriteria returns the addresses and the topics of each position of the filter,
+// which are the criteria of the blocks matched by the log index
+func (q *LogQuery) bloomCriteria() [][][]byte {
+	criteria := make([][][]byte, 0, len(q.Topics)+1)
+
+	if len(q.Addresses) > 0 {
+		addresses := make([][]byte, len(q.Addresses))
+		for i, addr := range q.Addresses {
+			addresses[i] = addr.Bytes()
+		}
+
+		criteria = append(criteria, addresses)
+	}
+
+	for _, sub := range q.Topics {
+		if len(sub) == 0 {
+			continue
+		}
+
+		topics := make([][]byte, len(sub))
+		for i, topic := range sub {
+			topics[i] = topic.Bytes()
+		}
+
+		criteria = append(criteria, topics)
+	}
+
+	return criteria
+}
+
 // Match returns whether the receipt includes topics for this filter
 func (q *LogQuery) Match(log *types.Log) bool {
 	// check addresses
@@ -0,0 +1,106 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimiterIdleTimeout is the time after which the limiter of an idle client is removed
+const rateLimiterIdleTimeout = 10 * time.Minute
+
+var errRateLimitExceeded = NewLimitExceededError("request rate limit exceeded")
+
+// rateLimiter limits the number of the requests per second of each client,
+// which is identified by its IP address
+type rateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	lock      sync.Mutex
+	clients   map[string]*clientLimiter
+	lastPrune time.Time
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newRateLimiter returns the limiter of the requests per second of each client,
+// or nil if the requests are not limited
+func newRateLimiter(requestsPerSecond uint64) *rateLimiter {
+	if requestsPerSecond == 0 {
+		return nil
+	}
+
+	return &rateLimiter{
+		limit:     rate.Limit(requestsPerSecond),
+		burst:     int(requestsPerSecond),
+		clients:   make(map[string]*clientLimiter),
+		lastPrune: time.Now(),
+	}
+}
+
+// allow returns whether the client can make n requests now, the requests are always allowed
+// if the limiter is nil
+func (l *rateLimiter) allow(client string, n int) bool {
+	if l == nil {
+		return true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(l.lastPrune) > rateLimiterIdleTimeout {
+		for key, c := range l.clients {
+			if now.Sub(c.lastSeen) > rateLimiterIdleTimeout {
+				delete(l.clients, key)
+			}
+		}
+
+		l.lastPrune = now
+	}
+
+	c, ok := l.clients[client]
+	if !ok {
+		c = &clientLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[client] = c
+	}
+
+	c.lastSeen = now
+
+	return c.limiter.AllowN(now, n)
+}
+
+// requestCount returns the number of the requests of the JSON-RPC request body,
+// which is the length of the batch or 1 for a single (or malformed) request
+func requestCount(body []byte) int {
+	if x := bytes.TrimLeft(body, " \t\r\n"); len(x) == 0 || x[0] != '[' {
+		return 1
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+		return 1
+	}
+
+	return len(batch)
+}
+
+// clientAddress returns the IP address of the client of the request
+func clientAddress(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,81 @@
+package jsonrpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	// the requests aren't limited without a limit
+	unlimited := newRateLimiter(0)
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, unlimited.allow("1.1.1.1", 1))
+	}
+
+	limiter := newRateLimiter(5)
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, limiter.allow("1.1.1.1", 1))
+	}
+
+	assert.False(t, limiter.allow("1.1.1.1", 1))
+
+	// the clients are limited separately
+	assert.True(t, limiter.allow("2.2.2.2", 3))
+	assert.False(t, limiter.allow("2.2.2.2", 3))
+}
+
+func TestRequestCount(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]int{
+		`{"method": "eth_chainId"}`:                               1,
+		` [{"method": "eth_chainId"}, {"method": "net_version"}]`: 2,
+		`[]`:         1,
+		`[{"method"`: 1,
+		``:           1,
+	}
+
+	for body, count := range cases {
+		assert.Equal(t, count, requestCount([]byte(body)), body)
+	}
+}
+
+func TestHandleJSONRPCRequest_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	j := &JSONRPC{
+		logger:  hclog.NewNullLogger(),
+		limiter: newRateLimiter(3),
+		dispatcher: newTestDispatcher(t, hclog.NewNullLogger(), newMockStore(), &dispatcherParams{
+			jsonRPCBatchLengthLimit: 20,
+			blockRangeLimit:         1000,
+		}),
+	}
+
+	send := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.RemoteAddr = "1.1.1.1:1234"
+
+		w := httptest.NewRecorder()
+		j.handleJSONRPCRequest(w, req)
+
+		return w.Code
+	}
+
+	batch := `[{"id": 1, "method": "web3_clientVersion"}, {"id": 2, "method": "web3_clientVersion"}]`
+
+	// each request of the batch is charged
+	assert.Equal(t, http.StatusOK, send(batch))
+	assert.Equal(t, http.StatusTooManyRequests, send(batch))
+	assert.Equal(t, http.StatusOK, send(`{"id": 3, "method": "web3_clientVersion"}`))
+	assert.Equal(t, http.StatusTooManyRequests, send(`{"id": 4, "method": "web3_clientVersion"}`))
+}
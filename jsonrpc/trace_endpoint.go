@@ -0,0 +1,489 @@
+package jsonrpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/newton2049/favo-chain/state/runtime"
+	"github.com/newton2049/favo-chain/state/runtime/tracer"
+	"github.com/newton2049/favo-chain/state/runtime/tracer/calltracer"
+	"github.com/newton2049/favo-chain/types"
+)
+
+var (
+	// ErrUnsupportedTraceType is returned when the requested trace type isn't supported
+	ErrUnsupportedTraceType = errors.New("unsupported trace type")
+	// ErrUnexpectedTraceResult is returned when the tracer returns an unexpected result
+	ErrUnexpectedTraceResult = errors.New("unexpected trace result")
+)
+
+const (
+	traceTypeTrace = "trace"
+
+	traceActionCall    = "call"
+	traceActionCreate  = "create"
+	traceActionSuicide = "suicide"
+
+	// traceRevertedError is the error of the reverted calls, as reported by parity
+	traceRevertedError = "Reverted"
+)
+
+type traceStore interface {
+	// Header returns the current header of the chain (genesis if empty)
+	Header() *types.Header
+
+	// ReadTxLookup returns a block hash in which a given txn was mined
+	ReadTxLookup(txnHash types.Hash) (types.Hash, bool)
+
+	// GetBlockByHash gets a block using the provided hash
+	GetBlockByHash(hash types.Hash, full bool) (*types.Block, bool)
+
+	// GetBlockByNumber gets a block using the provided height
+	GetBlockByNumber(num uint64, full bool) (*types.Block, bool)
+
+	// TraceBlock traces all transactions in the given block
+	TraceBlock(*types.Block, tracer.Tracer) ([]interface{}, error)
+
+	// TraceTxn traces a transaction in the block, associated with the given hash
+	TraceTxn(*types.Block, types.Hash, tracer.Tracer) (interface{}, error)
+}
+
+// Trace is the parity-style trace jsonrpc endpoint
+type Trace struct {
+	store           traceStore
+	blockRangeLimit uint64
+}
+
+type traceCallAction struct {
+	CallType string `json:"callType"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Gas      string `json:"gas"`
+	Input    string `json:"input"`
+	Value    string `json:"value"`
+}
+
+type traceCreateAction struct {
+	CreationMethod string `json:"creationMethod"`
+	From           string `json:"from"`
+	Gas            string `json:"gas"`
+	Init           string `json:"init"`
+	Value          string `json:"value"`
+}
+
+type traceSuicideAction struct {
+	Address       string `json:"address"`
+	RefundAddress string `json:"refundAddress"`
+	Balance       string `json:"balance"`
+}
+
+type traceCallResult struct {
+	GasUsed string `json:"gasUsed"`
+	Output  string `json:"output"`
+}
+
+type traceCreateResult struct {
+	Address string `json:"address"`
+	Code    string `json:"code"`
+	GasUsed string `json:"gasUsed"`
+}
+
+// TransactionTrace is a flat call trace of a transaction
+type TransactionTrace struct {
+	Action              interface{} `json:"action"`
+	BlockHash           *types.Hash `json:"blockHash,omitempty"`
+	BlockNumber         *uint64     `json:"blockNumber,omitempty"`
+	Error               string      `json:"error,omitempty"`
+	Result              interface{} `json:"result"`
+	Subtraces           int         `json:"subtraces"`
+	TraceAddress        []int       `json:"traceAddress"`
+	TransactionHash     *types.Hash `json:"transactionHash,omitempty"`
+	TransactionPosition *uint64     `json:"transactionPosition,omitempty"`
+	Type                string      `json:"type"`
+
+	from, to string
+}
+
+// TraceResults is the result of the replay of a transaction
+type TraceResults struct {
+	Output          string              `json:"output"`
+	StateDiff       interface{}         `json:"stateDiff"`
+	Trace           []*TransactionTrace `json:"trace"`
+	TransactionHash types.Hash          `json:"transactionHash"`
+	VMTrace         interface{}         `json:"vmTrace"`
+}
+
+// TraceFilter is the filter of trace_filter
+type TraceFilter struct {
+	FromBlock   *BlockNumber    `json:"fromBlock"`
+	ToBlock     *BlockNumber    `json:"toBlock"`
+	FromAddress []types.Address `json:"fromAddress"`
+	ToAddress   []types.Address `json:"toAddress"`
+	After       *uint64         `json:"after"`
+	Count       *uint64         `json:"count"`
+}
+
+// Block returns the traces of all the transactions in the given block
+func (t *Trace) Block(number BlockNumber) (interface{}, error) {
+	block, err := t.getBlock(number)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.traceBlock(block)
+}
+
+// Transaction returns the traces of the given transaction
+func (t *Trace) Transaction(txHash types.Hash) (interface{}, error) {
+	tx, block := GetTxAndBlockByTxHash(txHash, t.store)
+	if tx == nil {
+		return nil, fmt.Errorf("tx %s not found", txHash.String())
+	}
+
+	if block.Number() == 0 {
+		return nil, ErrTraceGenesisBlock
+	}
+
+	tracer, cancel, err := newTracer(&TraceConfig{Tracer: callTracerName})
+	if err != nil {
+		return nil, err
+	}
+
+	defer cancel()
+
+	res, err := t.store.TraceTxn(block, tx.Hash, tracer)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, txn := range block.Transactions {
+		if txn.Hash == tx.Hash {
+			return flattenCallFrame(res, block, uint64(idx))
+		}
+	}
+
+	return nil, fmt.Errorf("tx %s not found", txHash.String())
+}
+
+// ReplayBlockTransactions replays all the transactions in the given block
+func (t *Trace) ReplayBlockTransactions(number BlockNumber, traceTypes []string) (interface{}, error) {
+	for _, traceType := range traceTypes {
+		if traceType != traceTypeTrace {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedTraceType, traceType)
+		}
+	}
+
+	block, err := t.getBlock(number)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Number() == 0 {
+		return nil, ErrTraceGenesisBlock
+	}
+
+	tracer, cancel, err := newTracer(&TraceConfig{Tracer: callTracerName})
+	if err != nil {
+		return nil, err
+	}
+
+	defer cancel()
+
+	results, err := t.store.TraceBlock(block, tracer)
+	if err != nil {
+		return nil, err
+	}
+
+	replays := make([]*TraceResults, len(results))
+
+	for idx, res := range results {
+		frame, ok := res.(*calltracer.CallFrame)
+		if !ok {
+			return nil, ErrUnexpectedTraceResult
+		}
+
+		replay := &TraceResults{
+			Output:          frame.Output,
+			TransactionHash: block.Transactions[idx].Hash,
+		}
+
+		if replay.Output == "" {
+			replay.Output = "0x"
+		}
+
+		// the traces of the replays don't carry the block and transaction details
+		if replay.Trace, err = flattenCallFrame(frame, nil, 0); err != nil {
+			return nil, err
+		}
+
+		replays[idx] = replay
+	}
+
+	return replays, nil
+}
+
+// Filter returns the traces in the given block range matching the given addresses
+func (t *Trace) Filter(filter TraceFilter) (interface{}, error) {
+	from, err := t.getFilterBlockNumber(filter.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := t.getFilterBlockNumber(filter.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if to < from {
+		return nil, ErrIncorrectBlockRange
+	}
+
+	// if not disabled, avoid handling large block ranges
+	if t.blockRangeLimit != 0 && to-from > t.blockRangeLimit {
+		return nil, ErrBlockRangeTooHigh
+	}
+
+	// genesis block can't be traced
+	if from == 0 {
+		from = 1
+	}
+
+	var (
+		fromAddresses = addressSet(filter.FromAddress)
+		toAddresses   = addressSet(filter.ToAddress)
+		after         uint64
+		traces        = make([]*TransactionTrace, 0)
+	)
+
+	if filter.After != nil {
+		after = *filter.After
+	}
+
+	for i := from; i <= to; i++ {
+		block, ok := t.store.GetBlockByNumber(i, true)
+		if !ok {
+			break
+		}
+
+		if len(block.Transactions) == 0 {
+			continue
+		}
+
+		blockTraces, err := t.traceBlock(block)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, trace := range blockTraces {
+			if !matchTraceAddress(fromAddresses, trace.from) || !matchTraceAddress(toAddresses, trace.to) {
+				continue
+			}
+
+			if after > 0 {
+				after--
+
+				continue
+			}
+
+			traces = append(traces, trace)
+
+			if filter.Count != nil && uint64(len(traces)) >= *filter.Count {
+				return traces, nil
+			}
+		}
+	}
+
+	return traces, nil
+}
+
+func (t *Trace) getBlock(number BlockNumber) (*types.Block, error) {
+	num, err := GetNumericBlockNumber(number, t.store)
+	if err != nil {
+		return nil, err
+	}
+
+	block, ok := t.store.GetBlockByNumber(num, true)
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", num)
+	}
+
+	return block, nil
+}
+
+func (t *Trace) getFilterBlockNumber(number *BlockNumber) (uint64, error) {
+	if number == nil {
+		return GetNumericBlockNumber(LatestBlockNumber, t.store)
+	}
+
+	return GetNumericBlockNumber(*number, t.store)
+}
+
+func (t *Trace) traceBlock(block *types.Block) ([]*TransactionTrace, error) {
+	if block.Number() == 0 {
+		return nil, ErrTraceGenesisBlock
+	}
+
+	tracer, cancel, err := newTracer(&TraceConfig{Tracer: callTracerName})
+	if err != nil {
+		return nil, err
+	}
+
+	defer cancel()
+
+	results, err := t.store.TraceBlock(block, tracer)
+	if err != nil {
+		return nil, err
+	}
+
+	traces := make([]*TransactionTrace, 0, len(results))
+
+	for idx, res := range results {
+		txTraces, err := flattenCallFrame(res, block, uint64(idx))
+		if err != nil {
+			return nil, err
+		}
+
+		traces = append(traces, txTraces...)
+	}
+
+	return traces, nil
+}
+
+// flattenCallFrame converts the call tree of a transaction to the list of its traces,
+// ordered depth-first. The block details are omitted if block is nil
+func flattenCallFrame(res interface{}, block *types.Block, txIndex uint64) ([]*TransactionTrace, error) {
+	frame, ok := res.(*calltracer.CallFrame)
+	if !ok {
+		return nil, ErrUnexpectedTraceResult
+	}
+
+	traces := make([]*TransactionTrace, 0)
+
+	var flatten func(frame *calltracer.CallFrame, traceAddress []int)
+
+	flatten = func(frame *calltracer.CallFrame, traceAddress []int) {
+		trace := newTransactionTrace(frame, traceAddress)
+
+		if block != nil {
+			var (
+				blockHash   = block.Hash()
+				blockNumber = block.Number()
+				txHash      = block.Transactions[txIndex].Hash
+				txPosition  = txIndex
+			)
+
+			trace.BlockHash = &blockHash
+			trace.BlockNumber = &blockNumber
+			trace.TransactionHash = &txHash
+			trace.TransactionPosition = &txPosition
+		}
+
+		traces = append(traces, trace)
+
+		for idx, call := range frame.Calls {
+			// copy the address, since the slice is shared by the children
+			childAddress := make([]int, len(traceAddress)+1)
+			copy(childAddress, traceAddress)
+			childAddress[len(traceAddress)] = idx
+
+			flatten(call, childAddress)
+		}
+	}
+
+	flatten(frame, []int{})
+
+	return traces, nil
+}
+
+func newTransactionTrace(frame *calltracer.CallFrame, traceAddress []int) *TransactionTrace {
+	trace := &TransactionTrace{
+		Subtraces:    len(frame.Calls),
+		TraceAddress: traceAddress,
+		from:         frame.From,
+		to:           frame.To,
+	}
+
+	value := frame.Value
+	if value == "" {
+		value = "0x0"
+	}
+
+	output := frame.Output
+	if output == "" {
+		output = "0x"
+	}
+
+	switch frame.Type {
+	case "CREATE", "CREATE2":
+		trace.Type = traceActionCreate
+		trace.Action = &traceCreateAction{
+			CreationMethod: strings.ToLower(frame.Type),
+			From:           frame.From,
+			Gas:            frame.Gas,
+			Init:           frame.Input,
+			Value:          value,
+		}
+		trace.Result = &traceCreateResult{
+			Address: frame.To,
+			Code:    output,
+			GasUsed: frame.GasUsed,
+		}
+
+	case "SELFDESTRUCT":
+		trace.Type = traceActionSuicide
+		trace.Action = &traceSuicideAction{
+			Address:       frame.From,
+			RefundAddress: frame.To,
+			Balance:       value,
+		}
+
+	default:
+		trace.Type = traceActionCall
+		trace.Action = &traceCallAction{
+			CallType: strings.ToLower(frame.Type),
+			From:     frame.From,
+			To:       frame.To,
+			Gas:      frame.Gas,
+			Input:    frame.Input,
+			Value:    value,
+		}
+		trace.Result = &traceCallResult{
+			GasUsed: frame.GasUsed,
+			Output:  output,
+		}
+	}
+
+	if frame.Error != "" {
+		// the failed calls don't have any result
+		trace.Result = nil
+		trace.Error = frame.Error
+
+		if frame.Error == runtime.ErrExecutionReverted.Error() {
+			trace.Error = traceRevertedError
+		}
+	}
+
+	return trace
+}
+
+func addressSet(addrs []types.Address) map[string]struct{} {
+	set := make(map[string]struct{}, len(addrs))
+
+	for _, addr := range addrs {
+		set[addr.String()] = struct{}{}
+	}
+
+	return set
+}
+
+// matchTraceAddress returns true if the set is empty or contains the address
+func matchTraceAddress(set map[string]struct{}, addr string) bool {
+	if len(set) == 0 {
+		return true
+	}
+
+	_, ok := set[addr]
+
+	return ok
+}
@@ -0,0 +1,261 @@
+package jsonrpc
+
+import (
+	"testing"
+
+	"github.com/newton2049/favo-chain/state/runtime"
+	"github.com/newton2049/favo-chain/state/runtime/tracer"
+	"github.com/newton2049/favo-chain/state/runtime/tracer/calltracer"
+	"github.com/newton2049/favo-chain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	traceAddr1 = types.StringToAddress("1")
+	traceAddr2 = types.StringToAddress("2")
+	traceAddr3 = types.StringToAddress("3")
+
+	traceTxHash1 = types.StringToHash("1")
+	traceTxHash2 = types.StringToHash("2")
+)
+
+// newTestTraceFrames returns the call trees of the transactions of the test block
+func newTestTraceFrames() []interface{} {
+	return []interface{}{
+		&calltracer.CallFrame{
+			Type:    "CALL",
+			From:    traceAddr1.String(),
+			To:      traceAddr2.String(),
+			Value:   "0x1",
+			Gas:     "0x5208",
+			GasUsed: "0x5208",
+			Input:   "0x",
+			Calls: []*calltracer.CallFrame{
+				{
+					Type:    "DELEGATECALL",
+					From:    traceAddr2.String(),
+					To:      traceAddr3.String(),
+					Gas:     "0x100",
+					GasUsed: "0x10",
+					Input:   "0x01",
+					Output:  "0x02",
+				},
+				{
+					Type:    "CREATE2",
+					From:    traceAddr2.String(),
+					To:      traceAddr3.String(),
+					Value:   "0x0",
+					Gas:     "0x200",
+					GasUsed: "0x20",
+					Input:   "0x03",
+					Error:   runtime.ErrExecutionReverted.Error(),
+				},
+			},
+		},
+		&calltracer.CallFrame{
+			Type:    "CALL",
+			From:    traceAddr3.String(),
+			To:      traceAddr1.String(),
+			Value:   "0x2",
+			Gas:     "0x5208",
+			GasUsed: "0x5208",
+			Input:   "0x",
+		},
+	}
+}
+
+func newTestTraceStore() *debugEndpointMockStore {
+	block := &types.Block{
+		Header: &types.Header{
+			Number: 1,
+		},
+		Transactions: []*types.Transaction{
+			{Hash: traceTxHash1},
+			{Hash: traceTxHash2},
+		},
+	}
+
+	return &debugEndpointMockStore{
+		headerFn: func() *types.Header {
+			return block.Header
+		},
+		readTxLookupFn: func(hash types.Hash) (types.Hash, bool) {
+			return block.Hash(), true
+		},
+		getBlockByHashFn: func(hash types.Hash, full bool) (*types.Block, bool) {
+			return block, true
+		},
+		getBlockByNumberFn: func(num uint64, full bool) (*types.Block, bool) {
+			if num != block.Number() {
+				return nil, false
+			}
+
+			return block, true
+		},
+		traceBlockFn: func(b *types.Block, tracer tracer.Tracer) ([]interface{}, error) {
+			return newTestTraceFrames(), nil
+		},
+		traceTxnFn: func(b *types.Block, hash types.Hash, tracer tracer.Tracer) (interface{}, error) {
+			return newTestTraceFrames()[1], nil
+		},
+	}
+}
+
+func TestTrace_Block(t *testing.T) {
+	t.Parallel()
+
+	store := newTestTraceStore()
+	endpoint := &Trace{store: store}
+
+	res, err := endpoint.Block(LatestBlockNumber)
+	assert.NoError(t, err)
+
+	traces, ok := res.([]*TransactionTrace)
+	assert.True(t, ok)
+	assert.Len(t, traces, 4)
+
+	// the root call of the first transaction
+	assert.Equal(t, traceActionCall, traces[0].Type)
+	assert.Equal(t, 2, traces[0].Subtraces)
+	assert.Equal(t, []int{}, traces[0].TraceAddress)
+	assert.Equal(t, traceTxHash1, *traces[0].TransactionHash)
+	assert.Equal(t, uint64(0), *traces[0].TransactionPosition)
+	assert.Equal(t, uint64(1), *traces[0].BlockNumber)
+	assert.Equal(t, &traceCallResult{GasUsed: "0x5208", Output: "0x"}, traces[0].Result)
+
+	// the delegate call doesn't transfer any value
+	assert.Equal(t, []int{0}, traces[1].TraceAddress)
+	assert.Equal(t, &traceCallAction{
+		CallType: "delegatecall",
+		From:     traceAddr2.String(),
+		To:       traceAddr3.String(),
+		Gas:      "0x100",
+		Input:    "0x01",
+		Value:    "0x0",
+	}, traces[1].Action)
+
+	// the reverted creation doesn't have any result
+	assert.Equal(t, []int{1}, traces[2].TraceAddress)
+	assert.Equal(t, traceActionCreate, traces[2].Type)
+	assert.Equal(t, "create2", traces[2].Action.(*traceCreateAction).CreationMethod)
+	assert.Equal(t, traceRevertedError, traces[2].Error)
+	assert.Nil(t, traces[2].Result)
+
+	assert.Equal(t, traceTxHash2, *traces[3].TransactionHash)
+	assert.Equal(t, uint64(1), *traces[3].TransactionPosition)
+}
+
+func TestTrace_Transaction(t *testing.T) {
+	t.Parallel()
+
+	store := newTestTraceStore()
+	endpoint := &Trace{store: store}
+
+	res, err := endpoint.Transaction(traceTxHash2)
+	assert.NoError(t, err)
+
+	traces, ok := res.([]*TransactionTrace)
+	assert.True(t, ok)
+	assert.Len(t, traces, 1)
+	assert.Equal(t, traceTxHash2, *traces[0].TransactionHash)
+	assert.Equal(t, uint64(1), *traces[0].TransactionPosition)
+}
+
+func TestTrace_ReplayBlockTransactions(t *testing.T) {
+	t.Parallel()
+
+	store := newTestTraceStore()
+	endpoint := &Trace{store: store}
+
+	res, err := endpoint.ReplayBlockTransactions(LatestBlockNumber, []string{traceTypeTrace})
+	assert.NoError(t, err)
+
+	replays, ok := res.([]*TraceResults)
+	assert.True(t, ok)
+	assert.Len(t, replays, 2)
+	assert.Equal(t, traceTxHash1, replays[0].TransactionHash)
+	assert.Equal(t, "0x", replays[0].Output)
+	assert.Len(t, replays[0].Trace, 3)
+	assert.Nil(t, replays[0].Trace[0].TransactionHash)
+	assert.Nil(t, replays[0].Trace[0].BlockNumber)
+
+	_, err = endpoint.ReplayBlockTransactions(LatestBlockNumber, []string{"vmTrace"})
+	assert.ErrorIs(t, err, ErrUnsupportedTraceType)
+}
+
+func TestTrace_Filter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		earliest = EarliestBlockNumber
+		latest   = LatestBlockNumber
+		one      = uint64(1)
+	)
+
+	tests := []struct {
+		name     string
+		filter   TraceFilter
+		expected []string // from addresses of the traces
+	}{
+		{
+			name:     "should return all traces",
+			filter:   TraceFilter{FromBlock: &earliest, ToBlock: &latest},
+			expected: []string{traceAddr1.String(), traceAddr2.String(), traceAddr2.String(), traceAddr3.String()},
+		},
+		{
+			name:     "should filter by from address",
+			filter:   TraceFilter{FromAddress: []types.Address{traceAddr2}},
+			expected: []string{traceAddr2.String(), traceAddr2.String()},
+		},
+		{
+			name: "should filter by from and to address",
+			filter: TraceFilter{
+				FromAddress: []types.Address{traceAddr1, traceAddr3},
+				ToAddress:   []types.Address{traceAddr1},
+			},
+			expected: []string{traceAddr3.String()},
+		},
+		{
+			name:     "should apply after and count",
+			filter:   TraceFilter{FromBlock: &earliest, After: &one, Count: &one},
+			expected: []string{traceAddr2.String()},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			endpoint := &Trace{store: newTestTraceStore()}
+
+			res, err := endpoint.Filter(tt.filter)
+			assert.NoError(t, err)
+
+			traces, ok := res.([]*TransactionTrace)
+			assert.True(t, ok)
+
+			from := make([]string, len(traces))
+			for i, trace := range traces {
+				from[i] = trace.from
+			}
+
+			assert.Equal(t, tt.expected, from)
+		})
+	}
+
+	t.Run("should fail if the block range is too high", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			from = BlockNumber(0)
+			to   = BlockNumber(100)
+		)
+
+		endpoint := &Trace{store: newTestTraceStore(), blockRangeLimit: 10}
+
+		_, err := endpoint.Filter(TraceFilter{FromBlock: &from, ToBlock: &to})
+		assert.ErrorIs(t, err, ErrBlockRangeTooHigh)
+	})
+}
@@ -15,20 +15,25 @@ type transactionOrHash interface {
 }
 
 type transaction struct {
-	Nonce       argUint64      `json:"nonce"`
-	GasPrice    argBig         `json:"gasPrice"`
-	Gas         argUint64      `json:"gas"`
-	To          *types.Address `json:"to"`
-	Value       argBig         `json:"value"`
-	Input       argBytes       `json:"input"`
-	V           argBig         `json:"v"`
-	R           argBig         `json:"r"`
-	S           argBig         `json:"s"`
-	Hash        types.Hash     `json:"hash"`
-	From        types.Address  `json:"from"`
-	BlockHash   *types.Hash    `json:"blockHash"`
-	BlockNumber *argUint64     `json:"blockNumber"`
-	TxIndex     *argUint64     `json:"transactionIndex"`
+	Nonce       argUint64           `json:"nonce"`
+	GasPrice    argBig              `json:"gasPrice"`
+	GasTipCap   *argBig             `json:"maxPriorityFeePerGas,omitempty"`
+	GasFeeCap   *argBig             `json:"maxFeePerGas,omitempty"`
+	Gas         argUint64           `json:"gas"`
+	To          *types.Address      `json:"to"`
+	Value       argBig              `json:"value"`
+	Input       argBytes            `json:"input"`
+	V           argBig              `json:"v"`
+	R           argBig              `json:"r"`
+	S           argBig              `json:"s"`
+	Hash        types.Hash          `json:"hash"`
+	From        types.Address       `json:"from"`
+	BlockHash   *types.Hash         `json:"blockHash"`
+	BlockNumber *argUint64          `json:"blockNumber"`
+	TxIndex     *argUint64          `json:"transactionIndex"`
+	ChainID     *argBig             `json:"chainId,omitempty"`
+	Type        argUint64           `json:"type"`
+	AccessList  *types.TxAccessList `json:"accessList,omitempty"`
 }
 
 func (t transaction) getHash() types.Hash { return t.Hash }
@@ -43,18 +48,19 @@ func (h transactionHash) MarshalText() ([]byte, error) {
 }
 
 func toPendingTransaction(t *types.Transaction) *transaction {
-	return toTransaction(t, nil, nil, nil)
+	return toTransaction(t, nil, nil)
 }
 
+// toTransaction returns the transaction, mined at the index of the block with the given header
+// if the header is set. The gas price of a mined dynamic fee transaction is the price it paid
 func toTransaction(
 	t *types.Transaction,
-	blockNumber *argUint64,
-	blockHash *types.Hash,
+	header *types.Header,
 	txIndex *int,
 ) *transaction {
 	res := &transaction{
 		Nonce:    argUint64(t.Nonce),
-		GasPrice: argBig(*t.GasPrice),
+		GasPrice: argBig(*t.GetGasFeeCap()),
 		Gas:      argUint64(t.Gas),
 		To:       t.To,
 		Value:    argBig(*t.Value),
@@ -64,14 +70,34 @@ func toTransaction(
 		S:        argBig(*t.S),
 		Hash:     t.Hash,
 		From:     t.From,
+		Type:     argUint64(t.Type),
 	}
 
-	if blockNumber != nil {
-		res.BlockNumber = blockNumber
+	if t.Type == types.DynamicFeeTx {
+		res.GasTipCap = argBigPtr(t.GetGasTipCap())
+		res.GasFeeCap = argBigPtr(t.GetGasFeeCap())
 	}
 
-	if blockHash != nil {
-		res.BlockHash = blockHash
+	if t.Type == types.AccessListTx || t.Type == types.DynamicFeeTx {
+		accessList := t.AccessList
+		if accessList == nil {
+			accessList = types.TxAccessList{}
+		}
+
+		res.AccessList = &accessList
+
+		if t.ChainID != nil {
+			res.ChainID = argBigPtr(t.ChainID)
+		}
+	}
+
+	if header != nil {
+		res.BlockNumber = argUintPtr(header.Number)
+		res.BlockHash = argHashPtr(header.Hash)
+
+		if t.Type == types.DynamicFeeTx {
+			res.GasPrice = argBig(*t.EffectiveGasPrice(new(big.Int).SetUint64(header.BaseFee)))
+		}
 	}
 
 	if txIndex != nil {
@@ -102,6 +128,7 @@ type block struct {
 	Hash            types.Hash          `json:"hash"`
 	Transactions    []transactionOrHash `json:"transactions"`
 	Uncles          []types.Hash        `json:"uncles"`
+	BaseFee         *argUint64          `json:"baseFeePerGas,omitempty"`
 }
 
 func (b *block) Copy() *block {
@@ -142,16 +169,15 @@ func toBlock(b *types.Block, fullTx bool) *block {
 		Uncles:          []types.Hash{},
 	}
 
+	if h.BaseFee != 0 {
+		res.BaseFee = argUintPtr(h.BaseFee)
+	}
+
 	for idx, txn := range b.Transactions {
 		if fullTx {
 			res.Transactions = append(
 				res.Transactions,
-				toTransaction(
-					txn,
-					argUintPtr(b.Number()),
-					argHashPtr(b.Hash()),
-					&idx,
-				),
+				toTransaction(txn, h, &idx),
 			)
 		} else {
 			res.Transactions = append(
@@ -168,6 +194,53 @@ func toBlock(b *types.Block, fullTx bool) *block {
 	return res
 }
 
+type header struct {
+	ParentHash   types.Hash  `json:"parentHash"`
+	Sha3Uncles   types.Hash  `json:"sha3Uncles"`
+	Miner        argBytes    `json:"miner"`
+	StateRoot    types.Hash  `json:"stateRoot"`
+	TxRoot       types.Hash  `json:"transactionsRoot"`
+	ReceiptsRoot types.Hash  `json:"receiptsRoot"`
+	LogsBloom    types.Bloom `json:"logsBloom"`
+	Difficulty   argUint64   `json:"difficulty"`
+	Number       argUint64   `json:"number"`
+	GasLimit     argUint64   `json:"gasLimit"`
+	GasUsed      argUint64   `json:"gasUsed"`
+	Timestamp    argUint64   `json:"timestamp"`
+	ExtraData    argBytes    `json:"extraData"`
+	MixHash      types.Hash  `json:"mixHash"`
+	Nonce        types.Nonce `json:"nonce"`
+	Hash         types.Hash  `json:"hash"`
+	BaseFee      *argUint64  `json:"baseFeePerGas,omitempty"`
+}
+
+func toHeader(h *types.Header) *header {
+	res := &header{
+		ParentHash:   h.ParentHash,
+		Sha3Uncles:   h.Sha3Uncles,
+		Miner:        argBytes(h.Miner),
+		StateRoot:    h.StateRoot,
+		TxRoot:       h.TxRoot,
+		ReceiptsRoot: h.ReceiptsRoot,
+		LogsBloom:    h.LogsBloom,
+		Difficulty:   argUint64(h.Difficulty),
+		Number:       argUint64(h.Number),
+		GasLimit:     argUint64(h.GasLimit),
+		GasUsed:      argUint64(h.GasUsed),
+		Timestamp:    argUint64(h.Timestamp),
+		ExtraData:    argBytes(h.ExtraData),
+		MixHash:      h.MixHash,
+		Nonce:        h.Nonce,
+		Hash:         h.Hash,
+	}
+
+	if h.BaseFee != 0 {
+		res.BaseFee = argUintPtr(h.BaseFee)
+	}
+
+	return res
+}
+
 type receipt struct {
 	Root              types.Hash     `json:"root"`
 	CumulativeGasUsed argUint64      `json:"cumulativeGasUsed"`
@@ -179,6 +252,7 @@ type receipt struct {
 	BlockHash         types.Hash     `json:"blockHash"`
 	BlockNumber       argUint64      `json:"blockNumber"`
 	GasUsed           argUint64      `json:"gasUsed"`
+	EffectiveGasPrice argBig         `json:"effectiveGasPrice"`
 	ContractAddress   *types.Address `json:"contractAddress"`
 	FromAddr          types.Address  `json:"from"`
 	ToAddr            *types.Address `json:"to"`
@@ -307,14 +381,144 @@ func encodeToHex(b []byte) []byte {
 
 // txnArgs is the transaction argument for the rpc endpoints
 type txnArgs struct {
-	From     *types.Address
-	To       *types.Address
-	Gas      *argUint64
-	GasPrice *argBytes
-	Value    *argBytes
-	Data     *argBytes
-	Input    *argBytes
-	Nonce    *argUint64
+	From      *types.Address
+	To        *types.Address
+	Gas       *argUint64
+	GasPrice  *argBytes
+	GasTipCap *argBytes `json:"maxPriorityFeePerGas"`
+	GasFeeCap *argBytes `json:"maxFeePerGas"`
+	Value     *argBytes
+	Data      *argBytes
+	Input     *argBytes
+	Nonce     *argUint64
+
+	AccessList *types.TxAccessList `json:"accessList"`
+}
+
+// stateOverride is the geth compatible set of the accounts replaced in the state of a call
+type stateOverride map[types.Address]overrideAccount
+
+type overrideAccount struct {
+	Nonce     *argUint64                `json:"nonce"`
+	Code      *argBytes                 `json:"code"`
+	Balance   *argBig                   `json:"balance"`
+	State     map[types.Hash]types.Hash `json:"state"`
+	StateDiff map[types.Hash]types.Hash `json:"stateDiff"`
+}
+
+func (s stateOverride) toType() types.StateOverride {
+	if s == nil {
+		return nil
+	}
+
+	override := make(types.StateOverride, len(s))
+
+	for addr, account := range s {
+		var overrideAccount types.OverrideAccount
+
+		if account.Nonce != nil {
+			nonce := uint64(*account.Nonce)
+			overrideAccount.Nonce = &nonce
+		}
+
+		if account.Code != nil {
+			overrideAccount.Code = *account.Code
+		}
+
+		if account.Balance != nil {
+			overrideAccount.Balance = new(big.Int).Set((*big.Int)(account.Balance))
+		}
+
+		overrideAccount.State = account.State
+		overrideAccount.StateDiff = account.StateDiff
+
+		override[addr] = overrideAccount
+	}
+
+	return override
+}
+
+// setSenderNonce sets the overridden nonce of the sender to the transaction arguments,
+// unless the nonce is given
+func (s stateOverride) setSenderNonce(arg *txnArgs) {
+	if arg.From == nil || arg.Nonce != nil {
+		return
+	}
+
+	if account, ok := s[*arg.From]; ok && account.Nonce != nil {
+		arg.Nonce = argUintPtr(uint64(*account.Nonce))
+	}
+}
+
+// blockOverride is the geth compatible set of the block header fields replaced for a call
+type blockOverride struct {
+	Number   *argUint64     `json:"number"`
+	Time     *argUint64     `json:"time"`
+	Coinbase *types.Address `json:"coinbase"`
+	GasLimit *argUint64     `json:"gasLimit"`
+	BaseFee  *argUint64     `json:"baseFee"`
+}
+
+func (b *blockOverride) toType() *types.BlockOverride {
+	if b == nil {
+		return nil
+	}
+
+	toUint64Ptr := func(arg *argUint64) *uint64 {
+		if arg == nil {
+			return nil
+		}
+
+		value := uint64(*arg)
+
+		return &value
+	}
+
+	return &types.BlockOverride{
+		Number:   toUint64Ptr(b.Number),
+		Time:     toUint64Ptr(b.Time),
+		Coinbase: b.Coinbase,
+		GasLimit: toUint64Ptr(b.GasLimit),
+		BaseFee:  toUint64Ptr(b.BaseFee),
+	}
+}
+
+type accessListResult struct {
+	AccessList types.TxAccessList `json:"accessList"`
+	GasUsed    argUint64          `json:"gasUsed"`
+	Error      string             `json:"error,omitempty"`
+}
+
+type feeHistory struct {
+	OldestBlock  argUint64   `json:"oldestBlock"`
+	BaseFee      []argUint64 `json:"baseFeePerGas"`
+	GasUsedRatio []float64   `json:"gasUsedRatio"`
+	Reward       [][]argBig  `json:"reward,omitempty"`
+}
+
+type accountProof struct {
+	Address      types.Address   `json:"address"`
+	AccountProof []argBytes      `json:"accountProof"`
+	Balance      argBig          `json:"balance"`
+	CodeHash     types.Hash      `json:"codeHash"`
+	Nonce        argUint64       `json:"nonce"`
+	StorageHash  types.Hash      `json:"storageHash"`
+	StorageProof []*storageProof `json:"storageProof"`
+}
+
+type storageProof struct {
+	Key   types.Hash `json:"key"`
+	Value argBig     `json:"value"`
+	Proof []argBytes `json:"proof"`
+}
+
+func toArgBytesList(list [][]byte) []argBytes {
+	res := make([]argBytes, len(list))
+	for i, b := range list {
+		res[i] = argBytes(b)
+	}
+
+	return res
 }
 
 type progression struct {
@@ -2,12 +2,14 @@ package server
 
 import (
 	"net"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 
 	"github.com/newton2049/favo-chain/chain"
 	"github.com/newton2049/favo-chain/network"
 	"github.com/newton2049/favo-chain/secrets"
+	itrie "github.com/newton2049/favo-chain/state/immutable-trie"
 )
 
 const DefaultGRPCPort int = 9632
@@ -24,6 +26,10 @@ type Config struct {
 	PriceLimit         uint64
 	MaxAccountEnqueued uint64
 	MaxSlots           uint64
+	PriceBump          uint64
+	AccountSlots       uint64
+	NoJournal          bool
+	JournalRotate      time.Duration
 	BlockTime          uint64
 
 	Telemetry *Telemetry
@@ -45,6 +51,12 @@ type Config struct {
 	Relayer bool
 
 	NumBlockConfirmations uint64
+
+	// StatePruning is the configuration of the state pruning, nil keeps the state of every block
+	StatePruning *itrie.PrunerConfig
+
+	// SnapSync syncs the state of a recent block from the peers instead of executing all the blocks
+	SnapSync bool
 }
 
 // Telemetry holds the config details for metric services
@@ -58,4 +70,13 @@ type JSONRPC struct {
 	AccessControlAllowOrigin []string
 	BatchLengthLimit         uint64
 	BlockRangeLimit          uint64
+	IPC                      bool
+	Namespaces               []string
+	AuthAddr                 *net.TCPAddr
+	AuthNamespaces           []string
+	JWTSecretPath            string
+	RateLimit                uint64
+	GraphQL                  bool
+	GraphQLMaxDepth          uint64
+	GraphQLMaxParallelism    uint64
 }
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"math/big"
@@ -9,6 +11,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/newton2049/favo-chain/blockchain/storage"
@@ -27,6 +31,7 @@ import (
 	"github.com/newton2049/favo-chain/crypto"
 	"github.com/newton2049/favo-chain/helper/common"
 	configHelper "github.com/newton2049/favo-chain/helper/config"
+	"github.com/newton2049/favo-chain/helper/hex"
 	"github.com/newton2049/favo-chain/helper/progress"
 	"github.com/newton2049/favo-chain/jsonrpc"
 	"github.com/newton2049/favo-chain/network"
@@ -38,6 +43,7 @@ import (
 	"github.com/newton2049/favo-chain/state/runtime/allowlist"
 	"github.com/newton2049/favo-chain/state/runtime/tracer"
 	"github.com/newton2049/favo-chain/txpool"
+	txpoolProto "github.com/newton2049/favo-chain/txpool/proto"
 	"github.com/newton2049/favo-chain/types"
 	"github.com/newton2049/favo-chain/validate"
 	"github.com/prometheus/client_golang/prometheus"
@@ -84,6 +90,11 @@ type Server struct {
 
 	// stateSyncRelayer is handling state syncs execution (Favobft exclusive)
 	stateSyncRelayer *statesyncrelayer.StateSyncRelayer
+
+	// statePruner removes the state of the old blocks (full pruning mode only)
+	statePruner       *itrie.Pruner
+	statePrunerSub    blockchain.Subscription
+	statePrunerDoneCh chan struct{}
 }
 
 // newFileLogger returns logger instance that writes all logs to a specified file.
@@ -198,6 +209,10 @@ func NewServer(config *Config) (*Server, error) {
 	st := itrie.NewState(stateStorage)
 	m.state = st
 
+	if err := st.EnableFlatSnapshot(logger); err != nil {
+		return nil, err
+	}
+
 	m.executor = state.NewExecutor(config.Chain.Params, st, logger)
 
 	// custom write genesis hook per consensus engine
@@ -244,8 +259,8 @@ func NewServer(config *Config) (*Server, error) {
 	// compute the genesis root state
 	config.Chain.Genesis.StateRoot = genesisRoot
 
-	// use the eip155 signer
-	signer := crypto.NewEIP155Signer(chain.AllForksEnabled.At(0), uint64(m.config.Chain.Params.ChainID))
+	// use the london signer (eip155 signer for the legacy transactions)
+	signer := crypto.NewSigner(chain.AllForksEnabled.At(0), uint64(m.config.Chain.Params.ChainID))
 
 	// create storage instance for blockchain
 	var db storage.Storage
@@ -285,6 +300,17 @@ func NewServer(config *Config) (*Server, error) {
 			return nil, err
 		}
 
+		ordering, err := txpool.NewOrdering(config.Chain.Params.TxOrdering)
+		if err != nil {
+			return nil, err
+		}
+
+		// the local transactions are journaled, unless the node keeps no data
+		journalPath := ""
+		if !m.config.NoJournal && m.config.DataDir != "" {
+			journalPath = filepath.Join(m.config.DataDir, "txpool.journal")
+		}
+
 		// start transaction pool
 		m.txpool, err = txpool.NewTxPool(
 			logger,
@@ -296,7 +322,12 @@ func NewServer(config *Config) (*Server, error) {
 				MaxSlots:            m.config.MaxSlots,
 				PriceLimit:          m.config.PriceLimit,
 				MaxAccountEnqueued:  m.config.MaxAccountEnqueued,
+				PriceBump:           m.config.PriceBump,
+				AccountSlots:        m.config.AccountSlots,
+				JournalPath:         journalPath,
+				JournalRotate:       m.config.JournalRotate,
 				DeploymentWhitelist: deploymentWhitelist,
+				Ordering:            ordering,
 			},
 		)
 		if err != nil {
@@ -321,11 +352,24 @@ func NewServer(config *Config) (*Server, error) {
 		return nil, err
 	}
 
+	// index the logs of the written blocks, and of the blocks to come
+	m.blockchain.StartLogIndex()
+
 	// initialize data in consensus layer
 	if err := m.consensus.Initialize(); err != nil {
 		return nil, err
 	}
 
+	// the validators prove their identity to the peers forwarding them the private transactions
+	if m.secretsManager.HasSecret(secrets.ValidatorKey) {
+		validatorKey, err := crypto.ReadConsensusKey(m.secretsManager)
+		if err != nil {
+			return nil, err
+		}
+
+		m.txpool.SetValidatorKey(validatorKey)
+	}
+
 	// setup and start grpc server
 	if err := m.setupGRPC(); err != nil {
 		return nil, err
@@ -357,6 +401,13 @@ func NewServer(config *Config) (*Server, error) {
 		}
 	}
 
+	// start state pruner
+	if config.StatePruning != nil {
+		if err := m.setupStatePruner(st); err != nil {
+			return nil, err
+		}
+	}
+
 	m.txpool.Start()
 
 	return m, nil
@@ -388,6 +439,46 @@ func (s *Server) restoreChain() error {
 	return nil
 }
 
+// setupStatePruner starts pruning the state as the new blocks are added
+func (s *Server) setupStatePruner(st *itrie.State) error {
+	pruner, err := itrie.NewPruner(s.logger, st, s.blockchain, s.config.StatePruning)
+	if err != nil {
+		return fmt.Errorf("failed to create state pruner: %w", err)
+	}
+
+	s.statePruner = pruner
+
+	// the chain can't be rewound to a pruned state
+	s.blockchain.SetStateRetention(s.config.StatePruning.Retention)
+	s.statePrunerSub = s.blockchain.SubscribeEvents()
+	s.statePrunerDoneCh = make(chan struct{})
+
+	go func() {
+		defer close(s.statePrunerDoneCh)
+
+		for {
+			ev := s.statePrunerSub.GetEvent()
+			if ev == nil {
+				return
+			}
+
+			if ev.Type == blockchain.EventFork {
+				continue
+			}
+
+			if err := pruner.OnNewHead(ev.Header().Number); err != nil {
+				if errors.Is(err, itrie.ErrPruningStopped) {
+					return
+				}
+
+				s.logger.Error("failed to prune state", "err", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
 type txpoolHub struct {
 	state state.State
 	*blockchain.Blockchain
@@ -508,11 +599,13 @@ func (s *Server) setupConsensus() error {
 			Network:               s.network,
 			Blockchain:            s.blockchain,
 			Executor:              s.executor,
+			State:                 s.state.(*itrie.State),
 			Grpc:                  s.grpcServer,
 			Logger:                s.logger,
 			SecretsManager:        s.secretsManager,
 			BlockTime:             s.config.BlockTime,
 			NumBlockConfirmations: s.config.NumBlockConfirmations,
+			SnapSync:              s.config.SnapSync,
 		},
 	)
 
@@ -610,6 +703,16 @@ func (j *jsonRPCHub) GetStorage(stateRoot types.Hash, addr types.Address, slot t
 	return res.Bytes(), nil
 }
 
+// GetProof returns the merkle proof of the account and of its storage slots at the given state root
+func (j *jsonRPCHub) GetProof(root types.Hash, addr types.Address, keys []types.Hash) (*itrie.AccountProof, error) {
+	st, ok := j.state.(*itrie.State)
+	if !ok {
+		return nil, errors.New("state proofs are not supported")
+	}
+
+	return st.GetProof(root, addr, keys)
+}
+
 func (j *jsonRPCHub) GetCode(root types.Hash, addr types.Address) ([]byte, error) {
 	account, err := getAccountImpl(j.state, root, addr)
 	if err != nil {
@@ -627,20 +730,182 @@ func (j *jsonRPCHub) GetCode(root types.Hash, addr types.Address) ([]byte, error
 func (j *jsonRPCHub) ApplyTxn(
 	header *types.Header,
 	txn *types.Transaction,
+	stateOverride types.StateOverride,
+	blockOverride *types.BlockOverride,
 ) (result *runtime.ExecutionResult, err error) {
+	transition, err := j.beginCallTxn(header, stateOverride, blockOverride, hasNoFees(txn))
+	if err != nil {
+		return
+	}
+
+	result, err = transition.Apply(txn)
+
+	return
+}
+
+// CreateAccessList applies the transaction on the state of the header,
+// and returns the access list of the addresses and the slots it accessed
+func (j *jsonRPCHub) CreateAccessList(
+	header *types.Header,
+	txn *types.Transaction,
+) (types.TxAccessList, *runtime.ExecutionResult, error) {
+	transition, err := j.beginCallTxn(header, nil, nil, hasNoFees(txn))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := transition.Apply(txn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return transition.AccessList(txn), result, nil
+}
+
+// SimulateTxns applies the transactions one after another on the state of the header,
+// with the accounts of the state and the fields of the header replaced by the overrides (if any).
+// The nonces and the base fee are only enforced if validate is set
+func (j *jsonRPCHub) SimulateTxns(
+	header *types.Header,
+	txns []*types.Transaction,
+	validate bool,
+	stateOverride types.StateOverride,
+	blockOverride *types.BlockOverride,
+) ([]*jsonrpc.SimulatedTxn, error) {
+	transition, err := j.beginCallTxn(header, stateOverride, blockOverride, !validate)
+	if err != nil {
+		return nil, err
+	}
+
+	gasLimit := header.GasLimit
+	if blockOverride != nil && blockOverride.GasLimit != nil {
+		gasLimit = *blockOverride.GasLimit
+	}
+
+	var (
+		results = make([]*jsonrpc.SimulatedTxn, len(txns))
+		gasUsed = uint64(0)
+		objects = make(map[types.Address]*state.Object)
+	)
+
+	// the overridden accounts aren't changes of the transactions
+	changedObjects(objects, transition.DirtyObjects())
+
+	for i, txn := range txns {
+		msg := txn.Copy()
+
+		if !validate {
+			msg.Nonce = transition.GetNonce(msg.From)
+		}
+
+		// the calls without the gas limit can use the gas left in the block
+		if msg.Gas == 0 && gasLimit > gasUsed {
+			msg.Gas = gasLimit - gasUsed
+		}
+
+		result, err := transition.Apply(msg)
+		if err != nil {
+			results[i] = &jsonrpc.SimulatedTxn{Err: err}
+
+			continue
+		}
+
+		gasUsed += result.GasUsed
+
+		results[i] = &jsonrpc.SimulatedTxn{
+			Result:  result,
+			Logs:    transition.Txn().Logs(),
+			Changes: changedObjects(objects, transition.DirtyObjects()),
+		}
+	}
+
+	return results, nil
+}
+
+// beginCallTxn begins the transition of the calls on the state of the header,
+// with the accounts of the state and the fields of the header replaced by the overrides (if any).
+// The base fee is not charged if noBaseFee is set
+func (j *jsonRPCHub) beginCallTxn(
+	header *types.Header,
+	stateOverride types.StateOverride,
+	blockOverride *types.BlockOverride,
+	noBaseFee bool,
+) (*state.Transition, error) {
 	blockCreator, err := j.GetConsensus().GetBlockCreator(header)
 	if err != nil {
 		return nil, err
 	}
 
-	transition, err := j.BeginTxn(header.StateRoot, header, blockCreator)
+	stateRoot := header.StateRoot
+
+	if blockOverride != nil {
+		// the state of the referenced block is kept
+		header = blockOverride.Apply(header)
+
+		if blockOverride.Coinbase != nil {
+			blockCreator = *blockOverride.Coinbase
+		}
+	}
+
+	if noBaseFee {
+		header = withoutBaseFee(header)
+	}
+
+	transition, err := j.BeginTxn(stateRoot, header, blockCreator)
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	result, err = transition.Apply(txn)
+	if err := transition.WithStateOverride(stateOverride); err != nil {
+		return nil, err
+	}
 
-	return
+	return transition, nil
+}
+
+// changedObjects returns the objects which differ from the previous objects,
+// with only their changed storage slots, and updates the previous objects
+func changedObjects(previous map[types.Address]*state.Object, objects []*state.Object) []*state.Object {
+	changed := make([]*state.Object, 0)
+
+	for _, object := range objects {
+		prev, ok := previous[object.Address]
+		previous[object.Address] = object
+
+		if !ok {
+			changed = append(changed, object)
+
+			continue
+		}
+
+		prevStorage := make(map[string][]byte, len(prev.Storage))
+		for _, slot := range prev.Storage {
+			prevStorage[string(slot.Key)] = slot.Val
+		}
+
+		storage := make([]*state.StorageObject, 0)
+
+		for _, slot := range object.Storage {
+			if val, ok := prevStorage[string(slot.Key)]; !ok || !bytes.Equal(val, slot.Val) {
+				storage = append(storage, slot)
+			}
+		}
+
+		if len(storage) == 0 &&
+			prev.Nonce == object.Nonce &&
+			prev.Balance.Cmp(object.Balance) == 0 &&
+			prev.CodeHash == object.CodeHash &&
+			prev.Deleted == object.Deleted {
+			continue
+		}
+
+		change := *object
+		change.Storage = storage
+
+		changed = append(changed, &change)
+	}
+
+	return changed
 }
 
 // TraceBlock traces all transactions in the given block and returns all results
@@ -749,7 +1014,7 @@ func (j *jsonRPCHub) TraceCall(
 		return nil, err
 	}
 
-	transition, err := j.BeginTxn(parentHeader.StateRoot, parentHeader, blockCreator)
+	transition, err := j.BeginTxn(parentHeader.StateRoot, callHeader(parentHeader, tx), blockCreator)
 	if err != nil {
 		return nil, err
 	}
@@ -763,6 +1028,33 @@ func (j *jsonRPCHub) TraceCall(
 	return tracer.GetResult()
 }
 
+// callHeader returns the header used to simulate the given transaction.
+// Calls without any gas price are not subject to the base fee, as in eth_call
+func callHeader(header *types.Header, txn *types.Transaction) *types.Header {
+	if !hasNoFees(txn) {
+		return header
+	}
+
+	return withoutBaseFee(header)
+}
+
+// hasNoFees returns whether the transaction doesn't specify any gas price
+func hasNoFees(txn *types.Transaction) bool {
+	return txn.GetGasFeeCap().Sign() == 0 && txn.GetGasTipCap().Sign() == 0
+}
+
+// withoutBaseFee returns the header without the base fee
+func withoutBaseFee(header *types.Header) *types.Header {
+	if header.BaseFee == 0 {
+		return header
+	}
+
+	header = header.Copy()
+	header.BaseFee = 0
+
+	return header
+}
+
 func (j *jsonRPCHub) GetSyncProgression() *progress.Progression {
 	// restore progression
 	if restoreProg := j.restoreProgression.GetProgression(); restoreProg != nil {
@@ -777,6 +1069,36 @@ func (j *jsonRPCHub) GetSyncProgression() *progress.Progression {
 	return nil
 }
 
+// SubscribePendingTxs subscribes for the hashes of the transactions promoted in the txpool
+func (j *jsonRPCHub) SubscribePendingTxs() (<-chan types.Hash, func()) {
+	eventCh, cancel := j.TxPool.SubscribeTxEvents(txpoolProto.EventType_PROMOTED)
+
+	var (
+		hashCh   = make(chan types.Hash)
+		doneCh   = make(chan struct{})
+		doneOnce sync.Once
+	)
+
+	go func() {
+		defer close(hashCh)
+
+		for event := range eventCh {
+			select {
+			case hashCh <- types.StringToHash(event.TxHash):
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+
+	return hashCh, func() {
+		doneOnce.Do(func() {
+			close(doneCh)
+			cancel()
+		})
+	}
+}
+
 // SETUP //
 
 // setupJSONRCP sets up the JSONRPC server, using the set configuration
@@ -801,6 +1123,31 @@ func (s *Server) setupJSONRPC() error {
 		PriceLimit:               s.config.PriceLimit,
 		BatchLengthLimit:         s.config.JSONRPC.BatchLengthLimit,
 		BlockRangeLimit:          s.config.JSONRPC.BlockRangeLimit,
+		Namespaces:               s.config.JSONRPC.Namespaces,
+		RateLimit:                s.config.JSONRPC.RateLimit,
+		GraphQL:                  s.config.JSONRPC.GraphQL,
+		GraphQLMaxDepth:          s.config.JSONRPC.GraphQLMaxDepth,
+		GraphQLMaxParallelism:    s.config.JSONRPC.GraphQLMaxParallelism,
+	}
+
+	if s.config.JSONRPC.IPC {
+		conf.IPCPath = filepath.Join(s.config.DataDir, "jsonrpc.ipc")
+	}
+
+	if s.config.JSONRPC.AuthAddr != nil {
+		secretPath := s.config.JSONRPC.JWTSecretPath
+		if secretPath == "" {
+			secretPath = filepath.Join(s.config.DataDir, "jwtsecret")
+		}
+
+		secret, err := readOrCreateJWTSecret(secretPath)
+		if err != nil {
+			return err
+		}
+
+		conf.AuthAddr = s.config.JSONRPC.AuthAddr
+		conf.AuthNamespaces = s.config.JSONRPC.AuthNamespaces
+		conf.JWTSecret = secret
 	}
 
 	srv, err := jsonrpc.NewJSONRPC(s.logger, conf)
@@ -813,6 +1160,36 @@ func (s *Server) setupJSONRPC() error {
 	return nil
 }
 
+// readOrCreateJWTSecret reads the hex encoded JWT secret from the file,
+// the file is created with a random secret if it doesn't exist
+func readOrCreateJWTSecret(path string) ([]byte, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		secret, err := hex.DecodeHex(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt secret in %s: %w", path, err)
+		}
+
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("jwt secret in %s is shorter than 32 bytes", path)
+		}
+
+		return secret, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	if err := common.SaveFileSafe(path, []byte(hex.EncodeToHex(secret)), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write jwt secret: %w", err)
+	}
+
+	return secret, nil
+}
+
 // setupGRPC sets up the grpc server and listens on tcp
 func (s *Server) setupGRPC() error {
 	proto.RegisterSystemServer(s.grpcServer, &systemService{server: s})
@@ -860,6 +1237,18 @@ func (s *Server) Close() {
 		s.logger.Error("failed to close consensus", "err", err.Error())
 	}
 
+	// Stop the state pruner before closing the state storage
+	if s.statePruner != nil {
+		s.statePruner.Stop()
+		s.statePrunerSub.Close()
+		<-s.statePrunerDoneCh
+	}
+
+	// Stop the flat state generation before closing the state storage
+	if st, ok := s.state.(*itrie.State); ok {
+		st.Close()
+	}
+
 	// Close the state storage
 	if err := s.stateStorage.Close(); err != nil {
 		s.logger.Error("failed to close storage for trie", "err", err.Error())
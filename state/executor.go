@@ -20,10 +20,13 @@ import (
 
 const (
 	SpuriousDragonMaxCodeSize = 24576
-	TxPoolMaxInitCodeSize     = 2 * SpuriousDragonMaxCodeSize
+	TxPoolMaxInitCodeSize     = runtime.MaxInitCodeSize
 
 	TxGas                 uint64 = 21000 // Per transaction not creating a contract
 	TxGasContractCreation uint64 = 53000 // Per transaction that creates a contract
+
+	TxAccessListAddressGas    uint64 = 2400 // Per address specified in the EIP-2930 access list
+	TxAccessListStorageKeyGas uint64 = 1900 // Per storage key specified in the EIP-2930 access list
 )
 
 var emptyCodeHashTwo = types.BytesToHash(crypto.Keccak256(nil))
@@ -188,6 +191,7 @@ func (e *Executor) BeginTxn(
 		Difficulty: types.BytesToHash(new(big.Int).SetUint64(header.Difficulty).Bytes()),
 		GasLimit:   int64(header.GasLimit),
 		ChainID:    e.config.ChainID,
+		BaseFee:    new(big.Int).SetUint64(header.BaseFee),
 	}
 
 	txn := &Transition{
@@ -267,7 +271,7 @@ var emptyFrom = types.Address{}
 func (t *Transition) WriteFailedReceipt(txn *types.Transaction) error {
 	signer := crypto.NewSigner(t.config, uint64(t.ctx.ChainID))
 
-	if txn.From == emptyFrom && txn.Type == types.LegacyTx {
+	if txn.From == emptyFrom && txn.Type != types.StateTx {
 		// Decrypt the from address
 		from, err := signer.Sender(txn)
 		if err != nil {
@@ -299,7 +303,7 @@ func (t *Transition) WriteFailedReceipt(txn *types.Transaction) error {
 func (t *Transition) Write(txn *types.Transaction) error {
 	var err error
 
-	if txn.From == emptyFrom && txn.Type == types.LegacyTx {
+	if txn.From == emptyFrom && txn.Type != types.StateTx {
 		// Decrypt the from address
 		signer := crypto.NewSigner(t.config, uint64(t.ctx.ChainID))
 
@@ -360,6 +364,11 @@ func (t *Transition) Commit() (Snapshot, types.Hash) {
 	return s2, types.BytesToHash(root)
 }
 
+// DirtyObjects returns the accounts changed by the transition so far, without committing them
+func (t *Transition) DirtyObjects() []*Object {
+	return t.state.Commit(t.config.EIP155)
+}
+
 func (t *Transition) subGasPool(amount uint64) error {
 	if t.gasPool < amount {
 		return ErrBlockLimitReached
@@ -400,10 +409,29 @@ func (t *Transition) ContextPtr() *runtime.TxContext {
 	return &t.ctx
 }
 
+// baseFee returns the EIP-1559 base fee of the block being processed
+func (t *Transition) baseFee() *big.Int {
+	if t.ctx.BaseFee == nil {
+		return new(big.Int)
+	}
+
+	return t.ctx.BaseFee
+}
+
 func (t *Transition) subGasLimitPrice(msg *types.Transaction) error {
-	// deduct the upfront max gas cost
-	upfrontGasCost := new(big.Int).Set(msg.GasPrice)
-	upfrontGasCost.Mul(upfrontGasCost, new(big.Int).SetUint64(msg.Gas))
+	gas := new(big.Int).SetUint64(msg.Gas)
+
+	// the sender must be able to cover the max gas cost and the value,
+	// even though only the effective gas price is charged
+	if msg.Type == types.DynamicFeeTx {
+		if t.state.GetBalance(msg.From).Cmp(msg.Cost()) < 0 {
+			return ErrNotEnoughFundsForGas
+		}
+	}
+
+	// deduct the upfront gas cost
+	upfrontGasCost := msg.EffectiveGasPrice(t.baseFee())
+	upfrontGasCost.Mul(upfrontGasCost, gas)
 
 	if err := t.state.SubBalance(msg.From, upfrontGasCost); err != nil {
 		if errors.Is(err, runtime.ErrNotEnoughFunds) {
@@ -436,6 +464,10 @@ var (
 	ErrIntrinsicGasOverflow  = fmt.Errorf("overflow in intrinsic gas calculation")
 	ErrNotEnoughIntrinsicGas = fmt.Errorf("not enough gas supplied for intrinsic gas costs")
 	ErrNotEnoughFunds        = fmt.Errorf("not enough funds for transfer with given value")
+	ErrTxTypeNotSupported    = fmt.Errorf("transaction type not supported")
+	ErrTipAboveFeeCap        = fmt.Errorf("max priority fee per gas higher than max fee per gas")
+	ErrFeeCapTooLow          = fmt.Errorf("max fee per gas less than block base fee")
+	ErrMaxInitCodeSize       = fmt.Errorf("max initcode size exceeded")
 )
 
 type TransitionApplicationError struct {
@@ -465,12 +497,17 @@ func NewGasLimitReachedTransitionApplicationError(err error) *GasLimitReachedTra
 }
 
 func (t *Transition) apply(msg *types.Transaction) (*runtime.ExecutionResult, error) {
+	// the tracer is started before any state change, so that it can capture the pre-state
+	if t.ctx.Tracer != nil {
+		t.ctx.Tracer.TxStart(msg.Gas, msg.From, msg.To, t.ctx.Coinbase, t)
+	}
+
 	if msg.Type == types.StateTx {
 		if err := checkAndProcessStateTx(msg, t); err != nil {
 			return nil, err
 		}
 	} else {
-		if err := checkAndProcessLegacyTx(msg, t); err != nil {
+		if err := checkAndProcessTx(msg, t); err != nil {
 			return nil, err
 		}
 	}
@@ -480,12 +517,8 @@ func (t *Transition) apply(msg *types.Transaction) (*runtime.ExecutionResult, er
 		return nil, NewGasLimitReachedTransitionApplicationError(err)
 	}
 
-	if t.ctx.Tracer != nil {
-		t.ctx.Tracer.TxStart(msg.Gas)
-	}
-
 	// 4. there is no overflow when calculating intrinsic gas
-	intrinsicGasCost, err := TransactionGasCost(msg, t.config.Homestead, t.config.Istanbul)
+	intrinsicGasCost, err := TransactionGasCost(msg, t.config.Homestead, t.config.Istanbul, t.config.Shanghai)
 	if err != nil {
 		return nil, NewTransitionApplicationError(err, false)
 	}
@@ -497,9 +530,12 @@ func (t *Transition) apply(msg *types.Transaction) (*runtime.ExecutionResult, er
 		return nil, NewTransitionApplicationError(ErrNotEnoughIntrinsicGas, false)
 	}
 
-	gasPrice := new(big.Int).Set(msg.GasPrice)
+	gasPrice := msg.EffectiveGasPrice(t.baseFee())
 	value := new(big.Int).Set(msg.Value)
 
+	t.prepareAccessList(msg)
+	t.state.ClearTransientStorage()
+
 	// set the specific transaction fields in the context
 	t.ctx.GasPrice = types.BytesToHash(gasPrice.Bytes())
 	t.ctx.Origin = msg.From
@@ -520,12 +556,15 @@ func (t *Transition) apply(msg *types.Transaction) (*runtime.ExecutionResult, er
 	}
 
 	// refund the sender
-	remaining := new(big.Int).Mul(new(big.Int).SetUint64(result.GasLeft), msg.GasPrice)
+	remaining := new(big.Int).Mul(new(big.Int).SetUint64(result.GasLeft), gasPrice)
 	t.state.AddBalance(msg.From, remaining)
 
-	// pay the coinbase
-	coinbaseFee := new(big.Int).Mul(new(big.Int).SetUint64(result.GasUsed), msg.GasPrice)
-	t.state.AddBalance(t.ctx.Coinbase, coinbaseFee)
+	// pay the coinbase the priority fee, the base fee part is burnt
+	tip := new(big.Int).Sub(gasPrice, t.baseFee())
+	if tip.Sign() > 0 {
+		coinbaseFee := new(big.Int).Mul(new(big.Int).SetUint64(result.GasUsed), tip)
+		t.state.AddBalance(t.ctx.Coinbase, coinbaseFee)
+	}
 
 	// return gas to the pool
 	t.addGasPool(result.GasLeft)
@@ -651,7 +690,7 @@ func (t *Transition) hasCodeOrNonce(addr types.Address) bool {
 	return false
 }
 
-func (t *Transition) applyCreate(c *runtime.Contract, host runtime.Host) *runtime.ExecutionResult {
+func (t *Transition) applyCreate(c *runtime.Contract, host runtime.Host) (result *runtime.ExecutionResult) {
 	gasLimit := c.Gas
 
 	if c.Depth > int(1024)+1 {
@@ -672,6 +711,11 @@ func (t *Transition) applyCreate(c *runtime.Contract, host runtime.Host) *runtim
 		}
 	}
 
+	// the created address is warm even if the creation fails (eip-2929)
+	if t.config.Berlin {
+		t.state.AddAddressToAccessList(c.Address)
+	}
+
 	// Take snapshot of the current state
 	snapshot := t.state.Snapshot()
 
@@ -689,12 +733,15 @@ func (t *Transition) applyCreate(c *runtime.Contract, host runtime.Host) *runtim
 		}
 	}
 
-	var result *runtime.ExecutionResult
+	callType := runtime.Create
+	if c.Type == runtime.Create2 {
+		callType = runtime.Create2
+	}
 
-	t.captureCallStart(c, evm.CREATE)
+	t.captureCallStart(c, callType)
 
 	defer func() {
-		// pass result to be set later
+		// result is the named return value, set by any of the returns below
 		t.captureCallEnd(c, result)
 	}()
 
@@ -817,7 +864,7 @@ func (t *Transition) Selfdestruct(addr types.Address, beneficiary types.Address)
 }
 
 func (t *Transition) Callx(c *runtime.Contract, h runtime.Host) *runtime.ExecutionResult {
-	if c.Type == runtime.Create {
+	if c.Type == runtime.Create || c.Type == runtime.Create2 {
 		return t.applyCreate(c, h)
 	}
 
@@ -855,6 +902,42 @@ func (t *Transition) SetCodeDirectly(addr types.Address, code []byte) error {
 	return nil
 }
 
+// WithStateOverride replaces the accounts of the state with the overridden fields
+// NOTE: WithStateOverride changes the world state without a transaction
+func (t *Transition) WithStateOverride(override types.StateOverride) error {
+	for addr, account := range override {
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("cannot override both state and state diff of %s", addr)
+		}
+
+		if account.Nonce != nil {
+			t.state.SetNonce(addr, *account.Nonce)
+		}
+
+		if account.Balance != nil {
+			t.state.SetBalance(addr, account.Balance)
+		}
+
+		if account.Code != nil {
+			t.state.SetCode(addr, account.Code)
+		}
+
+		if account.State != nil {
+			t.state.ClearState(addr)
+
+			for key, value := range account.State {
+				t.state.SetState(addr, key, value)
+			}
+		}
+
+		for key, value := range account.StateDiff {
+			t.state.SetState(addr, key, value)
+		}
+	}
+
+	return nil
+}
+
 // SetTracer sets tracer to the context in order to enable it
 func (t *Transition) SetTracer(tracer tracer.Tracer) {
 	t.ctx.Tracer = tracer
@@ -869,7 +952,103 @@ func (t *Transition) GetRefund() uint64 {
 	return t.state.GetRefund()
 }
 
-func TransactionGasCost(msg *types.Transaction, isHomestead, isIstanbul bool) (uint64, error) {
+// AddressInAccessList checks if the address is in the EIP-2929 access list
+func (t *Transition) AddressInAccessList(addr types.Address) bool {
+	return t.state.AddressInAccessList(addr)
+}
+
+// SlotInAccessList checks if the address and the (address, slot) pair are in the EIP-2929 access list
+func (t *Transition) SlotInAccessList(addr types.Address, slot types.Hash) (bool, bool) {
+	return t.state.SlotInAccessList(addr, slot)
+}
+
+// AddAddressToAccessList adds the address to the EIP-2929 access list
+func (t *Transition) AddAddressToAccessList(addr types.Address) {
+	t.state.AddAddressToAccessList(addr)
+}
+
+// AddSlotToAccessList adds the (address, slot) pair to the EIP-2929 access list
+func (t *Transition) AddSlotToAccessList(addr types.Address, slot types.Hash) {
+	t.state.AddSlotToAccessList(addr, slot)
+}
+
+// AccessList returns the EIP-2930 access list of the addresses and the slots accessed by the message,
+// without the addresses which are always warm (the sender, the recipient, the precompiles and the coinbase)
+// unless their slots are accessed
+func (t *Transition) AccessList(msg *types.Transaction) types.TxAccessList {
+	warm := map[types.Address]struct{}{
+		msg.From: {},
+	}
+
+	if msg.To != nil {
+		warm[*msg.To] = struct{}{}
+	}
+
+	for _, addr := range t.precompiles.Addresses(&t.config) {
+		warm[addr] = struct{}{}
+	}
+
+	if t.config.Shanghai {
+		warm[t.ctx.Coinbase] = struct{}{}
+	}
+
+	accessList := types.TxAccessList{}
+
+	for _, tuple := range t.state.AccessList() {
+		if _, ok := warm[tuple.Address]; ok && len(tuple.StorageKeys) == 0 {
+			continue
+		}
+
+		accessList = append(accessList, tuple)
+	}
+
+	return accessList
+}
+
+// GetTransientState returns the EIP-1153 transient storage value of the (address, key) pair
+func (t *Transition) GetTransientState(addr types.Address, key types.Hash) types.Hash {
+	return t.state.GetTransientState(addr, key)
+}
+
+// SetTransientState sets the EIP-1153 transient storage value of the (address, key) pair
+func (t *Transition) SetTransientState(addr types.Address, key types.Hash, value types.Hash) {
+	t.state.SetTransientState(addr, key, value)
+}
+
+// prepareAccessList resets the EIP-2929 access list and pre-warms it with the sender,
+// the recipient, the precompiles, the EIP-2930 access list of the message and,
+// from Shanghai, the coinbase (EIP-3651)
+func (t *Transition) prepareAccessList(msg *types.Transaction) {
+	t.state.ClearAccessList()
+
+	if !t.config.Berlin {
+		return
+	}
+
+	t.state.AddAddressToAccessList(msg.From)
+
+	if msg.To != nil {
+		t.state.AddAddressToAccessList(*msg.To)
+	}
+
+	for _, addr := range t.precompiles.Addresses(&t.config) {
+		t.state.AddAddressToAccessList(addr)
+	}
+
+	if t.config.Shanghai {
+		t.state.AddAddressToAccessList(t.ctx.Coinbase)
+	}
+
+	for _, tuple := range msg.AccessList {
+		t.state.AddAddressToAccessList(tuple.Address)
+
+		for _, key := range tuple.StorageKeys {
+			t.state.AddSlotToAccessList(tuple.Address, key)
+		}
+	}
+}
+
+func TransactionGasCost(msg *types.Transaction, isHomestead, isIstanbul, isShanghai bool) (uint64, error) {
 	cost := uint64(0)
 
 	// Contract creation is only paid on the homestead fork
@@ -909,20 +1088,46 @@ func TransactionGasCost(msg *types.Transaction, isHomestead, isIstanbul bool) (u
 		cost += zeros * 4
 	}
 
+	// eip-3860
+	if msg.IsContractCreation() && isShanghai {
+		cost += ((uint64(len(payload)) + 31) / 32) * runtime.InitCodeWordGas
+	}
+
+	// eip-2930
+	if len(msg.AccessList) > 0 {
+		cost += uint64(len(msg.AccessList)) * TxAccessListAddressGas
+		cost += uint64(msg.AccessList.StorageKeys()) * TxAccessListStorageKeyGas
+	}
+
 	return cost, nil
 }
 
-// checkAndProcessLegacyTx - first check if this message satisfies all consensus rules before
+// checkAndProcessTx - first check if this message satisfies all consensus rules before
 // applying the message. The rules include these clauses:
 // 1. the nonce of the message caller is correct
-// 2. caller has enough balance to cover transaction fee(gaslimit * gasprice)
-func checkAndProcessLegacyTx(msg *types.Transaction, t *Transition) error {
+// 2. the transaction type is supported and the fee fields are valid for the current base fee (EIP-1559)
+// 3. caller has enough balance to cover transaction fee(gaslimit * gasprice)
+func checkAndProcessTx(msg *types.Transaction, t *Transition) error {
 	// 1. the nonce of the message caller is correct
 	if err := t.nonceCheck(msg); err != nil {
 		return NewTransitionApplicationError(err, true)
 	}
 
-	// 2. caller has enough balance to cover transaction fee(gaslimit * gasprice)
+	// 2. the transaction type is supported and the fee fields are valid for the current base fee (EIP-1559)
+	if err := t.checkTxType(msg); err != nil {
+		return err
+	}
+
+	if err := t.checkDynamicFees(msg); err != nil {
+		return err
+	}
+
+	// 3. the initcode of a contract creation is within the limit (EIP-3860)
+	if t.config.Shanghai && msg.IsContractCreation() && len(msg.Input) > TxPoolMaxInitCodeSize {
+		return NewTransitionApplicationError(ErrMaxInitCodeSize, false)
+	}
+
+	// 4. caller has enough balance to cover transaction fee(gaslimit * gasprice)
 	if err := t.subGasLimitPrice(msg); err != nil {
 		return NewTransitionApplicationError(err, true)
 	}
@@ -930,6 +1135,42 @@ func checkAndProcessLegacyTx(msg *types.Transaction, t *Transition) error {
 	return nil
 }
 
+// checkTxType checks if the transaction type is enabled by the active forks
+func (t *Transition) checkTxType(msg *types.Transaction) error {
+	switch msg.Type {
+	case types.AccessListTx:
+		if !t.config.Berlin {
+			return NewTransitionApplicationError(ErrTxTypeNotSupported, false)
+		}
+	case types.DynamicFeeTx:
+		if !t.config.London {
+			return NewTransitionApplicationError(ErrTxTypeNotSupported, false)
+		}
+	}
+
+	return nil
+}
+
+// checkDynamicFees checks the EIP-1559 fee fields of the message against the block base fee
+func (t *Transition) checkDynamicFees(msg *types.Transaction) error {
+	if msg.Type == types.DynamicFeeTx {
+		if msg.GetGasTipCap().Cmp(msg.GetGasFeeCap()) > 0 {
+			return NewTransitionApplicationError(ErrTipAboveFeeCap, false)
+		}
+	}
+
+	// the fee cap may become valid again once the base fee drops
+	if msg.GetGasFeeCap().Cmp(t.baseFee()) < 0 {
+		return NewTransitionApplicationError(
+			fmt.Errorf("%w: address %s, maxFeePerGas: %s, baseFee: %s",
+				ErrFeeCapTooLow, msg.From, msg.GetGasFeeCap(), t.baseFee()),
+			true,
+		)
+	}
+
+	return nil
+}
+
 func checkAndProcessStateTx(msg *types.Transaction, t *Transition) error {
 	if msg.GasPrice.Cmp(big.NewInt(0)) != 0 {
 		return NewTransitionApplicationError(
@@ -988,6 +1229,7 @@ func (t *Transition) captureCallEnd(c *runtime.Contract, result *runtime.Executi
 	t.ctx.Tracer.CallEnd(
 		c.Depth,
 		result.ReturnValue,
+		c.Gas-result.GasLeft,
 		result.Err,
 	)
 }
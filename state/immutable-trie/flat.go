@@ -0,0 +1,749 @@
+package itrie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/newton2049/favo-chain/state"
+	"github.com/newton2049/favo-chain/types"
+)
+
+const (
+	// maxFlatDiffLayers is the number of the recent states kept in memory on top of the flat state on disk
+	maxFlatDiffLayers = 128
+
+	// flatGeneratorBatchSize is the number of the flat entries written at once by the generator
+	flatGeneratorBatchSize = 10000
+)
+
+var (
+	// flatAccountPrefix is the prefix of the flat accounts, keyed by the account hash
+	flatAccountPrefix = []byte("fa")
+	// flatStoragePrefix is the prefix of the flat storage slots, keyed by the account hash and the slot hash
+	flatStoragePrefix = []byte("fs")
+	// flatRootKey is the key of the state root of the flat state on disk
+	flatRootKey = []byte("flatRoot")
+	// flatGeneratorKey is the key of the state root and the marker of the flat state being generated on disk
+	flatGeneratorKey = []byte("flatGenerator")
+
+	// ErrFlatSnapshotUnsupported is returned when the state storage can't hold the flat state
+	ErrFlatSnapshotUnsupported = errors.New("state storage doesn't support flat snapshots")
+
+	errFlatGenerationAborted = errors.New("flat state generation aborted")
+	errFlatChunkFull         = errors.New("flat state chunk full")
+)
+
+// flatDiff is the change made to the flat state by a commit
+type flatDiff struct {
+	// accounts are the updated accounts, nil for the deleted ones
+	accounts map[types.Hash][]byte
+	// storage are the updated slots of the accounts, nil for the deleted ones
+	storage map[types.Hash]map[types.Hash][]byte
+	// destructed are the accounts whose previous storage has been wiped
+	destructed map[types.Hash]struct{}
+}
+
+func newFlatDiff() *flatDiff {
+	return &flatDiff{
+		accounts:   make(map[types.Hash][]byte),
+		storage:    make(map[types.Hash]map[types.Hash][]byte),
+		destructed: make(map[types.Hash]struct{}),
+	}
+}
+
+func (d *flatDiff) setSlot(account, slot types.Hash, value []byte) {
+	slots, ok := d.storage[account]
+	if !ok {
+		slots = make(map[types.Hash][]byte)
+		d.storage[account] = slots
+	}
+
+	slots[slot] = value
+}
+
+// diffLayer is a state kept in memory on top of its parent
+type diffLayer struct {
+	parent *diffLayer // nil if the parent is the disk layer
+	root   types.Hash
+	diff   *flatDiff
+	depth  int // number of the diff layers down to the disk layer, this one included
+}
+
+// diskLayer is the flat state persisted in the storage
+type diskLayer struct {
+	root      types.Hash
+	generated uint32 // set once the flat state has been fully generated
+
+	// marker is the position (account hash, followed by the slot hash for the storage)
+	// of the last entry written by the generator, the entries up to it are on disk.
+	// It's guarded by the flatten lock of the tree
+	marker []byte
+}
+
+func (d *diskLayer) isGenerated() bool {
+	return atomic.LoadUint32(&d.generated) == 1
+}
+
+// covers returns true if the entry at the given position is on disk, either generated or not yet
+func (d *diskLayer) covers(position []byte) bool {
+	return d.isGenerated() || (len(d.marker) > 0 && bytes.Compare(position, d.marker) <= 0)
+}
+
+// flatTree is the flat key-value view of the recent states, which avoids the trie traversal on reads.
+// The most recent states are diff layers in memory, on top of a single flat state on disk.
+// The flat state is (re)generated in background from the trie when the parent of a committed state is unknown,
+// and the reads fall back to the trie until it's complete. The progress of the generation is persisted,
+// and the diff layers are written to disk on close, so that the flat state or its generation is resumed
+// after a restart
+type flatTree struct {
+	logger  hclog.Logger
+	storage PrunableStorage
+
+	lock   sync.RWMutex
+	disk   *diskLayer
+	layers map[types.Hash]*diffLayer
+	head   types.Hash // the most recently committed state
+
+	// flattenLock serializes the writes to the flat state on disk, which are done without holding lock
+	flattenLock sync.Mutex
+
+	genAbort     chan struct{}
+	genDone      chan struct{}
+	genBatchSize int
+}
+
+func newFlatTree(logger hclog.Logger, storage PrunableStorage) *flatTree {
+	t := &flatTree{
+		logger:       logger.Named("flat"),
+		storage:      storage,
+		layers:       make(map[types.Hash]*diffLayer),
+		genBatchSize: flatGeneratorBatchSize,
+	}
+
+	// the root is only written along with a complete flat state
+	if root, ok := storage.Get(flatRootKey); ok && len(root) == types.HashLength {
+		t.disk = &diskLayer{root: types.BytesToHash(root), generated: 1}
+
+		return t
+	}
+
+	// the interrupted generation is resumed from its marker
+	if gen, ok := storage.Get(flatGeneratorKey); ok && len(gen) >= types.HashLength {
+		t.disk = &diskLayer{
+			root:   types.BytesToHash(gen[:types.HashLength]),
+			marker: append([]byte{}, gen[types.HashLength:]...),
+		}
+		t.genAbort = make(chan struct{})
+		t.genDone = make(chan struct{})
+
+		go t.generate(t.disk, false, t.genAbort, nil, t.genDone)
+	}
+
+	return t
+}
+
+// account returns the account data at the given state root, nil if the account doesn't exist.
+// ok is false if the flat state isn't available and the trie must be used instead
+func (t *flatTree) account(root, account types.Hash) (data []byte, ok bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if !t.hasState(root) {
+		return nil, false
+	}
+
+	for layer := t.layers[root]; layer != nil; layer = layer.parent {
+		if data, ok := layer.diff.accounts[account]; ok {
+			return data, true
+		}
+	}
+
+	if !t.disk.isGenerated() {
+		return nil, false
+	}
+
+	data, ok = t.storage.Get(concat(flatAccountPrefix, account.Bytes()))
+	if !ok || len(data) == 0 {
+		return nil, true
+	}
+
+	return data, true
+}
+
+// storageSlot returns the slot data of the account at the given state root, nil if the slot is empty.
+// ok is false if the flat state isn't available and the trie must be used instead
+func (t *flatTree) storageSlot(root, account, slot types.Hash) (data []byte, ok bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if !t.hasState(root) {
+		return nil, false
+	}
+
+	for layer := t.layers[root]; layer != nil; layer = layer.parent {
+		if data, ok := layer.diff.storage[account][slot]; ok {
+			return data, true
+		}
+
+		if _, ok := layer.diff.destructed[account]; ok {
+			return nil, true
+		}
+	}
+
+	if !t.disk.isGenerated() {
+		return nil, false
+	}
+
+	data, ok = t.storage.Get(flatStorageKey(account, slot))
+	if !ok || len(data) == 0 {
+		return nil, true
+	}
+
+	return data, true
+}
+
+// hasState returns true if the state with the given root is in the tree
+func (t *flatTree) hasState(root types.Hash) bool {
+	if t.disk == nil {
+		return false
+	}
+
+	if t.disk.root == root {
+		return true
+	}
+
+	_, ok := t.layers[root]
+
+	return ok
+}
+
+// update adds the state with the given root, made by the diff on top of the parent state
+func (t *flatTree) update(root, parent types.Hash, diff *flatDiff) {
+	t.lock.Lock()
+
+	if t.hasState(root) {
+		t.lock.Unlock()
+
+		return
+	}
+
+	var parentLayer *diffLayer
+
+	if t.disk == nil || (t.disk.root != parent && t.layers[parent] == nil) {
+		t.rebuild(parent)
+	} else {
+		parentLayer = t.layers[parent]
+	}
+
+	layer := &diffLayer{
+		parent: parentLayer,
+		root:   root,
+		diff:   diff,
+		depth:  1,
+	}
+
+	if parentLayer != nil {
+		layer.depth = parentLayer.depth + 1
+	}
+
+	t.layers[root] = layer
+	t.head = root
+
+	t.lock.Unlock()
+
+	// the layers beyond the limit are written to disk, even while the flat state is being generated
+	if layer.depth > maxFlatDiffLayers {
+		t.flatten(root, maxFlatDiffLayers)
+	}
+}
+
+// rebuild drops the current flat state and starts generating the one with the given root
+func (t *flatTree) rebuild(root types.Hash) {
+	prevDone := t.genDone
+	if t.genAbort != nil {
+		close(t.genAbort)
+	}
+
+	t.disk = &diskLayer{root: root}
+	t.layers = make(map[types.Hash]*diffLayer)
+	t.genAbort = make(chan struct{})
+	t.genDone = make(chan struct{})
+
+	go t.generate(t.disk, true, t.genAbort, prevDone, t.genDone)
+}
+
+// flatten writes the bottom diff layers of the state with the given root to disk,
+// until the state is at most maxDepth layers above the disk layer.
+// The layers which don't descend from the written ones are dropped
+func (t *flatTree) flatten(root types.Hash, maxDepth int) {
+	t.flattenLock.Lock()
+	defer t.flattenLock.Unlock()
+
+	for {
+		t.lock.Lock()
+
+		layer, disk := t.layers[root], t.disk
+		if layer == nil || disk == nil || layer.depth <= maxDepth {
+			t.lock.Unlock()
+
+			return
+		}
+
+		bottom := layer
+		for bottom.parent != nil {
+			bottom = bottom.parent
+		}
+
+		// the disk layer moves to the bottom layer, which is kept in memory until it's written,
+		// so that the reads don't see the partially written flat state
+		for r, l := range t.layers {
+			base := l
+			for base.parent != nil {
+				base = base.parent
+			}
+
+			if base != bottom {
+				delete(t.layers, r)
+			}
+		}
+
+		disk.root = bottom.root
+
+		t.lock.Unlock()
+
+		err := t.writeDiff(disk, bottom)
+
+		t.lock.Lock()
+
+		if t.disk != disk {
+			// the flat state has been rebuilt meanwhile
+			t.lock.Unlock()
+
+			return
+		}
+
+		if err != nil {
+			t.logger.Error("failed to write flat state, dropping it", "err", err)
+
+			t.disk = nil
+			t.layers = make(map[types.Hash]*diffLayer)
+			t.lock.Unlock()
+
+			return
+		}
+
+		delete(t.layers, bottom.root)
+
+		for _, l := range t.layers {
+			if l.parent == bottom {
+				l.parent = nil
+			}
+
+			l.depth--
+		}
+
+		t.lock.Unlock()
+	}
+}
+
+// writeDiff writes the diff of the layer to the flat state on disk. While the flat state is being generated,
+// only the entries already generated are written, the others are generated from the trie of the new root
+func (t *flatTree) writeDiff(disk *diskLayer, layer *diffLayer) error {
+	// the root is removed first, so that a partially written flat state is regenerated
+	removed := [][]byte{flatRootKey}
+
+	for account := range layer.diff.destructed {
+		if err := t.storage.ForEachKey(concat(flatStoragePrefix, account.Bytes()), func(k []byte) bool {
+			removed = append(removed, k)
+
+			return true
+		}); err != nil {
+			return err
+		}
+	}
+
+	for account, data := range layer.diff.accounts {
+		if data == nil {
+			removed = append(removed, concat(flatAccountPrefix, account.Bytes()))
+		}
+	}
+
+	for account, slots := range layer.diff.storage {
+		for slot, data := range slots {
+			if data == nil {
+				removed = append(removed, flatStorageKey(account, slot))
+			}
+		}
+	}
+
+	if err := t.storage.Delete(removed); err != nil {
+		return err
+	}
+
+	batch := t.storage.Batch()
+
+	for account, data := range layer.diff.accounts {
+		if data != nil && disk.covers(account.Bytes()) {
+			batch.Put(concat(flatAccountPrefix, account.Bytes()), data)
+		}
+	}
+
+	for account, slots := range layer.diff.storage {
+		for slot, data := range slots {
+			if data != nil && disk.covers(concat(account.Bytes(), slot.Bytes())) {
+				batch.Put(flatStorageKey(account, slot), data)
+			}
+		}
+	}
+
+	if disk.isGenerated() {
+		batch.Put(flatRootKey, layer.root.Bytes())
+	} else {
+		batch.Put(flatGeneratorKey, concat(layer.root.Bytes(), disk.marker))
+	}
+
+	batch.Write()
+
+	return nil
+}
+
+// generate writes the flat state of the disk layer from its trie,
+// the previous flat state is wiped first unless the generation is resumed
+func (t *flatTree) generate(disk *diskLayer, wipe bool, abort, prevDone, done chan struct{}) {
+	defer close(done)
+
+	// wait for the previous generation to stop writing
+	if prevDone != nil {
+		<-prevDone
+	}
+
+	t.logger.Info("generating flat state", "root", disk.root, "resumed", !wipe)
+
+	err := t.generateState(disk, wipe, abort)
+	if errors.Is(err, errFlatGenerationAborted) {
+		return
+	}
+
+	if err != nil {
+		t.logger.Error("failed to generate flat state", "root", disk.root, "err", err)
+
+		t.lock.Lock()
+		defer t.lock.Unlock()
+
+		// the state is regenerated on the next commit
+		if t.disk == disk {
+			t.disk = nil
+			t.layers = make(map[types.Hash]*diffLayer)
+		}
+
+		return
+	}
+
+	t.logger.Info("flat state generated", "root", disk.root)
+}
+
+func (t *flatTree) generateState(disk *diskLayer, wipe bool, abort chan struct{}) error {
+	if wipe {
+		if err := t.wipe(disk); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-abort:
+			return errFlatGenerationAborted
+		default:
+		}
+
+		// the disk layer moves up while the flat state is generated, as the diff layers are flattened
+		t.flattenLock.Lock()
+		root, marker := disk.root, disk.marker
+		t.flattenLock.Unlock()
+
+		batch, next, complete, err := t.generateChunk(root, marker, abort)
+		if err != nil {
+			return err
+		}
+
+		t.flattenLock.Lock()
+
+		// the chunk is generated again from the trie of the new root
+		if disk.root != root {
+			t.flattenLock.Unlock()
+
+			continue
+		}
+
+		if complete {
+			batch.Put(flatRootKey, root.Bytes())
+			batch.Put(flatGeneratorKey, nil)
+		} else {
+			batch.Put(flatGeneratorKey, concat(root.Bytes(), next))
+		}
+
+		batch.Write()
+
+		disk.marker = next
+
+		if complete {
+			atomic.StoreUint32(&disk.generated, 1)
+		}
+
+		t.flattenLock.Unlock()
+
+		if complete {
+			return nil
+		}
+	}
+}
+
+// wipe removes the previous flat state, and marks the generation of the new one as started
+func (t *flatTree) wipe(disk *diskLayer) error {
+	t.flattenLock.Lock()
+	defer t.flattenLock.Unlock()
+
+	// the root and the marker are removed first, so that the partially removed flat state isn't reused
+	if err := t.storage.Delete([][]byte{flatRootKey, flatGeneratorKey}); err != nil {
+		return err
+	}
+
+	removed := make([][]byte, 0)
+
+	for _, prefix := range [][]byte{flatAccountPrefix, flatStoragePrefix} {
+		if err := t.storage.ForEachKey(prefix, func(k []byte) bool {
+			removed = append(removed, k)
+
+			return true
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := t.storage.Delete(removed); err != nil {
+		return err
+	}
+
+	batch := t.storage.Batch()
+	batch.Put(flatGeneratorKey, disk.root.Bytes())
+	batch.Write()
+
+	return nil
+}
+
+// generateChunk returns the batch of the next flat entries of the trie with the given root, after the marker.
+// The next marker is the position of the last entry of the batch, complete is set if the trie has no more entries
+func (t *flatTree) generateChunk(
+	root types.Hash,
+	marker []byte,
+	abort chan struct{},
+) (batch Batch, next []byte, complete bool, err error) {
+	var accountOrigin, slotOrigin []byte
+
+	if len(marker) >= types.HashLength {
+		accountOrigin = marker[:types.HashLength]
+		slotOrigin = marker[types.HashLength:]
+	}
+
+	batch = t.storage.Batch()
+	next = marker
+	count := 0
+
+	put := func(k, v, position []byte) error {
+		select {
+		case <-abort:
+			return errFlatGenerationAborted
+		default:
+		}
+
+		// the walk starts at the marker, whose entry is already written
+		if len(marker) > 0 && bytes.Compare(position, marker) <= 0 {
+			return nil
+		}
+
+		batch.Put(k, v)
+		next = position
+
+		if count++; count >= t.genBatchSize {
+			return errFlatChunkFull
+		}
+
+		return nil
+	}
+
+	err = walkTrie(root, accountOrigin, t.storage, func(key, value []byte) error {
+		account := types.BytesToHash(key)
+
+		var acc state.Account
+		if err := acc.UnmarshalRlp(value); err != nil {
+			return err
+		}
+
+		if err := put(concat(flatAccountPrefix, key), value, account.Bytes()); err != nil {
+			return err
+		}
+
+		// the storage of the account of the marker is resumed from its last generated slot
+		var origin []byte
+		if bytes.Equal(key, accountOrigin) {
+			origin = slotOrigin
+		}
+
+		return walkTrie(acc.Root, origin, t.storage, func(slot, value []byte) error {
+			return put(flatStorageKey(account, types.BytesToHash(slot)), value, concat(key, slot))
+		})
+	})
+
+	switch {
+	case errors.Is(err, errFlatChunkFull):
+		return batch, next, false, nil
+	case err != nil:
+		return nil, nil, false, err
+	}
+
+	return batch, nil, true, nil
+}
+
+// close stops the generation of the flat state, if any,
+// and writes the diff layers of the most recent state to disk
+func (t *flatTree) close() {
+	t.lock.Lock()
+
+	done := t.genDone
+	if t.genAbort != nil {
+		close(t.genAbort)
+		t.genAbort = nil
+	}
+
+	head := t.head
+
+	t.lock.Unlock()
+
+	if done != nil {
+		<-done
+	}
+
+	t.flatten(head, 0)
+}
+
+func flatStorageKey(account, slot types.Hash) []byte {
+	return concat(concat(flatStoragePrefix, account.Bytes()), slot.Bytes())
+}
+
+// errStopWalk stops the walk of the trie without an error
+var errStopWalk = errors.New("stop walk")
+
+// walkTrie calls fn, in ascending order of the keys, for every leaf of the stored trie
+// with the given root whose key is not lower than the origin
+func walkTrie(root types.Hash, origin []byte, storage Storage, fn func(key, value []byte) error) error {
+	if root == types.EmptyRootHash {
+		return nil
+	}
+
+	node, ok, err := GetNode(root.Bytes(), storage)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("trie node %s not found", root)
+	}
+
+	var originPath []byte
+	if origin != nil {
+		originPath = keyPath(origin)
+	}
+
+	err = walkNode(node, nil, originPath, storage, fn)
+	if errors.Is(err, errStopWalk) {
+		return nil
+	}
+
+	return err
+}
+
+func walkNode(node Node, path, origin []byte, storage Storage, fn func(key, value []byte) error) error {
+	// the subtree is skipped if all its keys are lower than the origin
+	if comparePath(path, origin) < 0 {
+		return nil
+	}
+
+	switch n := node.(type) {
+	case nil:
+		return nil
+
+	case *FullNode:
+		if err := walkNode(n.value, path, origin, storage, fn); err != nil {
+			return err
+		}
+
+		for i, child := range n.children {
+			if err := walkNode(child, concat(path, []byte{byte(i)}), origin, storage, fn); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case *ShortNode:
+		return walkNode(n.child, concat(path, n.key), origin, storage, fn)
+
+	case *ValueNode:
+		if n.hash {
+			child, ok, err := GetNode(n.buf, storage)
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				return fmt.Errorf("trie node %s not found", types.BytesToHash(n.buf))
+			}
+
+			return walkNode(child, path, origin, storage, fn)
+		}
+
+		return fn(hexToKeyBytes(path), n.buf)
+	}
+
+	return fmt.Errorf("unknown node type %T", node)
+}
+
+// keyPath returns the nibbles of the key without the terminator
+func keyPath(key []byte) []byte {
+	path := bytesToHexNibbles(key)
+
+	return path[:len(path)-1]
+}
+
+// comparePath compares the nibble path with the prefix of the same length of the key path,
+// the terminator of the path is ignored
+func comparePath(path, key []byte) int {
+	if hasTerminator(path) {
+		path = path[:len(path)-1]
+	}
+
+	if len(key) > len(path) {
+		key = key[:len(path)]
+	}
+
+	return bytes.Compare(path, key)
+}
+
+// hexToKeyBytes packs the nibbles of the path into bytes, the terminator is ignored
+func hexToKeyBytes(hex []byte) []byte {
+	if hasTerminator(hex) {
+		hex = hex[:len(hex)-1]
+	}
+
+	key := make([]byte, len(hex)/2)
+	for i := range key {
+		key[i] = hex[2*i]<<4 | hex[2*i+1]
+	}
+
+	return key
+}
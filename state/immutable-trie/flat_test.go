@@ -0,0 +1,362 @@
+package itrie
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newton2049/favo-chain/chain"
+	"github.com/newton2049/favo-chain/crypto"
+	"github.com/newton2049/favo-chain/state"
+	"github.com/newton2049/favo-chain/types"
+)
+
+// waitFlatGenerated waits for the running generation of the flat state
+func waitFlatGenerated(t *testing.T, st *State) {
+	t.Helper()
+
+	st.flat.lock.RLock()
+	done := st.flat.genDone
+	st.flat.lock.RUnlock()
+
+	require.NotNil(t, done)
+	<-done
+
+	st.flat.lock.RLock()
+	defer st.flat.lock.RUnlock()
+
+	require.NotNil(t, st.flat.disk)
+	require.True(t, st.flat.disk.isGenerated())
+}
+
+// assertFlatState checks that the flat state at the given root is available and matches the trie
+func assertFlatState(t *testing.T, st *State, root types.Hash, value int64) {
+	t.Helper()
+
+	var (
+		accountHash = types.BytesToHash(crypto.Keccak256(prunerTestAddr.Bytes()))
+		slotHash    = types.BytesToHash(crypto.Keccak256(prunerTestSlot.Bytes()))
+	)
+
+	data, ok := st.flat.account(root, accountHash)
+	require.True(t, ok)
+
+	var account state.Account
+	require.NoError(t, account.UnmarshalRlp(data))
+	assert.Equal(t, big.NewInt(value), account.Balance)
+
+	data, ok = st.flat.storageSlot(root, accountHash, slotHash)
+	require.True(t, ok)
+	assert.Equal(t, types.BytesToHash(big.NewInt(value).Bytes()), decodeStorageValue(data))
+
+	// the trie returns the same state
+	snap, err := st.NewSnapshotAt(root)
+	require.NoError(t, err)
+
+	trieAccount, ok := snap.(*Snapshot).trie.Get(accountHash.Bytes(), st.storage)
+	require.True(t, ok)
+
+	var expected state.Account
+	require.NoError(t, expected.UnmarshalRlp(trieAccount))
+	assert.Equal(t, expected.Root, account.Root)
+}
+
+func TestFlatTree_DiffLayers(t *testing.T) {
+	t.Parallel()
+
+	st := NewState(NewMemoryStorage())
+	require.NoError(t, st.EnableFlatSnapshot(hclog.NewNullLogger()))
+
+	defer st.Close()
+
+	snap := st.NewSnapshot()
+	roots := make([]types.Hash, 0, 3)
+
+	for i := int64(1); i <= 3; i++ {
+		var root types.Hash
+
+		snap, root = commitTestBlock(t, snap, i)
+		roots = append(roots, root)
+	}
+
+	waitFlatGenerated(t, st)
+
+	for i, root := range roots {
+		assertFlatState(t, st, root, int64(i+1))
+	}
+
+	// a fork from the first block is a diff layer as well
+	forkSnap, err := st.NewSnapshotAt(roots[0])
+	require.NoError(t, err)
+
+	_, forkRoot := commitTestBlock(t, forkSnap, 10)
+	assertFlatState(t, st, forkRoot, 10)
+
+	// the deleted account doesn't exist anymore, as well as its storage
+	_, deletedRoot := snap.Commit([]*state.Object{
+		{
+			Address: prunerTestAddr,
+			Deleted: true,
+		},
+	})
+
+	accountHash := types.BytesToHash(crypto.Keccak256(prunerTestAddr.Bytes()))
+
+	data, ok := st.flat.account(types.BytesToHash(deletedRoot), accountHash)
+	assert.True(t, ok)
+	assert.Nil(t, data)
+
+	data, ok = st.flat.storageSlot(
+		types.BytesToHash(deletedRoot),
+		accountHash,
+		types.BytesToHash(crypto.Keccak256(prunerTestSlot.Bytes())),
+	)
+	assert.True(t, ok)
+	assert.Nil(t, data)
+
+	// the unknown states fall back to the trie
+	_, ok = st.flat.account(types.StringToHash("1"), accountHash)
+	assert.False(t, ok)
+}
+
+func TestFlatTree_Generate(t *testing.T) {
+	t.Parallel()
+
+	st := NewState(NewMemoryStorage())
+
+	// the state is written before the flat snapshot is enabled
+	snap := st.NewSnapshot()
+	for i := int64(1); i <= 3; i++ {
+		snap, _ = commitTestBlock(t, snap, i)
+	}
+
+	require.NoError(t, st.EnableFlatSnapshot(hclog.NewNullLogger()))
+
+	defer st.Close()
+
+	// every entry is generated in its own chunk
+	st.flat.genBatchSize = 1
+
+	// the flat state of the parent is generated on the first commit
+	snap, root := commitTestBlock(t, snap, 4)
+
+	waitFlatGenerated(t, st)
+	assertFlatState(t, st, root, 4)
+
+	// the reads of the snapshot are served by the flat state
+	account, err := snap.GetAccount(prunerTestAddr)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(4), account.Balance)
+	assert.Equal(
+		t,
+		types.BytesToHash(big.NewInt(4).Bytes()),
+		snap.GetStorage(prunerTestAddr, account.Root, prunerTestSlot),
+	)
+}
+
+func TestFlatTree_Flatten(t *testing.T) {
+	t.Parallel()
+
+	storage := NewMemoryStorage()
+
+	st := NewState(storage)
+	require.NoError(t, st.EnableFlatSnapshot(hclog.NewNullLogger()))
+
+	defer st.Close()
+
+	snap, _ := commitTestBlock(t, st.NewSnapshot(), 1)
+	waitFlatGenerated(t, st)
+
+	roots := make([]types.Hash, 0, maxFlatDiffLayers+2)
+
+	for i := int64(2); i <= maxFlatDiffLayers+3; i++ {
+		var root types.Hash
+
+		snap, root = commitTestBlock(t, snap, i)
+		roots = append(roots, root)
+	}
+
+	// the layers beyond the limit are written to the disk
+	assert.Len(t, st.flat.layers, maxFlatDiffLayers)
+
+	diskRoot, ok := storage.Get(flatRootKey)
+	require.True(t, ok)
+	assert.Equal(t, roots[len(roots)-maxFlatDiffLayers-1], types.BytesToHash(diskRoot))
+
+	assertFlatState(t, st, types.BytesToHash(diskRoot), int64(len(roots)-maxFlatDiffLayers+1))
+	assertFlatState(t, st, roots[len(roots)-1], int64(len(roots)+1))
+
+	// the flattened states aren't available anymore
+	_, ok = st.flat.account(roots[0], types.BytesToHash(crypto.Keccak256(prunerTestAddr.Bytes())))
+	assert.False(t, ok)
+
+	// the flat state on disk is reused after a restart
+	restarted := NewState(storage)
+	require.NoError(t, restarted.EnableFlatSnapshot(hclog.NewNullLogger()))
+
+	defer restarted.Close()
+
+	assertFlatState(t, restarted, types.BytesToHash(diskRoot), int64(len(roots)-maxFlatDiffLayers+1))
+}
+
+func TestFlatTree_ResumeGeneration(t *testing.T) {
+	t.Parallel()
+
+	storage := NewMemoryStorage()
+
+	st := NewState(storage)
+
+	snap := st.NewSnapshot()
+
+	var root types.Hash
+
+	for i := int64(1); i <= 3; i++ {
+		snap, root = commitTestBlock(t, snap, i)
+	}
+
+	accountHash := types.BytesToHash(crypto.Keccak256(prunerTestAddr.Bytes()))
+
+	trieSnap, err := st.NewSnapshotAt(root)
+	require.NoError(t, err)
+
+	account, ok := trieSnap.(*Snapshot).trie.Get(accountHash.Bytes(), storage)
+	require.True(t, ok)
+
+	// the generation has been interrupted after the account, before its storage
+	sentinel := concat(flatAccountPrefix, types.StringToHash("1").Bytes())
+
+	storage.Put(sentinel, []byte{0x1})
+	storage.Put(concat(flatAccountPrefix, accountHash.Bytes()), account)
+	storage.Put(flatGeneratorKey, concat(root.Bytes(), accountHash.Bytes()))
+
+	require.NoError(t, st.EnableFlatSnapshot(hclog.NewNullLogger()))
+
+	defer st.Close()
+
+	waitFlatGenerated(t, st)
+	assertFlatState(t, st, root, 3)
+
+	// the entries generated before the restart are kept
+	_, ok = storage.Get(sentinel)
+	assert.True(t, ok)
+
+	diskRoot, ok := storage.Get(flatRootKey)
+	require.True(t, ok)
+	assert.Equal(t, root, types.BytesToHash(diskRoot))
+
+	marker, _ := storage.Get(flatGeneratorKey)
+	assert.Empty(t, marker)
+}
+
+func TestFlatTree_FlattenWhileGenerating(t *testing.T) {
+	t.Parallel()
+
+	storage := NewMemoryStorage()
+
+	st := NewState(storage)
+	require.NoError(t, st.EnableFlatSnapshot(hclog.NewNullLogger()))
+
+	snap, root := commitTestBlock(t, st.NewSnapshot(), 1)
+	waitFlatGenerated(t, st)
+
+	// the flat state is being generated, without any entry written yet
+	st.flat.lock.Lock()
+	st.flat.disk = &diskLayer{root: root}
+	st.flat.layers = make(map[types.Hash]*diffLayer)
+	st.flat.lock.Unlock()
+
+	roots := make([]types.Hash, 0, maxFlatDiffLayers+2)
+
+	for i := int64(2); i <= maxFlatDiffLayers+3; i++ {
+		snap, root = commitTestBlock(t, snap, i)
+		roots = append(roots, root)
+	}
+
+	// the layers beyond the limit are written to the disk anyway, along with the generator marker
+	assert.Len(t, st.flat.layers, maxFlatDiffLayers)
+
+	gen, ok := storage.Get(flatGeneratorKey)
+	require.True(t, ok)
+	assert.Equal(t, roots[len(roots)-maxFlatDiffLayers-1].Bytes(), gen)
+
+	// the remaining layers are written on close, and the generation is resumed from the head after a restart
+	st.Close()
+
+	gen, ok = storage.Get(flatGeneratorKey)
+	require.True(t, ok)
+	assert.Equal(t, root.Bytes(), gen)
+
+	restarted := NewState(storage)
+	require.NoError(t, restarted.EnableFlatSnapshot(hclog.NewNullLogger()))
+
+	defer restarted.Close()
+
+	waitFlatGenerated(t, restarted)
+	assertFlatState(t, restarted, root, int64(len(roots)+1))
+}
+
+func TestFlatTree_RecreatedAccount(t *testing.T) {
+	t.Parallel()
+
+	st := NewState(NewMemoryStorage())
+	require.NoError(t, st.EnableFlatSnapshot(hclog.NewNullLogger()))
+
+	defer st.Close()
+
+	snap, root := commitTestBlock(t, st.NewSnapshot(), 1)
+	waitFlatGenerated(t, st)
+	assertFlatState(t, st, root, 1)
+
+	txn := state.NewTxn(snap)
+	assert.Equal(t, types.BytesToHash(big.NewInt(1).Bytes()), txn.GetState(prunerTestAddr, prunerTestSlot))
+
+	// the account re-created in the block has an empty storage, whatever the flat state holds
+	txn.CreateAccount(prunerTestAddr)
+	assert.Equal(t, types.ZeroHash, txn.GetState(prunerTestAddr, prunerTestSlot))
+
+	snap, _ = snap.Commit(txn.Commit(false))
+
+	account, err := snap.GetAccount(prunerTestAddr)
+	require.NoError(t, err)
+	assert.Equal(t, types.EmptyRootHash, account.Root)
+	assert.Equal(t, types.ZeroHash, snap.GetStorage(prunerTestAddr, account.Root, prunerTestSlot))
+}
+
+func TestFlatTree_StateOverride(t *testing.T) {
+	t.Parallel()
+
+	st := NewState(NewMemoryStorage())
+	require.NoError(t, st.EnableFlatSnapshot(hclog.NewNullLogger()))
+
+	defer st.Close()
+
+	snap, _ := commitTestBlock(t, st.NewSnapshot(), 1)
+	waitFlatGenerated(t, st)
+
+	otherSlot := types.StringToHash("2")
+
+	// the state override replaces the whole storage, the slots left out are empty
+	transition := state.NewTransition(chain.ForksInTime{}, snap, state.NewTxn(snap))
+	require.NoError(t, transition.WithStateOverride(types.StateOverride{
+		prunerTestAddr: {
+			State: map[types.Hash]types.Hash{otherSlot: otherSlot},
+		},
+	}))
+
+	assert.Equal(t, types.ZeroHash, transition.GetStorage(prunerTestAddr, prunerTestSlot))
+	assert.Equal(t, otherSlot, transition.GetStorage(prunerTestAddr, otherSlot))
+
+	// unlike the state diff override
+	transition = state.NewTransition(chain.ForksInTime{}, snap, state.NewTxn(snap))
+	require.NoError(t, transition.WithStateOverride(types.StateOverride{
+		prunerTestAddr: {
+			StateDiff: map[types.Hash]types.Hash{otherSlot: otherSlot},
+		},
+	}))
+
+	assert.Equal(t, types.BytesToHash(big.NewInt(1).Bytes()), transition.GetStorage(prunerTestAddr, prunerTestSlot))
+	assert.Equal(t, otherSlot, transition.GetStorage(prunerTestAddr, otherSlot))
+}
@@ -0,0 +1,156 @@
+package itrie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/umbracle/fastrlp"
+
+	"github.com/newton2049/favo-chain/crypto"
+	"github.com/newton2049/favo-chain/state"
+	"github.com/newton2049/favo-chain/types"
+)
+
+var (
+	// ErrProofNodeNotFound is returned when a node on the path of the key is missing
+	ErrProofNodeNotFound = errors.New("trie node of the proof not found")
+)
+
+// AccountProof is the merkle proof of an account and of its storage slots (EIP-1186)
+type AccountProof struct {
+	// Account is the proven account, nil if it doesn't exist
+	Account *state.Account
+	// Proof are the account trie nodes from the state root to the account
+	Proof [][]byte
+	// StorageProofs are the proofs of the requested slots in the account storage trie
+	StorageProofs []*StorageProof
+}
+
+// StorageProof is the merkle proof of a storage slot
+type StorageProof struct {
+	Key types.Hash
+	// Value is the RLP encoded slot value, nil if the slot is empty
+	Value []byte
+	// Proof are the storage trie nodes from the storage root to the slot
+	Proof [][]byte
+}
+
+// GetProof returns the proof of the account and of the given storage slots at the state root
+func (s *State) GetProof(root types.Hash, addr types.Address, keys []types.Hash) (*AccountProof, error) {
+	accountProof, data, err := Prove(root, crypto.Keccak256(addr.Bytes()), s.storage)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AccountProof{
+		Proof:         accountProof,
+		StorageProofs: make([]*StorageProof, 0, len(keys)),
+	}
+
+	storageRoot := types.EmptyRootHash
+
+	if data != nil {
+		var account state.Account
+		if err := account.UnmarshalRlp(data); err != nil {
+			return nil, err
+		}
+
+		res.Account = &account
+		storageRoot = account.Root
+	}
+
+	for _, key := range keys {
+		proof, value, err := Prove(storageRoot, crypto.Keccak256(key.Bytes()), s.storage)
+		if err != nil {
+			return nil, err
+		}
+
+		res.StorageProofs = append(res.StorageProofs, &StorageProof{
+			Key:   key,
+			Value: value,
+			Proof: proof,
+		})
+	}
+
+	return res, nil
+}
+
+// Prove returns the RLP encoded stored nodes on the path from the root to the key, and the value of the key.
+// The value is nil if the key doesn't exist, in which case the proof ends with the node proving its absence
+func Prove(root types.Hash, key []byte, storage Storage) ([][]byte, []byte, error) {
+	if root == types.EmptyRootHash {
+		return [][]byte{}, nil, nil
+	}
+
+	return proveStored(root.Bytes(), bytesToHexNibbles(key), storage, [][]byte{})
+}
+
+// VerifyProof checks the proof of the key against the root and returns the value of the key,
+// nil if the proof shows that the key doesn't exist
+func VerifyProof(root types.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	proofStorage := NewMemoryStorage()
+
+	for _, node := range proof {
+		proofStorage.Put(crypto.Keccak256(node), node)
+	}
+
+	_, value, err := Prove(root, key, proofStorage)
+
+	return value, err
+}
+
+func proveStored(hash []byte, key []byte, storage Storage, proof [][]byte) ([][]byte, []byte, error) {
+	data, ok := storage.Get(hash)
+	if !ok || len(data) == 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrProofNodeNotFound, types.BytesToHash(hash))
+	}
+
+	p := &fastrlp.Parser{}
+
+	v, err := p.Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	node, err := decodeNode(v, storage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return proveNode(node, key, storage, append(proof, data))
+}
+
+func proveNode(node Node, key []byte, storage Storage, proof [][]byte) ([][]byte, []byte, error) {
+	switch n := node.(type) {
+	case nil:
+		return proof, nil, nil
+
+	case *ValueNode:
+		if n.hash {
+			return proveStored(n.buf, key, storage, proof)
+		}
+
+		if len(key) == 0 {
+			return proof, n.buf, nil
+		}
+
+		return proof, nil, nil
+
+	case *ShortNode:
+		if !bytes.HasPrefix(key, n.key) {
+			return proof, nil, nil
+		}
+
+		return proveNode(n.child, key[len(n.key):], storage, proof)
+
+	case *FullNode:
+		if len(key) == 0 {
+			return proveNode(n.value, key, storage, proof)
+		}
+
+		return proveNode(n.getEdge(key[0]), key[1:], storage, proof)
+	}
+
+	return nil, nil, fmt.Errorf("unknown node type %T", node)
+}
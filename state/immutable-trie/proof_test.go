@@ -0,0 +1,110 @@
+package itrie
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newton2049/favo-chain/crypto"
+	"github.com/newton2049/favo-chain/state"
+	"github.com/newton2049/favo-chain/types"
+)
+
+func TestProof_ProveAndVerify(t *testing.T) {
+	t.Parallel()
+
+	storage := NewMemoryStorage()
+
+	txn := NewTrie().Txn(storage)
+	txn.batch = storage.Batch()
+
+	values := make(map[string][]byte)
+
+	for i := 0; i < 100; i++ {
+		key := hashit(big.NewInt(int64(i)).Bytes())
+		value := big.NewInt(int64(i + 1)).Bytes()
+
+		txn.Insert(key, value)
+		values[string(key)] = value
+	}
+
+	rawRoot, err := txn.Hash()
+	require.NoError(t, err)
+
+	root := types.BytesToHash(rawRoot)
+
+	for key, value := range values {
+		proof, res, err := Prove(root, []byte(key), storage)
+		require.NoError(t, err)
+		assert.Equal(t, value, res)
+
+		verified, err := VerifyProof(root, []byte(key), proof)
+		require.NoError(t, err)
+		assert.Equal(t, value, verified)
+	}
+
+	// the absence of a key is proven as well
+	missingKey := hashit([]byte("missing"))
+
+	proof, res, err := Prove(root, missingKey, storage)
+	require.NoError(t, err)
+	assert.Nil(t, res)
+	assert.NotEmpty(t, proof)
+
+	verified, err := VerifyProof(root, missingKey, proof)
+	require.NoError(t, err)
+	assert.Nil(t, verified)
+
+	// an incomplete proof is rejected
+	proof, _, err = Prove(root, hashit(big.NewInt(1).Bytes()), storage)
+	require.NoError(t, err)
+
+	_, err = VerifyProof(root, hashit(big.NewInt(1).Bytes()), proof[:len(proof)-1])
+	assert.ErrorIs(t, err, ErrProofNodeNotFound)
+}
+
+func TestProof_GetProof(t *testing.T) {
+	t.Parallel()
+
+	st := NewState(NewMemoryStorage())
+
+	_, root := commitTestBlock(t, st.NewSnapshot(), 5)
+
+	emptySlot := types.StringToHash("2")
+
+	res, err := st.GetProof(root, prunerTestAddr, []types.Hash{prunerTestSlot, emptySlot})
+	require.NoError(t, err)
+	require.NotNil(t, res.Account)
+	assert.Equal(t, big.NewInt(5), res.Account.Balance)
+
+	// the account proof is verified against the state root
+	data, err := VerifyProof(root, crypto.Keccak256(prunerTestAddr.Bytes()), res.Proof)
+	require.NoError(t, err)
+
+	var account state.Account
+	require.NoError(t, account.UnmarshalRlp(data))
+	assert.Equal(t, res.Account.Root, account.Root)
+
+	// the storage proofs are verified against the storage root of the account
+	require.Len(t, res.StorageProofs, 2)
+
+	value, err := VerifyProof(account.Root, crypto.Keccak256(prunerTestSlot.Bytes()), res.StorageProofs[0].Proof)
+	require.NoError(t, err)
+	assert.Equal(t, res.StorageProofs[0].Value, value)
+	assert.Equal(t, types.BytesToHash(big.NewInt(5).Bytes()), decodeStorageValue(value))
+
+	assert.Nil(t, res.StorageProofs[1].Value)
+
+	// the proof of a missing account is the proof of its absence
+	res, err = st.GetProof(root, types.StringToAddress("2"), []types.Hash{prunerTestSlot})
+	require.NoError(t, err)
+	assert.Nil(t, res.Account)
+	assert.NotEmpty(t, res.Proof)
+	assert.Empty(t, res.StorageProofs[0].Proof)
+
+	// the state must exist
+	_, err = st.GetProof(types.StringToHash("1"), prunerTestAddr, nil)
+	assert.ErrorIs(t, err, ErrProofNodeNotFound)
+}
@@ -0,0 +1,726 @@
+package itrie
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/newton2049/favo-chain/state"
+	"github.com/newton2049/favo-chain/types"
+)
+
+const (
+	// pruneDeleteChunkSize is the number of nodes removed from the storage at once
+	pruneDeleteChunkSize = 10000
+)
+
+var (
+	// refCountPrefix is the prefix of the reference counts of the nodes, keyed by the node hash
+	refCountPrefix = []byte("rc")
+	// pinnedRootsPrefix is the prefix of the state roots pinned by the blocks, keyed by the block number
+	pinnedRootsPrefix = []byte("rp")
+	// prunedHeadKey is the key of the last head processed by the pruner
+	prunedHeadKey = []byte("prunedHead")
+)
+
+var (
+	// ErrStorageNotPrunable is returned when the state storage doesn't support the pruning
+	ErrStorageNotPrunable = errors.New("state storage is not prunable")
+	// ErrPruningStopped is returned when the pruner is stopped during a prune
+	ErrPruningStopped = errors.New("pruning stopped")
+)
+
+// PrunerConfig is the configuration of the state pruning
+type PrunerConfig struct {
+	// Retention is the number of the most recent blocks whose state is kept
+	Retention uint64
+	// CheckpointInterval is the interval of the blocks whose state is kept forever,
+	// 0 disables the checkpoints
+	CheckpointInterval uint64
+}
+
+type prunerBlockchain interface {
+	// GetHeaderByNumber gets a header using the provided number
+	GetHeaderByNumber(uint64) (*types.Header, bool)
+}
+
+// Pruner removes the trie nodes which are not reachable from the state of the retained blocks.
+// The nodes are reference counted: each block pins its state root, which is unpinned once the block
+// leaves the retention window, and the nodes which aren't referenced anymore are removed along with
+// the references they hold. The nodes created since the recent heads are tracked as well,
+// so that the states of the blocks which are never inserted are removed once they are old enough.
+// The reference counts are built by a full mark and sweep of the state, when they are missing or stale
+type Pruner struct {
+	logger     hclog.Logger
+	state      *State
+	storage    PrunableStorage
+	blockchain prunerBlockchain
+	config     *PrunerConfig
+
+	// epochs are the nodes created since each of the recent heads, the oldest first
+	epochs      [][]types.Hash
+	lastHead    uint64
+	initialized bool
+	stopped     uint32
+}
+
+// NewPruner creates a pruner of the given state and enables the reference counting of the written nodes
+func NewPruner(
+	logger hclog.Logger,
+	st *State,
+	blockchain prunerBlockchain,
+	config *PrunerConfig,
+) (*Pruner, error) {
+	storage, ok := st.storage.(PrunableStorage)
+	if !ok {
+		return nil, ErrStorageNotPrunable
+	}
+
+	if config.Retention == 0 {
+		return nil, errors.New("state retention must be greater than 0")
+	}
+
+	st.enableRefCounting()
+
+	return &Pruner{
+		logger:     logger.Named("pruner"),
+		state:      st,
+		storage:    storage,
+		blockchain: blockchain,
+		config:     config,
+	}, nil
+}
+
+// Stop aborts the running prune, if any, and prevents any further prune
+func (p *Pruner) Stop() {
+	atomic.StoreUint32(&p.stopped, 1)
+}
+
+func (p *Pruner) isStopped() bool {
+	return atomic.LoadUint32(&p.stopped) == 1
+}
+
+// OnNewHead pins the state of the new head and removes the state of the blocks which have left the retention window
+func (p *Pruner) OnNewHead(head uint64) error {
+	if p.isStopped() {
+		return ErrPruningStopped
+	}
+
+	if !p.initialized {
+		// the reference counts are stale if some blocks have been inserted while the pruning was disabled
+		last, ok := p.prunedHead()
+		if !ok || head > last+1 {
+			return p.Prune(head)
+		}
+
+		p.lastHead = last
+		p.initialized = true
+	}
+
+	return p.advance(head)
+}
+
+// advance moves the retention window to the given head
+func (p *Pruner) advance(head uint64) error {
+	p.state.refLock.Lock()
+	defer p.state.refLock.Unlock()
+
+	if header, ok := p.blockchain.GetHeaderByNumber(head); ok {
+		p.pin(head, header.StateRoot)
+	}
+
+	p.epochs = append(p.epochs, p.state.rotateCreated())
+
+	released := make([]types.Hash, 0)
+
+	// the blocks between the previous window and the new one are unpinned
+	if head > p.lastHead && head >= p.config.Retention {
+		var from uint64
+		if p.lastHead+1 >= p.config.Retention {
+			from = p.lastHead + 1 - p.config.Retention
+		}
+
+		for number := from; number <= head-p.config.Retention; number++ {
+			if !p.isCheckpoint(number) {
+				released = append(released, p.unpin(number)...)
+			}
+		}
+	}
+
+	// the unreferenced nodes created before the window are the states of the blocks which are never inserted,
+	// except the states of the blocks inserted after the head, which aren't pinned yet
+	if len(p.epochs) > int(p.config.Retention) {
+		pending := make(map[types.Hash]struct{})
+
+		for number := head + 1; ; number++ {
+			header, ok := p.blockchain.GetHeaderByNumber(number)
+			if !ok {
+				break
+			}
+
+			pending[header.StateRoot] = struct{}{}
+		}
+
+		for _, hash := range p.epochs[0] {
+			if _, ok := pending[hash]; !ok && p.state.refCount(hash) == 0 {
+				released = append(released, hash)
+			}
+		}
+
+		p.epochs = p.epochs[1:]
+	}
+
+	removed, err := p.release(released)
+	if err != nil {
+		return err
+	}
+
+	if removed > 0 {
+		// the cached tries might reference the removed nodes
+		p.state.cache.Purge()
+	}
+
+	p.lastHead = head
+	p.storage.Put(prunedHeadKey, encodeUint64(head))
+
+	p.logger.Debug("state pruned", "head", head, "removed", removed)
+
+	return nil
+}
+
+// release removes the given nodes if they aren't referenced, along with their references
+func (p *Pruner) release(hashes []types.Hash) (int, error) {
+	var (
+		released = make(map[types.Hash]struct{})
+		chunk    = make([][]byte, 0)
+	)
+
+	for len(hashes) > 0 {
+		hash := hashes[len(hashes)-1]
+		hashes = hashes[:len(hashes)-1]
+
+		if _, ok := released[hash]; ok || p.state.refCount(hash) > 0 {
+			continue
+		}
+
+		node, ok, err := GetNode(hash.Bytes(), p.storage)
+		if err != nil {
+			return len(released), err
+		}
+
+		if !ok {
+			continue
+		}
+
+		released[hash] = struct{}{}
+
+		for _, ref := range nodeRefs(node) {
+			if p.decRefCount(ref) == 0 {
+				hashes = append(hashes, ref)
+			}
+		}
+
+		chunk = append(chunk, hash.Bytes())
+
+		if len(chunk) == pruneDeleteChunkSize {
+			if err := p.storage.Delete(chunk); err != nil {
+				return len(released), err
+			}
+
+			chunk = chunk[:0]
+		}
+	}
+
+	return len(released), p.storage.Delete(chunk)
+}
+
+// pin references the state root of the block, unless it's already pinned by it
+func (p *Pruner) pin(number uint64, root types.Hash) {
+	roots := p.pinnedRoots(number)
+
+	for _, pinned := range roots {
+		if pinned == root {
+			return
+		}
+	}
+
+	p.state.storage.Put(refCountKey(root), encodeRefCount(p.state.refCount(root)+1))
+	p.storage.Put(pinnedRootsKey(number), concat(encodeHashes(roots), root.Bytes()))
+}
+
+// unpin removes the references of the block to its state roots, and returns the roots which aren't referenced anymore
+func (p *Pruner) unpin(number uint64) []types.Hash {
+	roots := p.pinnedRoots(number)
+	if len(roots) == 0 {
+		return nil
+	}
+
+	released := make([]types.Hash, 0, len(roots))
+
+	for _, root := range roots {
+		if p.decRefCount(root) == 0 {
+			released = append(released, root)
+		}
+	}
+
+	_ = p.storage.Delete([][]byte{pinnedRootsKey(number)})
+
+	return released
+}
+
+func (p *Pruner) pinnedRoots(number uint64) []types.Hash {
+	data, _ := p.storage.Get(pinnedRootsKey(number))
+
+	roots := make([]types.Hash, 0, len(data)/types.HashLength)
+	for i := 0; i+types.HashLength <= len(data); i += types.HashLength {
+		roots = append(roots, types.BytesToHash(data[i:i+types.HashLength]))
+	}
+
+	return roots
+}
+
+// decRefCount decrements the reference count of the node and returns the new one
+func (p *Pruner) decRefCount(hash types.Hash) uint32 {
+	count := p.state.refCount(hash)
+	if count > 0 {
+		count--
+	}
+
+	if count == 0 {
+		_ = p.storage.Delete([][]byte{refCountKey(hash)})
+	} else {
+		p.storage.Put(refCountKey(hash), encodeRefCount(count))
+	}
+
+	return count
+}
+
+// prunedHead returns the last head processed by the pruner, if any
+func (p *Pruner) prunedHead() (uint64, bool) {
+	data, ok := p.storage.Get(prunedHeadKey)
+	if !ok || len(data) != 8 {
+		return 0, false
+	}
+
+	return binary.BigEndian.Uint64(data), true
+}
+
+// isCheckpoint returns true if the state of the block is kept forever
+func (p *Pruner) isCheckpoint(number uint64) bool {
+	// the genesis state is a checkpoint as well
+	return number == 0 || (p.config.CheckpointInterval > 0 && number%p.config.CheckpointInterval == 0)
+}
+
+// Prune removes the state which is not retained at the given head by a full mark and sweep,
+// and rebuilds the reference counts of the retained nodes along with the pins of the retained blocks
+func (p *Pruner) Prune(head uint64) error {
+	var (
+		retained = p.retainedRoots(head)
+		marked   = make(map[types.Hash]struct{})
+		counts   = make(map[types.Hash]uint32)
+	)
+
+	for _, root := range retained {
+		if err := p.mark(root, false, marked, counts); err != nil {
+			return fmt.Errorf("failed to mark state %s: %w", root, err)
+		}
+	}
+
+	removed, err := p.sweep(marked)
+	if err != nil {
+		return err
+	}
+
+	p.state.refLock.Lock()
+	defer p.state.refLock.Unlock()
+
+	// the nodes created since the pruning is enabled are kept, since they might belong to the blocks
+	// which are not inserted yet, and they reference the nodes as well
+	created := p.state.rotateCreated()
+	for _, epoch := range p.epochs {
+		created = append(created, epoch...)
+	}
+
+	for _, hash := range created {
+		if _, ok := marked[hash]; ok {
+			continue
+		}
+
+		marked[hash] = struct{}{}
+
+		node, ok, err := GetNode(hash.Bytes(), p.storage)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			for _, ref := range nodeRefs(node) {
+				counts[ref]++
+			}
+		}
+	}
+
+	// the previous reference counts and pins are replaced
+	for _, prefix := range [][]byte{refCountPrefix, pinnedRootsPrefix} {
+		if err := p.deletePrefix(prefix); err != nil {
+			return err
+		}
+	}
+
+	batch := p.storage.Batch()
+
+	for hash, count := range counts {
+		batch.Put(refCountKey(hash), encodeRefCount(count))
+	}
+
+	batch.Write()
+
+	for number, root := range retained {
+		p.pin(number, root)
+	}
+
+	p.epochs = [][]types.Hash{created}
+	p.lastHead = head
+	p.initialized = true
+	p.storage.Put(prunedHeadKey, encodeUint64(head))
+
+	// the cached tries might reference the removed nodes
+	p.state.cache.Purge()
+
+	p.logger.Info("state pruned", "head", head, "retained", len(marked), "removed", removed)
+
+	return nil
+}
+
+func (p *Pruner) deletePrefix(prefix []byte) error {
+	keys := make([][]byte, 0)
+
+	if err := p.storage.ForEachKey(prefix, func(k []byte) bool {
+		keys = append(keys, k)
+
+		return true
+	}); err != nil {
+		return err
+	}
+
+	return p.storage.Delete(keys)
+}
+
+// retainedRoots returns the state roots of the last blocks and of the checkpoints, by block number
+func (p *Pruner) retainedRoots(head uint64) map[uint64]types.Hash {
+	var (
+		roots = make(map[uint64]types.Hash, p.config.Retention)
+		from  uint64
+	)
+
+	if head >= p.config.Retention {
+		from = head - p.config.Retention + 1
+	}
+
+	appendRoot := func(number uint64) {
+		header, ok := p.blockchain.GetHeaderByNumber(number)
+		if !ok {
+			p.logger.Debug("header of the retained state not found", "number", number)
+
+			return
+		}
+
+		roots[number] = header.StateRoot
+	}
+
+	for number := from; number <= head; number++ {
+		appendRoot(number)
+	}
+
+	appendRoot(0)
+
+	if p.config.CheckpointInterval > 0 {
+		for number := p.config.CheckpointInterval; number < from; number += p.config.CheckpointInterval {
+			appendRoot(number)
+		}
+	}
+
+	return roots
+}
+
+// mark adds the nodes of the trie with the given root to the marked ones, and counts their references
+func (p *Pruner) mark(
+	root types.Hash,
+	isStorage bool,
+	marked map[types.Hash]struct{},
+	counts map[types.Hash]uint32,
+) error {
+	if root == types.EmptyRootHash {
+		return nil
+	}
+
+	if _, ok := marked[root]; ok {
+		return nil
+	}
+
+	node, ok, err := GetNode(root.Bytes(), p.storage)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		// the state of the block isn't available (e.g. it has been synced from a later block)
+		p.logger.Debug("retained state not found", "root", root)
+
+		return nil
+	}
+
+	marked[root] = struct{}{}
+
+	for _, ref := range nodeRefs(node) {
+		counts[ref]++
+	}
+
+	return p.markNode(node, isStorage, marked, counts)
+}
+
+func (p *Pruner) markNode(
+	node Node,
+	isStorage bool,
+	marked map[types.Hash]struct{},
+	counts map[types.Hash]uint32,
+) error {
+	if p.isStopped() {
+		return ErrPruningStopped
+	}
+
+	switch n := node.(type) {
+	case nil:
+		return nil
+
+	case *FullNode:
+		for _, child := range n.children {
+			if err := p.markNode(child, isStorage, marked, counts); err != nil {
+				return err
+			}
+		}
+
+		return p.markNode(n.value, isStorage, marked, counts)
+
+	case *ShortNode:
+		return p.markNode(n.child, isStorage, marked, counts)
+
+	case *ValueNode:
+		if n.hash {
+			return p.markChild(types.BytesToHash(n.buf), isStorage, marked, counts)
+		}
+
+		if isStorage {
+			return nil
+		}
+
+		// the leaves of the account trie reference the storage tries
+		var account state.Account
+		if err := account.UnmarshalRlp(n.buf); err != nil {
+			return err
+		}
+
+		return p.mark(account.Root, true, marked, counts)
+	}
+
+	return fmt.Errorf("unknown node type %T", node)
+}
+
+// markChild marks the stored child node, which must exist unlike the roots
+func (p *Pruner) markChild(
+	hash types.Hash,
+	isStorage bool,
+	marked map[types.Hash]struct{},
+	counts map[types.Hash]uint32,
+) error {
+	if _, ok := marked[hash]; ok {
+		return nil
+	}
+
+	node, ok, err := GetNode(hash.Bytes(), p.storage)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("trie node %s not found", hash)
+	}
+
+	marked[hash] = struct{}{}
+
+	for _, ref := range nodeRefs(node) {
+		counts[ref]++
+	}
+
+	return p.markNode(node, isStorage, marked, counts)
+}
+
+// sweep removes the trie nodes which are neither marked nor created since the pruning is enabled
+func (p *Pruner) sweep(marked map[types.Hash]struct{}) (int, error) {
+	var (
+		removed int
+		chunk   = make([][]byte, 0, pruneDeleteChunkSize)
+	)
+
+	deleteChunk := func() error {
+		// the created nodes are checked under the lock,
+		// so that a node can't be created between the check and the deletion
+		p.state.refLock.Lock()
+		defer p.state.refLock.Unlock()
+
+		created := make(map[types.Hash]struct{})
+
+		for _, hash := range p.state.created {
+			created[hash] = struct{}{}
+		}
+
+		for _, epoch := range p.epochs {
+			for _, hash := range epoch {
+				created[hash] = struct{}{}
+			}
+		}
+
+		keys := chunk[:0]
+
+		for _, k := range chunk {
+			if _, ok := created[types.BytesToHash(k)]; !ok {
+				keys = append(keys, k)
+			}
+		}
+
+		if err := p.storage.Delete(keys); err != nil {
+			return err
+		}
+
+		removed += len(keys)
+		chunk = chunk[:0]
+
+		return nil
+	}
+
+	var chunkErr error
+
+	err := p.storage.ForEachKey(nil, func(k []byte) bool {
+		if p.isStopped() {
+			chunkErr = ErrPruningStopped
+
+			return false
+		}
+
+		// the trie nodes are stored by their hash, the other entries (e.g. code) are kept
+		if len(k) != types.HashLength {
+			return true
+		}
+
+		if _, ok := marked[types.BytesToHash(k)]; ok {
+			return true
+		}
+
+		chunk = append(chunk, k)
+
+		if len(chunk) == pruneDeleteChunkSize {
+			if chunkErr = deleteChunk(); chunkErr != nil {
+				return false
+			}
+		}
+
+		return true
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	if chunkErr != nil {
+		return removed, chunkErr
+	}
+
+	if len(chunk) > 0 {
+		if err := deleteChunk(); err != nil {
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
+
+// nodeRefs returns the stored nodes referenced by the node, the storage tries included for the account leaves
+func nodeRefs(node Node) []types.Hash {
+	refs := make([]types.Hash, 0)
+
+	var walk func(node Node)
+
+	walk = func(node Node) {
+		switch n := node.(type) {
+		case *FullNode:
+			for _, child := range n.children {
+				walk(child)
+			}
+
+			walk(n.value)
+
+		case *ShortNode:
+			walk(n.child)
+
+		case *ValueNode:
+			if n.hash {
+				refs = append(refs, types.BytesToHash(n.buf))
+
+				return
+			}
+
+			// the storage values are encoded as bytes, they can't be decoded as an account
+			var account state.Account
+			if err := account.UnmarshalRlp(n.buf); err == nil && account.Root != types.EmptyRootHash {
+				refs = append(refs, account.Root)
+			}
+		}
+	}
+
+	walk(node)
+
+	return refs
+}
+
+// refCount returns the number of the references to the node, it must be called with the reference lock held
+func (s *State) refCount(hash types.Hash) uint32 {
+	data, ok := s.storage.Get(refCountKey(hash))
+	if !ok || len(data) != 4 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint32(data)
+}
+
+func refCountKey(hash types.Hash) []byte {
+	return concat(refCountPrefix, hash.Bytes())
+}
+
+func encodeRefCount(count uint32) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, count)
+
+	return buf
+}
+
+func pinnedRootsKey(number uint64) []byte {
+	return concat(pinnedRootsPrefix, encodeUint64(number))
+}
+
+func encodeUint64(n uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, n)
+
+	return buf
+}
+
+func encodeHashes(hashes []types.Hash) []byte {
+	buf := make([]byte, 0, len(hashes)*types.HashLength)
+	for _, hash := range hashes {
+		buf = append(buf, hash.Bytes()...)
+	}
+
+	return buf
+}
@@ -0,0 +1,281 @@
+package itrie
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newton2049/favo-chain/state"
+	"github.com/newton2049/favo-chain/types"
+)
+
+var (
+	prunerTestAddr = types.StringToAddress("1")
+	prunerTestSlot = types.StringToHash("1")
+)
+
+type mockPrunerBlockchain map[uint64]types.Hash
+
+func (m mockPrunerBlockchain) GetHeaderByNumber(number uint64) (*types.Header, bool) {
+	root, ok := m[number]
+	if !ok {
+		return nil, false
+	}
+
+	return &types.Header{Number: number, StateRoot: root}, true
+}
+
+// commitTestBlock commits a state where the balance and the storage slot of the test account are set to value
+func commitTestBlock(t *testing.T, snap state.Snapshot, value int64) (state.Snapshot, types.Hash) {
+	t.Helper()
+
+	account, err := snap.GetAccount(prunerTestAddr)
+	require.NoError(t, err)
+
+	root := types.EmptyRootHash
+	if account != nil {
+		root = account.Root
+	}
+
+	snap, rawRoot := snap.Commit([]*state.Object{
+		{
+			Address:  prunerTestAddr,
+			Balance:  big.NewInt(value),
+			CodeHash: types.BytesToHash(emptyCodeHash),
+			Root:     root,
+			Storage: []*state.StorageObject{
+				{
+					Key: prunerTestSlot.Bytes(),
+					Val: big.NewInt(value).Bytes(),
+				},
+			},
+		},
+	})
+
+	return snap, types.BytesToHash(rawRoot)
+}
+
+func TestPruner_Prune(t *testing.T) {
+	t.Parallel()
+
+	var (
+		st         = NewState(NewMemoryStorage())
+		blockchain = mockPrunerBlockchain{}
+		snap       = st.NewSnapshot()
+		root       types.Hash
+	)
+
+	for i := uint64(0); i < 5; i++ {
+		snap, root = commitTestBlock(t, snap, int64(i+1))
+		blockchain[i] = root
+	}
+
+	pruner, err := NewPruner(hclog.NewNullLogger(), st, blockchain, &PrunerConfig{Retention: 2})
+	require.NoError(t, err)
+
+	// the state written after the pruner is created belongs to a block which isn't inserted yet
+	_, pendingRoot := commitTestBlock(t, snap, 6)
+
+	require.NoError(t, pruner.Prune(4))
+
+	assertState := func(root types.Hash, value int64) {
+		t.Helper()
+
+		snap, err := st.NewSnapshotAt(root)
+		require.NoError(t, err)
+
+		account, err := snap.GetAccount(prunerTestAddr)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(value), account.Balance)
+
+		assert.Equal(
+			t,
+			types.BytesToHash(big.NewInt(value).Bytes()),
+			snap.GetStorage(prunerTestAddr, account.Root, prunerTestSlot),
+		)
+	}
+
+	// the genesis state, the retained blocks and the pending block are kept
+	assertState(blockchain[0], 1)
+	assertState(blockchain[3], 4)
+	assertState(blockchain[4], 5)
+	assertState(pendingRoot, 6)
+
+	for _, number := range []uint64{1, 2} {
+		_, err := st.NewSnapshotAt(blockchain[number])
+		assert.Error(t, err)
+	}
+}
+
+func TestPruner_Checkpoints(t *testing.T) {
+	t.Parallel()
+
+	var (
+		st         = NewState(NewMemoryStorage())
+		blockchain = mockPrunerBlockchain{}
+		snap       = st.NewSnapshot()
+		root       types.Hash
+	)
+
+	pruner, err := NewPruner(hclog.NewNullLogger(), st, blockchain, &PrunerConfig{
+		Retention:          1,
+		CheckpointInterval: 2,
+	})
+	require.NoError(t, err)
+
+	for i := uint64(0); i < 6; i++ {
+		snap, root = commitTestBlock(t, snap, int64(i+1))
+		blockchain[i] = root
+
+		require.NoError(t, pruner.OnNewHead(i))
+	}
+
+	for number, kept := range map[uint64]bool{0: true, 1: false, 2: true, 3: false, 4: true, 5: true} {
+		_, err := st.NewSnapshotAt(blockchain[number])
+		assert.Equal(t, kept, err == nil, "block %d", number)
+	}
+}
+
+func TestPruner_Stop(t *testing.T) {
+	t.Parallel()
+
+	var (
+		st         = NewState(NewMemoryStorage())
+		blockchain = mockPrunerBlockchain{}
+	)
+
+	_, root := commitTestBlock(t, st.NewSnapshot(), 1)
+	blockchain[0] = root
+
+	pruner, err := NewPruner(hclog.NewNullLogger(), st, blockchain, &PrunerConfig{Retention: 1})
+	require.NoError(t, err)
+
+	pruner.Stop()
+
+	assert.ErrorIs(t, pruner.Prune(0), ErrPruningStopped)
+}
+
+func TestPruner_Incremental(t *testing.T) {
+	t.Parallel()
+
+	var (
+		storage    = NewMemoryStorage()
+		st         = NewState(storage)
+		blockchain = mockPrunerBlockchain{}
+		snap       = st.NewSnapshot()
+		root       types.Hash
+		config     = &PrunerConfig{Retention: 2}
+	)
+
+	pruner, err := NewPruner(hclog.NewNullLogger(), st, blockchain, config)
+	require.NoError(t, err)
+
+	snap, root = commitTestBlock(t, snap, 1)
+	blockchain[0] = root
+
+	require.NoError(t, pruner.OnNewHead(0))
+
+	// the nodes which aren't tracked are only removed by a full prune
+	untracked := types.StringToHash("1")
+	storage.Put(untracked.Bytes(), []byte{0x1})
+
+	// the state of a block which is never inserted
+	_, orphanRoot := commitTestBlock(t, snap, 100)
+
+	for i := uint64(1); i < 5; i++ {
+		snap, root = commitTestBlock(t, snap, int64(i+1))
+		blockchain[i] = root
+
+		require.NoError(t, pruner.OnNewHead(i))
+	}
+
+	for number, kept := range map[uint64]bool{0: true, 1: false, 2: false, 3: true, 4: true} {
+		_, err := st.NewSnapshotAt(blockchain[number])
+		assert.Equal(t, kept, err == nil, "block %d", number)
+	}
+
+	_, err = st.NewSnapshotAt(orphanRoot)
+	assert.Error(t, err)
+
+	_, ok := storage.Get(untracked.Bytes())
+	assert.True(t, ok)
+
+	// the reference counts are reused after a restart
+	restarted := NewState(storage)
+
+	pruner, err = NewPruner(hclog.NewNullLogger(), restarted, blockchain, config)
+	require.NoError(t, err)
+
+	snap, err = restarted.NewSnapshotAt(root)
+	require.NoError(t, err)
+
+	_, root = commitTestBlock(t, snap, 6)
+	blockchain[5] = root
+
+	require.NoError(t, pruner.OnNewHead(5))
+
+	for number, kept := range map[uint64]bool{3: false, 4: true, 5: true} {
+		_, err := restarted.NewSnapshotAt(blockchain[number])
+		assert.Equal(t, kept, err == nil, "block %d", number)
+	}
+
+	_, ok = storage.Get(untracked.Bytes())
+	assert.True(t, ok)
+}
+
+func TestPruner_SharedNodes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		st         = NewState(NewMemoryStorage())
+		blockchain = mockPrunerBlockchain{}
+		other      = types.StringToAddress("2")
+	)
+
+	pruner, err := NewPruner(hclog.NewNullLogger(), st, blockchain, &PrunerConfig{Retention: 1})
+	require.NoError(t, err)
+
+	// both accounts have the same storage, whose trie is shared
+	snap, root := st.NewSnapshot().Commit([]*state.Object{
+		{
+			Address:  prunerTestAddr,
+			Balance:  big.NewInt(1),
+			CodeHash: types.BytesToHash(emptyCodeHash),
+			Root:     types.EmptyRootHash,
+			Storage:  []*state.StorageObject{{Key: prunerTestSlot.Bytes(), Val: big.NewInt(1).Bytes()}},
+		},
+		{
+			Address:  other,
+			Balance:  big.NewInt(1),
+			CodeHash: types.BytesToHash(emptyCodeHash),
+			Root:     types.EmptyRootHash,
+			Storage:  []*state.StorageObject{{Key: prunerTestSlot.Bytes(), Val: big.NewInt(1).Bytes()}},
+		},
+	})
+	blockchain[1] = types.BytesToHash(root)
+
+	require.NoError(t, pruner.OnNewHead(1))
+
+	// the storage of the first account changes, and its previous state is pruned
+	for i := uint64(2); i < 5; i++ {
+		var root types.Hash
+
+		snap, root = commitTestBlock(t, snap, int64(i))
+		blockchain[i] = root
+
+		require.NoError(t, pruner.OnNewHead(i))
+	}
+
+	_, err = st.NewSnapshotAt(blockchain[1])
+	assert.Error(t, err)
+
+	snap, err = st.NewSnapshotAt(blockchain[4])
+	require.NoError(t, err)
+
+	account, err := snap.GetAccount(other)
+	require.NoError(t, err)
+	assert.Equal(t, types.BytesToHash(big.NewInt(1).Bytes()), snap.GetStorage(other, account.Root, prunerTestSlot))
+}
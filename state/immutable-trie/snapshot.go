@@ -12,11 +12,16 @@ import (
 type Snapshot struct {
 	state *State
 	trie  *Trie
+	root  types.Hash
 }
 
 var emptyStateHash = types.StringToHash("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
 
 func (s *Snapshot) GetStorage(addr types.Address, root types.Hash, rawkey types.Hash) types.Hash {
+	if val, ok := s.getFlatStorage(addr, root, rawkey); ok {
+		return val
+	}
+
 	var (
 		err  error
 		trie *Trie
@@ -38,6 +43,42 @@ func (s *Snapshot) GetStorage(addr types.Address, root types.Hash, rawkey types.
 		return types.Hash{}
 	}
 
+	return decodeStorageValue(val)
+}
+
+// getFlatStorage returns the slot value from the flat state, ok is false if it isn't available.
+// The flat state holds the storage of the account at the state root of the snapshot,
+// so it's only used if the given storage root is the same (e.g. not reset by a re-creation in the block)
+func (s *Snapshot) getFlatStorage(addr types.Address, root types.Hash, rawkey types.Hash) (types.Hash, bool) {
+	if s.state.flat == nil || root == emptyStateHash {
+		return types.Hash{}, false
+	}
+
+	accountHash := types.BytesToHash(crypto.Keccak256(addr.Bytes()))
+
+	data, ok := s.state.flat.account(s.root, accountHash)
+	if !ok || data == nil {
+		return types.Hash{}, false
+	}
+
+	var account state.Account
+	if err := account.UnmarshalRlp(data); err != nil || account.Root != root {
+		return types.Hash{}, false
+	}
+
+	val, ok := s.state.flat.storageSlot(s.root, accountHash, types.BytesToHash(crypto.Keccak256(rawkey.Bytes())))
+	if !ok {
+		return types.Hash{}, false
+	}
+
+	if val == nil {
+		return types.Hash{}, true
+	}
+
+	return decodeStorageValue(val), true
+}
+
+func decodeStorageValue(val []byte) types.Hash {
 	p := &fastrlp.Parser{}
 
 	v, err := p.Parse(val)
@@ -56,8 +97,12 @@ func (s *Snapshot) GetStorage(addr types.Address, root types.Hash, rawkey types.
 func (s *Snapshot) GetAccount(addr types.Address) (*state.Account, error) {
 	key := crypto.Keccak256(addr.Bytes())
 
-	data, ok := s.trie.Get(key, s.state.storage)
+	data, ok := s.getFlatAccount(key)
 	if !ok {
+		data, ok = s.trie.Get(key, s.state.storage)
+	}
+
+	if !ok || data == nil {
 		return nil, nil
 	}
 
@@ -69,12 +114,21 @@ func (s *Snapshot) GetAccount(addr types.Address) (*state.Account, error) {
 	return &account, nil
 }
 
+// getFlatAccount returns the account data from the flat state, ok is false if it isn't available
+func (s *Snapshot) getFlatAccount(key []byte) (data []byte, ok bool) {
+	if s.state.flat == nil {
+		return nil, false
+	}
+
+	return s.state.flat.account(s.root, types.BytesToHash(key))
+}
+
 func (s *Snapshot) GetCode(hash types.Hash) ([]byte, bool) {
 	return s.state.GetCode(hash)
 }
 
 func (s *Snapshot) Commit(objs []*state.Object) (state.Snapshot, []byte) {
-	batch := s.state.storage.Batch()
+	batch := s.state.newBatch()
 
 	tt := s.trie.Txn(s.state.storage)
 	tt.batch = batch
@@ -85,10 +139,22 @@ func (s *Snapshot) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 	ar1 := stateArenaPool.Get()
 	defer stateArenaPool.Put(ar1)
 
+	diff := newFlatDiff()
+
 	for _, obj := range objs {
+		accountHash := types.BytesToHash(hashit(obj.Address.Bytes()))
+
 		if obj.Deleted {
 			tt.Delete(hashit(obj.Address.Bytes()))
+
+			diff.accounts[accountHash] = nil
+			diff.destructed[accountHash] = struct{}{}
 		} else {
+			// the storage of the account is reset when it's (re)created
+			if obj.Root == types.EmptyRootHash {
+				diff.destructed[accountHash] = struct{}{}
+			}
+
 			account := state.Account{
 				Balance:  obj.Balance,
 				Nonce:    obj.Nonce,
@@ -109,9 +175,13 @@ func (s *Snapshot) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 					k := hashit(entry.Key)
 					if entry.Deleted {
 						localTxn.Delete(k)
+						diff.setSlot(accountHash, types.BytesToHash(k), nil)
 					} else {
 						vv := ar1.NewBytes(bytes.TrimLeft(entry.Val, "\x00"))
-						localTxn.Insert(k, vv.MarshalTo(nil))
+						val := vv.MarshalTo(nil)
+
+						localTxn.Insert(k, val)
+						diff.setSlot(accountHash, types.BytesToHash(k), val)
 					}
 				}
 
@@ -133,6 +203,8 @@ func (s *Snapshot) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 
 			tt.Insert(hashit(obj.Address.Bytes()), data)
 			arena.Reset()
+
+			diff.accounts[accountHash] = data
 		}
 	}
 
@@ -145,5 +217,9 @@ func (s *Snapshot) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 
 	s.state.AddState(types.BytesToHash(root), nTrie)
 
-	return &Snapshot{trie: nTrie, state: s.state}, root
+	if s.state.flat != nil {
+		s.state.flat.update(types.BytesToHash(root), s.root, diff)
+	}
+
+	return &Snapshot{trie: nTrie, state: s.state, root: types.BytesToHash(root)}, root
 }
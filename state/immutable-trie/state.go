@@ -2,9 +2,12 @@ package itrie
 
 import (
 	"fmt"
+	"sync"
 
 	lru "github.com/hashicorp/golang-lru"
 
+	"github.com/hashicorp/go-hclog"
+
 	"github.com/newton2049/favo-chain/state"
 	"github.com/newton2049/favo-chain/types"
 )
@@ -12,6 +15,15 @@ import (
 type State struct {
 	storage Storage
 	cache   *lru.Cache
+
+	// the reference counts of the nodes are maintained only if the pruning is enabled,
+	// created are the nodes written for the first time since the previous head
+	refLock     sync.Mutex
+	refCounting bool
+	created     []types.Hash
+
+	// flat is the flat view of the recent states, nil if disabled
+	flat *flatTree
 }
 
 func NewState(storage Storage) *State {
@@ -26,7 +38,7 @@ func NewState(storage Storage) *State {
 }
 
 func (s *State) NewSnapshot() state.Snapshot {
-	return &Snapshot{state: s, trie: s.newTrie()}
+	return &Snapshot{state: s, trie: s.newTrie(), root: types.EmptyRootHash}
 }
 
 func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
@@ -35,7 +47,27 @@ func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
 		return nil, err
 	}
 
-	return &Snapshot{state: s, trie: t}, nil
+	return &Snapshot{state: s, trie: t, root: root}, nil
+}
+
+// EnableFlatSnapshot maintains a flat view of the recent states on commit,
+// which serves the account and storage reads without traversing the trie
+func (s *State) EnableFlatSnapshot(logger hclog.Logger) error {
+	storage, ok := s.storage.(PrunableStorage)
+	if !ok {
+		return ErrFlatSnapshotUnsupported
+	}
+
+	s.flat = newFlatTree(logger, storage)
+
+	return nil
+}
+
+// Close stops the background work on the state
+func (s *State) Close() {
+	if s.flat != nil {
+		s.flat.close()
+	}
 }
 
 func (s *State) newTrie() *Trie {
@@ -86,3 +118,116 @@ func (s *State) newTrieAt(root types.Hash) (*Trie, error) {
 func (s *State) AddState(root types.Hash, t *Trie) {
 	s.cache.Add(root, t)
 }
+
+// newBatch returns a batch for the storage,
+// which maintains the reference counts of the written nodes if the pruning is enabled
+func (s *State) newBatch() Batch {
+	batch := s.storage.Batch()
+
+	s.refLock.Lock()
+	defer s.refLock.Unlock()
+
+	if !s.refCounting {
+		return batch
+	}
+
+	return &refCountedBatch{
+		Batch: batch,
+		state: s,
+		seen:  make(map[types.Hash]struct{}),
+		refs:  make(map[types.Hash]uint32),
+	}
+}
+
+// enableRefCounting starts maintaining the reference counts of the nodes written to the storage
+func (s *State) enableRefCounting() {
+	s.refLock.Lock()
+	defer s.refLock.Unlock()
+
+	s.refCounting = true
+}
+
+// rotateCreated returns the nodes created since the previous call and resets the tracking,
+// it must be called with the reference lock held
+func (s *State) rotateCreated() []types.Hash {
+	created := s.created
+	s.created = nil
+
+	return created
+}
+
+// refCountedBatch is a batch which increments the reference counts
+// of the nodes referenced by the ones written for the first time
+type refCountedBatch struct {
+	Batch
+	state *State
+
+	seen     map[types.Hash]struct{}
+	created  []types.Hash
+	existing []storedNode
+	refs     map[types.Hash]uint32
+}
+
+// storedNode is a node which has been put while already stored
+type storedNode struct {
+	hash types.Hash
+	data []byte
+}
+
+func (b *refCountedBatch) Put(k, v []byte) {
+	// the trie nodes are stored by their hash, the other entries aren't counted
+	if len(k) != types.HashLength {
+		b.Batch.Put(k, v)
+
+		return
+	}
+
+	hash := types.BytesToHash(k)
+
+	if _, ok := b.seen[hash]; !ok {
+		b.seen[hash] = struct{}{}
+
+		if _, ok := b.state.storage.Get(k); ok {
+			// the node might be removed by the pruner until the batch is written
+			b.existing = append(b.existing, storedNode{hash: hash, data: append([]byte{}, v...)})
+		} else {
+			b.addCreated(hash, v)
+		}
+	}
+
+	b.Batch.Put(k, v)
+}
+
+func (b *refCountedBatch) addCreated(hash types.Hash, data []byte) {
+	node, err := decodeStoredNode(data, b.state.storage)
+	if err != nil {
+		return
+	}
+
+	for _, ref := range nodeRefs(node) {
+		b.refs[ref]++
+	}
+
+	b.created = append(b.created, hash)
+}
+
+func (b *refCountedBatch) Write() {
+	b.state.refLock.Lock()
+	defer b.state.refLock.Unlock()
+
+	// the nodes removed by the pruner since they have been put are created again
+	for _, n := range b.existing {
+		if _, ok := b.state.storage.Get(n.hash.Bytes()); !ok {
+			b.Batch.Put(n.hash.Bytes(), n.data)
+			b.addCreated(n.hash, n.data)
+		}
+	}
+
+	for ref, delta := range b.refs {
+		b.Batch.Put(refCountKey(ref), encodeRefCount(b.state.refCount(ref)+delta))
+	}
+
+	b.state.created = append(b.state.created, b.created...)
+
+	b.Batch.Write()
+}
@@ -1,6 +1,7 @@
 package itrie
 
 import (
+	"bytes"
 	"fmt"
 	"sync"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/newton2049/favo-chain/helper/hex"
 	"github.com/newton2049/favo-chain/types"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/umbracle/fastrlp"
 )
 
@@ -34,6 +36,16 @@ type Storage interface {
 	Close() error
 }
 
+// PrunableStorage is a storage whose entries can be iterated and removed
+type PrunableStorage interface {
+	Storage
+
+	// ForEachKey iterates over the keys with the given prefix until fn returns false
+	ForEachKey(prefix []byte, fn func(k []byte) bool) error
+	// Delete removes the given keys
+	Delete(keys [][]byte) error
+}
+
 // KVStorage is a k/v storage on memory using leveldb
 type KVStorage struct {
 	db *leveldb.DB
@@ -82,6 +94,33 @@ func (kv *KVStorage) Get(k []byte) ([]byte, bool) {
 	return data, true
 }
 
+func (kv *KVStorage) ForEachKey(prefix []byte, fn func(k []byte) bool) error {
+	iter := kv.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		// the key is only valid until the next call of Next
+		key := make([]byte, len(iter.Key()))
+		copy(key, iter.Key())
+
+		if !fn(key) {
+			break
+		}
+	}
+
+	return iter.Error()
+}
+
+func (kv *KVStorage) Delete(keys [][]byte) error {
+	batch := &leveldb.Batch{}
+
+	for _, k := range keys {
+		batch.Delete(k)
+	}
+
+	return kv.db.Write(batch, nil)
+}
+
 func (kv *KVStorage) Close() error {
 	return kv.db.Close()
 }
@@ -148,8 +187,48 @@ func (m *memStorage) GetCode(hash types.Hash) ([]byte, bool) {
 	return code, ok
 }
 
+func (m *memStorage) ForEachKey(prefix []byte, fn func(k []byte) bool) error {
+	m.l.Lock()
+
+	keys := make([][]byte, 0, len(m.db))
+
+	for k := range m.db {
+		key, err := hex.DecodeHex(k)
+		if err != nil {
+			m.l.Unlock()
+
+			return err
+		}
+
+		if bytes.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	m.l.Unlock()
+
+	for _, k := range keys {
+		if !fn(k) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (m *memStorage) Delete(keys [][]byte) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	for _, k := range keys {
+		delete(m.db, hex.EncodeToHex(k))
+	}
+
+	return nil
+}
+
 func (m *memStorage) Batch() Batch {
-	return &memBatch{db: &m.db, l: new(sync.Mutex)}
+	return &memBatch{db: &m.db, l: m.l}
 }
 
 func (m *memStorage) Close() error {
@@ -177,21 +256,26 @@ func GetNode(root []byte, storage Storage) (Node, bool, error) {
 
 	// NOTE. We dont need to make copies of the bytes because the nodes
 	// take the reference from data itself which is a safe copy.
+	n, err := decodeStoredNode(data, storage)
+
+	return n, err == nil, err
+}
+
+// decodeStoredNode decodes the encoding of a stored node, the node references the given data
+func decodeStoredNode(data []byte, storage Storage) (Node, error) {
 	p := parserPool.Get()
 	defer parserPool.Put(p)
 
 	v, err := p.Parse(data)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
 	if v.Type() != fastrlp.TypeArray {
-		return nil, false, fmt.Errorf("storage item should be an array")
+		return nil, fmt.Errorf("storage item should be an array")
 	}
 
-	n, err := decodeNode(v, storage)
-
-	return n, err == nil, err
+	return decodeNode(v, storage)
 }
 
 func decodeNode(v *fastrlp.Value, s Storage) (Node, error) {
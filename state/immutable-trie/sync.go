@@ -0,0 +1,544 @@
+package itrie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/umbracle/fastrlp"
+
+	"github.com/newton2049/favo-chain/crypto"
+	"github.com/newton2049/favo-chain/state"
+	"github.com/newton2049/favo-chain/types"
+)
+
+var (
+	// ErrInvalidRangeProof is returned when a range of leaves doesn't match its proof
+	ErrInvalidRangeProof = errors.New("invalid range proof")
+
+	// maxRangeKey is the end of the range which covers the rest of the trie
+	maxRangeKey = bytes.Repeat([]byte{0xff}, types.HashLength)
+)
+
+// HasState returns whether the trie with the given root is stored
+func (s *State) HasState(root types.Hash) bool {
+	if root == types.EmptyRootHash {
+		return true
+	}
+
+	data, ok := s.storage.Get(root.Bytes())
+
+	return ok && len(data) != 0
+}
+
+// GetRange returns at most limit leaves of the trie with the given root, starting from the origin key,
+// and the proof of the origin and of the end of the range (see VerifyRangeProof)
+func (s *State) GetRange(root types.Hash, origin []byte, limit uint64) ([][]byte, [][]byte, [][]byte, error) {
+	if limit == 0 {
+		return nil, nil, nil, errors.New("range limit must be greater than 0")
+	}
+
+	keys := make([][]byte, 0)
+	values := make([][]byte, 0)
+
+	err := walkTrie(root, origin, s.storage, func(key, value []byte) error {
+		keys = append(keys, key)
+		values = append(values, value)
+
+		if uint64(len(keys)) == limit {
+			return errStopWalk
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	proof, err := proveRange(root, origin, rangeEnd(keys, limit), s.storage)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return keys, values, proof, nil
+}
+
+// rangeEnd returns the end of the proven range, which is the last key of a full range,
+// or the end of the trie otherwise
+func rangeEnd(keys [][]byte, limit uint64) []byte {
+	if len(keys) > 0 && uint64(len(keys)) == limit {
+		return keys[len(keys)-1]
+	}
+
+	return maxRangeKey
+}
+
+// proveRange returns the union of the proofs of the origin and of the end
+func proveRange(root types.Hash, origin, end []byte, storage Storage) ([][]byte, error) {
+	proof, _, err := Prove(root, origin, storage)
+	if err != nil {
+		return nil, err
+	}
+
+	endProof, _, err := Prove(root, end, storage)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(proof))
+	for _, node := range proof {
+		seen[string(node)] = struct{}{}
+	}
+
+	for _, node := range endProof {
+		if _, ok := seen[string(node)]; !ok {
+			proof = append(proof, node)
+		}
+	}
+
+	return proof, nil
+}
+
+// VerifyRangeProof checks that the leaves are all the leaves of the trie with the given root
+// from the origin to the end of the range, which is the last key if limit leaves are returned
+// and the end of the trie otherwise.
+// The trie is rebuilt from the leaves and the proof nodes on the edges of the range, and its root is compared
+func VerifyRangeProof(root types.Hash, origin []byte, limit uint64, keys, values, proof [][]byte) error {
+	if len(keys) != len(values) || uint64(len(keys)) > limit {
+		return fmt.Errorf("%w: %d keys and %d values for limit %d", ErrInvalidRangeProof, len(keys), len(values), limit)
+	}
+
+	end := rangeEnd(keys, limit)
+
+	for i, key := range keys {
+		if len(key) != types.HashLength {
+			return fmt.Errorf("%w: invalid key length %d", ErrInvalidRangeProof, len(key))
+		}
+
+		if bytes.Compare(key, origin) < 0 || bytes.Compare(key, end) > 0 {
+			return fmt.Errorf("%w: key %x out of range", ErrInvalidRangeProof, key)
+		}
+
+		if i > 0 && bytes.Compare(keys[i-1], key) >= 0 {
+			return fmt.Errorf("%w: keys are not in ascending order", ErrInvalidRangeProof)
+		}
+	}
+
+	if root == types.EmptyRootHash {
+		if len(keys) != 0 {
+			return fmt.Errorf("%w: leaves of an empty trie", ErrInvalidRangeProof)
+		}
+
+		return nil
+	}
+
+	proofStorage := NewMemoryStorage()
+
+	for _, node := range proof {
+		proofStorage.Put(crypto.Keccak256(node), node)
+	}
+
+	r := &rangeRebuilder{
+		storage:    proofStorage,
+		originPath: keyPath(origin),
+		endPath:    keyPath(end),
+		keys:       keys,
+		values:     values,
+		used:       make([]bool, len(keys)),
+	}
+
+	node, err := r.resolve(root.Bytes())
+	if err != nil {
+		return err
+	}
+
+	node, err = r.rebuild(node, nil)
+	if err != nil {
+		return err
+	}
+
+	for i, used := range r.used {
+		if !used {
+			return fmt.Errorf("%w: key %x is not in the trie", ErrInvalidRangeProof, keys[i])
+		}
+	}
+
+	txn := &Txn{root: node}
+
+	hash, err := txn.Hash()
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(hash, root.Bytes()) {
+		return fmt.Errorf("%w: root mismatch", ErrInvalidRangeProof)
+	}
+
+	return nil
+}
+
+// rangeRebuilder rebuilds the trie of a range of leaves. The nodes on the path of the origin or of the end
+// are taken from the proof, the subtrees left of the origin or right of the end are kept as hash references,
+// and the subtrees in between are built from the leaves
+type rangeRebuilder struct {
+	storage    Storage
+	originPath []byte
+	endPath    []byte
+	keys       [][]byte
+	values     [][]byte
+	used       []bool
+}
+
+const (
+	pathOutside = iota
+	pathEdge
+	pathInside
+)
+
+func (r *rangeRebuilder) classify(path []byte) int {
+	originCmp, endCmp := comparePath(path, r.originPath), comparePath(path, r.endPath)
+
+	switch {
+	case originCmp == 0 || endCmp == 0:
+		return pathEdge
+	case originCmp > 0 && endCmp < 0:
+		return pathInside
+	default:
+		return pathOutside
+	}
+}
+
+func (r *rangeRebuilder) resolve(hash []byte) (Node, error) {
+	data, ok := r.storage.Get(hash)
+	if !ok || len(data) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrProofNodeNotFound, types.BytesToHash(hash))
+	}
+
+	p := &fastrlp.Parser{}
+
+	v, err := p.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeNode(v, r.storage)
+}
+
+// rebuild returns the node on the edge of the range at the given path
+func (r *rangeRebuilder) rebuild(node Node, path []byte) (Node, error) {
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
+
+	case *ValueNode:
+		if n.hash {
+			child, err := r.resolve(n.buf)
+			if err != nil {
+				return nil, err
+			}
+
+			return r.rebuild(child, path)
+		}
+
+		// the leaf is either the origin or the end, which must be in the range
+		key := hexToKeyBytes(path)
+
+		for i, k := range r.keys {
+			if bytes.Equal(k, key) {
+				if !bytes.Equal(r.values[i], n.buf) {
+					return nil, fmt.Errorf("%w: value mismatch of key %x", ErrInvalidRangeProof, key)
+				}
+
+				r.used[i] = true
+
+				return n, nil
+			}
+		}
+
+		return nil, fmt.Errorf("%w: missing key %x", ErrInvalidRangeProof, key)
+
+	case *ShortNode:
+		childPath := concat(path, n.key)
+
+		switch r.classify(childPath) {
+		case pathEdge:
+			child, err := r.rebuild(n.child, childPath)
+			if err != nil {
+				return nil, err
+			}
+
+			return &ShortNode{key: n.key, child: child}, nil
+		case pathInside:
+			return r.build(path), nil
+		default:
+			return n, nil
+		}
+
+	case *FullNode:
+		nc := &FullNode{}
+
+		value, err := r.rebuild(n.value, path)
+		if err != nil {
+			return nil, err
+		}
+
+		nc.value = value
+
+		for i, child := range n.children {
+			childPath := concat(path, []byte{byte(i)})
+
+			switch r.classify(childPath) {
+			case pathEdge:
+				if nc.children[i], err = r.rebuild(child, childPath); err != nil {
+					return nil, err
+				}
+			case pathInside:
+				nc.children[i] = r.build(childPath)
+			default:
+				nc.children[i] = child
+			}
+		}
+
+		return nc, nil
+	}
+
+	return nil, fmt.Errorf("unknown node type %T", node)
+}
+
+// build returns the subtree of the leaves whose keys start with the path
+func (r *rangeRebuilder) build(path []byte) Node {
+	var (
+		txn  = &Txn{}
+		root Node
+	)
+
+	for i, key := range r.keys {
+		keyPath := bytesToHexNibbles(key)
+		if !bytes.HasPrefix(keyPath, path) {
+			continue
+		}
+
+		root = txn.insert(root, keyPath[len(path):], r.values[i])
+		r.used[i] = true
+	}
+
+	return root
+}
+
+// syncProgressKey is the key of the progress of the state sync
+var syncProgressKey = []byte("syncProgress")
+
+// trieProgress is the progress of the sync of a trie, whose synced leaves are flushed to the storage
+type trieProgress struct {
+	root    types.Hash // the root of the synced trie
+	partial types.Hash // the root of the flushed nodes, the empty root if none
+	origin  types.Hash // the key of the next range
+}
+
+// syncProgress is the persisted progress of the state sync
+type syncProgress struct {
+	accounts trieProgress
+	storage  trieProgress // the storage trie being synced, its root is zero if none
+}
+
+func (p *syncProgress) marshal() []byte {
+	buf := make([]byte, 0, 6*types.HashLength)
+
+	for _, trie := range []trieProgress{p.accounts, p.storage} {
+		buf = append(buf, trie.root.Bytes()...)
+		buf = append(buf, trie.partial.Bytes()...)
+		buf = append(buf, trie.origin.Bytes()...)
+	}
+
+	return buf
+}
+
+func (p *syncProgress) unmarshal(buf []byte) bool {
+	if len(buf) != 6*types.HashLength {
+		return false
+	}
+
+	for _, trie := range []*trieProgress{&p.accounts, &p.storage} {
+		trie.root = types.BytesToHash(buf[:types.HashLength])
+		trie.partial = types.BytesToHash(buf[types.HashLength : 2*types.HashLength])
+		trie.origin = types.BytesToHash(buf[2*types.HashLength : 3*types.HashLength])
+		buf = buf[3*types.HashLength:]
+	}
+
+	return true
+}
+
+// StateSync writes a state which is synced by ranges of leaves. Each synced range is flushed to the storage
+// along with the progress of the sync, so that the synced tries aren't kept in memory
+// and an interrupted sync of the same state resumes where it stopped
+type StateSync struct {
+	state    *State
+	progress syncProgress
+	accounts *Txn // the account trie, on top of its flushed nodes
+	storage  *Txn // the storage trie being synced, nil if none
+}
+
+// NewStateSync creates the writer of the state with the given root,
+// which resumes the previous sync of the same state, if any
+func (s *State) NewStateSync(root types.Hash) *StateSync {
+	sync := &StateSync{
+		state: s,
+		progress: syncProgress{
+			accounts: trieProgress{root: root, partial: types.EmptyRootHash},
+		},
+	}
+
+	var progress syncProgress
+	if data, ok := s.storage.Get(syncProgressKey); ok && progress.unmarshal(data) && progress.accounts.root == root {
+		sync.progress = progress
+	}
+
+	sync.accounts = sync.openTrie(sync.progress.accounts.partial)
+
+	if sync.progress.storage.root != types.ZeroHash {
+		sync.storage = sync.openTrie(sync.progress.storage.partial)
+	}
+
+	return sync
+}
+
+// openTrie returns the transaction of the partially synced trie with the given root
+func (s *StateSync) openTrie(partial types.Hash) *Txn {
+	txn := NewTrie().Txn(s.state.storage)
+
+	if partial != types.EmptyRootHash {
+		txn.root = &ValueNode{hash: true, buf: partial.Bytes()}
+	}
+
+	return txn
+}
+
+// flush writes the nodes of the trie along with the progress, which is updated with the root of the flushed nodes,
+// and releases the nodes from memory, they are read back from the storage by the next inserts
+func (s *StateSync) flush(txn *Txn, update func(partial types.Hash)) error {
+	batch := s.state.newBatch()
+
+	txn.batch = batch
+	hash, err := txn.Hash()
+	txn.batch = nil
+
+	if err != nil {
+		return err
+	}
+
+	partial := types.BytesToHash(hash)
+	update(partial)
+
+	batch.Put(syncProgressKey, s.progress.marshal())
+	batch.Write()
+
+	txn.root = nil
+	if partial != types.EmptyRootHash {
+		txn.root = &ValueNode{hash: true, buf: partial.Bytes()}
+	}
+
+	return nil
+}
+
+// AccountOrigin returns the key of the next range of the account trie
+func (s *StateSync) AccountOrigin() []byte {
+	return s.progress.accounts.origin.Bytes()
+}
+
+// AddAccounts adds the leaves of the account trie and returns the decoded accounts.
+// They are flushed by FlushAccounts, once the storage tries and the codes of the accounts are synced
+func (s *StateSync) AddAccounts(keys, values [][]byte) ([]*state.Account, error) {
+	accounts := make([]*state.Account, len(keys))
+
+	for i, key := range keys {
+		var account state.Account
+		if err := account.UnmarshalRlp(values[i]); err != nil {
+			return nil, err
+		}
+
+		s.accounts.Insert(key, values[i])
+		accounts[i] = &account
+	}
+
+	return accounts, nil
+}
+
+// FlushAccounts writes the added accounts, next is the key of the next range, nil once the trie is complete
+func (s *StateSync) FlushAccounts(next []byte) error {
+	return s.flush(s.accounts, func(partial types.Hash) {
+		s.progress.accounts.partial = partial
+		s.progress.accounts.origin = types.BytesToHash(next)
+	})
+}
+
+// StorageOrigin starts the sync of the storage trie with the given root, or resumes it if it was interrupted,
+// and returns the key of its next range
+func (s *StateSync) StorageOrigin(root types.Hash) []byte {
+	if s.storage == nil || s.progress.storage.root != root {
+		s.progress.storage = trieProgress{root: root, partial: types.EmptyRootHash}
+		s.storage = s.openTrie(types.EmptyRootHash)
+	}
+
+	return s.progress.storage.origin.Bytes()
+}
+
+// AddStorage adds and writes the leaves of the storage trie being synced (see StorageOrigin),
+// next is the key of the next range, nil once the trie is complete
+func (s *StateSync) AddStorage(keys, values [][]byte, next []byte) error {
+	if s.storage == nil {
+		return errors.New("no storage trie is being synced")
+	}
+
+	for i, key := range keys {
+		s.storage.Insert(key, values[i])
+	}
+
+	var (
+		root   = s.progress.storage.root
+		synced types.Hash
+	)
+
+	err := s.flush(s.storage, func(partial types.Hash) {
+		synced = partial
+
+		if next == nil {
+			// the storage trie is complete
+			s.progress.storage = trieProgress{}
+
+			return
+		}
+
+		s.progress.storage.partial = partial
+		s.progress.storage.origin = types.BytesToHash(next)
+	})
+	if err != nil {
+		return err
+	}
+
+	if next != nil {
+		return nil
+	}
+
+	s.storage = nil
+
+	if synced != root {
+		return fmt.Errorf("storage root mismatch: expected %s, got %s", root, synced)
+	}
+
+	return nil
+}
+
+// Commit checks the root of the synced state and clears the progress of the sync.
+// The root of the state is written by the last flush, so that the state is only available once complete
+func (s *StateSync) Commit() error {
+	root, partial := s.progress.accounts.root, s.progress.accounts.partial
+
+	// the sync starts over if it didn't produce the expected state
+	s.state.storage.Put(syncProgressKey, nil)
+
+	if partial != root {
+		return fmt.Errorf("state root mismatch: expected %s, got %s", root, partial)
+	}
+
+	return nil
+}
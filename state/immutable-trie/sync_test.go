@@ -0,0 +1,239 @@
+package itrie
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newton2049/favo-chain/state"
+	"github.com/newton2049/favo-chain/types"
+)
+
+func TestRangeProof_Verify(t *testing.T) {
+	t.Parallel()
+
+	storage := NewMemoryStorage()
+
+	txn := NewTrie().Txn(storage)
+	txn.batch = storage.Batch()
+
+	keys := make([][]byte, 0, 100)
+
+	for i := 0; i < 100; i++ {
+		key := hashit(big.NewInt(int64(i)).Bytes())
+
+		txn.Insert(key, big.NewInt(int64(i+1)).Bytes())
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	rawRoot, err := txn.Hash()
+	require.NoError(t, err)
+
+	var (
+		root   = types.BytesToHash(rawRoot)
+		st     = NewState(storage)
+		origin = make([]byte, types.HashLength)
+		synced = make([][]byte, 0, len(keys))
+	)
+
+	// the trie is fetched by pages
+	for {
+		rangeKeys, rangeValues, proof, err := st.GetRange(root, origin, 30)
+		require.NoError(t, err)
+		require.NoError(t, VerifyRangeProof(root, origin, 30, rangeKeys, rangeValues, proof))
+
+		synced = append(synced, rangeKeys...)
+
+		if len(rangeKeys) < 30 {
+			break
+		}
+
+		origin = new(big.Int).Add(new(big.Int).SetBytes(rangeKeys[len(rangeKeys)-1]), big.NewInt(1)).FillBytes(origin)
+	}
+
+	assert.Equal(t, keys, synced)
+
+	rangeKeys, rangeValues, proof, err := st.GetRange(root, keys[10], 30)
+	require.NoError(t, err)
+	require.Equal(t, keys[10:40], rangeKeys)
+
+	t.Run("missing leaf", func(t *testing.T) {
+		t.Parallel()
+
+		missingKeys := append(append([][]byte{}, rangeKeys[:5]...), rangeKeys[6:]...)
+		missingValues := append(append([][]byte{}, rangeValues[:5]...), rangeValues[6:]...)
+
+		// the limit of the shorter range makes its last key the end of the range
+		assert.ErrorIs(t, VerifyRangeProof(root, keys[10], 29, missingKeys, missingValues, proof), ErrInvalidRangeProof)
+	})
+
+	t.Run("truncated range", func(t *testing.T) {
+		t.Parallel()
+
+		// the range claims to reach the end of the trie, which isn't proven
+		assert.Error(t, VerifyRangeProof(root, keys[10], 30, rangeKeys[:20], rangeValues[:20], proof))
+	})
+
+	t.Run("modified value", func(t *testing.T) {
+		t.Parallel()
+
+		modifiedValues := append([][]byte{}, rangeValues...)
+		modifiedValues[3] = []byte{0x1}
+
+		assert.ErrorIs(t, VerifyRangeProof(root, keys[10], 30, rangeKeys, modifiedValues, proof), ErrInvalidRangeProof)
+	})
+
+	t.Run("missing proof", func(t *testing.T) {
+		t.Parallel()
+
+		assert.ErrorIs(t, VerifyRangeProof(root, keys[10], 30, rangeKeys, rangeValues, nil), ErrProofNodeNotFound)
+	})
+
+	t.Run("empty trie", func(t *testing.T) {
+		t.Parallel()
+
+		assert.NoError(t, VerifyRangeProof(types.EmptyRootHash, keys[0], 30, nil, nil, nil))
+		assert.ErrorIs(t, VerifyRangeProof(types.EmptyRootHash, keys[0], 30, rangeKeys, rangeValues, nil), ErrInvalidRangeProof)
+	})
+}
+
+func TestStateSync_Commit(t *testing.T) {
+	t.Parallel()
+
+	source := NewState(NewMemoryStorage())
+
+	objs := make([]*state.Object, 0, 20)
+
+	for i := 0; i < 20; i++ {
+		objs = append(objs, &state.Object{
+			Address:  types.BytesToAddress(big.NewInt(int64(i + 1)).Bytes()),
+			Balance:  big.NewInt(int64(i + 1)),
+			CodeHash: types.BytesToHash(emptyCodeHash),
+			Root:     types.EmptyRootHash,
+			Storage: []*state.StorageObject{
+				{
+					Key: prunerTestSlot.Bytes(),
+					Val: big.NewInt(int64(i + 1)).Bytes(),
+				},
+			},
+		})
+	}
+
+	_, rawRoot := source.NewSnapshot().Commit(objs)
+	root := types.BytesToHash(rawRoot)
+
+	target := NewState(NewMemoryStorage())
+	require.False(t, target.HasState(root))
+
+	stateSync := target.NewStateSync(root)
+	origin := make([]byte, types.HashLength)
+
+	keys, values, proof, err := source.GetRange(root, origin, 100)
+	require.NoError(t, err)
+	require.NoError(t, VerifyRangeProof(root, origin, 100, keys, values, proof))
+
+	accounts, err := stateSync.AddAccounts(keys, values)
+	require.NoError(t, err)
+	require.Len(t, accounts, 20)
+
+	for _, account := range accounts {
+		keys, values, proof, err := source.GetRange(account.Root, stateSync.StorageOrigin(account.Root), 100)
+		require.NoError(t, err)
+		require.NoError(t, VerifyRangeProof(account.Root, origin, 100, keys, values, proof))
+
+		require.NoError(t, stateSync.AddStorage(keys, values, nil))
+		assert.True(t, target.HasState(account.Root))
+	}
+
+	require.NoError(t, stateSync.FlushAccounts(nil))
+	require.NoError(t, stateSync.Commit())
+	assert.True(t, target.HasState(root))
+
+	snap, err := target.NewSnapshotAt(root)
+	require.NoError(t, err)
+
+	account, err := snap.GetAccount(objs[5].Address)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(6), account.Balance)
+	assert.Equal(t, types.BytesToHash(big.NewInt(6).Bytes()), snap.GetStorage(objs[5].Address, account.Root, prunerTestSlot))
+
+	// a state with a different root is rejected
+	stateSync = target.NewStateSync(types.StringToHash("1"))
+
+	_, err = stateSync.AddAccounts(keys, values)
+	require.NoError(t, err)
+	require.NoError(t, stateSync.FlushAccounts(nil))
+	assert.Error(t, stateSync.Commit())
+}
+
+func TestStateSync_Resume(t *testing.T) {
+	t.Parallel()
+
+	storage := NewMemoryStorage()
+
+	txn := NewTrie().Txn(storage)
+	txn.batch = storage.Batch()
+
+	for i := 0; i < 100; i++ {
+		txn.Insert(hashit(big.NewInt(int64(i)).Bytes()), big.NewInt(int64(i+1)).Bytes())
+	}
+
+	rawRoot, err := txn.Hash()
+	require.NoError(t, err)
+
+	var (
+		root   = types.BytesToHash(rawRoot)
+		source = NewState(storage)
+		target = NewState(NewMemoryStorage())
+	)
+
+	// syncRanges syncs at most the given number of ranges of the storage trie, and returns whether it's complete
+	syncRanges := func(stateSync *StateSync, ranges int) bool {
+		t.Helper()
+
+		origin := stateSync.StorageOrigin(root)
+
+		for i := 0; i < ranges; i++ {
+			keys, values, proof, err := source.GetRange(root, origin, 30)
+			require.NoError(t, err)
+			require.NoError(t, VerifyRangeProof(root, origin, 30, keys, values, proof))
+
+			var next []byte
+			if len(keys) == 30 {
+				next = new(big.Int).Add(new(big.Int).SetBytes(keys[len(keys)-1]), big.NewInt(1)).FillBytes(make([]byte, 32))
+			}
+
+			require.NoError(t, stateSync.AddStorage(keys, values, next))
+
+			if next == nil {
+				return true
+			}
+
+			origin = next
+		}
+
+		return false
+	}
+
+	// the sync is interrupted after two ranges
+	assert.False(t, syncRanges(target.NewStateSync(types.EmptyRootHash), 2))
+	assert.False(t, target.HasState(root))
+
+	// the sync of the same state resumes from the third range
+	stateSync := target.NewStateSync(types.EmptyRootHash)
+	assert.NotEqual(t, make([]byte, types.HashLength), stateSync.StorageOrigin(root))
+
+	assert.True(t, syncRanges(stateSync, 2))
+	assert.True(t, target.HasState(root))
+
+	// the sync of another state starts over
+	assert.Equal(t, make([]byte, types.HashLength), target.NewStateSync(root).StorageOrigin(root))
+}
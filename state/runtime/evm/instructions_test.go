@@ -106,6 +106,8 @@ type mockHostForInstructions struct {
 	nonce       uint64
 	code        []byte
 	callxResult *runtime.ExecutionResult
+	accessList  map[types.Address]map[types.Hash]struct{}
+	transient   map[types.Address]map[types.Hash]types.Hash
 }
 
 func (m *mockHostForInstructions) GetNonce(types.Address) uint64 {
@@ -120,6 +122,54 @@ func (m *mockHostForInstructions) GetCode(addr types.Address) []byte {
 	return m.code
 }
 
+func (m *mockHostForInstructions) AddressInAccessList(addr types.Address) bool {
+	_, ok := m.accessList[addr]
+
+	return ok
+}
+
+func (m *mockHostForInstructions) SlotInAccessList(addr types.Address, slot types.Hash) (bool, bool) {
+	slots, ok := m.accessList[addr]
+	if !ok {
+		return false, false
+	}
+
+	_, slotOk := slots[slot]
+
+	return true, slotOk
+}
+
+func (m *mockHostForInstructions) AddAddressToAccessList(addr types.Address) {
+	if m.accessList == nil {
+		m.accessList = map[types.Address]map[types.Hash]struct{}{}
+	}
+
+	if _, ok := m.accessList[addr]; !ok {
+		m.accessList[addr] = map[types.Hash]struct{}{}
+	}
+}
+
+func (m *mockHostForInstructions) AddSlotToAccessList(addr types.Address, slot types.Hash) {
+	m.AddAddressToAccessList(addr)
+	m.accessList[addr][slot] = struct{}{}
+}
+
+func (m *mockHostForInstructions) GetTransientState(addr types.Address, key types.Hash) types.Hash {
+	return m.transient[addr][key]
+}
+
+func (m *mockHostForInstructions) SetTransientState(addr types.Address, key types.Hash, value types.Hash) {
+	if m.transient == nil {
+		m.transient = map[types.Address]map[types.Hash]types.Hash{}
+	}
+
+	if _, ok := m.transient[addr]; !ok {
+		m.transient[addr] = map[types.Hash]types.Hash{}
+	}
+
+	m.transient[addr][key] = value
+}
+
 var (
 	addr1 = types.StringToAddress("1")
 )
@@ -650,6 +700,10 @@ func Test_opCall(t *testing.T) {
 				callxResult: &runtime.ExecutionResult{
 					ReturnValue: []byte{0x03},
 				},
+				// the called address is warm (eip-2929)
+				accessList: map[types.Address]map[types.Hash]struct{}{
+					types.ZeroAddress: {},
+				},
 			},
 		},
 	}
@@ -677,3 +731,139 @@ func Test_opCall(t *testing.T) {
 		})
 	}
 }
+
+func TestAccessListGasCost(t *testing.T) {
+	t.Parallel()
+
+	s, closeFn := getState()
+	defer closeFn()
+
+	s.msg = &runtime.Contract{Address: addr1}
+	s.host = &mockHostForInstructions{}
+
+	// the first access is cold, the following ones are warm
+	assert.Equal(t, coldAccountAccessCost, s.accessAddressCost(addr1))
+	assert.Equal(t, warmStorageReadCost, s.accessAddressCost(addr1))
+
+	assert.Equal(t, coldSloadCost, s.accessSlotCost(types.Hash{0x1}))
+	assert.Equal(t, warmStorageReadCost, s.accessSlotCost(types.Hash{0x1}))
+	assert.Equal(t, coldSloadCost, s.accessSlotCost(types.Hash{0x2}))
+}
+
+func TestPush0(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should push zero after Shanghai", func(t *testing.T) {
+		t.Parallel()
+
+		s, closeFn := getState()
+		defer closeFn()
+
+		s.config = &allEnabledForks
+
+		opPush0(s)
+
+		assert.NoError(t, s.err)
+		assert.Equal(t, 1, s.sp)
+		assert.Equal(t, zero, s.pop())
+	})
+
+	t.Run("should fail before Shanghai", func(t *testing.T) {
+		t.Parallel()
+
+		s, closeFn := getState()
+		defer closeFn()
+
+		s.config = &chain.ForksInTime{}
+
+		opPush0(s)
+
+		assert.True(t, s.stop)
+		assert.Equal(t, errOpCodeNotFound, s.err)
+	})
+}
+
+func TestMCopy(t *testing.T) {
+	t.Parallel()
+
+	s, closeFn := getState()
+	defer closeFn()
+
+	s.config = &allEnabledForks
+	s.gas = 1000
+	s.memory = append([]byte{1, 2, 3, 4, 5, 6, 7, 8}, make([]byte, 24)...)
+
+	// overlapping areas: copy [0, 6) to [2, 8)
+	s.push(big.NewInt(6)) // length
+	s.push(big.NewInt(0)) // source offset
+	s.push(big.NewInt(2)) // destination offset
+
+	opMCopy(s)
+
+	assert.NoError(t, s.err)
+	assert.Equal(t, []byte{1, 2, 1, 2, 3, 4, 5, 6}, s.memory[:8])
+
+	// copying beyond the current memory expands it
+	s.push(big.NewInt(8))  // length
+	s.push(big.NewInt(0))  // source offset
+	s.push(big.NewInt(32)) // destination offset
+
+	opMCopy(s)
+
+	assert.NoError(t, s.err)
+	assert.Len(t, s.memory, 64)
+	assert.Equal(t, s.memory[:8], s.memory[32:40])
+}
+
+func TestTransientStorage(t *testing.T) {
+	t.Parallel()
+
+	s, closeFn := getState()
+	defer closeFn()
+
+	s.config = &allEnabledForks
+	s.msg = &runtime.Contract{Address: addr1}
+	s.host = &mockHostForInstructions{}
+
+	s.push(big.NewInt(10)) // value
+	s.push(big.NewInt(1))  // key
+
+	opTstore(s)
+	assert.NoError(t, s.err)
+
+	s.push(big.NewInt(1)) // key
+
+	opTload(s)
+	assert.NoError(t, s.err)
+	assert.Equal(t, big.NewInt(10), s.pop())
+
+	// transient storage can not be written in a static call
+	s.msg.Static = true
+
+	s.push(big.NewInt(10)) // value
+	s.push(big.NewInt(1))  // key
+
+	opTstore(s)
+	assert.Equal(t, errWriteProtection, s.err)
+}
+
+func TestCreateMaxInitCodeSize(t *testing.T) {
+	t.Parallel()
+
+	s, closeFn := getState()
+	defer closeFn()
+
+	s.config = &allEnabledForks
+	s.gas = 1000000
+	s.msg = &runtime.Contract{Address: addr1}
+	s.host = &mockHostForInstructions{}
+
+	s.push(big.NewInt(runtime.MaxInitCodeSize + 1)) // length
+	s.push(big.NewInt(0))                           // offset
+	s.push(big.NewInt(0))                           // value
+
+	opCreate(CREATE)(s)
+
+	assert.True(t, s.stop)
+	assert.Equal(t, runtime.ErrMaxInitCodeSizeExceeded, s.err)
+}
@@ -19,6 +19,7 @@ type TxContext struct {
 	GasLimit   int64
 	ChainID    int64
 	Difficulty types.Hash
+	BaseFee    *big.Int
 	Tracer     tracer.Tracer
 }
 
@@ -75,6 +76,12 @@ type Host interface {
 	Transfer(from types.Address, to types.Address, amount *big.Int) error
 	GetTracer() VMTracer
 	GetRefund() uint64
+	AddressInAccessList(addr types.Address) bool
+	SlotInAccessList(addr types.Address, slot types.Hash) (addressOk bool, slotOk bool)
+	AddAddressToAccessList(addr types.Address)
+	AddSlotToAccessList(addr types.Address, slot types.Hash)
+	GetTransientState(addr types.Address, key types.Hash) types.Hash
+	SetTransientState(addr types.Address, key types.Hash, value types.Hash)
 }
 
 type VMTracer interface {
@@ -133,6 +140,7 @@ var (
 	ErrNotEnoughFunds           = errors.New("not enough funds")
 	ErrInsufficientBalance      = errors.New("insufficient balance for transfer")
 	ErrMaxCodeSizeExceeded      = errors.New("evm: max code size exceeded")
+	ErrMaxInitCodeSizeExceeded  = errors.New("evm: max initcode size exceeded")
 	ErrContractAddressCollision = errors.New("contract address collision")
 	ErrDepth                    = errors.New("max call depth exceeded")
 	ErrExecutionReverted        = errors.New("execution was reverted")
@@ -142,6 +150,14 @@ var (
 	ErrNotAuth                  = errors.New("not in allow list")
 )
 
+const (
+	// MaxInitCodeSize is the max size of the initcode of a contract creation (EIP-3860)
+	MaxInitCodeSize = 2 * 24576
+
+	// InitCodeWordGas is the gas per word of the initcode of a contract creation (EIP-3860)
+	InitCodeWordGas uint64 = 2
+)
+
 type CallType int
 
 const (
@@ -0,0 +1,247 @@
+package calltracer
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/umbracle/ethgo/abi"
+
+	"github.com/newton2049/favo-chain/helper/hex"
+	"github.com/newton2049/favo-chain/state/runtime"
+	"github.com/newton2049/favo-chain/state/runtime/evm"
+	"github.com/newton2049/favo-chain/state/runtime/tracer"
+	"github.com/newton2049/favo-chain/types"
+)
+
+var (
+	// ErrNoCallFrame is returned when no call has been traced
+	ErrNoCallFrame = errors.New("no call frame captured")
+)
+
+type Config struct {
+	OnlyTopCall bool // capture only the top-level call
+}
+
+// CallFrame is a node of the call tree
+type CallFrame struct {
+	Type         string       `json:"type"`
+	From         string       `json:"from"`
+	To           string       `json:"to,omitempty"`
+	Value        string       `json:"value,omitempty"`
+	Gas          string       `json:"gas"`
+	GasUsed      string       `json:"gasUsed"`
+	Input        string       `json:"input"`
+	Output       string       `json:"output,omitempty"`
+	Error        string       `json:"error,omitempty"`
+	RevertReason string       `json:"revertReason,omitempty"`
+	Calls        []*CallFrame `json:"calls,omitempty"`
+}
+
+// CallTracer builds the tree of the calls made by a transaction
+type CallTracer struct {
+	Config Config
+
+	cancelLock sync.RWMutex
+	reason     error
+	interrupt  bool
+
+	gasLimit  uint64
+	root      *CallFrame
+	callstack []*CallFrame
+}
+
+func NewCallTracer(config Config) *CallTracer {
+	return &CallTracer{
+		Config:     config,
+		cancelLock: sync.RWMutex{},
+	}
+}
+
+func (t *CallTracer) Cancel(err error) {
+	t.cancelLock.Lock()
+	defer t.cancelLock.Unlock()
+
+	t.reason = err
+	t.interrupt = true
+}
+
+func (t *CallTracer) cancelled() bool {
+	t.cancelLock.RLock()
+	defer t.cancelLock.RUnlock()
+
+	return t.interrupt
+}
+
+func (t *CallTracer) Clear() {
+	t.reason = nil
+	t.interrupt = false
+	t.gasLimit = 0
+	t.root = nil
+	t.callstack = t.callstack[:0]
+}
+
+func (t *CallTracer) TxStart(
+	gasLimit uint64,
+	from types.Address,
+	to *types.Address,
+	coinbase types.Address,
+	host tracer.RuntimeHost,
+) {
+	t.gasLimit = gasLimit
+}
+
+func (t *CallTracer) TxEnd(gasLeft uint64) {
+	if t.root == nil {
+		return
+	}
+
+	// the top-level call accounts the whole transaction gas, intrinsic gas included
+	t.root.Gas = hex.EncodeUint64(t.gasLimit)
+	t.root.GasUsed = hex.EncodeUint64(t.gasLimit - gasLeft)
+}
+
+func (t *CallTracer) CallStart(
+	depth int,
+	from, to types.Address,
+	callType int,
+	gas uint64,
+	value *big.Int,
+	input []byte,
+) {
+	if t.Config.OnlyTopCall && depth > 1 {
+		return
+	}
+
+	frame := &CallFrame{
+		Type:  callTypeToString(runtime.CallType(callType)),
+		From:  from.String(),
+		To:    to.String(),
+		Gas:   hex.EncodeUint64(gas),
+		Input: hex.EncodeToHex(input),
+	}
+
+	// delegate and static calls don't transfer value
+	if value != nil && callType != int(runtime.DelegateCall) && callType != int(runtime.StaticCall) {
+		frame.Value = hex.EncodeBig(value)
+	}
+
+	if len(t.callstack) > 0 {
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+
+	t.callstack = append(t.callstack, frame)
+}
+
+func (t *CallTracer) CallEnd(
+	depth int,
+	output []byte,
+	gasUsed uint64,
+	err error,
+) {
+	if (t.Config.OnlyTopCall && depth > 1) || len(t.callstack) == 0 {
+		return
+	}
+
+	frame := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+
+	frame.GasUsed = hex.EncodeUint64(gasUsed)
+
+	switch {
+	case err == nil:
+		frame.Output = hex.EncodeToHex(output)
+
+	case errors.Is(err, runtime.ErrExecutionReverted):
+		frame.Error = err.Error()
+		frame.Output = hex.EncodeToHex(output)
+
+		if reason, unpackErr := abi.UnpackRevertError(output); unpackErr == nil {
+			frame.RevertReason = reason
+		}
+
+	default:
+		frame.Error = err.Error()
+	}
+
+	if depth == 1 {
+		t.root = frame
+	}
+}
+
+func (t *CallTracer) CaptureState(
+	memory []byte,
+	stack []*big.Int,
+	opCode int,
+	contractAddress types.Address,
+	sp int,
+	host tracer.RuntimeHost,
+	state tracer.VMState,
+) {
+	if t.cancelled() {
+		state.Halt()
+
+		return
+	}
+
+	// selfdestruct doesn't go through CallStart/CallEnd, but it transfers the balance
+	if opCode != evm.SELFDESTRUCT || sp < 1 || t.Config.OnlyTopCall || len(t.callstack) == 0 {
+		return
+	}
+
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, &CallFrame{
+		Type:    evm.OpCode(evm.SELFDESTRUCT).String(),
+		From:    contractAddress.String(),
+		To:      types.BytesToAddress(stack[sp-1].Bytes()).String(),
+		Value:   hex.EncodeBig(host.GetBalance(contractAddress)),
+		Gas:     hex.EncodeUint64(0),
+		GasUsed: hex.EncodeUint64(0),
+		Input:   hex.EncodeToHex(nil),
+	})
+}
+
+func (t *CallTracer) ExecuteState(
+	contractAddress types.Address,
+	ip uint64,
+	opCode string,
+	availableGas uint64,
+	cost uint64,
+	lastReturnData []byte,
+	depth int,
+	err error,
+	host tracer.RuntimeHost,
+) {
+}
+
+func (t *CallTracer) GetResult() (interface{}, error) {
+	if t.reason != nil {
+		return nil, t.reason
+	}
+
+	if t.root == nil {
+		return nil, ErrNoCallFrame
+	}
+
+	return t.root, nil
+}
+
+func callTypeToString(callType runtime.CallType) string {
+	switch callType {
+	case runtime.Call:
+		return evm.OpCode(evm.CALL).String()
+	case runtime.CallCode:
+		return evm.OpCode(evm.CALLCODE).String()
+	case runtime.DelegateCall:
+		return evm.OpCode(evm.DELEGATECALL).String()
+	case runtime.StaticCall:
+		return evm.OpCode(evm.STATICCALL).String()
+	case runtime.Create:
+		return evm.OpCode(evm.CREATE).String()
+	case runtime.Create2:
+		return evm.OpCode(evm.CREATE2).String()
+	default:
+		return ""
+	}
+}
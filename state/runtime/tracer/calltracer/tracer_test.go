@@ -0,0 +1,192 @@
+package calltracer
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/newton2049/favo-chain/helper/hex"
+	"github.com/newton2049/favo-chain/state/runtime"
+	"github.com/newton2049/favo-chain/state/runtime/evm"
+	"github.com/newton2049/favo-chain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testFrom  = types.StringToAddress("1")
+	testTo    = types.StringToAddress("2")
+	testInner = types.StringToAddress("3")
+)
+
+type mockState struct {
+	halted bool
+}
+
+func (m *mockState) Halt() {
+	m.halted = true
+}
+
+type mockHost struct {
+	balance *big.Int
+}
+
+func (m *mockHost) GetRefund() uint64 {
+	return 0
+}
+
+func (m *mockHost) GetStorage(types.Address, types.Hash) types.Hash {
+	return types.ZeroHash
+}
+
+func (m *mockHost) GetBalance(types.Address) *big.Int {
+	return m.balance
+}
+
+func (m *mockHost) GetNonce(types.Address) uint64 {
+	return 0
+}
+
+func (m *mockHost) GetCode(types.Address) []byte {
+	return nil
+}
+
+// revertOutput is the ABI encoding of Error("reason")
+var revertOutput = func() []byte {
+	out, _ := hex.DecodeHex(
+		"0x08c379a0" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000006" +
+			"726561736f6e0000000000000000000000000000000000000000000000000000",
+	)
+
+	return out
+}()
+
+func TestCallTracer_CallTree(t *testing.T) {
+	t.Parallel()
+
+	tracer := NewCallTracer(Config{})
+
+	tracer.TxStart(100000, testFrom, &testTo, types.ZeroAddress, &mockHost{})
+	tracer.CallStart(1, testFrom, testTo, int(runtime.Call), 79000, big.NewInt(10), []byte{0x1})
+	tracer.CallStart(2, testTo, testInner, int(runtime.StaticCall), 5000, big.NewInt(0), []byte{0x2})
+	tracer.CallEnd(2, []byte{0x3}, 300, nil)
+	tracer.CallStart(2, testTo, testInner, int(runtime.Call), 5000, big.NewInt(0), nil)
+	tracer.CallEnd(2, revertOutput, 200, runtime.ErrExecutionReverted)
+	tracer.CallEnd(1, []byte{0x4}, 1000, nil)
+	tracer.TxEnd(78000)
+
+	res, err := tracer.GetResult()
+	assert.NoError(t, err)
+
+	assert.Equal(t, &CallFrame{
+		Type:    "CALL",
+		From:    testFrom.String(),
+		To:      testTo.String(),
+		Value:   "0xa",
+		Gas:     hex.EncodeUint64(100000),
+		GasUsed: hex.EncodeUint64(22000),
+		Input:   "0x01",
+		Output:  "0x04",
+		Calls: []*CallFrame{
+			{
+				Type:    "STATICCALL",
+				From:    testTo.String(),
+				To:      testInner.String(),
+				Gas:     hex.EncodeUint64(5000),
+				GasUsed: hex.EncodeUint64(300),
+				Input:   "0x02",
+				Output:  "0x03",
+			},
+			{
+				Type:         "CALL",
+				From:         testTo.String(),
+				To:           testInner.String(),
+				Value:        "0x0",
+				Gas:          hex.EncodeUint64(5000),
+				GasUsed:      hex.EncodeUint64(200),
+				Input:        "0x",
+				Output:       hex.EncodeToHex(revertOutput),
+				Error:        runtime.ErrExecutionReverted.Error(),
+				RevertReason: "reason",
+			},
+		},
+	}, res)
+}
+
+func TestCallTracer_OnlyTopCall(t *testing.T) {
+	t.Parallel()
+
+	tracer := NewCallTracer(Config{OnlyTopCall: true})
+
+	tracer.TxStart(100000, testFrom, &testTo, types.ZeroAddress, &mockHost{})
+	tracer.CallStart(1, testFrom, testTo, int(runtime.Call), 79000, big.NewInt(0), nil)
+	tracer.CallStart(2, testTo, testInner, int(runtime.Call), 5000, big.NewInt(0), nil)
+	tracer.CallEnd(2, nil, 300, nil)
+	tracer.CallEnd(1, nil, 1000, errors.New("out of gas"))
+	tracer.TxEnd(0)
+
+	res, err := tracer.GetResult()
+	assert.NoError(t, err)
+
+	frame, ok := res.(*CallFrame)
+	assert.True(t, ok)
+	assert.Empty(t, frame.Calls)
+	assert.Equal(t, "out of gas", frame.Error)
+	assert.Empty(t, frame.Output)
+}
+
+func TestCallTracer_SelfDestruct(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tracer = NewCallTracer(Config{})
+		host   = &mockHost{balance: big.NewInt(5)}
+		state  = &mockState{}
+	)
+
+	tracer.TxStart(100000, testFrom, &testTo, types.ZeroAddress, host)
+	tracer.CallStart(1, testFrom, testTo, int(runtime.Call), 79000, big.NewInt(0), nil)
+	tracer.CaptureState(nil, []*big.Int{new(big.Int).SetBytes(testInner.Bytes())}, evm.SELFDESTRUCT, testTo, 1, host, state)
+	tracer.CallEnd(1, nil, 5000, nil)
+	tracer.TxEnd(0)
+
+	res, err := tracer.GetResult()
+	assert.NoError(t, err)
+
+	frame, ok := res.(*CallFrame)
+	assert.True(t, ok)
+	assert.Len(t, frame.Calls, 1)
+	assert.Equal(t, "SELFDESTRUCT", frame.Calls[0].Type)
+	assert.Equal(t, testInner.String(), frame.Calls[0].To)
+	assert.Equal(t, "0x5", frame.Calls[0].Value)
+	assert.False(t, state.halted)
+}
+
+func TestCallTracer_Cancel(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tracer = NewCallTracer(Config{})
+		state  = &mockState{}
+		err    = errors.New("timeout")
+	)
+
+	tracer.Cancel(err)
+	tracer.CaptureState(nil, nil, evm.ADD, testTo, 0, &mockHost{}, state)
+
+	assert.True(t, state.halted)
+
+	res, resErr := tracer.GetResult()
+	assert.Nil(t, res)
+	assert.Equal(t, err, resErr)
+}
+
+func TestCallTracer_NoCallFrame(t *testing.T) {
+	t.Parallel()
+
+	res, err := NewCallTracer(Config{}).GetResult()
+
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, ErrNoCallFrame)
+}
@@ -0,0 +1,343 @@
+package prestatetracer
+
+import (
+	"bytes"
+	"math/big"
+	"sync"
+
+	"github.com/newton2049/favo-chain/helper/hex"
+	"github.com/newton2049/favo-chain/state/runtime"
+	"github.com/newton2049/favo-chain/state/runtime/evm"
+	"github.com/newton2049/favo-chain/state/runtime/tracer"
+	"github.com/newton2049/favo-chain/types"
+)
+
+type Config struct {
+	DiffMode bool // return the pre and the post state of the modified accounts
+}
+
+// Account is the state of an account touched by the transaction
+type Account struct {
+	Balance string                    `json:"balance,omitempty"`
+	Nonce   uint64                    `json:"nonce,omitempty"`
+	Code    string                    `json:"code,omitempty"`
+	Storage map[types.Hash]types.Hash `json:"storage,omitempty"`
+}
+
+// DiffResult is the result of the tracer in diff mode
+type DiffResult struct {
+	Pre  map[types.Address]*Account `json:"pre"`
+	Post map[types.Address]*Account `json:"post"`
+}
+
+type account struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+	storage map[types.Hash]types.Hash
+}
+
+func (a *account) exists() bool {
+	return a.nonce > 0 || len(a.code) > 0 || len(a.storage) > 0 || a.balance.Sign() != 0
+}
+
+// PrestateTracer collects the state of the accounts touched by a transaction
+// before it is applied and, in diff mode, after it is applied
+type PrestateTracer struct {
+	Config Config
+
+	cancelLock sync.RWMutex
+	reason     error
+	interrupt  bool
+
+	host    tracer.RuntimeHost
+	pre     map[types.Address]*account
+	created map[types.Address]bool
+	deleted map[types.Address]bool
+}
+
+func NewPrestateTracer(config Config) *PrestateTracer {
+	return &PrestateTracer{
+		Config:     config,
+		cancelLock: sync.RWMutex{},
+		pre:        make(map[types.Address]*account),
+		created:    make(map[types.Address]bool),
+		deleted:    make(map[types.Address]bool),
+	}
+}
+
+func (t *PrestateTracer) Cancel(err error) {
+	t.cancelLock.Lock()
+	defer t.cancelLock.Unlock()
+
+	t.reason = err
+	t.interrupt = true
+}
+
+func (t *PrestateTracer) cancelled() bool {
+	t.cancelLock.RLock()
+	defer t.cancelLock.RUnlock()
+
+	return t.interrupt
+}
+
+func (t *PrestateTracer) Clear() {
+	t.reason = nil
+	t.interrupt = false
+	t.host = nil
+	t.pre = make(map[types.Address]*account)
+	t.created = make(map[types.Address]bool)
+	t.deleted = make(map[types.Address]bool)
+}
+
+func (t *PrestateTracer) TxStart(
+	gasLimit uint64,
+	from types.Address,
+	to *types.Address,
+	coinbase types.Address,
+	host tracer.RuntimeHost,
+) {
+	t.host = host
+
+	t.lookupAccount(from)
+	t.lookupAccount(coinbase)
+
+	if to != nil {
+		t.lookupAccount(*to)
+	}
+}
+
+func (t *PrestateTracer) TxEnd(gasLeft uint64) {
+}
+
+func (t *PrestateTracer) CallStart(
+	depth int,
+	from, to types.Address,
+	callType int,
+	gas uint64,
+	value *big.Int,
+	input []byte,
+) {
+	if _, ok := t.pre[to]; ok {
+		return
+	}
+
+	// the value has already been transferred at this point
+	t.lookupAccount(to)
+
+	acc := t.pre[to]
+	if value != nil && callType != int(runtime.DelegateCall) && callType != int(runtime.StaticCall) {
+		acc.balance.Sub(acc.balance, value)
+	}
+
+	if callType == int(runtime.Create) || callType == int(runtime.Create2) {
+		t.created[to] = true
+
+		// the nonce of the new contract has already been set
+		acc.nonce = 0
+	}
+}
+
+func (t *PrestateTracer) CallEnd(
+	depth int,
+	output []byte,
+	gasUsed uint64,
+	err error,
+) {
+}
+
+func (t *PrestateTracer) CaptureState(
+	memory []byte,
+	stack []*big.Int,
+	opCode int,
+	contractAddress types.Address,
+	sp int,
+	host tracer.RuntimeHost,
+	state tracer.VMState,
+) {
+	if t.cancelled() {
+		state.Halt()
+
+		return
+	}
+
+	stackAddr := func(n int) types.Address {
+		return types.BytesToAddress(stack[sp-n].Bytes())
+	}
+
+	switch {
+	case sp >= 1 && (opCode == evm.SLOAD || opCode == evm.SSTORE):
+		t.lookupStorage(contractAddress, types.BytesToHash(stack[sp-1].Bytes()))
+
+	case sp >= 1 && (opCode == evm.EXTCODECOPY || opCode == evm.EXTCODEHASH ||
+		opCode == evm.EXTCODESIZE || opCode == evm.BALANCE):
+		t.lookupAccount(stackAddr(1))
+
+	case sp >= 1 && opCode == evm.SELFDESTRUCT:
+		t.lookupAccount(stackAddr(1))
+		t.deleted[contractAddress] = true
+
+	case sp >= 2 && (opCode == evm.CALL || opCode == evm.CALLCODE ||
+		opCode == evm.DELEGATECALL || opCode == evm.STATICCALL):
+		t.lookupAccount(stackAddr(2))
+	}
+}
+
+func (t *PrestateTracer) ExecuteState(
+	contractAddress types.Address,
+	ip uint64,
+	opCode string,
+	availableGas uint64,
+	cost uint64,
+	lastReturnData []byte,
+	depth int,
+	err error,
+	host tracer.RuntimeHost,
+) {
+}
+
+// lookupAccount records the current state of the account, if it hasn't been recorded yet
+func (t *PrestateTracer) lookupAccount(addr types.Address) {
+	if _, ok := t.pre[addr]; ok {
+		return
+	}
+
+	t.pre[addr] = &account{
+		balance: new(big.Int).Set(t.host.GetBalance(addr)),
+		nonce:   t.host.GetNonce(addr),
+		code:    t.host.GetCode(addr),
+		storage: make(map[types.Hash]types.Hash),
+	}
+}
+
+// lookupStorage records the current value of the storage slot, if it hasn't been recorded yet
+func (t *PrestateTracer) lookupStorage(addr types.Address, slot types.Hash) {
+	t.lookupAccount(addr)
+
+	if _, ok := t.pre[addr].storage[slot]; ok {
+		return
+	}
+
+	t.pre[addr].storage[slot] = t.host.GetStorage(addr, slot)
+}
+
+func (t *PrestateTracer) GetResult() (interface{}, error) {
+	if t.reason != nil {
+		return nil, t.reason
+	}
+
+	if t.Config.DiffMode {
+		return t.diffResult(), nil
+	}
+
+	res := make(map[types.Address]*Account, len(t.pre))
+
+	for addr, acc := range t.pre {
+		// the contracts created by the transaction didn't exist before
+		if t.created[addr] && !acc.exists() {
+			continue
+		}
+
+		res[addr] = formatAccount(acc)
+	}
+
+	return res, nil
+}
+
+func (t *PrestateTracer) diffResult() *DiffResult {
+	res := &DiffResult{
+		Pre:  make(map[types.Address]*Account),
+		Post: make(map[types.Address]*Account),
+	}
+
+	for addr, pre := range t.pre {
+		// the destructed accounts only appear in the pre state
+		if t.deleted[addr] {
+			res.Pre[addr] = formatAccount(pre)
+
+			continue
+		}
+
+		var (
+			modified = false
+			preAcc   = &Account{}
+			postAcc  = &Account{}
+		)
+
+		if balance := t.host.GetBalance(addr); balance.Cmp(pre.balance) != 0 {
+			modified = true
+			postAcc.Balance = hex.EncodeBig(balance)
+		}
+
+		if nonce := t.host.GetNonce(addr); nonce != pre.nonce {
+			modified = true
+			postAcc.Nonce = nonce
+		}
+
+		if code := t.host.GetCode(addr); !bytes.Equal(code, pre.code) {
+			modified = true
+			postAcc.Code = hex.EncodeToHex(code)
+		}
+
+		for slot, value := range pre.storage {
+			newValue := t.host.GetStorage(addr, slot)
+			if newValue == value {
+				continue
+			}
+
+			modified = true
+
+			if preAcc.Storage == nil {
+				preAcc.Storage = make(map[types.Hash]types.Hash)
+			}
+
+			preAcc.Storage[slot] = value
+
+			if newValue != types.ZeroHash {
+				if postAcc.Storage == nil {
+					postAcc.Storage = make(map[types.Hash]types.Hash)
+				}
+
+				postAcc.Storage[slot] = newValue
+			}
+		}
+
+		if !modified {
+			continue
+		}
+
+		res.Post[addr] = postAcc
+
+		// the contracts created by the transaction didn't exist before
+		if t.created[addr] && !pre.exists() {
+			continue
+		}
+
+		full := formatAccount(pre)
+		full.Storage = preAcc.Storage
+		res.Pre[addr] = full
+	}
+
+	return res
+}
+
+func formatAccount(acc *account) *Account {
+	res := &Account{
+		Balance: hex.EncodeBig(acc.balance),
+		Nonce:   acc.nonce,
+	}
+
+	if len(acc.code) > 0 {
+		res.Code = hex.EncodeToHex(acc.code)
+	}
+
+	if len(acc.storage) > 0 {
+		res.Storage = make(map[types.Hash]types.Hash, len(acc.storage))
+
+		for slot, value := range acc.storage {
+			res.Storage[slot] = value
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,194 @@
+package prestatetracer
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/newton2049/favo-chain/state/runtime"
+	"github.com/newton2049/favo-chain/state/runtime/evm"
+	"github.com/newton2049/favo-chain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testFrom     = types.StringToAddress("1")
+	testTo       = types.StringToAddress("2")
+	testCoinbase = types.StringToAddress("3")
+	testCreated  = types.StringToAddress("4")
+
+	testSlot = types.StringToHash("1")
+)
+
+type mockState struct {
+	halted bool
+}
+
+func (m *mockState) Halt() {
+	m.halted = true
+}
+
+type mockAccount struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+	storage map[types.Hash]types.Hash
+}
+
+type mockHost struct {
+	accounts map[types.Address]*mockAccount
+}
+
+func newMockHost() *mockHost {
+	return &mockHost{
+		accounts: map[types.Address]*mockAccount{
+			testFrom: {balance: big.NewInt(1000), nonce: 1},
+			testTo: {
+				balance: big.NewInt(0),
+				code:    []byte{0x1},
+				storage: map[types.Hash]types.Hash{testSlot: types.StringToHash("a")},
+			},
+		},
+	}
+}
+
+func (m *mockHost) account(addr types.Address) *mockAccount {
+	acc, ok := m.accounts[addr]
+	if !ok {
+		acc = &mockAccount{balance: big.NewInt(0)}
+		m.accounts[addr] = acc
+	}
+
+	if acc.storage == nil {
+		acc.storage = make(map[types.Hash]types.Hash)
+	}
+
+	return acc
+}
+
+func (m *mockHost) GetRefund() uint64 {
+	return 0
+}
+
+func (m *mockHost) GetStorage(addr types.Address, slot types.Hash) types.Hash {
+	return m.account(addr).storage[slot]
+}
+
+func (m *mockHost) GetBalance(addr types.Address) *big.Int {
+	return m.account(addr).balance
+}
+
+func (m *mockHost) GetNonce(addr types.Address) uint64 {
+	return m.account(addr).nonce
+}
+
+func (m *mockHost) GetCode(addr types.Address) []byte {
+	return m.account(addr).code
+}
+
+// runTx simulates a transaction that sends 10 to testTo, which updates a storage slot
+// and creates testCreated
+func runTx(tracer *PrestateTracer, host *mockHost) {
+	state := &mockState{}
+
+	tracer.TxStart(100000, testFrom, &testTo, testCoinbase, host)
+
+	host.account(testFrom).nonce++
+	host.account(testFrom).balance = big.NewInt(990)
+	host.account(testTo).balance = big.NewInt(10)
+
+	tracer.CallStart(1, testFrom, testTo, int(runtime.Call), 79000, big.NewInt(10), nil)
+	tracer.CaptureState(nil, []*big.Int{big.NewInt(2), new(big.Int).SetBytes(testSlot.Bytes())},
+		evm.SSTORE, testTo, 2, host, state)
+	host.account(testTo).storage[testSlot] = types.StringToHash("b")
+
+	// the nonce of the new contract is set before the call starts, the code is deployed at the end
+	host.account(testCreated).nonce = 1
+	tracer.CallStart(2, testTo, testCreated, int(runtime.Create), 5000, big.NewInt(0), nil)
+	host.account(testCreated).code = []byte{0x2}
+	tracer.CallEnd(2, nil, 100, nil)
+
+	tracer.CallEnd(1, nil, 1000, nil)
+	tracer.TxEnd(0)
+}
+
+func TestPrestateTracer_Prestate(t *testing.T) {
+	t.Parallel()
+
+	tracer := NewPrestateTracer(Config{})
+	runTx(tracer, newMockHost())
+
+	res, err := tracer.GetResult()
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[types.Address]*Account{
+		testFrom: {
+			Balance: "0x3e8",
+			Nonce:   1,
+		},
+		testTo: {
+			Balance: "0x0",
+			Code:    "0x01",
+			Storage: map[types.Hash]types.Hash{testSlot: types.StringToHash("a")},
+		},
+		testCoinbase: {
+			Balance: "0x0",
+		},
+	}, res)
+}
+
+func TestPrestateTracer_DiffMode(t *testing.T) {
+	t.Parallel()
+
+	tracer := NewPrestateTracer(Config{DiffMode: true})
+	runTx(tracer, newMockHost())
+
+	res, err := tracer.GetResult()
+	assert.NoError(t, err)
+
+	assert.Equal(t, &DiffResult{
+		Pre: map[types.Address]*Account{
+			testFrom: {
+				Balance: "0x3e8",
+				Nonce:   1,
+			},
+			testTo: {
+				Balance: "0x0",
+				Code:    "0x01",
+				Storage: map[types.Hash]types.Hash{testSlot: types.StringToHash("a")},
+			},
+		},
+		Post: map[types.Address]*Account{
+			testFrom: {
+				Balance: "0x3de",
+				Nonce:   2,
+			},
+			testTo: {
+				Balance: "0xa",
+				Storage: map[types.Hash]types.Hash{testSlot: types.StringToHash("b")},
+			},
+			testCreated: {
+				Nonce: 1,
+				Code:  "0x02",
+			},
+		},
+	}, res)
+}
+
+func TestPrestateTracer_Cancel(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tracer = NewPrestateTracer(Config{})
+		state  = &mockState{}
+		err    = runtime.ErrExecutionReverted
+	)
+
+	tracer.Cancel(err)
+	tracer.CaptureState(nil, nil, evm.ADD, testTo, 0, newMockHost(), state)
+
+	assert.True(t, state.halted)
+
+	res, resErr := tracer.GetResult()
+	assert.Nil(t, res)
+	assert.Equal(t, err, resErr)
+}
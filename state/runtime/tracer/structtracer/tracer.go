@@ -97,7 +97,13 @@ func (t *StructTracer) Clear() {
 	t.currentStack = t.currentStack[:0]
 }
 
-func (t *StructTracer) TxStart(gasLimit uint64) {
+func (t *StructTracer) TxStart(
+	gasLimit uint64,
+	from types.Address,
+	to *types.Address,
+	coinbase types.Address,
+	host tracer.RuntimeHost,
+) {
 	t.gasLimit = gasLimit
 }
 
@@ -118,6 +124,7 @@ func (t *StructTracer) CallStart(
 func (t *StructTracer) CallEnd(
 	depth int,
 	output []byte,
+	gasUsed uint64,
 	err error,
 ) {
 	if depth == 1 {
@@ -12,6 +12,12 @@ type RuntimeHost interface {
 	GetRefund() uint64
 	// GetStorage access the storage slot at the given address and slot hash
 	GetStorage(types.Address, types.Hash) types.Hash
+	// GetBalance returns the balance of the given address
+	GetBalance(types.Address) *big.Int
+	// GetNonce returns the nonce of the given address
+	GetNonce(types.Address) uint64
+	// GetCode returns the code of the given address
+	GetCode(types.Address) []byte
 }
 
 type VMState interface {
@@ -28,7 +34,13 @@ type Tracer interface {
 	GetResult() (interface{}, error)
 
 	// Tx-level
-	TxStart(gasLimit uint64)
+	TxStart(
+		gasLimit uint64,
+		from types.Address,
+		to *types.Address, // nil for contract creation
+		coinbase types.Address,
+		host RuntimeHost, // state before the transaction is applied
+	)
 	TxEnd(gasLeft uint64)
 
 	// Call-level
@@ -43,6 +55,7 @@ type Tracer interface {
 	CallEnd(
 		depth int, // begins from 1
 		output []byte,
+		gasUsed uint64,
 		err error,
 	)
 
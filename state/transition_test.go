@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/newton2049/favo-chain/state/runtime"
+	"github.com/newton2049/favo-chain/state/runtime/precompiled"
 	"github.com/newton2049/favo-chain/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -88,6 +89,36 @@ func TestSubGasLimitPrice(t *testing.T) {
 	}
 }
 
+func TestSubGasLimitPrice_DynamicFee(t *testing.T) {
+	t.Parallel()
+
+	newMsg := func(value int64) *types.Transaction {
+		return &types.Transaction{
+			Type:      types.DynamicFeeTx,
+			From:      addr1,
+			Gas:       90,
+			GasFeeCap: big.NewInt(10),
+			GasTipCap: big.NewInt(1),
+			Value:     big.NewInt(value),
+		}
+	}
+
+	preState := map[types.Address]*PreState{
+		addr1: {
+			Nonce:   0,
+			Balance: 1000,
+		},
+	}
+
+	// the balance covers the max gas cost, but not the value on top of it
+	transition := newTestTransition(preState)
+	assert.Equal(t, ErrNotEnoughFundsForGas, transition.subGasLimitPrice(newMsg(101)))
+	assert.Equal(t, big.NewInt(1000), transition.GetBalance(addr1))
+
+	transition = newTestTransition(preState)
+	assert.NoError(t, transition.subGasLimitPrice(newMsg(100)))
+}
+
 func TestTransfer(t *testing.T) {
 	t.Parallel()
 
@@ -159,3 +190,175 @@ func TestTransfer(t *testing.T) {
 		})
 	}
 }
+
+func TestTransactionGasCost_AccessList(t *testing.T) {
+	t.Parallel()
+
+	to := types.StringToAddress("2")
+	msg := &types.Transaction{
+		Type: types.AccessListTx,
+		To:   &to,
+		AccessList: types.TxAccessList{
+			{Address: addr1, StorageKeys: []types.Hash{hash1, hash2}},
+			{Address: addr2},
+		},
+	}
+
+	cost, err := TransactionGasCost(msg, true, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, TxGas+2*TxAccessListAddressGas+2*TxAccessListStorageKeyGas, cost)
+}
+
+func TestTransactionGasCost_InitCode(t *testing.T) {
+	t.Parallel()
+
+	// 33 zero bytes of initcode take two words
+	msg := &types.Transaction{
+		Input: make([]byte, 33),
+	}
+
+	cost, err := TransactionGasCost(msg, true, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, TxGasContractCreation+33*4, cost)
+
+	cost, err = TransactionGasCost(msg, true, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, TxGasContractCreation+33*4+2*runtime.InitCodeWordGas, cost)
+}
+
+func TestPrepareAccessList(t *testing.T) {
+	t.Parallel()
+
+	to := types.StringToAddress("2")
+	msg := &types.Transaction{
+		From: addr1,
+		To:   &to,
+		AccessList: types.TxAccessList{
+			{Address: addr2, StorageKeys: []types.Hash{hash1}},
+		},
+	}
+
+	transition := newTestTransition(nil)
+	transition.precompiles = precompiled.NewPrecompiled()
+
+	// the access list is not used before the Berlin fork
+	transition.prepareAccessList(msg)
+	assert.False(t, transition.AddressInAccessList(addr1))
+
+	transition.config.Berlin = true
+	transition.prepareAccessList(msg)
+
+	assert.True(t, transition.AddressInAccessList(addr1))
+	assert.True(t, transition.AddressInAccessList(to))
+	assert.True(t, transition.AddressInAccessList(types.StringToAddress("1"))) // ecrecover precompile
+
+	addrOk, slotOk := transition.SlotInAccessList(addr2, hash1)
+	assert.True(t, addrOk)
+	assert.True(t, slotOk)
+
+	_, slotOk = transition.SlotInAccessList(addr2, hash2)
+	assert.False(t, slotOk)
+}
+
+func TestCheckAndProcessTx_MaxInitCodeSize(t *testing.T) {
+	t.Parallel()
+
+	transition := newTestTransition(map[types.Address]*PreState{
+		addr1: {
+			Nonce:   0,
+			Balance: 1000,
+		},
+	})
+	transition.config.Shanghai = true
+
+	msg := &types.Transaction{
+		From:     addr1,
+		Gas:      10,
+		GasPrice: big.NewInt(10),
+		Input:    make([]byte, runtime.MaxInitCodeSize+1),
+	}
+
+	// the oversized initcode is rejected before the gas is bought
+	err := checkAndProcessTx(msg, transition)
+	assert.Equal(t, NewTransitionApplicationError(ErrMaxInitCodeSize, false), err)
+	assert.Equal(t, big.NewInt(1000), transition.GetBalance(addr1))
+}
+
+func TestTransition_AccessList(t *testing.T) {
+	t.Parallel()
+
+	to := types.StringToAddress("2")
+	other := types.StringToAddress("0xabcd")
+	msg := &types.Transaction{
+		From: addr1,
+		To:   &to,
+	}
+
+	transition := newTestTransition(nil)
+	transition.precompiles = precompiled.NewPrecompiled()
+	transition.config.Berlin = true
+	transition.prepareAccessList(msg)
+
+	transition.state.AddAddressToAccessList(other)
+	transition.state.AddSlotToAccessList(to, hash1)
+
+	// the sender and the precompiles are warm anyway, only the touched
+	// accounts and the slots of the recipient are left
+	assert.Equal(t, types.TxAccessList{
+		{Address: to, StorageKeys: []types.Hash{hash1}},
+		{Address: other, StorageKeys: []types.Hash{}},
+	}, transition.AccessList(msg))
+}
+
+func TestTransition_WithStateOverride(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nonce = uint64(5)
+		code  = []byte{0x1, 0x2}
+	)
+
+	transition := newTestTransition(map[types.Address]*PreState{
+		addr1: {
+			Nonce:   1,
+			Balance: 100,
+			State: map[types.Hash]types.Hash{
+				hash1: hash1,
+			},
+		},
+	})
+
+	err := transition.WithStateOverride(types.StateOverride{
+		addr1: {
+			Nonce:   &nonce,
+			Balance: big.NewInt(1000),
+			StateDiff: map[types.Hash]types.Hash{
+				hash2: hash2,
+			},
+		},
+		addr2: {
+			Code: code,
+			State: map[types.Hash]types.Hash{
+				hash1: hash2,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, nonce, transition.GetNonce(addr1))
+	assert.Equal(t, big.NewInt(1000), transition.GetBalance(addr1))
+	assert.Equal(t, hash1, transition.GetStorage(addr1, hash1))
+	assert.Equal(t, hash2, transition.GetStorage(addr1, hash2))
+
+	assert.Equal(t, code, transition.GetCode(addr2))
+	assert.Equal(t, hash2, transition.GetStorage(addr2, hash1))
+
+	// the state and the state diff of an account can't be both overridden
+	err = transition.WithStateOverride(types.StateOverride{
+		addr1: {
+			State:     map[types.Hash]types.Hash{},
+			StateDiff: map[types.Hash]types.Hash{},
+		},
+	})
+	assert.Error(t, err)
+}
@@ -26,6 +26,12 @@ var (
 
 	// refundIndex is the index of the refund
 	refundIndex = types.BytesToHash([]byte{3}).Bytes()
+
+	// accessListIndex is the prefix of the EIP-2929 access list entries in the trie
+	accessListIndex = types.BytesToHash([]byte{4}).Bytes()
+
+	// transientIndex is the prefix of the EIP-1153 transient storage entries in the trie
+	transientIndex = types.BytesToHash([]byte{5}).Bytes()
 )
 
 // Txn is a reference of the state
@@ -283,13 +289,19 @@ func (txn *Txn) SetStorage(
 	if original == value {
 		if original == zeroHash { // reset to original nonexistent slot (2.2.2.1)
 			// Storage was used as memory (allocation and deallocation occurred within the same contract)
-			if config.Istanbul {
+			if config.Berlin {
+				// eip-2929
+				txn.AddRefund(19900)
+			} else if config.Istanbul {
 				txn.AddRefund(19200)
 			} else {
 				txn.AddRefund(19800)
 			}
 		} else { // reset to original existing slot (2.2.2.2)
-			if config.Istanbul {
+			if config.Berlin {
+				// eip-2929
+				txn.AddRefund(2800)
+			} else if config.Istanbul {
 				txn.AddRefund(4200)
 			} else {
 				txn.AddRefund(4800)
@@ -319,6 +331,14 @@ func (txn *Txn) SetState(
 	})
 }
 
+// ClearState removes all the storage slots of the account
+func (txn *Txn) ClearState(addr types.Address) {
+	txn.upsertAccount(addr, true, func(object *StateObject) {
+		object.Account.Root = emptyStateHash
+		object.Txn = iradix.New().Txn()
+	})
+}
+
 // GetState returns the state of the address at a given key
 func (txn *Txn) GetState(addr types.Address, key types.Hash) types.Hash {
 	object, exists := txn.getStateObject(addr)
@@ -474,6 +494,128 @@ func (txn *Txn) GetRefund() uint64 {
 	return data.(uint64)
 }
 
+// Access list (EIP-2929)
+//
+// The warm addresses and slots are stored in the radix tree,
+// so they are reverted together with the rest of the state on a snapshot revert
+
+func accessListAddressKey(addr types.Address) []byte {
+	key := make([]byte, 0, len(accessListIndex)+types.AddressLength)
+	key = append(key, accessListIndex...)
+
+	return append(key, addr.Bytes()...)
+}
+
+func accessListSlotKey(addr types.Address, slot types.Hash) []byte {
+	return append(accessListAddressKey(addr), slot.Bytes()...)
+}
+
+// AddAddressToAccessList adds the address to the access list
+func (txn *Txn) AddAddressToAccessList(addr types.Address) {
+	txn.txn.Insert(accessListAddressKey(addr), true)
+}
+
+// AddSlotToAccessList adds the (address, slot) pair to the access list
+func (txn *Txn) AddSlotToAccessList(addr types.Address, slot types.Hash) {
+	txn.AddAddressToAccessList(addr)
+	txn.txn.Insert(accessListSlotKey(addr, slot), true)
+}
+
+// AddressInAccessList checks if the address is in the access list
+func (txn *Txn) AddressInAccessList(addr types.Address) bool {
+	_, ok := txn.txn.Get(accessListAddressKey(addr))
+
+	return ok
+}
+
+// SlotInAccessList checks if the address and the (address, slot) pair are in the access list
+func (txn *Txn) SlotInAccessList(addr types.Address, slot types.Hash) (bool, bool) {
+	if !txn.AddressInAccessList(addr) {
+		return false, false
+	}
+
+	_, slotOk := txn.txn.Get(accessListSlotKey(addr, slot))
+
+	return true, slotOk
+}
+
+// AccessList returns the addresses and the slots of the access list
+func (txn *Txn) AccessList() types.TxAccessList {
+	var (
+		accessList = types.TxAccessList{}
+		indexes    = make(map[types.Address]int)
+	)
+
+	// the entry of an address precedes the entries of its slots
+	txn.txn.Root().WalkPrefix(accessListIndex, func(k []byte, _ interface{}) bool {
+		key := k[len(accessListIndex):]
+		addr := types.BytesToAddress(key[:types.AddressLength])
+
+		index, ok := indexes[addr]
+		if !ok {
+			index = len(accessList)
+			indexes[addr] = index
+			accessList = append(accessList, types.AccessTuple{Address: addr, StorageKeys: []types.Hash{}})
+		}
+
+		if len(key) > types.AddressLength {
+			accessList[index].StorageKeys = append(
+				accessList[index].StorageKeys,
+				types.BytesToHash(key[types.AddressLength:]),
+			)
+		}
+
+		return false
+	})
+
+	return accessList
+}
+
+// ClearAccessList removes all the entries of the access list
+func (txn *Txn) ClearAccessList() {
+	txn.txn.DeletePrefix(accessListIndex)
+}
+
+// Transient storage (EIP-1153)
+//
+// Like the access list, transient storage lives in the radix tree, so it is reverted
+// on a snapshot revert and discarded at the end of the transaction
+
+func transientStorageKey(addr types.Address, key types.Hash) []byte {
+	k := make([]byte, 0, len(transientIndex)+types.AddressLength+types.HashLength)
+	k = append(k, transientIndex...)
+	k = append(k, addr.Bytes()...)
+
+	return append(k, key.Bytes()...)
+}
+
+// GetTransientState returns the transient storage value of the (address, key) pair
+func (txn *Txn) GetTransientState(addr types.Address, key types.Hash) types.Hash {
+	data, exists := txn.txn.Get(transientStorageKey(addr, key))
+	if !exists {
+		return types.Hash{}
+	}
+
+	//nolint:forcetypeassert
+	return data.(types.Hash)
+}
+
+// SetTransientState sets the transient storage value of the (address, key) pair
+func (txn *Txn) SetTransientState(addr types.Address, key, value types.Hash) {
+	if value == types.ZeroHash {
+		txn.txn.Delete(transientStorageKey(addr, key))
+
+		return
+	}
+
+	txn.txn.Insert(transientStorageKey(addr, key), value)
+}
+
+// ClearTransientStorage removes all the entries of the transient storage
+func (txn *Txn) ClearTransientStorage() {
+	txn.txn.DeletePrefix(transientIndex)
+}
+
 // GetCommittedState returns the state of the address in the trie
 func (txn *Txn) GetCommittedState(addr types.Address, key types.Hash) types.Hash {
 	obj, ok := txn.getStateObject(addr)
@@ -569,6 +711,12 @@ func (txn *Txn) CleanDeleteObjects(deleteEmptyObjects bool) {
 
 	// delete refunds
 	txn.txn.Delete(refundIndex)
+
+	// delete the access list
+	txn.ClearAccessList()
+
+	// delete the transient storage
+	txn.ClearTransientStorage()
 }
 
 func (txn *Txn) Commit(deleteEmptyObjects bool) []*Object {
@@ -66,3 +66,47 @@ func TestSnapshotUpdateData(t *testing.T) {
 	txn.RevertToSnapshot(ss)
 	assert.Equal(t, hash1, txn.GetState(addr1, hash1))
 }
+
+func TestSnapshotUpdateAccessList(t *testing.T) {
+	txn := newTestTxn(defaultPreState)
+
+	txn.AddAddressToAccessList(addr1)
+	assert.True(t, txn.AddressInAccessList(addr1))
+
+	ss := txn.Snapshot()
+	txn.AddSlotToAccessList(addr2, hash1)
+
+	addrOk, slotOk := txn.SlotInAccessList(addr2, hash1)
+	assert.True(t, addrOk)
+	assert.True(t, slotOk)
+
+	txn.RevertToSnapshot(ss)
+
+	addrOk, slotOk = txn.SlotInAccessList(addr2, hash1)
+	assert.False(t, addrOk)
+	assert.False(t, slotOk)
+	assert.True(t, txn.AddressInAccessList(addr1))
+
+	txn.ClearAccessList()
+	assert.False(t, txn.AddressInAccessList(addr1))
+}
+
+func TestSnapshotUpdateTransientStorage(t *testing.T) {
+	txn := newTestTxn(defaultPreState)
+
+	txn.SetTransientState(addr1, hash1, hash2)
+	assert.Equal(t, hash2, txn.GetTransientState(addr1, hash1))
+
+	ss := txn.Snapshot()
+	txn.SetTransientState(addr1, hash1, hash1)
+	assert.Equal(t, hash1, txn.GetTransientState(addr1, hash1))
+
+	txn.RevertToSnapshot(ss)
+	assert.Equal(t, hash2, txn.GetTransientState(addr1, hash1))
+
+	// transient storage is not part of the persistent storage
+	assert.Equal(t, types.Hash{}, txn.GetState(addr1, hash2))
+
+	txn.ClearTransientStorage()
+	assert.Equal(t, types.Hash{}, txn.GetTransientState(addr1, hash1))
+}
@@ -23,6 +23,7 @@ const (
 	SyncPeerClientLoggerName = "sync-peer-client"
 	statusTopicName          = "syncer/status/0.1"
 	defaultTimeoutForStatus  = 10 * time.Second
+	defaultTimeoutForSync    = 30 * time.Second
 )
 
 type syncPeerClient struct {
@@ -337,6 +338,96 @@ func (m *syncPeerClient) GetBlocks(
 	return blockCh, nil
 }
 
+// GetReceipts returns the receipts of the blocks with the given hashes
+func (m *syncPeerClient) GetReceipts(peerID peer.ID, hashes []types.Hash) ([][]*types.Receipt, error) {
+	clt, err := m.newSyncPeerClient(peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), defaultTimeoutForSync)
+	defer cancel()
+
+	resp, err := clt.GetReceipts(timeoutCtx, &proto.GetReceiptsRequest{
+		Hashes: hashesToBytes(hashes),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Receipts) != len(hashes) {
+		return nil, fmt.Errorf("unexpected number of receipts, expected %d, got %d", len(hashes), len(resp.Receipts))
+	}
+
+	res := make([][]*types.Receipt, len(resp.Receipts))
+
+	for i, raw := range resp.Receipts {
+		receipts := types.Receipts{}
+		if err := receipts.UnmarshalStoreRLP(raw); err != nil {
+			return nil, err
+		}
+
+		res[i] = receipts
+	}
+
+	return res, nil
+}
+
+// GetStateRange returns a range of the leaves of the trie with the given root, from the origin key
+func (m *syncPeerClient) GetStateRange(
+	peerID peer.ID,
+	root types.Hash,
+	origin []byte,
+	limit uint64,
+) (*StateRange, error) {
+	clt, err := m.newSyncPeerClient(peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), defaultTimeoutForSync)
+	defer cancel()
+
+	resp, err := clt.GetStateRange(timeoutCtx, &proto.GetStateRangeRequest{
+		Root:   root.Bytes(),
+		Origin: origin,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &StateRange{
+		Keys:   resp.Keys,
+		Values: resp.Values,
+		Proof:  resp.Proof,
+	}, nil
+}
+
+// GetCodes returns the contract codes with the given hashes
+func (m *syncPeerClient) GetCodes(peerID peer.ID, hashes []types.Hash) ([][]byte, error) {
+	clt, err := m.newSyncPeerClient(peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), defaultTimeoutForSync)
+	defer cancel()
+
+	resp, err := clt.GetCodes(timeoutCtx, &proto.GetCodesRequest{
+		Hashes: hashesToBytes(hashes),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Codes) != len(hashes) {
+		return nil, fmt.Errorf("unexpected number of codes, expected %d, got %d", len(hashes), len(resp.Codes))
+	}
+
+	return resp.Codes, nil
+}
+
 // newSyncPeerClient creates gRPC client
 func (m *syncPeerClient) newSyncPeerClient(peerID peer.ID) (proto.SyncPeerClient, error) {
 	conn, err := m.network.NewProtoConnection(syncerProto, peerID)
@@ -359,6 +450,15 @@ func fromProto(protoBlock *proto.Block) (*types.Block, error) {
 	return block, nil
 }
 
+func hashesToBytes(hashes []types.Hash) [][]byte {
+	res := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		res[i] = hash.Bytes()
+	}
+
+	return res
+}
+
 func blockStreamToChannel(stream proto.SyncPeer_GetBlocksClient) (<-chan *types.Block, <-chan error) {
 	blockCh := make(chan *types.Block)
 	errorCh := make(chan error, 1)
@@ -22,6 +22,12 @@ type SyncPeerClient interface {
 	GetBlocks(ctx context.Context, in *GetBlocksRequest, opts ...grpc.CallOption) (SyncPeer_GetBlocksClient, error)
 	// Returns server's status
 	GetStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SyncPeerStatus, error)
+	// Returns the receipts of the blocks
+	GetReceipts(ctx context.Context, in *GetReceiptsRequest, opts ...grpc.CallOption) (*Receipts, error)
+	// Returns the leaves of a state trie range with the proof of its edges
+	GetStateRange(ctx context.Context, in *GetStateRangeRequest, opts ...grpc.CallOption) (*StateRange, error)
+	// Returns the contract codes
+	GetCodes(ctx context.Context, in *GetCodesRequest, opts ...grpc.CallOption) (*Codes, error)
 }
 
 type syncPeerClient struct {
@@ -73,6 +79,33 @@ func (c *syncPeerClient) GetStatus(ctx context.Context, in *emptypb.Empty, opts
 	return out, nil
 }
 
+func (c *syncPeerClient) GetReceipts(ctx context.Context, in *GetReceiptsRequest, opts ...grpc.CallOption) (*Receipts, error) {
+	out := new(Receipts)
+	err := c.cc.Invoke(ctx, "/v1.SyncPeer/GetReceipts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *syncPeerClient) GetStateRange(ctx context.Context, in *GetStateRangeRequest, opts ...grpc.CallOption) (*StateRange, error) {
+	out := new(StateRange)
+	err := c.cc.Invoke(ctx, "/v1.SyncPeer/GetStateRange", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *syncPeerClient) GetCodes(ctx context.Context, in *GetCodesRequest, opts ...grpc.CallOption) (*Codes, error) {
+	out := new(Codes)
+	err := c.cc.Invoke(ctx, "/v1.SyncPeer/GetCodes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SyncPeerServer is the server API for SyncPeer service.
 // All implementations must embed UnimplementedSyncPeerServer
 // for forward compatibility
@@ -81,6 +114,12 @@ type SyncPeerServer interface {
 	GetBlocks(*GetBlocksRequest, SyncPeer_GetBlocksServer) error
 	// Returns server's status
 	GetStatus(context.Context, *emptypb.Empty) (*SyncPeerStatus, error)
+	// Returns the receipts of the blocks
+	GetReceipts(context.Context, *GetReceiptsRequest) (*Receipts, error)
+	// Returns the leaves of a state trie range with the proof of its edges
+	GetStateRange(context.Context, *GetStateRangeRequest) (*StateRange, error)
+	// Returns the contract codes
+	GetCodes(context.Context, *GetCodesRequest) (*Codes, error)
 	mustEmbedUnimplementedSyncPeerServer()
 }
 
@@ -94,6 +133,15 @@ func (UnimplementedSyncPeerServer) GetBlocks(*GetBlocksRequest, SyncPeer_GetBloc
 func (UnimplementedSyncPeerServer) GetStatus(context.Context, *emptypb.Empty) (*SyncPeerStatus, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
 }
+func (UnimplementedSyncPeerServer) GetReceipts(context.Context, *GetReceiptsRequest) (*Receipts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReceipts not implemented")
+}
+func (UnimplementedSyncPeerServer) GetStateRange(context.Context, *GetStateRangeRequest) (*StateRange, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStateRange not implemented")
+}
+func (UnimplementedSyncPeerServer) GetCodes(context.Context, *GetCodesRequest) (*Codes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCodes not implemented")
+}
 func (UnimplementedSyncPeerServer) mustEmbedUnimplementedSyncPeerServer() {}
 
 // UnsafeSyncPeerServer may be embedded to opt out of forward compatibility for this service.
@@ -146,6 +194,60 @@ func _SyncPeer_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SyncPeer_GetReceipts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReceiptsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SyncPeerServer).GetReceipts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.SyncPeer/GetReceipts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SyncPeerServer).GetReceipts(ctx, req.(*GetReceiptsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SyncPeer_GetStateRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SyncPeerServer).GetStateRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.SyncPeer/GetStateRange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SyncPeerServer).GetStateRange(ctx, req.(*GetStateRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SyncPeer_GetCodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SyncPeerServer).GetCodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.SyncPeer/GetCodes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SyncPeerServer).GetCodes(ctx, req.(*GetCodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _SyncPeer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "v1.SyncPeer",
 	HandlerType: (*SyncPeerServer)(nil),
@@ -154,6 +256,18 @@ var _SyncPeer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetStatus",
 			Handler:    _SyncPeer_GetStatus_Handler,
 		},
+		{
+			MethodName: "GetReceipts",
+			Handler:    _SyncPeer_GetReceipts_Handler,
+		},
+		{
+			MethodName: "GetStateRange",
+			Handler:    _SyncPeer_GetStateRange_Handler,
+		},
+		{
+			MethodName: "GetCodes",
+			Handler:    _SyncPeer_GetCodes_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
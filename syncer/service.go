@@ -10,14 +10,24 @@ import (
 	"github.com/newton2049/favo-chain/types"
 )
 
+const (
+	// maxStateRangeLimit is the maximum number of the trie leaves returned at once
+	maxStateRangeLimit = 1024
+	// maxSyncItems is the maximum number of the receipts or the codes requested at once
+	maxSyncItems = 256
+)
+
 var (
-	ErrBlockNotFound = errors.New("block not found")
+	ErrBlockNotFound     = errors.New("block not found")
+	ErrStateNotAvailable = errors.New("state not available")
+	ErrTooManyItems      = errors.New("too many items requested")
 )
 
 type syncPeerService struct {
 	proto.UnimplementedSyncPeerServer
 
 	blockchain Blockchain       // reference to the blockchain module
+	state      State            // reference to the state, nil if the state isn't served
 	network    Network          // reference to the network module
 	stream     *grpc.GrpcStream // reference to the grpc stream
 }
@@ -25,9 +35,11 @@ type syncPeerService struct {
 func NewSyncPeerService(
 	network Network,
 	blockchain Blockchain,
+	state State,
 ) SyncPeerService {
 	return &syncPeerService{
 		blockchain: blockchain,
+		state:      state,
 		network:    network,
 	}
 }
@@ -89,6 +101,81 @@ func (s *syncPeerService) GetStatus(
 	}, nil
 }
 
+// GetReceipts is a gRPC endpoint to return the receipts of the blocks
+func (s *syncPeerService) GetReceipts(
+	ctx context.Context,
+	req *proto.GetReceiptsRequest,
+) (*proto.Receipts, error) {
+	if len(req.Hashes) > maxSyncItems {
+		return nil, ErrTooManyItems
+	}
+
+	resp := &proto.Receipts{
+		Receipts: make([][]byte, 0, len(req.Hashes)),
+	}
+
+	for _, hash := range req.Hashes {
+		receipts, err := s.blockchain.GetReceiptsByHash(types.BytesToHash(hash))
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Receipts = append(resp.Receipts, types.Receipts(receipts).MarshalStoreRLPTo(nil))
+	}
+
+	return resp, nil
+}
+
+// GetStateRange is a gRPC endpoint to return a range of the leaves of a state trie
+func (s *syncPeerService) GetStateRange(
+	ctx context.Context,
+	req *proto.GetStateRangeRequest,
+) (*proto.StateRange, error) {
+	if s.state == nil {
+		return nil, ErrStateNotAvailable
+	}
+
+	if req.Limit > maxStateRangeLimit {
+		return nil, ErrTooManyItems
+	}
+
+	keys, values, proof, err := s.state.GetRange(types.BytesToHash(req.Root), req.Origin, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.StateRange{
+		Keys:   keys,
+		Values: values,
+		Proof:  proof,
+	}, nil
+}
+
+// GetCodes is a gRPC endpoint to return the contract codes, empty if the code isn't found
+func (s *syncPeerService) GetCodes(
+	ctx context.Context,
+	req *proto.GetCodesRequest,
+) (*proto.Codes, error) {
+	if s.state == nil {
+		return nil, ErrStateNotAvailable
+	}
+
+	if len(req.Hashes) > maxSyncItems {
+		return nil, ErrTooManyItems
+	}
+
+	resp := &proto.Codes{
+		Codes: make([][]byte, 0, len(req.Hashes)),
+	}
+
+	for _, hash := range req.Hashes {
+		code, _ := s.state.GetCode(types.BytesToHash(hash))
+		resp.Codes = append(resp.Codes, code)
+	}
+
+	return resp, nil
+}
+
 // toProtoBlock converts type.Block -> proto.Block
 func toProtoBlock(block *types.Block) *proto.Block {
 	return &proto.Block{
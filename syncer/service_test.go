@@ -2,14 +2,18 @@ package syncer
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log"
 	"net"
 	"testing"
 
+	"github.com/newton2049/favo-chain/crypto"
+	itrie "github.com/newton2049/favo-chain/state/immutable-trie"
 	"github.com/newton2049/favo-chain/syncer/proto"
 	"github.com/newton2049/favo-chain/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/test/bufconn"
@@ -154,3 +158,96 @@ func TestGetStatus(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, headerNumber, status.Number)
 }
+
+func TestGetReceipts(t *testing.T) {
+	t.Parallel()
+
+	receipts := []*types.Receipt{
+		{CumulativeGasUsed: 21000, TxHash: types.StringToHash("1")},
+	}
+	receipts[0].SetStatus(types.ReceiptSuccess)
+
+	service := &syncPeerService{
+		blockchain: &mockBlockchain{
+			getReceiptsByHashHandler: func(hash types.Hash) ([]*types.Receipt, error) {
+				if hash != types.StringToHash("1") {
+					return nil, errors.New("not found")
+				}
+
+				return receipts, nil
+			},
+		},
+	}
+
+	client := newMockGrpcClient(t, service)
+
+	resp, err := client.GetReceipts(context.Background(), &proto.GetReceiptsRequest{
+		Hashes: [][]byte{types.StringToHash("1").Bytes()},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Receipts, 1)
+
+	decoded := types.Receipts{}
+	require.NoError(t, decoded.UnmarshalStoreRLP(resp.Receipts[0]))
+	assert.Equal(t, types.Receipts(receipts), decoded)
+
+	_, err = client.GetReceipts(context.Background(), &proto.GetReceiptsRequest{
+		Hashes: [][]byte{types.StringToHash("2").Bytes()},
+	})
+	assert.Error(t, err)
+}
+
+func TestGetStateRange(t *testing.T) {
+	t.Parallel()
+
+	st, root := newSnapSyncTestState(t)
+
+	client := newMockGrpcClient(t, &syncPeerService{state: st})
+
+	origin := make([]byte, types.HashLength)
+
+	resp, err := client.GetStateRange(context.Background(), &proto.GetStateRangeRequest{
+		Root:   root.Bytes(),
+		Origin: origin,
+		Limit:  20,
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.Keys, 20)
+	assert.NoError(t, itrie.VerifyRangeProof(root, origin, 20, resp.Keys, resp.Values, resp.Proof))
+
+	// the limit is capped
+	_, err = client.GetStateRange(context.Background(), &proto.GetStateRangeRequest{
+		Root:   root.Bytes(),
+		Origin: origin,
+		Limit:  maxStateRangeLimit + 1,
+	})
+	assert.ErrorContains(t, err, ErrTooManyItems.Error())
+
+	// the state must be served
+	client = newMockGrpcClient(t, &syncPeerService{})
+
+	_, err = client.GetStateRange(context.Background(), &proto.GetStateRangeRequest{
+		Root:   root.Bytes(),
+		Origin: origin,
+		Limit:  20,
+	})
+	assert.ErrorContains(t, err, ErrStateNotAvailable.Error())
+}
+
+func TestGetCodes(t *testing.T) {
+	t.Parallel()
+
+	st, _ := newSnapSyncTestState(t)
+
+	client := newMockGrpcClient(t, &syncPeerService{state: st})
+
+	codeHash := crypto.Keccak256Hash([]byte{0x60, 0x00})
+
+	resp, err := client.GetCodes(context.Background(), &proto.GetCodesRequest{
+		Hashes: [][]byte{codeHash.Bytes(), types.StringToHash("1").Bytes()},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Codes, 2)
+	assert.Equal(t, []byte{0x60, 0x00}, resp.Codes[0])
+	assert.Empty(t, resp.Codes[1])
+}
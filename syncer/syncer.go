@@ -3,22 +3,34 @@ package syncer
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/newton2049/favo-chain/crypto"
 	"github.com/newton2049/favo-chain/helper/progress"
 	"github.com/newton2049/favo-chain/network/event"
+	itrie "github.com/newton2049/favo-chain/state/immutable-trie"
 	"github.com/newton2049/favo-chain/types"
 )
 
 const (
 	syncerName  = "syncer"
 	syncerProto = "/syncer/0.2"
+
+	// snapSyncPivotDistance is the number of the peer's latest blocks executed after the state is snap synced
+	snapSyncPivotDistance = 64
+
+	// snapSyncMaxPivotAge is the number of the peer's latest blocks an interrupted snap sync resumes from,
+	// the state of an older pivot might not be served by the peers anymore
+	snapSyncMaxPivotAge = 2 * snapSyncPivotDistance
 )
 
 var (
 	errTimeout = errors.New("timeout awaiting block from peer")
+
+	emptyCodeHash = crypto.Keccak256Hash(nil)
 )
 
 // XXX: Don't use this syncer for the consensus that may cause fork.
@@ -26,6 +38,7 @@ var (
 type syncer struct {
 	logger          hclog.Logger
 	blockchain      Blockchain
+	state           State
 	syncProgression Progression
 
 	peerMap         *PeerMap
@@ -35,6 +48,12 @@ type syncer struct {
 	// Timeout for syncing a block
 	blockTimeout time.Duration
 
+	// Whether the state of a recent block is synced instead of executing all the blocks
+	snapSync bool
+
+	// Maximum number of the trie leaves requested at once while snap syncing
+	stateRangeLimit uint64
+
 	// Channel to notify Sync that a new status arrived
 	newStatusCh chan struct{}
 }
@@ -43,15 +62,20 @@ func NewSyncer(
 	logger hclog.Logger,
 	network Network,
 	blockchain Blockchain,
+	state State,
 	blockTimeout time.Duration,
+	snapSync bool,
 ) Syncer {
 	return &syncer{
 		logger:          logger.Named(syncerName),
 		blockchain:      blockchain,
+		state:           state,
 		syncProgression: progress.NewProgressionWrapper(progress.ChainSyncBulk),
-		syncPeerService: NewSyncPeerService(network, blockchain),
+		syncPeerService: NewSyncPeerService(network, blockchain, state),
 		syncPeerClient:  NewSyncPeerClient(logger, network, blockchain),
 		blockTimeout:    blockTimeout,
+		snapSync:        snapSync,
+		stateRangeLimit: maxStateRangeLimit,
 		newStatusCh:     make(chan struct{}),
 		peerMap:         new(PeerMap),
 	}
@@ -186,6 +210,22 @@ func (s *syncer) Sync(callback func(*types.FullBlock) bool) error {
 			continue
 		}
 
+		// sync the state of a recent block instead of executing the blocks up to it
+		if s.shouldSnapSync(bestPeer.Number) {
+			shouldTerminate, err := s.snapSyncWithPeer(bestPeer.ID, bestPeer.Number, callback)
+			if err != nil {
+				s.logger.Warn("failed to complete snap sync with peer, try to next one", "peer ID", bestPeer.ID, "error", err)
+
+				skipList[bestPeer.ID] = true
+
+				continue
+			}
+
+			if shouldTerminate {
+				break
+			}
+		}
+
 		// fetch block from the peer
 		lastNumber, shouldTerminate, err := s.bulkSyncWithPeer(bestPeer.ID, callback)
 		if err != nil {
@@ -255,3 +295,272 @@ func (s *syncer) bulkSyncWithPeer(peerID peer.ID, newBlockCallback func(*types.F
 		}
 	}
 }
+
+// shouldSnapSync returns whether the state is synced from the peer with the given latest block,
+// which is the case for a new node far behind the peer,
+// or for a node whose state is missing after an interrupted snap sync
+func (s *syncer) shouldSnapSync(peerNumber uint64) bool {
+	if !s.snapSync || s.state == nil {
+		return false
+	}
+
+	header := s.blockchain.Header()
+
+	if header.Number == 0 {
+		return peerNumber > snapSyncPivotDistance
+	}
+
+	return !s.state.HasState(header.StateRoot)
+}
+
+// snapSyncWithPeer writes the blocks up to the pivot without executing them,
+// then syncs the state of the pivot block from the peer
+func (s *syncer) snapSyncWithPeer(
+	peerID peer.ID,
+	peerNumber uint64,
+	newBlockCallback func(*types.FullBlock) bool,
+) (bool, error) {
+	var pivot uint64
+	if peerNumber > snapSyncPivotDistance {
+		pivot = peerNumber - snapSyncPivotDistance
+	}
+
+	header := s.blockchain.Header()
+
+	switch {
+	case header.Number > 0 && header.Number+snapSyncMaxPivotAge >= peerNumber &&
+		!s.state.HasState(header.StateRoot):
+		// resume the interrupted sync of the state of the local head, the synced ranges are kept
+		pivot = header.Number
+	case pivot < header.Number:
+		pivot = header.Number
+	}
+
+	s.logger.Info("snap sync started", "peer", peerID, "pivot", pivot)
+
+	pivotBlock, err := s.syncBlocksWithPeer(peerID, pivot)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.syncStateWithPeer(peerID, pivotBlock.Block.Header.StateRoot); err != nil {
+		return false, fmt.Errorf("failed to sync state: %w", err)
+	}
+
+	s.logger.Info("snap sync completed", "pivot", pivot, "root", pivotBlock.Block.Header.StateRoot)
+
+	return newBlockCallback(pivotBlock), nil
+}
+
+// syncBlocksWithPeer writes the blocks up to the pivot, verified with their receipts, and returns the pivot block
+func (s *syncer) syncBlocksWithPeer(peerID peer.ID, pivot uint64) (*types.FullBlock, error) {
+	localLatest := s.blockchain.Header().Number
+
+	if localLatest >= pivot {
+		block, ok := s.blockchain.GetBlockByNumber(pivot, true)
+		if !ok {
+			return nil, ErrBlockNotFound
+		}
+
+		receipts, err := s.blockchain.GetReceiptsByHash(block.Hash())
+		if err != nil {
+			return nil, err
+		}
+
+		return &types.FullBlock{Block: block, Receipts: receipts}, nil
+	}
+
+	blockCh, err := s.syncPeerClient.GetBlocks(peerID, localLatest+1, s.blockTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := s.syncPeerClient.CloseStream(peerID); err != nil {
+			s.logger.Error("Failed to close stream: ", err)
+		}
+	}()
+
+	var (
+		batch      = make([]*types.Block, 0, maxSyncItems)
+		pivotBlock *types.FullBlock
+	)
+
+	writeBatch := func() error {
+		hashes := make([]types.Hash, len(batch))
+		for i, block := range batch {
+			hashes[i] = block.Hash()
+		}
+
+		receipts, err := s.syncPeerClient.GetReceipts(peerID, hashes)
+		if err != nil {
+			return fmt.Errorf("failed to get receipts: %w", err)
+		}
+
+		for i, block := range batch {
+			fullBlock, err := s.blockchain.VerifySyncedBlock(block, receipts[i])
+			if err != nil {
+				return fmt.Errorf("unable to verify block, %w", err)
+			}
+
+			if err := s.blockchain.WriteFullBlock(fullBlock, syncerName); err != nil {
+				return fmt.Errorf("failed to write block while snap syncing: %w", err)
+			}
+
+			pivotBlock = fullBlock
+		}
+
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for pivotBlock == nil || pivotBlock.Block.Number() < pivot {
+		select {
+		case block, ok := <-blockCh:
+			if !ok {
+				return nil, fmt.Errorf("blocks stream closed before the pivot %d", pivot)
+			}
+
+			batch = append(batch, block)
+
+			if len(batch) == maxSyncItems || block.Number() == pivot {
+				if err := writeBatch(); err != nil {
+					return nil, err
+				}
+			}
+		case <-time.After(s.blockTimeout):
+			return nil, errTimeout
+		}
+	}
+
+	return pivotBlock, nil
+}
+
+// syncStateWithPeer syncs the state with the given root from the peer, verifying each range of the tries.
+// The synced ranges are written as they are verified, and the sync resumes where it stopped
+// if it was interrupted, e.g. to continue with another peer
+func (s *syncer) syncStateWithPeer(peerID peer.ID, root types.Hash) error {
+	stateSync := s.state.NewStateSync(root)
+
+	err := s.syncTrieWithPeer(peerID, root, stateSync.AccountOrigin(), func(keys, values [][]byte, next []byte) error {
+		accounts, err := stateSync.AddAccounts(keys, values)
+		if err != nil {
+			return err
+		}
+
+		codeHashes := make(map[types.Hash]struct{})
+
+		for _, account := range accounts {
+			if err := s.syncStorageWithPeer(peerID, stateSync, account.Root); err != nil {
+				return err
+			}
+
+			codeHash := types.BytesToHash(account.CodeHash)
+			if _, ok := s.state.GetCode(codeHash); !ok && codeHash != emptyCodeHash {
+				codeHashes[codeHash] = struct{}{}
+			}
+		}
+
+		if err := s.syncCodesWithPeer(peerID, codeHashes); err != nil {
+			return err
+		}
+
+		// the range of accounts is written once their storage tries and codes are
+		return stateSync.FlushAccounts(next)
+	})
+	if err != nil {
+		return err
+	}
+
+	return stateSync.Commit()
+}
+
+// syncStorageWithPeer syncs the storage trie with the given root from the peer, unless it's already stored
+func (s *syncer) syncStorageWithPeer(peerID peer.ID, stateSync *itrie.StateSync, root types.Hash) error {
+	if s.state.HasState(root) {
+		return nil
+	}
+
+	return s.syncTrieWithPeer(peerID, root, stateSync.StorageOrigin(root), stateSync.AddStorage)
+}
+
+// syncTrieWithPeer fetches the leaves of the trie with the given root by ranges, starting from the origin.
+// The handler gets the key of the next range, nil once the trie is complete
+func (s *syncer) syncTrieWithPeer(
+	peerID peer.ID,
+	root types.Hash,
+	origin []byte,
+	handler func(keys, values [][]byte, next []byte) error,
+) error {
+	for {
+		stateRange, err := s.syncPeerClient.GetStateRange(peerID, root, origin, s.stateRangeLimit)
+		if err != nil {
+			return err
+		}
+
+		if err := itrie.VerifyRangeProof(
+			root, origin, s.stateRangeLimit, stateRange.Keys, stateRange.Values, stateRange.Proof,
+		); err != nil {
+			return err
+		}
+
+		next := nextRangeOrigin(stateRange.Keys, s.stateRangeLimit)
+
+		if err := handler(stateRange.Keys, stateRange.Values, next); err != nil {
+			return err
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		origin = next
+	}
+}
+
+// nextRangeOrigin returns the key following the last key of a full range, nil if the trie is complete
+func nextRangeOrigin(keys [][]byte, limit uint64) []byte {
+	if uint64(len(keys)) < limit {
+		return nil
+	}
+
+	next := new(big.Int).SetBytes(keys[len(keys)-1])
+	if next.Add(next, big.NewInt(1)).BitLen() > types.HashLength*8 {
+		return nil
+	}
+
+	return next.FillBytes(make([]byte, types.HashLength))
+}
+
+// syncCodesWithPeer fetches the contract codes with the given hashes
+func (s *syncer) syncCodesWithPeer(peerID peer.ID, codeHashes map[types.Hash]struct{}) error {
+	hashes := make([]types.Hash, 0, len(codeHashes))
+	for hash := range codeHashes {
+		hashes = append(hashes, hash)
+	}
+
+	for len(hashes) > 0 {
+		batch := hashes
+		if len(batch) > maxSyncItems {
+			batch = batch[:maxSyncItems]
+		}
+
+		hashes = hashes[len(batch):]
+
+		codes, err := s.syncPeerClient.GetCodes(peerID, batch)
+		if err != nil {
+			return err
+		}
+
+		for i, code := range codes {
+			if crypto.Keccak256Hash(code) != batch[i] {
+				return fmt.Errorf("invalid code of hash %s", batch[i])
+			}
+
+			s.state.SetCode(batch[i], code)
+		}
+	}
+
+	return nil
+}
@@ -11,10 +11,14 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/newton2049/favo-chain/blockchain"
+	"github.com/newton2049/favo-chain/crypto"
 	"github.com/newton2049/favo-chain/helper/progress"
 	"github.com/newton2049/favo-chain/network/event"
+	"github.com/newton2049/favo-chain/state"
+	itrie "github.com/newton2049/favo-chain/state/immutable-trie"
 	"github.com/newton2049/favo-chain/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockProgression struct {
@@ -42,6 +46,8 @@ type mockBlockchain struct {
 	verifyFinalizedBlockHandler func(*types.Block) (*types.FullBlock, error)
 	writeBlockHandler           func(*types.Block) error
 	writeFullBlockHandler       func(*types.FullBlock) error
+	getReceiptsByHashHandler    func(types.Hash) ([]*types.Receipt, error)
+	verifySyncedBlockHandler    func(*types.Block, []*types.Receipt) (*types.FullBlock, error)
 }
 
 func (m *mockBlockchain) SubscribeEvents() blockchain.Subscription {
@@ -68,6 +74,14 @@ func (m *mockBlockchain) WriteFullBlock(b *types.FullBlock, s string) error {
 	return m.writeFullBlockHandler(b)
 }
 
+func (m *mockBlockchain) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
+	return m.getReceiptsByHashHandler(hash)
+}
+
+func (m *mockBlockchain) VerifySyncedBlock(b *types.Block, receipts []*types.Receipt) (*types.FullBlock, error) {
+	return m.verifySyncedBlockHandler(b, receipts)
+}
+
 func newSimpleHeaderHandler(num uint64) func() *types.Header {
 	return func() *types.Header {
 		return &types.Header{
@@ -92,6 +106,9 @@ type mockSyncPeerClient struct {
 	getBlocksHandler                      func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error)
 	getPeerStatusUpdateChHandler          func() <-chan *NoForkPeer
 	getPeerConnectionUpdateEventChHandler func() <-chan *event.PeerEvent
+	getReceiptsHandler                    func(peer.ID, []types.Hash) ([][]*types.Receipt, error)
+	getStateRangeHandler                  func(peer.ID, types.Hash, []byte, uint64) (*StateRange, error)
+	getCodesHandler                       func(peer.ID, []types.Hash) ([][]byte, error)
 }
 
 func (m *mockSyncPeerClient) DisablePublishingPeerStatus() {}
@@ -120,6 +137,23 @@ func (m *mockSyncPeerClient) GetBlocks(
 	return m.getBlocksHandler(id, start, timeoutPerBlock)
 }
 
+func (m *mockSyncPeerClient) GetReceipts(id peer.ID, hashes []types.Hash) ([][]*types.Receipt, error) {
+	return m.getReceiptsHandler(id, hashes)
+}
+
+func (m *mockSyncPeerClient) GetStateRange(
+	id peer.ID,
+	root types.Hash,
+	origin []byte,
+	limit uint64,
+) (*StateRange, error) {
+	return m.getStateRangeHandler(id, root, origin, limit)
+}
+
+func (m *mockSyncPeerClient) GetCodes(id peer.ID, hashes []types.Hash) ([][]byte, error) {
+	return m.getCodesHandler(id, hashes)
+}
+
 func (m *mockSyncPeerClient) GetPeerStatusUpdateCh() <-chan *NoForkPeer {
 	return m.getPeerStatusUpdateChHandler()
 }
@@ -171,6 +205,7 @@ func NewTestSyncer(
 		syncPeerService: &mockSyncPeerService{},
 		syncPeerClient:  mockSyncPeerClient,
 		blockTimeout:    blockTimeout,
+		stateRangeLimit: maxStateRangeLimit,
 		newStatusCh:     make(chan struct{}),
 		peerMap:         new(PeerMap),
 	}
@@ -859,3 +894,297 @@ func Test_bulkSyncWithPeer(t *testing.T) {
 		})
 	}
 }
+
+// newSnapSyncTestState creates a state with accounts, storage and code, and returns its root
+func newSnapSyncTestState(t *testing.T) (*itrie.State, types.Hash) {
+	t.Helper()
+
+	var (
+		st   = itrie.NewState(itrie.NewMemoryStorage())
+		code = []byte{0x60, 0x00}
+		objs = make([]*state.Object, 0, 50)
+	)
+
+	for i := 0; i < 50; i++ {
+		obj := &state.Object{
+			Address:  types.BytesToAddress(big.NewInt(int64(i + 1)).Bytes()),
+			Balance:  big.NewInt(int64(i + 1)),
+			CodeHash: emptyCodeHash,
+			Root:     types.EmptyRootHash,
+			Storage: []*state.StorageObject{
+				{
+					Key: types.StringToHash("1").Bytes(),
+					Val: big.NewInt(int64(i + 1)).Bytes(),
+				},
+			},
+		}
+
+		if i%10 == 0 {
+			obj.CodeHash = crypto.Keccak256Hash(code)
+			obj.DirtyCode = true
+			obj.Code = code
+		}
+
+		objs = append(objs, obj)
+	}
+
+	_, root := st.NewSnapshot().Commit(objs)
+
+	return st, types.BytesToHash(root)
+}
+
+func Test_shouldSnapSync(t *testing.T) {
+	t.Parallel()
+
+	source, root := newSnapSyncTestState(t)
+
+	tests := []struct {
+		name       string
+		snapSync   bool
+		header     *types.Header
+		peerNumber uint64
+		expected   bool
+	}{
+		{
+			name:       "should not snap sync if disabled",
+			snapSync:   false,
+			header:     &types.Header{Number: 0},
+			peerNumber: 1000,
+			expected:   false,
+		},
+		{
+			name:       "should snap sync a new node far behind the peer",
+			snapSync:   true,
+			header:     &types.Header{Number: 0},
+			peerNumber: 1000,
+			expected:   true,
+		},
+		{
+			name:       "should not snap sync a new node close to the peer",
+			snapSync:   true,
+			header:     &types.Header{Number: 0},
+			peerNumber: snapSyncPivotDistance,
+			expected:   false,
+		},
+		{
+			name:       "should snap sync if the state of the head is missing",
+			snapSync:   true,
+			header:     &types.Header{Number: 10, StateRoot: types.StringToHash("1")},
+			peerNumber: 20,
+			expected:   true,
+		},
+		{
+			name:       "should not snap sync if the state of the head exists",
+			snapSync:   true,
+			header:     &types.Header{Number: 10, StateRoot: root},
+			peerNumber: 1000,
+			expected:   false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			syncer := NewTestSyncer(
+				nil,
+				&mockBlockchain{
+					headerHandler: func() *types.Header {
+						return test.header
+					},
+				},
+				time.Second,
+				&mockSyncPeerClient{},
+				&mockProgression{},
+			)
+			syncer.state = source
+			syncer.snapSync = test.snapSync
+
+			assert.Equal(t, test.expected, syncer.shouldSnapSync(test.peerNumber))
+		})
+	}
+}
+
+func Test_snapSyncWithPeer(t *testing.T) {
+	t.Parallel()
+
+	source, root := newSnapSyncTestState(t)
+
+	const peerNumber = 100
+
+	pivot := uint64(peerNumber - snapSyncPivotDistance)
+	blocks := createMockBlocks(peerNumber)
+	blocks[pivot-1].Header.StateRoot = root
+
+	newPeerClient := func(getStateRange func(peer.ID, types.Hash, []byte, uint64) (*StateRange, error)) *mockSyncPeerClient {
+		return &mockSyncPeerClient{
+			getBlocksHandler: func(id peer.ID, start uint64, _ time.Duration) (<-chan *types.Block, error) {
+				return blocksToCh(blocks[start-1:], 0), nil
+			},
+			getReceiptsHandler: func(id peer.ID, hashes []types.Hash) ([][]*types.Receipt, error) {
+				return make([][]*types.Receipt, len(hashes)), nil
+			},
+			getStateRangeHandler: getStateRange,
+			getCodesHandler: func(id peer.ID, hashes []types.Hash) ([][]byte, error) {
+				codes := make([][]byte, len(hashes))
+				for i, hash := range hashes {
+					codes[i], _ = source.GetCode(hash)
+				}
+
+				return codes, nil
+			},
+		}
+	}
+
+	getStateRange := func(id peer.ID, root types.Hash, origin []byte, limit uint64) (*StateRange, error) {
+		keys, values, proof, err := source.GetRange(root, origin, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		return &StateRange{Keys: keys, Values: values, Proof: proof}, nil
+	}
+
+	newTestSnapSyncer := func(peerClient *mockSyncPeerClient) (*syncer, *itrie.State, *[]*types.Block) {
+		var (
+			target       = itrie.NewState(itrie.NewMemoryStorage())
+			syncedBlocks = make([]*types.Block, 0, pivot)
+		)
+
+		syncer := NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: newSimpleHeaderHandler(0),
+				verifySyncedBlockHandler: func(b *types.Block, receipts []*types.Receipt) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b, Receipts: receipts}, nil
+				},
+				writeFullBlockHandler: func(b *types.FullBlock) error {
+					syncedBlocks = append(syncedBlocks, b.Block)
+
+					return nil
+				},
+			},
+			time.Second,
+			peerClient,
+			&mockProgression{},
+		)
+		syncer.state = target
+		syncer.snapSync = true
+
+		return syncer, target, &syncedBlocks
+	}
+
+	t.Run("should sync the state of the pivot block", func(t *testing.T) {
+		t.Parallel()
+
+		syncer, target, syncedBlocks := newTestSnapSyncer(newPeerClient(getStateRange))
+
+		var callbackBlock *types.FullBlock
+
+		shouldTerminate, err := syncer.snapSyncWithPeer(peer.ID("X"), peerNumber, func(b *types.FullBlock) bool {
+			callbackBlock = b
+
+			return false
+		})
+
+		require.NoError(t, err)
+		assert.False(t, shouldTerminate)
+		assert.Equal(t, blocks[:pivot], *syncedBlocks)
+		assert.Equal(t, blocks[pivot-1], callbackBlock.Block)
+
+		// the synced state is complete
+		assert.True(t, target.HasState(root))
+
+		snap, err := target.NewSnapshotAt(root)
+		require.NoError(t, err)
+
+		account, err := snap.GetAccount(types.BytesToAddress(big.NewInt(11).Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(11), account.Balance)
+
+		code, ok := target.GetCode(types.BytesToHash(account.CodeHash))
+		assert.True(t, ok)
+		assert.Equal(t, []byte{0x60, 0x00}, code)
+	})
+
+	t.Run("should resume the interrupted sync with another peer", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			accountOrigins [][]byte
+			storageRanges  int
+			failing        = true
+		)
+
+		syncer, target, _ := newTestSnapSyncer(newPeerClient(
+			func(id peer.ID, stateRoot types.Hash, origin []byte, limit uint64) (*StateRange, error) {
+				if stateRoot != root {
+					storageRanges++
+
+					return getStateRange(id, stateRoot, origin, limit)
+				}
+
+				// the first peer disconnects after two ranges of accounts
+				if failing && len(accountOrigins) == 2 {
+					return nil, errors.New("peer disconnected")
+				}
+
+				accountOrigins = append(accountOrigins, origin)
+
+				return getStateRange(id, stateRoot, origin, limit)
+			},
+		))
+
+		syncer.stateRangeLimit = 10
+
+		_, err := syncer.snapSyncWithPeer(peer.ID("X"), peerNumber, func(b *types.FullBlock) bool {
+			return false
+		})
+
+		require.Error(t, err)
+		assert.False(t, target.HasState(root))
+
+		failing = false
+
+		_, err = syncer.snapSyncWithPeer(peer.ID("Y"), peerNumber, func(b *types.FullBlock) bool {
+			return false
+		})
+
+		require.NoError(t, err)
+		assert.True(t, target.HasState(root))
+
+		// each range of accounts is fetched once (the last one is empty), and the storage of each account too
+		require.Len(t, accountOrigins, 6)
+		assert.Equal(t, make([]byte, types.HashLength), accountOrigins[0])
+		assert.NotEqual(t, make([]byte, types.HashLength), accountOrigins[2])
+		assert.Equal(t, 50, storageRanges)
+	})
+
+	t.Run("should reject a range which doesn't match its proof", func(t *testing.T) {
+		t.Parallel()
+
+		syncer, target, _ := newTestSnapSyncer(newPeerClient(
+			func(id peer.ID, root types.Hash, origin []byte, limit uint64) (*StateRange, error) {
+				stateRange, err := getStateRange(id, root, origin, limit)
+				if err != nil {
+					return nil, err
+				}
+
+				// the peer omits a leaf
+				stateRange.Keys = stateRange.Keys[1:]
+				stateRange.Values = stateRange.Values[1:]
+
+				return stateRange, nil
+			},
+		))
+
+		_, err := syncer.snapSyncWithPeer(peer.ID("X"), peerNumber, func(b *types.FullBlock) bool {
+			return false
+		})
+
+		assert.ErrorIs(t, err, itrie.ErrInvalidRangeProof)
+		assert.False(t, target.HasState(root))
+	})
+}
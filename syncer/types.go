@@ -12,6 +12,7 @@ import (
 	"github.com/newton2049/favo-chain/helper/progress"
 	"github.com/newton2049/favo-chain/network"
 	"github.com/newton2049/favo-chain/network/event"
+	itrie "github.com/newton2049/favo-chain/state/immutable-trie"
 	"github.com/newton2049/favo-chain/types"
 	"google.golang.org/protobuf/proto"
 )
@@ -29,6 +30,23 @@ type Blockchain interface {
 	WriteBlock(*types.Block, string) error
 	// WriteFullBlock writes a given block to chain and saves its receipts to cache
 	WriteFullBlock(*types.FullBlock, string) error
+	// GetReceiptsByHash returns the receipts of the block with the given hash
+	GetReceiptsByHash(types.Hash) ([]*types.Receipt, error)
+	// VerifySyncedBlock verifies the block synced along with its receipts, without executing it
+	VerifySyncedBlock(*types.Block, []*types.Receipt) (*types.FullBlock, error)
+}
+
+type State interface {
+	// HasState returns whether the state with the given root is stored
+	HasState(types.Hash) bool
+	// GetRange returns a range of the leaves of a trie and the proof of its edges
+	GetRange(root types.Hash, origin []byte, limit uint64) ([][]byte, [][]byte, [][]byte, error)
+	// GetCode returns the contract code with the given hash
+	GetCode(types.Hash) ([]byte, bool)
+	// SetCode stores the contract code
+	SetCode(types.Hash, []byte)
+	// NewStateSync creates the writer of the synced state with the given root
+	NewStateSync(types.Hash) *itrie.StateSync
 }
 
 type Network interface {
@@ -97,6 +115,12 @@ type SyncPeerClient interface {
 	GetConnectedPeerStatuses() []*NoForkPeer
 	// GetBlocks returns a stream of blocks from given height to peer's latest
 	GetBlocks(peer.ID, uint64, time.Duration) (<-chan *types.Block, error)
+	// GetReceipts returns the receipts of the blocks with the given hashes
+	GetReceipts(peer.ID, []types.Hash) ([][]*types.Receipt, error)
+	// GetStateRange returns a range of the leaves of the trie with the given root, from the origin key
+	GetStateRange(id peer.ID, root types.Hash, origin []byte, limit uint64) (*StateRange, error)
+	// GetCodes returns the contract codes with the given hashes
+	GetCodes(peer.ID, []types.Hash) ([][]byte, error)
 	// GetPeerStatusUpdateCh returns a channel of peer's status update
 	GetPeerStatusUpdateCh() <-chan *NoForkPeer
 	// GetPeerConnectionUpdateEventCh returns peer's connection change event
@@ -108,3 +132,10 @@ type SyncPeerClient interface {
 	// EnablePublishingPeerStatus enables publishing status in syncer topic
 	EnablePublishingPeerStatus()
 }
+
+// StateRange is a range of the leaves of a trie with the proof of its edges
+type StateRange struct {
+	Keys   [][]byte
+	Values [][]byte
+	Proof  [][]byte
+}
@@ -440,6 +440,17 @@ var Forks = map[string]*chain.Forks{
 		Petersburg:     chain.NewFork(0),
 		Istanbul:       chain.NewFork(0),
 	},
+	"Berlin": {
+		Homestead:      chain.NewFork(0),
+		EIP150:         chain.NewFork(0),
+		EIP155:         chain.NewFork(0),
+		EIP158:         chain.NewFork(0),
+		Byzantium:      chain.NewFork(0),
+		Constantinople: chain.NewFork(0),
+		Petersburg:     chain.NewFork(0),
+		Istanbul:       chain.NewFork(0),
+		Berlin:         chain.NewFork(0),
+	},
 	"London": {
 		Homestead:      chain.NewFork(0),
 		EIP150:         chain.NewFork(0),
@@ -449,7 +460,35 @@ var Forks = map[string]*chain.Forks{
 		Constantinople: chain.NewFork(0),
 		Petersburg:     chain.NewFork(0),
 		Istanbul:       chain.NewFork(0),
+		Berlin:         chain.NewFork(0),
+		London:         chain.NewFork(0),
+	},
+	"Shanghai": {
+		Homestead:      chain.NewFork(0),
+		EIP150:         chain.NewFork(0),
+		EIP155:         chain.NewFork(0),
+		EIP158:         chain.NewFork(0),
+		Byzantium:      chain.NewFork(0),
+		Constantinople: chain.NewFork(0),
+		Petersburg:     chain.NewFork(0),
+		Istanbul:       chain.NewFork(0),
+		Berlin:         chain.NewFork(0),
+		London:         chain.NewFork(0),
+		Shanghai:       chain.NewFork(0),
+	},
+	"Cancun": {
+		Homestead:      chain.NewFork(0),
+		EIP150:         chain.NewFork(0),
+		EIP155:         chain.NewFork(0),
+		EIP158:         chain.NewFork(0),
+		Byzantium:      chain.NewFork(0),
+		Constantinople: chain.NewFork(0),
+		Petersburg:     chain.NewFork(0),
+		Istanbul:       chain.NewFork(0),
+		Berlin:         chain.NewFork(0),
 		London:         chain.NewFork(0),
+		Shanghai:       chain.NewFork(0),
+		Cancun:         chain.NewFork(0),
 	},
 	"FrontierToHomesteadAt5": {
 		Homestead: chain.NewFork(5),
@@ -1,6 +1,7 @@
 package txpool
 
 import (
+	"math/big"
 	"sync"
 	"sync/atomic"
 
@@ -156,6 +157,10 @@ type account struct {
 
 	//	maximum number of enqueued transactions
 	maxEnqueued uint64
+
+	// local is set to 1 once the account submitted a local transaction,
+	// the transactions of the local accounts are never evicted
+	local uint32
 }
 
 // getNonce returns the next expected nonce for this account.
@@ -168,6 +173,16 @@ func (a *account) setNonce(nonce uint64) {
 	atomic.StoreUint64(&a.nextNonce, nonce)
 }
 
+// isLocal returns whether the account submitted a local transaction.
+func (a *account) isLocal() bool {
+	return atomic.LoadUint32(&a.local) == 1
+}
+
+// setLocal marks the account as local.
+func (a *account) setLocal() {
+	atomic.StoreUint32(&a.local, 1)
+}
+
 // Demotions returns the current value of demotions
 func (a *account) Demotions() uint64 {
 	return a.demotions
@@ -242,6 +257,62 @@ func (a *account) enqueue(tx *types.Transaction) error {
 	return nil
 }
 
+// replace swaps the promoted or enqueued transaction of the same nonce for the given one,
+// if the given one pays at least priceBump percent more. It returns the replaced transaction,
+// or nil if the account has no transaction of that nonce.
+func (a *account) replace(tx *types.Transaction, priceBump uint64) (*types.Transaction, error) {
+	a.promoted.lock(true)
+	a.enqueued.lock(true)
+
+	defer func() {
+		a.enqueued.unlock()
+		a.promoted.unlock()
+	}()
+
+	queue, old := a.sameNonce(tx)
+	if old == nil {
+		return nil, nil
+	}
+
+	if !isPriceBumped(tx, old, priceBump) {
+		return nil, ErrReplacementUnderpriced
+	}
+
+	return queue.replace(tx), nil
+}
+
+// replaceable returns the promoted or enqueued transaction the given one would replace,
+// or nil if the account has no transaction of that nonce. It fails if the given one
+// doesn't pay at least priceBump percent more, as replace would.
+func (a *account) replaceable(tx *types.Transaction, priceBump uint64) (*types.Transaction, error) {
+	a.promoted.lock(false)
+	a.enqueued.lock(false)
+
+	defer func() {
+		a.enqueued.unlock()
+		a.promoted.unlock()
+	}()
+
+	_, old := a.sameNonce(tx)
+	if old != nil && !isPriceBumped(tx, old, priceBump) {
+		return nil, ErrReplacementUnderpriced
+	}
+
+	return old, nil
+}
+
+// sameNonce returns the queue holding the transaction of the same nonce as the given one,
+// and that transaction, or nil if there is none. The queues must be locked
+func (a *account) sameNonce(tx *types.Transaction) (*accountQueue, *types.Transaction) {
+	for _, queue := range []*accountQueue{a.promoted, a.enqueued} {
+		if old := queue.getByNonce(tx.Nonce); old != nil {
+			return queue, old
+		}
+	}
+
+	return nil, nil
+}
+
 // Promote moves eligible transactions from enqueued to promoted.
 //
 // Eligible transactions are all sequential in order of nonce
@@ -298,6 +369,70 @@ func (a *account) promote() (promoted []*types.Transaction, pruned []*types.Tran
 	return
 }
 
+// evictionCandidate returns the transaction of the account which would be evicted first,
+// the one with the highest nonce so that no nonce gap is created, if the account occupies
+// more than quota slots. The transactions of the local accounts and the primaries are never evicted.
+func (a *account) evictionCandidate(quota uint64) *types.Transaction {
+	if a.isLocal() {
+		return nil
+	}
+
+	a.promoted.lock(false)
+	a.enqueued.lock(false)
+
+	defer func() {
+		a.enqueued.unlock()
+		a.promoted.unlock()
+	}()
+
+	if slotsRequired(a.promoted.queue...)+slotsRequired(a.enqueued.queue...) <= quota {
+		return nil
+	}
+
+	if last := a.enqueued.last(); last != nil {
+		return last
+	}
+
+	if a.promoted.length() > 1 {
+		return a.promoted.last()
+	}
+
+	return nil
+}
+
+// evict removes the given transaction if it's still the one evicted first,
+// and returns whether it was removed and whether it was promoted.
+func (a *account) evict(tx *types.Transaction) (evicted bool, promoted bool) {
+	a.promoted.lock(true)
+	a.enqueued.lock(true)
+
+	defer func() {
+		a.enqueued.unlock()
+		a.promoted.unlock()
+	}()
+
+	if last := a.enqueued.last(); last != nil {
+		if last != tx {
+			return false, false
+		}
+
+		a.enqueued.removeLast()
+
+		return true, false
+	}
+
+	if a.promoted.length() > 1 && a.promoted.last() == tx {
+		a.promoted.removeLast()
+
+		// the evicted nonce is expected again
+		a.setNonce(tx.Nonce)
+
+		return true, true
+	}
+
+	return false, false
+}
+
 // resetSkips sets 0 to skips
 func (a *account) resetSkips() {
 	a.skips = 0
@@ -327,3 +462,20 @@ func (a *account) getLowestTx() *types.Transaction {
 
 	return nil
 }
+
+// isPriceBumped checks if both the fee cap and the tip cap of the transaction
+// are higher than the ones of the old transaction by at least priceBump percent
+func isPriceBumped(tx, old *types.Transaction, priceBump uint64) bool {
+	bumped := func(price, oldPrice *big.Int) bool {
+		if price.Cmp(oldPrice) <= 0 {
+			return false
+		}
+
+		// price * 100 >= oldPrice * (100 + priceBump)
+		threshold := new(big.Int).Mul(oldPrice, new(big.Int).SetUint64(100+priceBump))
+
+		return new(big.Int).Mul(price, big.NewInt(100)).Cmp(threshold) >= 0
+	}
+
+	return bumped(tx.GetGasFeeCap(), old.GetGasFeeCap()) && bumped(tx.GetGasTipCap(), old.GetGasTipCap())
+}
@@ -16,6 +16,9 @@ type eventManager struct {
 	subscriptionsLock sync.RWMutex
 	numSubscriptions  int64
 	logger            hclog.Logger
+
+	// isPrivate reports the private transactions, whose events aren't sent to the public subscriptions
+	isPrivate func(types.Hash) bool
 }
 
 func newEventManager(logger hclog.Logger) *eventManager {
@@ -33,12 +36,22 @@ type subscribeResult struct {
 
 // subscribe registers a new listener for TxPool events
 func (em *eventManager) subscribe(eventTypes []proto.EventType) *subscribeResult {
+	return em.addSubscription(eventTypes, false)
+}
+
+// subscribePublic registers a new listener for TxPool events, which leaves out the private transactions
+func (em *eventManager) subscribePublic(eventTypes []proto.EventType) *subscribeResult {
+	return em.addSubscription(eventTypes, true)
+}
+
+func (em *eventManager) addSubscription(eventTypes []proto.EventType, public bool) *subscribeResult {
 	em.subscriptionsLock.Lock()
 	defer em.subscriptionsLock.Unlock()
 
 	id := uuid.New().ID()
 	subscription := &eventSubscription{
 		eventTypes: eventTypes,
+		public:     public,
 		outputCh:   make(chan *proto.TxPoolEvent),
 		doneCh:     make(chan struct{}),
 		notifyCh:   make(chan struct{}, 1),
@@ -82,6 +95,9 @@ func (em *eventManager) Close() {
 		subscription.close()
 	}
 
+	// canceling a closed subscription is a no-op
+	em.subscriptions = make(map[subscriptionID]*eventSubscription)
+
 	atomic.StoreInt64(&em.numSubscriptions, 0)
 }
 
@@ -97,7 +113,13 @@ func (em *eventManager) signalEvent(eventType proto.EventType, txHashes ...types
 	defer em.subscriptionsLock.RUnlock()
 
 	for _, txHash := range txHashes {
+		private := em.isPrivate != nil && em.isPrivate(txHash)
+
 		for _, subscription := range em.subscriptions {
+			if private && subscription.public {
+				continue
+			}
+
 			subscription.pushEvent(&proto.TxPoolEvent{
 				Type:   eventType,
 				TxHash: txHash.String(),
@@ -10,6 +10,9 @@ type eventSubscription struct {
 	// eventTypes is the list of subscribed event types
 	eventTypes []proto.EventType
 
+	// public is set if the events of the private transactions are left out
+	public bool
+
 	// outputCh is the update channel for the subscriber
 	outputCh chan *proto.TxPoolEvent
 
@@ -0,0 +1,111 @@
+package txpool
+
+import (
+	"math/big"
+
+	"github.com/newton2049/favo-chain/txpool/proto"
+	"github.com/newton2049/favo-chain/types"
+)
+
+// ensureSlots makes sure the pool has the required slots for the transaction,
+// by evicting cheaper remote transactions if the pool is full
+func (p *TxPool) ensureSlots(tx *types.Transaction, required uint64) error {
+	if required == 0 {
+		return nil
+	}
+
+	p.evictLock.Lock()
+	defer p.evictLock.Unlock()
+
+	height := p.gauge.read()
+	if height <= p.gauge.max && required <= p.gauge.max-height {
+		return nil
+	}
+
+	missing := required
+	if height <= p.gauge.max {
+		missing -= p.gauge.max - height
+	}
+
+	if !p.evict(tx, missing) {
+		return ErrTxPoolOverflow
+	}
+
+	return nil
+}
+
+// evict removes the cheapest transactions of the remote accounts occupying more than their
+// quota of slots, until the given number of slots is freed. Only the transactions paying
+// less than the given transaction are evicted, it returns whether enough slots were freed
+func (p *TxPool) evict(tx *types.Transaction, slots uint64) bool {
+	baseFee := new(big.Int).SetUint64(p.store.CalculateBaseFee(p.store.Header()))
+	price := tx.EffectiveTip(baseFee)
+
+	// the global heap of the transactions each account would evict first
+	candidates := newMinPriceQueue(baseFee)
+
+	p.accounts.Range(func(key, value interface{}) bool {
+		addr, _ := key.(types.Address)
+		account, _ := value.(*account)
+
+		// the transactions preceding the new one aren't evicted
+		if addr == tx.From {
+			return true
+		}
+
+		if candidate := account.evictionCandidate(p.accountSlots); candidate != nil {
+			candidates.push(candidate)
+		}
+
+		return true
+	})
+
+	var (
+		freed   uint64
+		evicted []*types.Transaction
+	)
+
+	for freed < slots {
+		cheapest := candidates.pop()
+		if cheapest == nil || cheapest.EffectiveTip(baseFee).Cmp(price) >= 0 {
+			break
+		}
+
+		account := p.accounts.get(cheapest.From)
+
+		removed, promoted := account.evict(cheapest)
+		if removed {
+			p.index.remove(cheapest)
+			p.gauge.decrease(slotsRequired(cheapest))
+
+			if promoted {
+				p.updatePending(-1)
+			}
+
+			freed += slotsRequired(cheapest)
+			evicted = append(evicted, cheapest)
+		}
+
+		// the account competes with its next transaction
+		if candidate := account.evictionCandidate(p.accountSlots); candidate != nil {
+			candidates.push(candidate)
+		}
+	}
+
+	if len(evicted) > 0 {
+		p.eventManager.signalEvent(proto.EventType_DROPPED, toHash(evicted...)...)
+		p.logger.Debug("evicted txs", "num", len(evicted), "slots", freed, "for", tx.Hash.String())
+	}
+
+	return freed >= slots
+}
+
+// replacementSlots returns the slots the transaction adds to the pool
+// when it replaces the old one, none if the old one is at least as large
+func replacementSlots(tx, old *types.Transaction) uint64 {
+	if required, freed := slotsRequired(tx), slotsRequired(old); required > freed {
+		return required - freed
+	}
+
+	return 0
+}
@@ -0,0 +1,216 @@
+package txpool
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/newton2049/favo-chain/types"
+)
+
+var errJournalClosed = errors.New("txpool journal is not open")
+
+// journalPrivateFlag is the bit of the entry length marking the private transactions
+const journalPrivateFlag = uint32(1) << 31
+
+// journalEntry is a journaled transaction
+type journalEntry struct {
+	tx      *types.Transaction
+	private bool
+}
+
+// journal is the on-disk log of the local transactions, so that they survive the node restarts.
+// Each entry is the RLP encoding of a transaction prefixed by its 4 byte big endian length,
+// whose highest bit marks the private transactions.
+// The transactions are appended as they are added, and the journal is rotated periodically
+// to only keep the transactions of the local accounts which are still in the pool
+type journal struct {
+	logger hclog.Logger
+	path   string
+
+	lock   sync.Mutex
+	file   *os.File                   // the file the transactions are appended to, nil if not open
+	locals map[types.Address]struct{} // the senders of the journaled transactions
+	closed bool
+}
+
+func newJournal(logger hclog.Logger, path string) *journal {
+	return &journal{
+		logger: logger.Named("journal"),
+		path:   path,
+		locals: make(map[types.Address]struct{}),
+	}
+}
+
+// load reads the journaled transactions. A missing journal has no transactions,
+// and the entries following a corrupted one are discarded
+func (j *journal) load() ([]*journalEntry, error) {
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*journalEntry, 0)
+
+	for len(data) > 0 {
+		if len(data) < 4 {
+			j.logger.Warn("discarding the truncated journal entry")
+
+			break
+		}
+
+		size := binary.BigEndian.Uint32(data)
+		private := size&journalPrivateFlag != 0
+		size &^= journalPrivateFlag
+
+		if uint64(len(data)-4) < uint64(size) {
+			j.logger.Warn("discarding the truncated journal entry")
+
+			break
+		}
+
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalRLP(data[4 : 4+size]); err != nil {
+			j.logger.Warn("discarding the corrupted journal entries", "err", err)
+
+			break
+		}
+
+		entries = append(entries, &journalEntry{tx: tx, private: private})
+		data = data[4+size:]
+	}
+
+	return entries, nil
+}
+
+// addLocal marks the account as local, its transactions are kept on rotation
+func (j *journal) addLocal(addr types.Address) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.locals[addr] = struct{}{}
+}
+
+// insert appends the local transaction to the journal
+func (j *journal) insert(tx *types.Transaction, private bool) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.file == nil {
+		return errJournalClosed
+	}
+
+	j.locals[tx.From] = struct{}{}
+
+	return writeJournalEntry(j.file, tx, private)
+}
+
+// rotate rewrites the journal with the pooled transactions of the local accounts,
+// and forgets the local accounts which don't have any transaction left.
+// The transactions reported by isPrivate (if set) are journaled as private
+func (j *journal) rotate(pooled map[types.Address][]*types.Transaction, isPrivate func(types.Hash) bool) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.closed {
+		return errJournalClosed
+	}
+
+	if j.file != nil {
+		if err := j.file.Close(); err != nil {
+			return err
+		}
+
+		j.file = nil
+	}
+
+	tmpPath := j.path + ".new"
+
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	locals := make(map[types.Address]struct{})
+	count := 0
+
+	for addr, txs := range pooled {
+		if _, ok := j.locals[addr]; !ok || len(txs) == 0 {
+			continue
+		}
+
+		for _, tx := range txs {
+			private := isPrivate != nil && isPrivate(tx.Hash)
+
+			if err := writeJournalEntry(tmp, tx, private); err != nil {
+				_ = tmp.Close()
+
+				return err
+			}
+		}
+
+		locals[addr] = struct{}{}
+		count += len(txs)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return err
+	}
+
+	if j.file, err = os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600); err != nil {
+		return err
+	}
+
+	j.locals = locals
+
+	j.logger.Debug("rotated journal", "transactions", count, "accounts", len(locals))
+
+	return nil
+}
+
+// close closes the journal, the transactions aren't journaled anymore
+func (j *journal) close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.closed = true
+
+	if j.file == nil {
+		return nil
+	}
+
+	err := j.file.Close()
+	j.file = nil
+
+	return err
+}
+
+// writeJournalEntry writes the length prefixed RLP encoding of the transaction
+func writeJournalEntry(w io.Writer, tx *types.Transaction, private bool) error {
+	raw := tx.MarshalRLP()
+
+	size := uint32(len(raw))
+	if private {
+		size |= journalPrivateFlag
+	}
+
+	entry := make([]byte, 4+len(raw))
+	binary.BigEndian.PutUint32(entry, size)
+	copy(entry[4:], raw)
+
+	if _, err := w.Write(entry); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package txpool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/newton2049/favo-chain/txpool/proto"
+	"github.com/newton2049/favo-chain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "txpool.journal")
+	j := newJournal(hclog.NewNullLogger(), path)
+
+	// a missing journal has no transactions
+	txs, err := j.load()
+	require.NoError(t, err)
+	assert.Empty(t, txs)
+
+	// the journal is opened by the first rotation
+	tx1, tx2 := newTx(addr1, 0, 1), newTx(addr2, 0, 1)
+	assert.ErrorIs(t, j.insert(tx1, false), errJournalClosed)
+
+	require.NoError(t, j.rotate(nil, nil))
+	require.NoError(t, j.insert(tx1, false))
+	require.NoError(t, j.insert(tx2, true))
+
+	txs, err = j.load()
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, tx1.MarshalRLP(), txs[0].tx.MarshalRLP())
+	assert.False(t, txs[0].private)
+	assert.Equal(t, tx2.MarshalRLP(), txs[1].tx.MarshalRLP())
+	assert.True(t, txs[1].private)
+
+	// only the pooled transactions of the local accounts are kept
+	tx3 := newTx(addr3, 0, 1)
+	tx1.ComputeHash()
+
+	require.NoError(t, j.rotate(map[types.Address][]*types.Transaction{
+		addr1: {tx1},
+		addr3: {tx3},
+	}, func(hash types.Hash) bool {
+		return hash == tx1.Hash
+	}))
+
+	txs, err = j.load()
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, tx1.MarshalRLP(), txs[0].tx.MarshalRLP())
+	assert.True(t, txs[0].private)
+
+	// the accounts without transactions aren't local anymore
+	require.NoError(t, j.rotate(map[types.Address][]*types.Transaction{
+		addr2: {tx2},
+	}, nil))
+
+	txs, err = j.load()
+	require.NoError(t, err)
+	assert.Empty(t, txs)
+
+	require.NoError(t, j.close())
+	assert.ErrorIs(t, j.insert(tx1, false), errJournalClosed)
+	assert.ErrorIs(t, j.rotate(nil, nil), errJournalClosed)
+}
+
+func TestJournal_LoadCorrupted(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "txpool.journal")
+	j := newJournal(hclog.NewNullLogger(), path)
+
+	tx := newTx(addr1, 0, 1)
+
+	require.NoError(t, j.rotate(nil, nil))
+	require.NoError(t, j.insert(tx, false))
+	require.NoError(t, j.close())
+
+	// a partially written entry
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+
+	_, err = file.Write([]byte{0x0, 0x0, 0x1, 0x0, 0xf8})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	txs, err := j.load()
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, tx.MarshalRLP(), txs[0].tx.MarshalRLP())
+}
+
+func TestJournal_Restart(t *testing.T) {
+	t.Parallel()
+
+	journalPath := filepath.Join(t.TempDir(), "txpool.journal")
+	sender := new(eoa).create(t)
+
+	newJournaledPool := func() *TxPool {
+		t.Helper()
+
+		pool, err := NewTxPool(
+			hclog.NewNullLogger(),
+			forks.At(0),
+			defaultMockStore{DefaultHeader: mockHeader},
+			nil,
+			nil,
+			&Config{
+				PriceLimit:         defaultPriceLimit,
+				MaxSlots:           defaultMaxSlots,
+				MaxAccountEnqueued: defaultMaxAccountEnqueued,
+				PriceBump:          defaultPriceBump,
+				JournalPath:        journalPath,
+				JournalRotate:      time.Minute,
+			},
+		)
+		require.NoError(t, err)
+
+		pool.SetSigner(signerEIP155)
+
+		return pool
+	}
+
+	waitForPromoted := func(pool *TxPool, subscription *subscribeResult, count int) {
+		t.Helper()
+
+		ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelFn()
+
+		assert.Len(t, waitForEvents(ctx, subscription, count), count)
+		pool.eventManager.cancelSubscription(subscription.subscriptionID)
+	}
+
+	pool := newJournaledPool()
+	subscription := pool.eventManager.subscribe([]proto.EventType{proto.EventType_PROMOTED})
+
+	pool.Start()
+
+	journaled := make([]*types.Transaction, 0, 2)
+
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx := sender.signTx(t, newTx(sender.Address, nonce, 1), signerEIP155)
+		require.NoError(t, pool.AddTx(tx))
+
+		journaled = append(journaled, tx)
+	}
+
+	waitForPromoted(pool, subscription, 2)
+	pool.Close()
+
+	// the journaled transactions are added again on start
+	pool = newJournaledPool()
+	subscription = pool.eventManager.subscribe([]proto.EventType{proto.EventType_PROMOTED})
+
+	pool.Start()
+	defer pool.Close()
+
+	// the journal is rewritten with the replayed transactions, even before they are pooled
+	txs, err := newJournal(hclog.NewNullLogger(), journalPath).load()
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+
+	for i, entry := range txs {
+		assert.Equal(t, journaled[i].MarshalRLP(), entry.tx.MarshalRLP())
+	}
+
+	waitForPromoted(pool, subscription, 2)
+
+	assert.Equal(t, uint64(2), pool.accounts.get(sender.Address).promoted.length())
+	assert.Equal(t, uint64(2), pool.accounts.get(sender.Address).getNonce())
+}
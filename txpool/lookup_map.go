@@ -10,11 +10,18 @@ import (
 type lookupMap struct {
 	sync.RWMutex
 	all map[types.Hash]*types.Transaction
+
+	// arrivals are the sequence numbers of the arrivals of the transactions in the pool
+	arrivals    map[types.Hash]uint64
+	nextArrival uint64
+
+	// private are the private transactions, which are kept out of the public queries and feeds of the pool
+	private map[types.Hash]struct{}
 }
 
 // add inserts the given transaction into the map. Returns false
 // if it already exists. [thread-safe]
-func (m *lookupMap) add(tx *types.Transaction) bool {
+func (m *lookupMap) add(tx *types.Transaction, private bool) bool {
 	m.Lock()
 	defer m.Unlock()
 
@@ -23,6 +30,12 @@ func (m *lookupMap) add(tx *types.Transaction) bool {
 	}
 
 	m.all[tx.Hash] = tx
+	m.arrivals[tx.Hash] = m.nextArrival
+	m.nextArrival++
+
+	if private {
+		m.private[tx.Hash] = struct{}{}
+	}
 
 	return true
 }
@@ -34,6 +47,8 @@ func (m *lookupMap) remove(txs ...*types.Transaction) {
 
 	for _, tx := range txs {
 		delete(m.all, tx.Hash)
+		delete(m.arrivals, tx.Hash)
+		delete(m.private, tx.Hash)
 	}
 }
 
@@ -49,3 +64,21 @@ func (m *lookupMap) get(hash types.Hash) (*types.Transaction, bool) {
 
 	return tx, true
 }
+
+// arrival returns the sequence number of the arrival of the transaction in the pool. [thread-safe]
+func (m *lookupMap) arrival(hash types.Hash) uint64 {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.arrivals[hash]
+}
+
+// isPrivate returns whether the transaction associated with the given hash is private. [thread-safe]
+func (m *lookupMap) isPrivate(hash types.Hash) bool {
+	m.RLock()
+	defer m.RUnlock()
+
+	_, ok := m.private[hash]
+
+	return ok
+}
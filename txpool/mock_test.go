@@ -35,6 +35,10 @@ func (m defaultMockStore) GetBlockByHash(types.Hash, bool) (*types.Block, bool)
 	return nil, false
 }
 
+func (m defaultMockStore) CalculateBaseFee(*types.Header) uint64 {
+	return 0
+}
+
 func (m defaultMockStore) GetBalance(types.Hash, types.Address) (*big.Int, error) {
 	balance := big.NewInt(0).SetUint64(100000000000000)
 
@@ -56,6 +60,10 @@ func (fms faultyMockStore) GetBlockByHash(hash types.Hash, b bool) (*types.Block
 	return nil, false
 }
 
+func (fms faultyMockStore) CalculateBaseFee(*types.Header) uint64 {
+	return 0
+}
+
 func (fms faultyMockStore) GetBalance(root types.Hash, addr types.Address) (*big.Int, error) {
 	return nil, fmt.Errorf("unable to fetch account state")
 }
@@ -0,0 +1,143 @@
+package txpool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/newton2049/favo-chain/chain"
+	"github.com/newton2049/favo-chain/types"
+)
+
+const (
+	// GasPriceOrdering includes the transactions paying the highest tip first, it's the default policy
+	GasPriceOrdering = "gas-price"
+
+	// FIFOOrdering includes the transactions in the order they arrived in the pool
+	FIFOOrdering = "fifo"
+)
+
+var ErrUnknownOrdering = errors.New("unknown transaction ordering policy")
+
+// TxCandidate is an executable transaction competing for the inclusion in the block being built
+type TxCandidate struct {
+	Tx *types.Transaction
+
+	// Arrival is the sequence number of the arrival of the transaction in the pool
+	Arrival uint64
+}
+
+// Ordering is the policy selecting the order in which the executable transactions
+// of the accounts (their primaries) are handed out to the sealers for block building
+type Ordering interface {
+	// Less reports whether the candidate a is included before the candidate b,
+	// the base fee is the one of the block being built
+	Less(a, b *TxCandidate, baseFee *big.Int) bool
+
+	// Admit reports whether another transaction of the sender can be included in the block being built,
+	// given the number of its transactions already included
+	Admit(sender types.Address, included uint64) bool
+}
+
+// NewOrdering returns the ordering policy of the configuration, the gas price ordering if none is configured.
+// The priority senders and the per sender cap apply on top of the base policy
+func NewOrdering(config *chain.TxOrdering) (Ordering, error) {
+	if config == nil {
+		return gasPriceOrdering{}, nil
+	}
+
+	var ordering Ordering
+
+	switch config.Policy {
+	case "", GasPriceOrdering:
+		ordering = gasPriceOrdering{}
+	case FIFOOrdering:
+		ordering = fifoOrdering{}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOrdering, config.Policy)
+	}
+
+	if len(config.PrioritySenders) > 0 {
+		ordering = newPrioritySendersOrdering(ordering, config.PrioritySenders)
+	}
+
+	if config.MaxTxsPerSender > 0 {
+		ordering = &senderCapOrdering{Ordering: ordering, max: config.MaxTxsPerSender}
+	}
+
+	return ordering, nil
+}
+
+// gasPriceOrdering includes the transactions by effective tip (descending)
+type gasPriceOrdering struct{}
+
+func (gasPriceOrdering) Less(a, b *TxCandidate, baseFee *big.Int) bool {
+	switch a.Tx.EffectiveTip(baseFee).Cmp(b.Tx.EffectiveTip(baseFee)) {
+	case 1:
+		return true
+	case -1:
+		return false
+	}
+
+	// same tip, the transaction with the higher fee cap goes first
+	return a.Tx.GetGasFeeCap().Cmp(b.Tx.GetGasFeeCap()) > 0
+}
+
+func (gasPriceOrdering) Admit(types.Address, uint64) bool {
+	return true
+}
+
+// fifoOrdering includes the transactions by arrival in the pool (ascending)
+type fifoOrdering struct{}
+
+func (fifoOrdering) Less(a, b *TxCandidate, _ *big.Int) bool {
+	return a.Arrival < b.Arrival
+}
+
+func (fifoOrdering) Admit(types.Address, uint64) bool {
+	return true
+}
+
+// prioritySendersOrdering includes the transactions of the priority senders before the others,
+// which are ordered by the underlying policy
+type prioritySendersOrdering struct {
+	Ordering
+
+	senders map[types.Address]struct{}
+}
+
+func newPrioritySendersOrdering(ordering Ordering, senders []types.Address) *prioritySendersOrdering {
+	o := &prioritySendersOrdering{
+		Ordering: ordering,
+		senders:  make(map[types.Address]struct{}, len(senders)),
+	}
+
+	for _, sender := range senders {
+		o.senders[sender] = struct{}{}
+	}
+
+	return o
+}
+
+func (o *prioritySendersOrdering) Less(a, b *TxCandidate, baseFee *big.Int) bool {
+	_, aPriority := o.senders[a.Tx.From]
+	_, bPriority := o.senders[b.Tx.From]
+
+	if aPriority != bPriority {
+		return aPriority
+	}
+
+	return o.Ordering.Less(a, b, baseFee)
+}
+
+// senderCapOrdering limits the number of the transactions of each sender per block,
+// which are ordered by the underlying policy
+type senderCapOrdering struct {
+	Ordering
+
+	max uint64
+}
+
+func (o *senderCapOrdering) Admit(sender types.Address, included uint64) bool {
+	return included < o.max && o.Ordering.Admit(sender, included)
+}
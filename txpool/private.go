@@ -0,0 +1,355 @@
+package txpool
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/newton2049/favo-chain/crypto"
+	"github.com/newton2049/favo-chain/network"
+	"github.com/newton2049/favo-chain/network/grpc"
+	"github.com/newton2049/favo-chain/txpool/proto"
+	"github.com/newton2049/favo-chain/types"
+	rawGrpc "google.golang.org/grpc"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	// privateTxProto is the libp2p protocol the private transactions are forwarded over
+	privateTxProto = "/txpool-private/0.1"
+
+	// privateTxTimeout is the timeout of each request to a proposer
+	privateTxTimeout = 5 * time.Second
+)
+
+var (
+	ErrNotValidator = errors.New("node is not a validator")
+
+	// identityPrefix separates the signatures of the validator identities from any other signature
+	identityPrefix = []byte("txpool-private-identity")
+)
+
+// privateNetwork is the networking stack the private transactions are forwarded over
+type privateNetwork interface {
+	// AddrInfo returns the network info of the node
+	AddrInfo() *peer.AddrInfo
+	// RegisterProtocol registers the gRPC service of the protocol
+	RegisterProtocol(string, network.Protocol)
+	// Peers returns the connected peers
+	Peers() []*network.PeerConnInfo
+	// NewProtoConnection opens up a new stream on the protocol to the peer
+	NewProtoConnection(protocol string, peerID peer.ID) (*rawGrpc.ClientConn, error)
+}
+
+// privateForwarder forwards the private transactions directly to the current and next proposers,
+// instead of gossiping them, and adds the private transactions forwarded by the peers to the pool
+type privateForwarder struct {
+	proto.UnimplementedTxnForwarderServer
+
+	logger  hclog.Logger
+	network privateNetwork
+	stream  *grpc.GrpcStream
+
+	// addTx adds the forwarded transactions to the pool
+	addTx func(txOrigin, *types.Transaction) error
+
+	lock         sync.RWMutex
+	validatorKey *ecdsa.PrivateKey      // signs the identity of the node, nil if not a validator
+	proposers    func() []types.Address // returns the current and next proposers, nil if unknown
+
+	validatorsLock sync.Mutex
+	validators     map[peer.ID]types.Address // the validators of the peers, the zero address if none
+
+	pendingLock  sync.Mutex
+	pending      map[types.Hash]*types.Transaction // the submitted private transactions not yet included or dropped
+	resubmitting sync.Mutex                        // held while the pending transactions are forwarded again
+}
+
+func newPrivateForwarder(
+	logger hclog.Logger,
+	network privateNetwork,
+	addTx func(txOrigin, *types.Transaction) error,
+) *privateForwarder {
+	return &privateForwarder{
+		logger:     logger.Named("private"),
+		network:    network,
+		addTx:      addTx,
+		validators: make(map[peer.ID]types.Address),
+		pending:    make(map[types.Hash]*types.Transaction),
+	}
+}
+
+// start serves the private transactions protocol
+func (f *privateForwarder) start() {
+	f.stream = grpc.NewGrpcStream()
+
+	proto.RegisterTxnForwarderServer(f.stream.GrpcServer(), f)
+	f.stream.Serve()
+	f.network.RegisterProtocol(privateTxProto, f.stream)
+}
+
+// close stops serving the private transactions protocol
+func (f *privateForwarder) close() error {
+	if f.stream == nil {
+		return nil
+	}
+
+	return f.stream.Close()
+}
+
+// setValidatorKey sets the key the node proves its validator identity with
+func (f *privateForwarder) setValidatorKey(key *ecdsa.PrivateKey) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.validatorKey = key
+}
+
+// setProposers sets the function returning the current and next proposers
+func (f *privateForwarder) setProposers(proposers func() []types.Address) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.proposers = proposers
+}
+
+// submit forwards the private transaction submitted to the node,
+// it's forwarded again on each new head until it's included or dropped
+func (f *privateForwarder) submit(tx *types.Transaction) {
+	f.pendingLock.Lock()
+	f.pending[tx.Hash] = tx
+	f.pendingLock.Unlock()
+
+	f.forward(tx)
+}
+
+// resubmit forwards the pending private transactions again to the current and next proposers,
+// and forgets the ones which aren't pooled anymore. It's skipped if the previous pass is still running
+func (f *privateForwarder) resubmit(pooled func(types.Hash) bool) {
+	if !f.resubmitting.TryLock() {
+		return
+	}
+
+	defer f.resubmitting.Unlock()
+
+	f.pendingLock.Lock()
+
+	txs := make([]*types.Transaction, 0, len(f.pending))
+
+	for hash, tx := range f.pending {
+		if !pooled(hash) {
+			delete(f.pending, hash)
+
+			continue
+		}
+
+		txs = append(txs, tx)
+	}
+
+	f.pendingLock.Unlock()
+
+	for _, tx := range txs {
+		f.forward(tx)
+	}
+}
+
+// forward sends the private transaction to the connected peers which are the current or next proposers,
+// and returns the number of the proposers it was forwarded to
+func (f *privateForwarder) forward(tx *types.Transaction) int {
+	f.lock.RLock()
+	getProposers, key := f.proposers, f.validatorKey
+	f.lock.RUnlock()
+
+	if getProposers == nil {
+		f.logger.Debug("the proposers are unknown, the private tx is kept local", "hash", tx.Hash.String())
+
+		return 0
+	}
+
+	proposers := make(map[types.Address]struct{})
+	for _, proposer := range getProposers() {
+		proposers[proposer] = struct{}{}
+	}
+
+	// the transaction is already in the pool of the node
+	if key != nil {
+		delete(proposers, crypto.PubKeyToAddress(&key.PublicKey))
+	}
+
+	peers := f.network.Peers()
+	f.forgetDisconnected(peers)
+
+	forwarded := 0
+
+	for _, peerInfo := range peers {
+		if len(proposers) == 0 {
+			break
+		}
+
+		peerID := peerInfo.Info.ID
+
+		validator, err := f.validatorOf(peerID)
+		if err != nil {
+			f.logger.Debug("failed to identify the peer", "peer", peerID, "err", err)
+
+			continue
+		}
+
+		if _, ok := proposers[validator]; !ok {
+			continue
+		}
+
+		if err := f.forwardTo(peerID, tx); err != nil {
+			f.logger.Error("failed to forward the private tx", "validator", validator, "peer", peerID, "err", err)
+
+			continue
+		}
+
+		delete(proposers, validator)
+
+		forwarded++
+	}
+
+	for proposer := range proposers {
+		f.logger.Warn("no connected peer of the proposer", "validator", proposer, "hash", tx.Hash.String())
+	}
+
+	f.logger.Debug("forwarded private tx", "hash", tx.Hash.String(), "proposers", forwarded)
+
+	return forwarded
+}
+
+// forwardTo sends the private transaction to the peer
+func (f *privateForwarder) forwardTo(peerID peer.ID, tx *types.Transaction) error {
+	conn, err := f.network.NewProtoConnection(privateTxProto, peerID)
+	if err != nil {
+		return fmt.Errorf("failed to open a stream, err %w", err)
+	}
+
+	defer conn.Close()
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), privateTxTimeout)
+	defer cancelFn()
+
+	_, err = proto.NewTxnForwarderClient(conn).ForwardTxn(ctx, &proto.Txn{
+		Raw: &any.Any{
+			Value: tx.MarshalRLP(),
+		},
+	})
+
+	return err
+}
+
+// validatorOf returns the validator of the peer, the zero address if the peer isn't a validator.
+// The peers prove their validator by signing their peer ID with their validator key
+func (f *privateForwarder) validatorOf(peerID peer.ID) (types.Address, error) {
+	f.validatorsLock.Lock()
+	validator, ok := f.validators[peerID]
+	f.validatorsLock.Unlock()
+
+	if ok {
+		return validator, nil
+	}
+
+	conn, err := f.network.NewProtoConnection(privateTxProto, peerID)
+	if err != nil {
+		return types.ZeroAddress, fmt.Errorf("failed to open a stream, err %w", err)
+	}
+
+	defer conn.Close()
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), privateTxTimeout)
+	defer cancelFn()
+
+	identity, err := proto.NewTxnForwarderClient(conn).Identify(ctx, &empty.Empty{})
+	if err != nil {
+		return types.ZeroAddress, err
+	}
+
+	if len(identity.Signature) != 0 {
+		pub, err := crypto.RecoverPubkey(identity.Signature, identityHash(peerID))
+		if err != nil {
+			return types.ZeroAddress, fmt.Errorf("invalid identity signature, err %w", err)
+		}
+
+		validator = crypto.PubKeyToAddress(pub)
+	}
+
+	f.validatorsLock.Lock()
+	f.validators[peerID] = validator
+	f.validatorsLock.Unlock()
+
+	return validator, nil
+}
+
+// forgetDisconnected removes the validators of the disconnected peers
+func (f *privateForwarder) forgetDisconnected(peers []*network.PeerConnInfo) {
+	connected := make(map[peer.ID]struct{}, len(peers))
+	for _, peerInfo := range peers {
+		connected[peerInfo.Info.ID] = struct{}{}
+	}
+
+	f.validatorsLock.Lock()
+	defer f.validatorsLock.Unlock()
+
+	for peerID := range f.validators {
+		if _, ok := connected[peerID]; !ok {
+			delete(f.validators, peerID)
+		}
+	}
+}
+
+// Identify implements the private transactions protocol, the validators sign their peer ID
+func (f *privateForwarder) Identify(_ context.Context, _ *empty.Empty) (*proto.ValidatorIdentity, error) {
+	f.lock.RLock()
+	key := f.validatorKey
+	f.lock.RUnlock()
+
+	if key == nil {
+		return &proto.ValidatorIdentity{}, nil
+	}
+
+	signature, err := crypto.Sign(key, identityHash(f.network.AddrInfo().ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ValidatorIdentity{Signature: signature}, nil
+}
+
+// ForwardTxn implements the private transactions protocol, the forwarded transaction isn't gossiped
+func (f *privateForwarder) ForwardTxn(_ context.Context, raw *proto.Txn) (*empty.Empty, error) {
+	f.lock.RLock()
+	isValidator := f.validatorKey != nil
+	f.lock.RUnlock()
+
+	if !isValidator {
+		return nil, ErrNotValidator
+	}
+
+	if raw.Raw == nil {
+		return nil, fmt.Errorf("transaction's field raw is empty")
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalRLP(raw.Raw.Value); err != nil {
+		return nil, err
+	}
+
+	if err := f.addTx(private, tx); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// identityHash returns the hash of the peer ID signed by the validators
+func identityHash(peerID peer.ID) []byte {
+	return crypto.Keccak256(identityPrefix, []byte(peerID))
+}
@@ -0,0 +1,277 @@
+package txpool
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/newton2049/favo-chain/crypto"
+	"github.com/newton2049/favo-chain/network"
+	"github.com/newton2049/favo-chain/txpool/proto"
+	"github.com/newton2049/favo-chain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// mockPrivateNetwork connects the private forwarders of the test nodes in memory
+type mockPrivateNetwork struct {
+	id        peer.ID
+	listeners map[peer.ID]*bufconn.Listener // the listeners of the peers of the node
+}
+
+func (m *mockPrivateNetwork) AddrInfo() *peer.AddrInfo {
+	return &peer.AddrInfo{ID: m.id}
+}
+
+func (m *mockPrivateNetwork) RegisterProtocol(string, network.Protocol) {}
+
+func (m *mockPrivateNetwork) Peers() []*network.PeerConnInfo {
+	peers := make([]*network.PeerConnInfo, 0, len(m.listeners))
+	for id := range m.listeners {
+		peers = append(peers, &network.PeerConnInfo{Info: peer.AddrInfo{ID: id}})
+	}
+
+	return peers
+}
+
+func (m *mockPrivateNetwork) NewProtoConnection(_ string, peerID peer.ID) (*grpc.ClientConn, error) {
+	listener := m.listeners[peerID]
+
+	return grpc.Dial(
+		string(peerID),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+}
+
+// privateTestNode is a node receiving the forwarded private transactions
+type privateTestNode struct {
+	forwarder *privateForwarder
+	listener  *bufconn.Listener
+
+	lock sync.Mutex
+	txs  []*types.Transaction
+}
+
+func newPrivateTestNode(t *testing.T, id peer.ID, key *ecdsa.PrivateKey) *privateTestNode {
+	t.Helper()
+
+	node := &privateTestNode{listener: bufconn.Listen(1024 * 1024)}
+
+	node.forwarder = newPrivateForwarder(
+		hclog.NewNullLogger(),
+		&mockPrivateNetwork{id: id},
+		func(origin txOrigin, tx *types.Transaction) error {
+			assert.Equal(t, private, origin)
+
+			node.lock.Lock()
+			defer node.lock.Unlock()
+
+			node.txs = append(node.txs, tx)
+
+			return nil
+		},
+	)
+	node.forwarder.setValidatorKey(key)
+
+	server := grpc.NewServer()
+	proto.RegisterTxnForwarderServer(server, node.forwarder)
+
+	go func() {
+		_ = server.Serve(node.listener)
+	}()
+
+	t.Cleanup(server.Stop)
+
+	return node
+}
+
+func (n *privateTestNode) received() []*types.Transaction {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.txs
+}
+
+func TestPrivateForwarder_Forward(t *testing.T) {
+	t.Parallel()
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := crypto.GenerateECDSAKey()
+		require.NoError(t, err)
+
+		return key
+	}
+
+	proposerKey, validatorKey := newKey(), newKey()
+
+	nodes := map[peer.ID]*privateTestNode{
+		// the current proposer
+		"proposer": newPrivateTestNode(t, "proposer", proposerKey),
+		// a validator which isn't proposing
+		"validator": newPrivateTestNode(t, "validator", validatorKey),
+		// a node which isn't a validator
+		"node": newPrivateTestNode(t, "node", nil),
+		// a node replaying the identity of the proposer
+		"spoofer": newPrivateTestNode(t, "proposer", proposerKey),
+	}
+
+	network := &mockPrivateNetwork{id: "sender", listeners: make(map[peer.ID]*bufconn.Listener)}
+	for id, node := range nodes {
+		network.listeners[id] = node.listener
+	}
+
+	sender := newPrivateForwarder(hclog.NewNullLogger(), network, nil)
+
+	tx := newTx(addr1, 0, 1)
+	tx.ComputeHash()
+
+	// the proposers are unknown
+	assert.Equal(t, 0, sender.forward(tx))
+
+	sender.setProposers(func() []types.Address {
+		return []types.Address{
+			crypto.PubKeyToAddress(&proposerKey.PublicKey),
+			addr2,
+		}
+	})
+
+	// the tx is forwarded to the proposer only
+	assert.Equal(t, 1, sender.forward(tx))
+
+	received := nodes["proposer"].received()
+	require.Len(t, received, 1)
+	assert.Equal(t, tx.Hash, received[0].ComputeHash().Hash)
+
+	for _, id := range []peer.ID{"validator", "node", "spoofer"} {
+		assert.Empty(t, nodes[id].received())
+	}
+
+	// the identities of the peers are cached
+	assert.Equal(t, crypto.PubKeyToAddress(&validatorKey.PublicKey), sender.validators["validator"])
+	assert.Equal(t, types.ZeroAddress, sender.validators["node"])
+	assert.NotEqual(t, crypto.PubKeyToAddress(&proposerKey.PublicKey), sender.validators["spoofer"])
+}
+
+func TestPrivateForwarder_ForwardTxn(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateECDSAKey()
+	require.NoError(t, err)
+
+	node := newPrivateTestNode(t, "node", nil)
+
+	tx := newTx(addr1, 0, 1)
+	raw := &proto.Txn{Raw: &any.Any{Value: tx.MarshalRLP()}}
+
+	// only the validators accept the private transactions
+	_, err = node.forwarder.ForwardTxn(context.Background(), raw)
+	assert.ErrorIs(t, err, ErrNotValidator)
+
+	node.forwarder.setValidatorKey(key)
+
+	_, err = node.forwarder.ForwardTxn(context.Background(), raw)
+	assert.NoError(t, err)
+	assert.Len(t, node.received(), 1)
+}
+
+func TestAddPrivateTx(t *testing.T) {
+	t.Parallel()
+
+	pool, err := newTestPool()
+	require.NoError(t, err)
+	pool.SetSigner(&mockSigner{})
+
+	pool.Start()
+	defer pool.Close()
+
+	subscription := pool.eventManager.subscribe([]proto.EventType{proto.EventType_PROMOTED})
+	publicSubscription := pool.eventManager.subscribePublic([]proto.EventType{proto.EventType_PROMOTED})
+
+	// the private tx is kept in the pool of the node
+	tx := newTx(addr1, 0, 1)
+	require.NoError(t, pool.AddPrivateTx(tx))
+
+	publicTx := newTx(addr2, 0, 1)
+	require.NoError(t, pool.AddTx(publicTx))
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+
+	assert.Len(t, waitForEvents(ctx, subscription, 2), 2)
+	assert.Equal(t, uint64(1), pool.accounts.get(addr1).promoted.length())
+
+	// the private tx is left out of the public feed
+	events := waitForEvents(ctx, publicSubscription, 1)
+	require.Len(t, events, 1)
+	assert.Equal(t, publicTx.Hash.String(), events[0].TxHash)
+
+	select {
+	case event := <-publicSubscription.subscriptionChannel:
+		t.Fatalf("unexpected event of the tx %s", event.TxHash)
+	default:
+	}
+
+	// the private tx is left out of the queries
+	_, ok := pool.GetPendingTx(tx.Hash)
+	assert.False(t, ok)
+
+	_, ok = pool.GetPendingTx(publicTx.Hash)
+	assert.True(t, ok)
+
+	promoted, _ := pool.GetTxs(true)
+	assert.Equal(t, map[types.Address][]*types.Transaction{addr2: {publicTx}}, promoted)
+
+	// the private tx is rejected like any other tx
+	assert.ErrorIs(t, pool.AddPrivateTx(tx), ErrAlreadyKnown)
+}
+
+func TestPrivateForwarder_Resubmit(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateECDSAKey()
+	require.NoError(t, err)
+
+	proposer := newPrivateTestNode(t, "proposer", key)
+
+	network := &mockPrivateNetwork{id: "sender", listeners: make(map[peer.ID]*bufconn.Listener)}
+	sender := newPrivateForwarder(hclog.NewNullLogger(), network, nil)
+
+	sender.setProposers(func() []types.Address {
+		return []types.Address{crypto.PubKeyToAddress(&key.PublicKey)}
+	})
+
+	included, pending := newTx(addr1, 0, 1), newTx(addr2, 0, 1)
+	included.ComputeHash()
+	pending.ComputeHash()
+
+	// the proposer isn't connected yet
+	sender.submit(included)
+	sender.submit(pending)
+	assert.Empty(t, proposer.received())
+
+	network.listeners["proposer"] = proposer.listener
+
+	// the pooled txs are forwarded again on the new head, the others are forgotten
+	sender.resubmit(func(hash types.Hash) bool {
+		return hash == pending.Hash
+	})
+
+	received := proposer.received()
+	require.Len(t, received, 1)
+	assert.Equal(t, pending.Hash, received[0].ComputeHash().Hash)
+
+	assert.Len(t, sender.pending, 1)
+	assert.Contains(t, sender.pending, pending.Hash)
+}
@@ -161,3 +161,107 @@ var _ interface {
 	Cause() error
 	ErrorName() string
 } = TxnValidationError{}
+
+// Validate checks the field values on ValidatorIdentity with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *ValidatorIdentity) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ValidatorIdentity with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ValidatorIdentityMultiError, or nil if none found.
+func (m *ValidatorIdentity) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ValidatorIdentity) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Signature
+
+	if len(errors) > 0 {
+		return ValidatorIdentityMultiError(errors)
+	}
+
+	return nil
+}
+
+// ValidatorIdentityMultiError is an error wrapping multiple validation errors
+// returned by ValidatorIdentity.ValidateAll() if the designated constraints
+// aren't met.
+type ValidatorIdentityMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ValidatorIdentityMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ValidatorIdentityMultiError) AllErrors() []error { return m }
+
+// ValidatorIdentityValidationError is the validation error returned by
+// ValidatorIdentity.Validate if the designated constraints aren't met.
+type ValidatorIdentityValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ValidatorIdentityValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ValidatorIdentityValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ValidatorIdentityValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ValidatorIdentityValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ValidatorIdentityValidationError) ErrorName() string {
+	return "ValidatorIdentityValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ValidatorIdentityValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sValidatorIdentity.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ValidatorIdentityValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ValidatorIdentityValidationError{}
@@ -0,0 +1,146 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.7
+// source: txpool/proto/v1.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// TxnForwarderClient is the client API for TxnForwarder service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TxnForwarderClient interface {
+	// Identify returns the signature of the peer ID of the node by its validator key
+	Identify(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ValidatorIdentity, error)
+	// ForwardTxn adds a private transaction to the pool of the proposer, it isn't gossiped
+	ForwardTxn(ctx context.Context, in *Txn, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type txnForwarderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTxnForwarderClient(cc grpc.ClientConnInterface) TxnForwarderClient {
+	return &txnForwarderClient{cc}
+}
+
+func (c *txnForwarderClient) Identify(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ValidatorIdentity, error) {
+	out := new(ValidatorIdentity)
+	err := c.cc.Invoke(ctx, "/v1.TxnForwarder/Identify", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *txnForwarderClient) ForwardTxn(ctx context.Context, in *Txn, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/v1.TxnForwarder/ForwardTxn", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TxnForwarderServer is the server API for TxnForwarder service.
+// All implementations must embed UnimplementedTxnForwarderServer
+// for forward compatibility
+type TxnForwarderServer interface {
+	// Identify returns the signature of the peer ID of the node by its validator key
+	Identify(context.Context, *emptypb.Empty) (*ValidatorIdentity, error)
+	// ForwardTxn adds a private transaction to the pool of the proposer, it isn't gossiped
+	ForwardTxn(context.Context, *Txn) (*emptypb.Empty, error)
+	mustEmbedUnimplementedTxnForwarderServer()
+}
+
+// UnimplementedTxnForwarderServer must be embedded to have forward compatible implementations.
+type UnimplementedTxnForwarderServer struct {
+}
+
+func (UnimplementedTxnForwarderServer) Identify(context.Context, *emptypb.Empty) (*ValidatorIdentity, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Identify not implemented")
+}
+func (UnimplementedTxnForwarderServer) ForwardTxn(context.Context, *Txn) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForwardTxn not implemented")
+}
+func (UnimplementedTxnForwarderServer) mustEmbedUnimplementedTxnForwarderServer() {}
+
+// UnsafeTxnForwarderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TxnForwarderServer will
+// result in compilation errors.
+type UnsafeTxnForwarderServer interface {
+	mustEmbedUnimplementedTxnForwarderServer()
+}
+
+func RegisterTxnForwarderServer(s grpc.ServiceRegistrar, srv TxnForwarderServer) {
+	s.RegisterService(&TxnForwarder_ServiceDesc, srv)
+}
+
+func _TxnForwarder_Identify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TxnForwarderServer).Identify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.TxnForwarder/Identify",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TxnForwarderServer).Identify(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TxnForwarder_ForwardTxn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Txn)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TxnForwarderServer).ForwardTxn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.TxnForwarder/ForwardTxn",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TxnForwarderServer).ForwardTxn(ctx, req.(*Txn))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TxnForwarder_ServiceDesc is the grpc.ServiceDesc for TxnForwarder service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TxnForwarder_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "v1.TxnForwarder",
+	HandlerType: (*TxnForwarderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Identify",
+			Handler:    _TxnForwarder_Identify_Handler,
+		},
+		{
+			MethodName: "ForwardTxn",
+			Handler:    _TxnForwarder_ForwardTxn_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "txpool/proto/v1.proto",
+}
@@ -28,21 +28,45 @@ func (p *TxPool) GetCapacity() (uint64, uint64) {
 	return p.gauge.read(), p.gauge.max
 }
 
-// GetPendingTx returns the transaction by hash in the TxPool (pending txn) [Thread-safe]
+// GetPendingTx returns the transaction by hash in the TxPool (pending txn),
+// the private transactions aren't returned [Thread-safe]
 func (p *TxPool) GetPendingTx(txHash types.Hash) (*types.Transaction, bool) {
 	tx, ok := p.index.get(txHash)
-	if !ok {
+	if !ok || p.index.isPrivate(txHash) {
 		return nil, false
 	}
 
 	return tx, true
 }
 
-// GetTxs gets pending and queued transactions
+// GetTxs gets pending and queued transactions, the private transactions are left out
 func (p *TxPool) GetTxs(inclQueued bool) (
 	allPromoted, allEnqueued map[types.Address][]*types.Transaction,
 ) {
 	allPromoted, allEnqueued = p.accounts.allTxs(inclQueued)
 
-	return
+	return p.withoutPrivate(allPromoted), p.withoutPrivate(allEnqueued)
+}
+
+// withoutPrivate removes the private transactions from the transactions of the accounts
+func (p *TxPool) withoutPrivate(
+	txs map[types.Address][]*types.Transaction,
+) map[types.Address][]*types.Transaction {
+	for addr, accountTxs := range txs {
+		public := make([]*types.Transaction, 0, len(accountTxs))
+
+		for _, tx := range accountTxs {
+			if !p.index.isPrivate(tx.Hash) {
+				public = append(public, tx)
+			}
+		}
+
+		if len(public) == 0 {
+			delete(txs, addr)
+		} else {
+			txs[addr] = public
+		}
+	}
+
+	return txs
 }
@@ -2,6 +2,7 @@ package txpool
 
 import (
 	"container/heap"
+	"math/big"
 	"sync"
 	"sync/atomic"
 
@@ -72,6 +73,70 @@ func (q *accountQueue) clear() (removed []*types.Transaction) {
 	return
 }
 
+// getByNonce returns the transaction of the given nonce, or nil if there is none.
+func (q *accountQueue) getByNonce(nonce uint64) *types.Transaction {
+	for _, tx := range q.queue {
+		if tx.Nonce == nonce {
+			return tx
+		}
+	}
+
+	return nil
+}
+
+// replace swaps the transaction of the same nonce for the given one,
+// and returns the replaced transaction or nil if there is none.
+func (q *accountQueue) replace(tx *types.Transaction) *types.Transaction {
+	for i, old := range q.queue {
+		if old.Nonce != tx.Nonce {
+			continue
+		}
+
+		q.queue[i] = tx
+		heap.Fix(&q.queue, i)
+
+		return old
+	}
+
+	return nil
+}
+
+// last returns the transaction with the highest nonce without removing it.
+func (q *accountQueue) last() *types.Transaction {
+	if q.length() == 0 {
+		return nil
+	}
+
+	return q.queue[q.lastIndex()]
+}
+
+// removeLast removes the transaction with the highest nonce from the queue and returns it.
+func (q *accountQueue) removeLast() *types.Transaction {
+	if q.length() == 0 {
+		return nil
+	}
+
+	transaction, ok := heap.Remove(&q.queue, q.lastIndex()).(*types.Transaction)
+	if !ok {
+		return nil
+	}
+
+	return transaction
+}
+
+// lastIndex returns the index of the transaction with the highest nonce, the queue must not be empty.
+func (q *accountQueue) lastIndex() int {
+	last := 0
+
+	for i, tx := range q.queue {
+		if tx.Nonce > q.queue[last].Nonce {
+			last = i
+		}
+	}
+
+	return last
+}
+
 // push pushes the given transactions onto the queue.
 func (q *accountQueue) push(tx *types.Transaction) {
 	heap.Push(&q.queue, tx)
@@ -129,7 +194,7 @@ func (q *minNonceQueue) Swap(i, j int) {
 func (q *minNonceQueue) Less(i, j int) bool {
 	// The higher gas price Tx comes first if the nonces are same
 	if (*q)[i].Nonce == (*q)[j].Nonce {
-		return (*q)[i].GasPrice.Cmp((*q)[j].GasPrice) > 0
+		return (*q)[i].GetGasFeeCap().Cmp((*q)[j].GetGasFeeCap()) > 0
 	}
 
 	return (*q)[i].Nonce < (*q)[j].Nonce
@@ -153,89 +218,189 @@ func (q *minNonceQueue) Pop() interface{} {
 	return x
 }
 
-type pricedQueue struct {
-	queue maxPriceQueue
+// executablesQueue hands out the executable transactions for block building,
+// in the order of the ordering policy
+type executablesQueue struct {
+	queue *candidatesQueue
+
+	// included is the number of the transactions of each sender included since the last clear
+	included map[types.Address]uint64
 }
 
-func newPricedQueue() *pricedQueue {
-	q := pricedQueue{
-		queue: make(maxPriceQueue, 0),
+func newExecutablesQueue(ordering Ordering) *executablesQueue {
+	q := executablesQueue{
+		queue: &candidatesQueue{
+			ordering:   ordering,
+			baseFee:    new(big.Int),
+			candidates: make([]*TxCandidate, 0),
+		},
+		included: make(map[types.Address]uint64),
 	}
 
-	heap.Init(&q.queue)
+	heap.Init(q.queue)
 
 	return &q
 }
 
-// clear empties the underlying queue.
-func (q *pricedQueue) clear() {
-	q.queue = q.queue[:0]
+// clear empties the underlying queue and forgets the included transactions.
+func (q *executablesQueue) clear() {
+	q.queue.candidates = q.queue.candidates[:0]
+	q.included = make(map[types.Address]uint64)
 }
 
-// Pushes the given transactions onto the queue.
-func (q *pricedQueue) push(tx *types.Transaction) {
-	heap.Push(&q.queue, tx)
+// setBaseFee sets the base fee of the block being built, used by the ordering policy.
+// It must be called only when the queue is empty.
+func (q *executablesQueue) setBaseFee(baseFee uint64) {
+	q.queue.baseFee = new(big.Int).SetUint64(baseFee)
+}
+
+// Pushes the given transaction onto the queue,
+// unless the ordering policy doesn't admit more transactions of its sender.
+func (q *executablesQueue) push(tx *types.Transaction, arrival uint64) {
+	if !q.queue.ordering.Admit(tx.From, q.included[tx.From]) {
+		return
+	}
+
+	heap.Push(q.queue, &TxCandidate{Tx: tx, Arrival: arrival})
 }
 
 // Pop removes the first transaction from the queue
 // or nil if the queue is empty.
-func (q *pricedQueue) pop() *types.Transaction {
+func (q *executablesQueue) pop() *types.Transaction {
 	if q.length() == 0 {
 		return nil
 	}
 
-	transaction, ok := heap.Pop(&q.queue).(*types.Transaction)
+	candidate, ok := heap.Pop(q.queue).(*TxCandidate)
 	if !ok {
 		return nil
 	}
 
-	return transaction
+	return candidate.Tx
+}
+
+// include records the inclusion of the transaction in the block being built.
+func (q *executablesQueue) include(tx *types.Transaction) {
+	q.included[tx.From]++
 }
 
 // length returns the number of transactions in the queue.
-func (q *pricedQueue) length() uint64 {
+func (q *executablesQueue) length() uint64 {
 	return uint64(q.queue.Len())
 }
 
-// transactions sorted by gas price (descending)
-type maxPriceQueue []*types.Transaction
+// candidates sorted by the ordering policy
+type candidatesQueue struct {
+	ordering   Ordering
+	baseFee    *big.Int
+	candidates []*TxCandidate
+}
 
 /* Queue methods required by the heap interface */
 
-func (q *maxPriceQueue) Peek() *types.Transaction {
+func (q *candidatesQueue) Len() int {
+	return len(q.candidates)
+}
+
+func (q *candidatesQueue) Swap(i, j int) {
+	q.candidates[i], q.candidates[j] = q.candidates[j], q.candidates[i]
+}
+
+func (q *candidatesQueue) Less(i, j int) bool {
+	return q.ordering.Less(q.candidates[i], q.candidates[j], q.baseFee)
+}
+
+func (q *candidatesQueue) Push(x interface{}) {
+	candidate, ok := x.(*TxCandidate)
+	if !ok {
+		return
+	}
+
+	q.candidates = append(q.candidates, candidate)
+}
+
+func (q *candidatesQueue) Pop() interface{} {
+	old := q.candidates
+	n := len(old)
+	x := old[n-1]
+	q.candidates = old[0 : n-1]
+
+	return x
+}
+
+// transactions sorted by effective tip (ascending)
+type minPriceQueue struct {
+	baseFee *big.Int
+	txs     []*types.Transaction
+}
+
+func newMinPriceQueue(baseFee *big.Int) *minPriceQueue {
+	q := &minPriceQueue{
+		baseFee: baseFee,
+		txs:     make([]*types.Transaction, 0),
+	}
+
+	heap.Init(q)
+
+	return q
+}
+
+// push pushes the given transaction onto the queue.
+func (q *minPriceQueue) push(tx *types.Transaction) {
+	heap.Push(q, tx)
+}
+
+// pop removes the cheapest transaction from the queue
+// or nil if the queue is empty.
+func (q *minPriceQueue) pop() *types.Transaction {
 	if q.Len() == 0 {
 		return nil
 	}
 
-	return (*q)[0]
+	transaction, ok := heap.Pop(q).(*types.Transaction)
+	if !ok {
+		return nil
+	}
+
+	return transaction
 }
 
-func (q *maxPriceQueue) Len() int {
-	return len(*q)
+/* Queue methods required by the heap interface */
+
+func (q *minPriceQueue) Len() int {
+	return len(q.txs)
 }
 
-func (q *maxPriceQueue) Swap(i, j int) {
-	(*q)[i], (*q)[j] = (*q)[j], (*q)[i]
+func (q *minPriceQueue) Swap(i, j int) {
+	q.txs[i], q.txs[j] = q.txs[j], q.txs[i]
 }
 
-func (q *maxPriceQueue) Less(i, j int) bool {
-	return (*q)[i].GasPrice.Uint64() > (*q)[j].GasPrice.Uint64()
+func (q *minPriceQueue) Less(i, j int) bool {
+	switch q.txs[i].EffectiveTip(q.baseFee).Cmp(q.txs[j].EffectiveTip(q.baseFee)) {
+	case -1:
+		return true
+	case 1:
+		return false
+	}
+
+	// same tip, the transaction with the lower fee cap goes first
+	return q.txs[i].GetGasFeeCap().Cmp(q.txs[j].GetGasFeeCap()) < 0
 }
 
-func (q *maxPriceQueue) Push(x interface{}) {
+func (q *minPriceQueue) Push(x interface{}) {
 	transaction, ok := x.(*types.Transaction)
 	if !ok {
 		return
 	}
 
-	*q = append(*q, transaction)
+	q.txs = append(q.txs, transaction)
 }
 
-func (q *maxPriceQueue) Pop() interface{} {
-	old := q
-	n := len(*old)
-	x := (*old)[n-1]
-	*q = (*old)[0 : n-1]
+func (q *minPriceQueue) Pop() interface{} {
+	old := q.txs
+	n := len(old)
+	x := old[n-1]
+	q.txs = old[0 : n-1]
 
 	return x
 }
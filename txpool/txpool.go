@@ -1,9 +1,12 @@
 package txpool
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -35,6 +38,13 @@ const (
 
 	pruningCooldown = 5000 * time.Millisecond
 
+	// defaultAccountSlots is the number of slots of an account which are protected from eviction,
+	// if none is configured
+	defaultAccountSlots uint64 = 16
+
+	// defaultJournalRotate is the interval of the journal rotations, if none is configured
+	defaultJournalRotate = time.Hour
+
 	// txPoolMetrics is a prefix used for txpool-related metrics
 	txPoolMetrics = "txpool"
 )
@@ -57,14 +67,19 @@ var (
 	ErrRejectFutureTx          = errors.New("rejected future tx due to low slots")
 	ErrSmartContractRestricted = errors.New("smart contract deployment restricted")
 	ErrInvalidTxType           = errors.New("invalid tx type")
+	ErrTxTypeNotSupported      = errors.New("transaction type not supported")
+	ErrTipAboveFeeCap          = errors.New("max priority fee per gas higher than max fee per gas")
+	ErrReplacementUnderpriced  = errors.New("replacement transaction underpriced")
 )
 
 // indicates origin of a transaction
 type txOrigin int
 
 const (
-	local  txOrigin = iota // json-RPC/gRPC endpoints
-	gossip                 // gossip protocol
+	local        txOrigin = iota // json-RPC/gRPC endpoints
+	gossip                       // gossip protocol
+	private                      // private transactions protocol
+	localPrivate                 // json-RPC private transactions endpoint
 )
 
 func (o txOrigin) String() (s string) {
@@ -73,17 +88,32 @@ func (o txOrigin) String() (s string) {
 		s = "local"
 	case gossip:
 		s = "gossip"
+	case private:
+		s = "private"
+	case localPrivate:
+		s = "local-private"
 	}
 
 	return
 }
 
+// isLocal returns whether the transaction was submitted to the node
+func (o txOrigin) isLocal() bool {
+	return o == local || o == localPrivate
+}
+
+// isPrivate returns whether the transaction is kept out of the public queries and feeds of the pool
+func (o txOrigin) isPrivate() bool {
+	return o == private || o == localPrivate
+}
+
 // store interface defines State helper methods the TxPool should have access to
 type store interface {
 	Header() *types.Header
 	GetNonce(root types.Hash, addr types.Address) uint64
 	GetBalance(root types.Hash, addr types.Address) (*big.Int, error)
 	GetBlockByHash(types.Hash, bool) (*types.Block, bool)
+	CalculateBaseFee(parent *types.Header) uint64
 }
 
 type signer interface {
@@ -95,6 +125,24 @@ type Config struct {
 	MaxSlots            uint64
 	MaxAccountEnqueued  uint64
 	DeploymentWhitelist []types.Address
+
+	// PriceBump is the minimum percentage by which a transaction must raise
+	// the gas price of the pooled transaction of the same nonce to replace it
+	PriceBump uint64
+
+	// AccountSlots is the number of slots each account is guaranteed when the pool is full,
+	// only the transactions of the accounts above it are evicted for better paying transactions
+	AccountSlots uint64
+
+	// Ordering is the policy ordering the transactions handed out for block building,
+	// by gas price if none is set
+	Ordering Ordering
+
+	// JournalPath is the file the local transactions are journaled to, they aren't journaled if empty
+	JournalPath string
+
+	// JournalRotate is the interval of the journal rotations
+	JournalRotate time.Duration
 }
 
 /* All requests are passed to the main loop
@@ -143,8 +191,8 @@ type TxPool struct {
 	// map of all accounts registered by the pool
 	accounts accountsMap
 
-	// all the primaries sorted by max gas price
-	executables *pricedQueue
+	// all the primaries sorted by max effective tip
+	executables *executablesQueue
 
 	// lookup map keeping track of all
 	// transactions present in the pool
@@ -153,12 +201,24 @@ type TxPool struct {
 	// networking stack
 	topic *network.Topic
 
+	// forwarder of the private transactions to the proposers, nil without networking
+	forwarder *privateForwarder
+
 	// gauge for measuring pool capacity
 	gauge slotGauge
 
+	// accountSlots is the number of slots of an account protected from eviction
+	accountSlots uint64
+
+	// evictLock serializes the evictions of the full pool
+	evictLock sync.Mutex
+
 	// priceLimit is a lower threshold for gas price
 	priceLimit uint64
 
+	// priceBump is the minimum gas price increase (in percent) of a replacement transaction
+	priceBump uint64
+
 	// channels on which the pool's event loop
 	// does dispatching/handling requests.
 	enqueueReqCh chan enqueueRequest
@@ -175,6 +235,10 @@ type TxPool struct {
 	// Event manager for txpool events
 	eventManager *eventManager
 
+	// journal of the local transactions, nil if disabled
+	journal       *journal
+	journalRotate time.Duration
+
 	// deploymentWhitelist map
 	deploymentWhitelist deploymentWhitelist
 
@@ -230,15 +294,26 @@ func NewTxPool(
 	network *network.Server,
 	config *Config,
 ) (*TxPool, error) {
+	ordering := config.Ordering
+	if ordering == nil {
+		ordering = gasPriceOrdering{}
+	}
+
 	pool := &TxPool{
 		logger:      logger.Named("txpool"),
 		forks:       forks,
 		store:       store,
-		executables: newPricedQueue(),
+		executables: newExecutablesQueue(ordering),
 		accounts:    accountsMap{maxEnqueuedLimit: config.MaxAccountEnqueued},
-		index:       lookupMap{all: make(map[types.Hash]*types.Transaction)},
-		gauge:       slotGauge{height: 0, max: config.MaxSlots},
-		priceLimit:  config.PriceLimit,
+		index: lookupMap{
+			all:      make(map[types.Hash]*types.Transaction),
+			arrivals: make(map[types.Hash]uint64),
+			private:  make(map[types.Hash]struct{}),
+		},
+		gauge:        slotGauge{height: 0, max: config.MaxSlots},
+		priceLimit:   config.PriceLimit,
+		priceBump:    config.PriceBump,
+		accountSlots: config.AccountSlots,
 
 		//	main loop channels
 		enqueueReqCh: make(chan enqueueRequest),
@@ -249,6 +324,7 @@ func NewTxPool(
 
 	// Attach the event manager
 	pool.eventManager = newEventManager(pool.logger)
+	pool.eventManager.isPrivate = pool.index.isPrivate
 
 	if network != nil {
 		// subscribe to the gossip protocol
@@ -262,6 +338,23 @@ func NewTxPool(
 		}
 
 		pool.topic = topic
+
+		// serve the private transactions protocol
+		pool.forwarder = newPrivateForwarder(pool.logger, network, pool.addTx)
+		pool.forwarder.start()
+	}
+
+	if pool.accountSlots == 0 {
+		pool.accountSlots = defaultAccountSlots
+	}
+
+	if config.JournalPath != "" {
+		pool.journal = newJournal(pool.logger, config.JournalPath)
+		pool.journalRotate = config.JournalRotate
+
+		if pool.journalRotate == 0 {
+			pool.journalRotate = defaultJournalRotate
+		}
 	}
 
 	// initialize deployment whitelist
@@ -315,12 +408,59 @@ func (p *TxPool) Start() {
 			}
 		}
 	}()
+
+	if p.journal != nil {
+		// the journaled transactions are added once the tx pipeline runs,
+		// and the journal is rewritten with the ones which were accepted,
+		// as they may not be pooled yet
+		if err := p.journal.rotate(p.loadJournal(), p.index.isPrivate); err != nil {
+			p.logger.Error("failed to rotate the journal", "err", err)
+		}
+
+		//	run the journal rotations off the tx pipeline, as they do file IO
+		go func() {
+			ticker := time.NewTicker(p.journalRotate)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-p.shutdownCh:
+					return
+				case <-ticker.C:
+					p.rotateJournal()
+				}
+			}
+		}()
+	}
 }
 
 // Close shuts down the pool's main loop.
 func (p *TxPool) Close() {
 	p.eventManager.Close()
-	p.shutdownCh <- struct{}{}
+	close(p.shutdownCh)
+
+	if p.journal != nil {
+		if err := p.journal.close(); err != nil {
+			p.logger.Error("failed to close the journal", "err", err)
+		}
+	}
+
+	if p.forwarder != nil {
+		if err := p.forwarder.close(); err != nil {
+			p.logger.Error("failed to close the private transactions protocol", "err", err)
+		}
+	}
+}
+
+// SubscribeTxEvents registers a listener for the given TxPool event types, the private transactions are left out.
+// It returns the events channel, which is closed once the subscription is canceled,
+// and the function canceling the subscription
+func (p *TxPool) SubscribeTxEvents(eventTypes ...proto.EventType) (<-chan *proto.TxPoolEvent, func()) {
+	subscription := p.eventManager.subscribePublic(eventTypes)
+
+	return subscription.subscriptionChannel, func() {
+		p.eventManager.cancelSubscription(subscription.subscriptionID)
+	}
 }
 
 // SetSigner sets the signer the pool will use
@@ -329,6 +469,22 @@ func (p *TxPool) SetSigner(s signer) {
 	p.signer = s
 }
 
+// SetValidatorKey sets the key the node proves to the peers it's a validator with,
+// so that they forward it the private transactions
+func (p *TxPool) SetValidatorKey(key *ecdsa.PrivateKey) {
+	if p.forwarder != nil {
+		p.forwarder.setValidatorKey(key)
+	}
+}
+
+// SetProposers sets the function returning the current and next proposers,
+// which the private transactions are forwarded to
+func (p *TxPool) SetProposers(proposers func() []types.Address) {
+	if p.forwarder != nil {
+		p.forwarder.setProposers(proposers)
+	}
+}
+
 // SetSealing sets the sealing flag
 func (p *TxPool) SetSealing(sealing bool) {
 	newValue := uint32(0)
@@ -351,9 +507,7 @@ func (p *TxPool) getSealing() bool {
 // AddTx adds a new transaction to the pool (sent from json-RPC/gRPC endpoints)
 // and broadcasts it to the network (if enabled).
 func (p *TxPool) AddTx(tx *types.Transaction) error {
-	if err := p.addTx(local, tx); err != nil {
-		p.logger.Error("failed to add tx", "err", err)
-
+	if err := p.addLocalTx(local, tx); err != nil {
 		return err
 	}
 
@@ -374,37 +528,144 @@ func (p *TxPool) AddTx(tx *types.Transaction) error {
 	return nil
 }
 
+// AddPrivateTx adds the local transaction to the pool without gossiping it, nor exposing it to the public
+// queries and feeds of the pool, and forwards it directly to the current and next proposers (if enabled),
+// until it's included or dropped.
+func (p *TxPool) AddPrivateTx(tx *types.Transaction) error {
+	if err := p.addLocalTx(localPrivate, tx); err != nil {
+		return err
+	}
+
+	if p.forwarder != nil {
+		go p.forwarder.submit(tx)
+	}
+
+	return nil
+}
+
+// addLocalTx adds the local transaction to the pool and journals it
+func (p *TxPool) addLocalTx(origin txOrigin, tx *types.Transaction) error {
+	if err := p.addTx(origin, tx); err != nil {
+		p.logger.Error("failed to add tx", "err", err)
+
+		return err
+	}
+
+	if p.journal != nil {
+		if err := p.journal.insert(tx, origin.isPrivate()); err != nil {
+			p.logger.Error("failed to journal tx", "err", err)
+		}
+	}
+
+	return nil
+}
+
+// loadJournal adds the journaled transactions to the pool as local transactions,
+// and returns the accepted ones by sender, in nonce order
+func (p *TxPool) loadJournal() map[types.Address][]*types.Transaction {
+	entries, err := p.journal.load()
+	if err != nil {
+		p.logger.Error("failed to load the journal", "err", err)
+
+		return nil
+	}
+
+	added := make(map[types.Address][]*types.Transaction)
+
+	for _, entry := range entries {
+		tx, origin := entry.tx, local
+		if entry.private {
+			origin = localPrivate
+		}
+
+		if err := p.addTx(origin, tx); err != nil {
+			p.logger.Debug("discarded journaled tx", "hash", tx.Hash.String(), "err", err)
+
+			continue
+		}
+
+		p.journal.addLocal(tx.From)
+
+		added[tx.From] = append(added[tx.From], tx)
+	}
+
+	sortByNonce(added)
+
+	p.logger.Info("loaded journaled transactions", "transactions", len(entries), "added", countTxs(added))
+
+	return added
+}
+
+// rotateJournal rewrites the journal with the pooled transactions of the local accounts, in nonce order
+func (p *TxPool) rotateJournal() {
+	promoted, enqueued := p.accounts.allTxs(true)
+
+	pooled := make(map[types.Address][]*types.Transaction, len(promoted)+len(enqueued))
+
+	for _, all := range []map[types.Address][]*types.Transaction{promoted, enqueued} {
+		for addr, txs := range all {
+			pooled[addr] = append(pooled[addr], txs...)
+		}
+	}
+
+	sortByNonce(pooled)
+
+	if err := p.journal.rotate(pooled, p.index.isPrivate); err != nil {
+		p.logger.Error("failed to rotate the journal", "err", err)
+	}
+}
+
+// sortByNonce sorts the transactions of each sender by nonce
+func sortByNonce(txs map[types.Address][]*types.Transaction) {
+	for _, senderTxs := range txs {
+		sort.Slice(senderTxs, func(i, j int) bool {
+			return senderTxs[i].Nonce < senderTxs[j].Nonce
+		})
+	}
+}
+
+// countTxs returns the number of the transactions of all the senders
+func countTxs(txs map[types.Address][]*types.Transaction) int {
+	count := 0
+	for _, senderTxs := range txs {
+		count += len(senderTxs)
+	}
+
+	return count
+}
+
 // Prepare generates all the transactions
 // ready for execution. (primaries)
 func (p *TxPool) Prepare() {
 	// clear from previous round
-	if p.executables.length() != 0 {
-		p.executables.clear()
-	}
+	p.executables.clear()
+
+	// primaries are ordered by the ordering policy, on top of the next block's base fee
+	p.executables.setBaseFee(p.store.CalculateBaseFee(p.store.Header()))
 
 	// fetch primary from each account
 	primaries := p.accounts.getPrimaries()
 
 	// push primaries to the executables queue
 	for _, tx := range primaries {
-		p.executables.push(tx)
+		p.executables.push(tx, p.index.arrival(tx.Hash))
 	}
 }
 
-// Peek returns the best-price selected
-// transaction ready for execution.
+// Peek returns the transaction ready for execution
+// selected first by the ordering policy.
 func (p *TxPool) Peek() *types.Transaction {
 	// Popping the executables queue
 	// does not remove the actual tx
 	// from the pool.
 	// The executables queue just provides
 	// insight into which account has the
-	// highest priced tx (head of promoted queue)
+	// first selected tx (head of promoted queue)
 	return p.executables.pop()
 }
 
 // Pop removes the given transaction from the
-// associated promoted queue (account).
+// associated promoted queue (account), if it's still there.
 // Will update executables with the next primary
 // from that account (if any).
 func (p *TxPool) Pop(tx *types.Transaction) {
@@ -414,6 +675,14 @@ func (p *TxPool) Pop(tx *types.Transaction) {
 	account.promoted.lock(true)
 	defer account.promoted.unlock()
 
+	// the executed tx might have been replaced since it was peeked,
+	// its replacement is pruned on the next reset as its nonce is used
+	if head := account.promoted.peek(); head == nil || head.Hash != tx.Hash {
+		p.executables.include(tx)
+
+		return
+	}
+
 	// pop the top most promoted tx
 	account.promoted.pop()
 
@@ -427,8 +696,10 @@ func (p *TxPool) Pop(tx *types.Transaction) {
 	p.updatePending(-1)
 
 	// update executables
+	p.executables.include(tx)
+
 	if tx := account.promoted.peek(); tx != nil {
-		p.executables.push(tx)
+		p.executables.push(tx, p.index.arrival(tx.Hash))
 	}
 }
 
@@ -513,6 +784,15 @@ func (p *TxPool) ResetWithHeaders(headers ...*types.Header) {
 	p.processEvent(&blockchain.Event{
 		NewChain: headers,
 	})
+
+	// the private transactions which are still pooled are forwarded to the new proposers
+	if p.forwarder != nil {
+		go p.forwarder.resubmit(func(hash types.Hash) bool {
+			_, ok := p.index.get(hash)
+
+			return ok
+		})
+	}
 }
 
 // processEvent collects the latest nonces for each account containted
@@ -580,6 +860,22 @@ func (p *TxPool) validateTx(tx *types.Transaction) error {
 		return ErrInvalidTxType
 	}
 
+	// Access list transactions are accepted only after the Berlin fork
+	if tx.Type == types.AccessListTx && !p.forks.Berlin {
+		return ErrTxTypeNotSupported
+	}
+
+	// Dynamic fee transactions are accepted only after the London fork
+	if tx.Type == types.DynamicFeeTx {
+		if !p.forks.London {
+			return ErrTxTypeNotSupported
+		}
+
+		if tx.GetGasTipCap().Cmp(tx.GetGasFeeCap()) > 0 {
+			return ErrTipAboveFeeCap
+		}
+	}
+
 	// Check the transaction size to overcome DOS Attacks
 	if uint64(len(tx.MarshalRLP())) > txMaxSize {
 		return ErrOversizedData
@@ -616,7 +912,7 @@ func (p *TxPool) validateTx(tx *types.Transaction) error {
 			return ErrSmartContractRestricted
 		}
 
-		if p.forks.EIP158 && len(tx.Input) > state.TxPoolMaxInitCodeSize {
+		if p.forks.Shanghai && len(tx.Input) > state.TxPoolMaxInitCodeSize {
 			return runtime.ErrMaxCodeSizeExceeded
 		}
 	}
@@ -626,6 +922,12 @@ func (p *TxPool) validateTx(tx *types.Transaction) error {
 		return ErrUnderpriced
 	}
 
+	// Reject transactions which can't pay the base fee of the next block
+	baseFee := p.store.CalculateBaseFee(p.store.Header())
+	if tx.GetGasFeeCap().Cmp(new(big.Int).SetUint64(baseFee)) < 0 {
+		return ErrUnderpriced
+	}
+
 	// Grab the state root for the latest block
 	stateRoot := p.store.Header().StateRoot
 
@@ -645,7 +947,7 @@ func (p *TxPool) validateTx(tx *types.Transaction) error {
 	}
 
 	// Make sure the transaction has more gas than the basic transaction fee
-	intrinsicGas, err := state.TransactionGasCost(tx, p.forks.Homestead, p.forks.Istanbul)
+	intrinsicGas, err := state.TransactionGasCost(tx, p.forks.Homestead, p.forks.Istanbul, p.forks.Shanghai)
 	if err != nil {
 		return err
 	}
@@ -724,21 +1026,72 @@ func (p *TxPool) addTx(origin txOrigin, tx *types.Transaction) error {
 		}
 	}
 
-	// check for overflow
-	if slotsRequired(tx) > p.gauge.max-p.gauge.read() {
-		return ErrTxPoolOverflow
-	}
-
 	tx.ComputeHash()
 
 	// add to index
-	if ok := p.index.add(tx); !ok {
+	if ok := p.index.add(tx, origin.isPrivate()); !ok {
 		return ErrAlreadyKnown
 	}
 
 	// initialize account for this address once
 	p.createAccountOnce(tx.From)
 
+	account := p.accounts.get(tx.From)
+	if origin.isLocal() {
+		account.setLocal()
+	}
+
+	// the replacement is checked before any transaction is evicted for it
+	old, err := account.replaceable(tx, p.priceBump)
+	if err != nil {
+		p.index.remove(tx)
+
+		return err
+	}
+
+	// check for overflow, the cheaper remote transactions are evicted if the pool is full.
+	// A replacement only requires the slots it adds to the ones it frees
+	required := slotsRequired(tx)
+	if old != nil {
+		required = replacementSlots(tx, old)
+	}
+
+	if err := p.ensureSlots(tx, required); err != nil {
+		p.index.remove(tx)
+
+		return err
+	}
+
+	// replace the pooled transaction of the same nonce, if any
+	replaced, err := account.replace(tx, p.priceBump)
+	if err != nil {
+		p.index.remove(tx)
+
+		return err
+	}
+
+	if replaced == nil && old != nil {
+		// the replaced transaction left the pool in the meantime, the new one takes its own slots
+		if err := p.ensureSlots(tx, slotsRequired(tx)-required); err != nil {
+			p.index.remove(tx)
+
+			return err
+		}
+	}
+
+	if replaced != nil {
+		p.index.remove(replaced)
+		p.gauge.decrease(slotsRequired(replaced))
+		p.gauge.increase(slotsRequired(tx))
+
+		p.eventManager.signalEvent(proto.EventType_ADDED, tx.Hash)
+		p.eventManager.signalEvent(proto.EventType_REPLACED, replaced.Hash)
+
+		p.logger.Debug("replaced tx", "hash", replaced.Hash.String(), "replacement", tx.Hash.String())
+
+		return nil
+	}
+
 	// send request [BLOCKING]
 	p.enqueueReqCh <- enqueueRequest{tx: tx}
 	p.eventManager.signalEvent(proto.EventType_ADDED, tx.Hash)
@@ -27,6 +27,7 @@ const (
 	defaultPriceLimit         uint64 = 1
 	defaultMaxSlots           uint64 = 4096
 	defaultMaxAccountEnqueued uint64 = 128
+	defaultPriceBump          uint64 = 10
 	validGasLimit             uint64 = 4712350
 )
 
@@ -95,6 +96,7 @@ func newTestPoolWithSlots(maxSlots uint64, mockStore ...store) (*TxPool, error)
 			MaxSlots:            maxSlots,
 			MaxAccountEnqueued:  defaultMaxAccountEnqueued,
 			DeploymentWhitelist: []types.Address{},
+			PriceBump:           defaultPriceBump,
 		},
 	)
 }
@@ -1443,6 +1445,48 @@ func TestPop(t *testing.T) {
 	assert.Equal(t, uint64(0), pool.accounts.get(addr1).promoted.length())
 }
 
+func TestPop_Replaced(t *testing.T) {
+	t.Parallel()
+
+	pool, err := newTestPool()
+	assert.NoError(t, err)
+	pool.SetSigner(&mockSigner{})
+
+	// send 1 tx and promote it
+	go func() {
+		assert.NoError(t, pool.addTx(local, newTx(addr1, 0, 1)))
+	}()
+	go pool.handleEnqueueRequest(<-pool.enqueueReqCh)
+	pool.handlePromoteRequest(<-pool.promoteReqCh)
+
+	pool.Prepare()
+	tx := pool.Peek()
+
+	// the tx is replaced while it's being executed
+	replacement := newTx(addr1, 0, 1)
+	replacement.GasPrice = big.NewInt(2)
+	assert.NoError(t, pool.addTx(local, replacement))
+
+	pool.Pop(tx)
+
+	// the replacement stays in the pool until its nonce is known to be used
+	account := pool.accounts.get(addr1)
+	assert.Equal(t, replacement, account.promoted.peek())
+	assert.Equal(t, uint64(1), pool.gauge.read())
+	assert.Nil(t, pool.Peek())
+
+	_, ok := pool.index.get(replacement.Hash)
+	assert.True(t, ok)
+
+	pool.resetAccounts(map[types.Address]uint64{addr1: 1})
+
+	assert.Equal(t, uint64(0), account.promoted.length())
+	assert.Equal(t, uint64(0), pool.gauge.read())
+
+	_, ok = pool.index.get(replacement.Hash)
+	assert.False(t, ok)
+}
+
 func TestDrop(t *testing.T) {
 	t.Parallel()
 
@@ -1472,6 +1516,238 @@ func TestDrop(t *testing.T) {
 	assert.Equal(t, uint64(0), pool.accounts.get(addr1).promoted.length())
 }
 
+func TestReplaceTx(t *testing.T) {
+	t.Parallel()
+
+	// returns the tx of the nonce paying the given gas price
+	newPricedTx := func(nonce, gasPrice uint64) *types.Transaction {
+		tx := newTx(addr1, nonce, 1)
+		tx.GasPrice = new(big.Int).SetUint64(gasPrice)
+
+		return tx
+	}
+
+	pool, err := newTestPool()
+	assert.NoError(t, err)
+	pool.SetSigner(&mockSigner{})
+
+	replacedCh, cancel := pool.SubscribeTxEvents(proto.EventType_REPLACED)
+	defer cancel()
+
+	// promote the tx of nonce 0, and enqueue the tx of nonce 2
+	go func() {
+		assert.NoError(t, pool.addTx(local, newPricedTx(0, 100)))
+	}()
+	go pool.handleEnqueueRequest(<-pool.enqueueReqCh)
+	pool.handlePromoteRequest(<-pool.promoteReqCh)
+
+	go func() {
+		assert.NoError(t, pool.addTx(local, newPricedTx(2, 100)))
+	}()
+	pool.handleEnqueueRequest(<-pool.enqueueReqCh)
+
+	account := pool.accounts.get(addr1)
+	promotedTx := account.promoted.peek()
+	enqueuedTx := account.enqueued.peek()
+
+	// the price must be raised by the price bump
+	assert.ErrorIs(t, pool.addTx(local, newPricedTx(0, 109)), ErrReplacementUnderpriced)
+	assert.ErrorIs(t, pool.addTx(local, newPricedTx(2, 100)), ErrReplacementUnderpriced)
+	assert.Equal(t, promotedTx, account.promoted.peek())
+	assert.Equal(t, enqueuedTx, account.enqueued.peek())
+
+	// the promoted tx is replaced
+	replacement := newPricedTx(0, 110)
+	assert.NoError(t, pool.addTx(local, replacement))
+
+	assert.Equal(t, replacement, account.promoted.peek())
+	assert.Equal(t, uint64(1), account.promoted.length())
+	assert.Equal(t, uint64(1), account.getNonce())
+	assert.Equal(t, promotedTx.Hash.String(), (<-replacedCh).TxHash)
+
+	_, ok := pool.index.get(promotedTx.Hash)
+	assert.False(t, ok)
+
+	_, ok = pool.index.get(replacement.Hash)
+	assert.True(t, ok)
+
+	// the enqueued tx is replaced by a bigger one
+	replacement = newTx(addr1, 2, 3)
+	replacement.GasPrice = big.NewInt(200)
+	assert.NoError(t, pool.addTx(local, replacement))
+
+	assert.Equal(t, replacement, account.enqueued.peek())
+	assert.Equal(t, uint64(1), account.enqueued.length())
+	assert.Equal(t, enqueuedTx.Hash.String(), (<-replacedCh).TxHash)
+	assert.Equal(t, uint64(4), pool.gauge.read())
+}
+
+func TestEvictTx(t *testing.T) {
+	t.Parallel()
+
+	// returns the tx of the account and nonce paying the given gas price
+	newPricedTx := func(addr types.Address, nonce, gasPrice uint64) *types.Transaction {
+		tx := newTx(addr, nonce, 1)
+		tx.GasPrice = new(big.Int).SetUint64(gasPrice)
+
+		return tx
+	}
+
+	pool, err := newTestPoolWithSlots(4)
+	assert.NoError(t, err)
+	pool.SetSigner(&mockSigner{})
+
+	// each account is guaranteed a single slot
+	pool.accountSlots = 1
+
+	droppedCh, cancel := pool.SubscribeTxEvents(proto.EventType_DROPPED)
+	defer cancel()
+
+	// fill the pool with the enqueued txs of a remote and a local account
+	remoteTxs := []*types.Transaction{newPricedTx(addr1, 1, 1), newPricedTx(addr1, 2, 1)}
+	localTxs := []*types.Transaction{newPricedTx(addr2, 1, 1), newPricedTx(addr2, 2, 1)}
+
+	for _, tx := range remoteTxs {
+		go func(tx *types.Transaction) {
+			assert.NoError(t, pool.addTx(gossip, tx))
+		}(tx)
+		pool.handleEnqueueRequest(<-pool.enqueueReqCh)
+	}
+
+	for _, tx := range localTxs {
+		go func(tx *types.Transaction) {
+			assert.NoError(t, pool.addTx(local, tx))
+		}(tx)
+		pool.handleEnqueueRequest(<-pool.enqueueReqCh)
+	}
+
+	assert.Equal(t, uint64(4), pool.gauge.read())
+
+	// the txs not paying more than the pooled ones aren't added
+	assert.ErrorIs(t, pool.addTx(gossip, newPricedTx(addr3, 0, 1)), ErrTxPoolOverflow)
+
+	// the remote tx of the highest nonce is evicted for the better paying tx
+	go func() {
+		assert.NoError(t, pool.addTx(gossip, newPricedTx(addr3, 0, 10)))
+	}()
+	go pool.handleEnqueueRequest(<-pool.enqueueReqCh)
+	pool.handlePromoteRequest(<-pool.promoteReqCh)
+
+	assert.Equal(t, remoteTxs[1].Hash.String(), (<-droppedCh).TxHash)
+	assert.Equal(t, uint64(4), pool.gauge.read())
+	assert.Equal(t, uint64(1), pool.accounts.get(addr1).enqueued.length())
+	assert.Equal(t, uint64(2), pool.accounts.get(addr2).enqueued.length())
+
+	_, ok := pool.index.get(remoteTxs[1].Hash)
+	assert.False(t, ok)
+
+	// the remote account within its quota and the local account are protected
+	assert.ErrorIs(t, pool.addTx(gossip, newPricedTx(addr4, 0, 10)), ErrTxPoolOverflow)
+	assert.Equal(t, uint64(4), pool.gauge.read())
+}
+
+func TestEvictTx_Replacement(t *testing.T) {
+	t.Parallel()
+
+	newPricedTx := func(addr types.Address, nonce, gasPrice uint64) *types.Transaction {
+		tx := newTx(addr, nonce, 1)
+		tx.GasPrice = new(big.Int).SetUint64(gasPrice)
+
+		return tx
+	}
+
+	pool, err := newTestPoolWithSlots(3)
+	assert.NoError(t, err)
+	pool.SetSigner(&mockSigner{})
+
+	// each account is guaranteed a single slot
+	pool.accountSlots = 1
+
+	// fill the pool with the enqueued txs of a cheap remote account
+	cheapTxs := []*types.Transaction{newPricedTx(addr1, 1, 1), newPricedTx(addr1, 2, 1)}
+
+	for _, tx := range cheapTxs {
+		go func(tx *types.Transaction) {
+			assert.NoError(t, pool.addTx(gossip, tx))
+		}(tx)
+		pool.handleEnqueueRequest(<-pool.enqueueReqCh)
+	}
+
+	// and the promoted tx of an expensive remote account
+	pricedTx := newPricedTx(addr2, 0, 5)
+
+	go func() {
+		assert.NoError(t, pool.addTx(gossip, pricedTx))
+	}()
+	go pool.handleEnqueueRequest(<-pool.enqueueReqCh)
+	pool.handlePromoteRequest(<-pool.promoteReqCh)
+
+	assert.Equal(t, uint64(3), pool.gauge.read())
+
+	// the underpriced replacement doesn't evict any tx
+	assert.ErrorIs(t, pool.addTx(gossip, newPricedTx(addr2, 0, 5)), ErrReplacementUnderpriced)
+
+	assert.Equal(t, uint64(3), pool.gauge.read())
+	assert.Equal(t, uint64(2), pool.accounts.get(addr1).enqueued.length())
+
+	_, ok := pool.index.get(cheapTxs[1].Hash)
+	assert.True(t, ok)
+
+	// the replacement frees its own slots, it doesn't evict any tx either
+	replacement := newPricedTx(addr2, 0, 10)
+	assert.NoError(t, pool.addTx(gossip, replacement))
+
+	assert.Equal(t, uint64(3), pool.gauge.read())
+	assert.Equal(t, uint64(2), pool.accounts.get(addr1).enqueued.length())
+	assert.Equal(t, replacement.Hash, pool.accounts.get(addr2).promoted.getByNonce(0).Hash)
+
+	_, ok = pool.index.get(pricedTx.Hash)
+	assert.False(t, ok)
+}
+
+func Test_isPriceBumped(t *testing.T) {
+	t.Parallel()
+
+	dynamicFeeTx := func(feeCap, tipCap int64) *types.Transaction {
+		return &types.Transaction{
+			Type:      types.DynamicFeeTx,
+			GasFeeCap: big.NewInt(feeCap),
+			GasTipCap: big.NewInt(tipCap),
+		}
+	}
+
+	legacyTx := func(gasPrice int64) *types.Transaction {
+		return &types.Transaction{GasPrice: big.NewInt(gasPrice)}
+	}
+
+	cases := []struct {
+		name      string
+		tx, old   *types.Transaction
+		priceBump uint64
+		bumped    bool
+	}{
+		{"legacy bumped", legacyTx(110), legacyTx(100), 10, true},
+		{"legacy not bumped enough", legacyTx(109), legacyTx(100), 10, false},
+		{"same price without bump", legacyTx(100), legacyTx(100), 0, false},
+		{"higher price without bump", legacyTx(101), legacyTx(100), 0, true},
+		{"zero price", legacyTx(1), legacyTx(0), 10, true},
+		{"dynamic fee bumped", dynamicFeeTx(220, 11), dynamicFeeTx(200, 10), 10, true},
+		{"dynamic fee tip not bumped", dynamicFeeTx(220, 10), dynamicFeeTx(200, 10), 10, false},
+		{"dynamic fee cap not bumped", dynamicFeeTx(219, 20), dynamicFeeTx(200, 10), 10, false},
+		{"dynamic fee replacing legacy", dynamicFeeTx(110, 110), legacyTx(100), 10, true},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, c.bumped, isPriceBumped(c.tx, c.old, c.priceBump))
+		})
+	}
+}
+
 func TestDemote(t *testing.T) {
 	t.Parallel()
 
@@ -1753,7 +2029,7 @@ func TestPermissionSmartContractDeployment(t *testing.T) {
 	t.Run("Input larger than the TxPoolMaxInitCodeSize", func(t *testing.T) {
 		t.Parallel()
 		pool := setupPool()
-		pool.forks.EIP158 = true
+		pool.forks.Shanghai = true
 
 		input := make([]byte, state.TxPoolMaxInitCodeSize+1)
 		_, err := rand.Read(input)
@@ -1769,10 +2045,29 @@ func TestPermissionSmartContractDeployment(t *testing.T) {
 		)
 	})
 
-	t.Run("Input the same as TxPoolMaxInitCodeSize", func(t *testing.T) {
+	t.Run("Input larger than the TxPoolMaxInitCodeSize before Shanghai", func(t *testing.T) {
 		t.Parallel()
 		pool := setupPool()
 		pool.forks.EIP158 = true
+		pool.forks.Shanghai = false
+
+		input := make([]byte, state.TxPoolMaxInitCodeSize+1)
+		_, err := rand.Read(input)
+		require.NoError(t, err)
+
+		tx := newTx(defaultAddr, 0, 1)
+		tx.To = nil
+		tx.Input = input
+
+		assert.NoError(t,
+			pool.validateTx(signTx(tx)),
+		)
+	})
+
+	t.Run("Input the same as TxPoolMaxInitCodeSize", func(t *testing.T) {
+		t.Parallel()
+		pool := setupPool()
+		pool.forks.Shanghai = true
 
 		input := make([]byte, state.TxPoolMaxInitCodeSize)
 		_, err := rand.Read(input)
@@ -2315,6 +2610,126 @@ func TestExecutablesOrder(t *testing.T) {
 	}
 }
 
+func TestExecutablesOrdering(t *testing.T) {
+	t.Parallel()
+
+	newPricedTx := func(addr types.Address, nonce, gasPrice uint64) *types.Transaction {
+		tx := newTx(addr, nonce, 1)
+		tx.GasPrice.SetUint64(gasPrice)
+
+		return tx
+	}
+
+	// the txs in the order of their arrival
+	newTxs := func() []*types.Transaction {
+		return []*types.Transaction{
+			newPricedTx(addr1, 0, 1),
+			newPricedTx(addr2, 0, 3),
+			newPricedTx(addr1, 1, 5),
+			newPricedTx(addr3, 0, 2),
+			newPricedTx(addr2, 1, 4),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		ordering      *chain.TxOrdering
+		expectedOrder []int // the indexes of the txs in the order they are handed out
+	}{
+		{
+			name:          "gas price",
+			ordering:      nil,
+			expectedOrder: []int{1, 4, 3, 0, 2},
+		},
+		{
+			name:          "fifo",
+			ordering:      &chain.TxOrdering{Policy: FIFOOrdering},
+			expectedOrder: []int{0, 1, 2, 3, 4},
+		},
+		{
+			name:          "fifo with priority sender",
+			ordering:      &chain.TxOrdering{Policy: FIFOOrdering, PrioritySenders: []types.Address{addr3}},
+			expectedOrder: []int{3, 0, 1, 2, 4},
+		},
+		{
+			name:          "fifo with per sender cap",
+			ordering:      &chain.TxOrdering{Policy: FIFOOrdering, MaxTxsPerSender: 1},
+			expectedOrder: []int{0, 1, 3},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ordering, err := NewOrdering(test.ordering)
+			require.NoError(t, err)
+
+			pool, err := newTestPool()
+			require.NoError(t, err)
+			pool.SetSigner(&mockSigner{})
+			pool.executables = newExecutablesQueue(ordering)
+
+			pool.Start()
+			defer pool.Close()
+
+			subscription := pool.eventManager.subscribe(
+				[]proto.EventType{proto.EventType_PROMOTED},
+			)
+
+			txs := newTxs()
+			for _, tx := range txs {
+				assert.NoError(t, pool.addTx(local, tx))
+			}
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), time.Second*10)
+			defer cancelFn()
+
+			assert.Len(t, waitForEvents(ctx, subscription, len(txs)), len(txs))
+
+			pool.Prepare()
+
+			var selected []*types.Transaction
+			for {
+				tx := pool.Peek()
+				if tx == nil {
+					break
+				}
+
+				pool.Pop(tx)
+				selected = append(selected, tx)
+			}
+
+			require.Len(t, selected, len(test.expectedOrder))
+
+			for i, tx := range selected {
+				assert.Equal(t, txs[test.expectedOrder[i]], tx)
+			}
+		})
+	}
+}
+
+func TestNewOrdering(t *testing.T) {
+	t.Parallel()
+
+	ordering, err := NewOrdering(nil)
+	require.NoError(t, err)
+	assert.Equal(t, gasPriceOrdering{}, ordering)
+
+	ordering, err = NewOrdering(&chain.TxOrdering{Policy: GasPriceOrdering})
+	require.NoError(t, err)
+	assert.Equal(t, gasPriceOrdering{}, ordering)
+
+	ordering, err = NewOrdering(&chain.TxOrdering{MaxTxsPerSender: 2})
+	require.NoError(t, err)
+	assert.True(t, ordering.Admit(addr1, 1))
+	assert.False(t, ordering.Admit(addr1, 2))
+
+	_, err = NewOrdering(&chain.TxOrdering{Policy: "random"})
+	assert.ErrorIs(t, err, ErrUnknownOrdering)
+}
+
 type status int
 
 // Status of a transaction resulted
@@ -0,0 +1,52 @@
+package types
+
+import "math/big"
+
+// StateOverride are the accounts replaced in the state for the execution of a call
+type StateOverride map[Address]OverrideAccount
+
+// OverrideAccount are the fields of an account replaced for the execution of a call.
+// State replaces the whole storage of the account, while StateDiff replaces only the given slots
+type OverrideAccount struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[Hash]Hash
+	StateDiff map[Hash]Hash
+}
+
+// BlockOverride are the fields of the block header replaced for the execution of a call
+type BlockOverride struct {
+	Number   *uint64
+	Time     *uint64
+	Coinbase *Address
+	GasLimit *uint64
+	BaseFee  *uint64
+}
+
+// Apply returns the copy of the header with the overridden fields replaced
+func (o *BlockOverride) Apply(header *Header) *Header {
+	header = header.Copy()
+
+	if o.Number != nil {
+		header.Number = *o.Number
+	}
+
+	if o.Time != nil {
+		header.Timestamp = *o.Time
+	}
+
+	if o.Coinbase != nil {
+		header.Miner = o.Coinbase.Bytes()
+	}
+
+	if o.GasLimit != nil {
+		header.GasLimit = *o.GasLimit
+	}
+
+	if o.BaseFee != nil {
+		header.BaseFee = *o.BaseFee
+	}
+
+	return header
+}
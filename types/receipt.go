@@ -53,6 +53,9 @@ type Log struct {
 
 const BloomByteLength = 256
 
+// BloomBitLength is the number of bits of the bloom filter
+const BloomBitLength = 8 * BloomByteLength
+
 type Bloom [BloomByteLength]byte
 
 func (b *Bloom) UnmarshalText(input []byte) error {
@@ -108,6 +111,29 @@ func (b *Bloom) setEncode(hasher *keccak.Keccak, h []byte) {
 	}
 }
 
+// BloomBits returns the positions of the bits which are set in the bloom filter for the data
+func BloomBits(data []byte) [3]uint {
+	hasher := keccak.DefaultKeccakPool.Get()
+	defer keccak.DefaultKeccakPool.Put(hasher)
+
+	hasher.Reset()
+	hasher.Write(data) //nolint:errcheck
+	buf := hasher.Read()
+
+	var bits [3]uint
+
+	for i := 0; i < 6; i += 2 {
+		bits[i/2] = (uint(buf[i+1]) + (uint(buf[i]) << 8)) & (BloomBitLength - 1)
+	}
+
+	return bits
+}
+
+// IsBitSet checks if the bit at the given position is set in the bloom filter
+func (b *Bloom) IsBitSet(bit uint) bool {
+	return b[BloomByteLength-1-bit/8]&(1<<(bit%8)) != 0
+}
+
 // IsLogInBloom checks if the log has a possible presence in the bloom filter
 func (b *Bloom) IsLogInBloom(log *Log) bool {
 	hasher := keccak.DefaultKeccakPool.Get()
@@ -166,6 +166,85 @@ func TestRLPMarshall_And_Unmarshall_TypedTransaction(t *testing.T) {
 	}
 }
 
+func TestRLPMarshall_And_Unmarshall_DynamicFeeTransaction(t *testing.T) {
+	addrTo := StringToAddress("11")
+	originalTx := &Transaction{
+		Type:      DynamicFeeTx,
+		ChainID:   big.NewInt(100),
+		Nonce:     1,
+		GasTipCap: big.NewInt(2),
+		GasFeeCap: big.NewInt(20),
+		Gas:       11,
+		To:        &addrTo,
+		Value:     big.NewInt(1),
+		Input:     []byte{1, 2},
+		V:         big.NewInt(1),
+		S:         big.NewInt(26),
+		R:         big.NewInt(27),
+	}
+	originalTx.ComputeHash()
+
+	txRLP := originalTx.MarshalRLP()
+
+	unmarshalledTx := new(Transaction)
+	assert.NoError(t, unmarshalledTx.UnmarshalRLP(txRLP))
+
+	assert.Equal(t, DynamicFeeTx, unmarshalledTx.Type)
+	assert.Equal(t, originalTx.ChainID, unmarshalledTx.ChainID)
+	assert.Equal(t, originalTx.GasTipCap, unmarshalledTx.GasTipCap)
+	assert.Equal(t, originalTx.GasFeeCap, unmarshalledTx.GasFeeCap)
+	assert.Equal(t, originalTx.Hash, unmarshalledTx.Hash)
+
+	// the type prefix is part of the hash
+	legacyTx := originalTx.Copy()
+	legacyTx.Type = LegacyTx
+	legacyTx.GasPrice = big.NewInt(20)
+	legacyTx.ComputeHash()
+
+	assert.NotEqual(t, legacyTx.Hash, originalTx.Hash)
+}
+
+func TestRLPMarshall_And_Unmarshall_AccessList(t *testing.T) {
+	addrTo := StringToAddress("11")
+	accessList := TxAccessList{
+		{
+			Address:     StringToAddress("33"),
+			StorageKeys: []Hash{StringToHash("1"), StringToHash("2")},
+		},
+		{
+			Address:     StringToAddress("44"),
+			StorageKeys: []Hash{},
+		},
+	}
+
+	for _, txType := range []TxType{AccessListTx, DynamicFeeTx} {
+		originalTx := &Transaction{
+			Type:       txType,
+			ChainID:    big.NewInt(100),
+			Nonce:      1,
+			GasPrice:   big.NewInt(10),
+			GasTipCap:  big.NewInt(2),
+			GasFeeCap:  big.NewInt(20),
+			Gas:        11,
+			To:         &addrTo,
+			Value:      big.NewInt(1),
+			Input:      []byte{1, 2},
+			AccessList: accessList,
+			V:          big.NewInt(1),
+			S:          big.NewInt(26),
+			R:          big.NewInt(27),
+		}
+		originalTx.ComputeHash()
+
+		unmarshalledTx := new(Transaction)
+		assert.NoError(t, unmarshalledTx.UnmarshalRLP(originalTx.MarshalRLP()))
+
+		assert.Equal(t, txType, unmarshalledTx.Type)
+		assert.Equal(t, originalTx.AccessList, unmarshalledTx.AccessList)
+		assert.Equal(t, originalTx.Hash, unmarshalledTx.Hash)
+	}
+}
+
 func TestRLPMarshall_Unmarshall_Missing_Data(t *testing.T) {
 	t.Parallel()
 
@@ -247,6 +326,14 @@ func TestRLPMarshall_And_Unmarshall_TxType(t *testing.T) {
 			name:   "LegacyTx",
 			txType: LegacyTx,
 		},
+		{
+			name:   "AccessListTx",
+			txType: AccessListTx,
+		},
+		{
+			name:   "DynamicFeeTx",
+			txType: DynamicFeeTx,
+		},
 		{
 			name:        "undefined type",
 			txType:      TxType(0x09),
@@ -91,6 +91,12 @@ func (h *Header) MarshalRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
 	vv.Set(arena.NewBytes(h.MixHash.Bytes()))
 	vv.Set(arena.NewCopyBytes(h.Nonce[:]))
 
+	// the base fee is only part of the header once EIP-1559 is active,
+	// so the hashes of the older headers stay the same
+	if h.BaseFee != 0 {
+		vv.Set(arena.NewUint(h.BaseFee))
+	}
+
 	return vv
 }
 
@@ -186,6 +192,10 @@ func (t *Transaction) MarshalRLPTo(dst []byte) []byte {
 
 // MarshalRLPWith marshals the transaction to RLP with a specific fastrlp.Arena
 func (t *Transaction) MarshalRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
+	if t.Type == AccessListTx || t.Type == DynamicFeeTx {
+		return t.marshalTypedRLPWith(arena)
+	}
+
 	vv := arena.NewArray()
 
 	vv.Set(arena.NewUint(t.Nonce))
@@ -213,3 +223,62 @@ func (t *Transaction) MarshalRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
 
 	return vv
 }
+
+// marshalTypedRLPWith marshals the EIP-2718 typed transaction payload to RLP.
+// Access list transactions (EIP-2930) are encoded as
+// [chainID, nonce, gasPrice, gas, to, value, input, accessList, v, r, s]
+// and dynamic fee transactions (EIP-1559) as
+// [chainID, nonce, gasTipCap, gasFeeCap, gas, to, value, input, accessList, v, r, s]
+func (t *Transaction) marshalTypedRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
+	vv := arena.NewArray()
+
+	vv.Set(arena.NewBigInt(t.ChainID))
+	vv.Set(arena.NewUint(t.Nonce))
+
+	if t.Type == DynamicFeeTx {
+		vv.Set(arena.NewBigInt(t.GasTipCap))
+		vv.Set(arena.NewBigInt(t.GasFeeCap))
+	} else {
+		vv.Set(arena.NewBigInt(t.GasPrice))
+	}
+
+	vv.Set(arena.NewUint(t.Gas))
+
+	// Address may be empty
+	if t.To != nil {
+		vv.Set(arena.NewBytes((*t.To).Bytes()))
+	} else {
+		vv.Set(arena.NewNull())
+	}
+
+	vv.Set(arena.NewBigInt(t.Value))
+	vv.Set(arena.NewCopyBytes(t.Input))
+	vv.Set(t.AccessList.MarshalRLPWith(arena))
+
+	// signature values
+	vv.Set(arena.NewBigInt(t.V))
+	vv.Set(arena.NewBigInt(t.R))
+	vv.Set(arena.NewBigInt(t.S))
+
+	return vv
+}
+
+// MarshalRLPWith marshals the access list to RLP with a specific fastrlp.Arena
+func (al TxAccessList) MarshalRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
+	vv := arena.NewArray()
+
+	for _, tuple := range al {
+		tupleVal := arena.NewArray()
+		tupleVal.Set(arena.NewCopyBytes(tuple.Address.Bytes()))
+
+		keys := arena.NewArray()
+		for _, key := range tuple.StorageKeys {
+			keys.Set(arena.NewCopyBytes(key.Bytes()))
+		}
+
+		tupleVal.Set(keys)
+		vv.Set(tupleVal)
+	}
+
+	return vv
+}
@@ -218,6 +218,14 @@ func (h *Header) unmarshalRLPFrom(_ *fastrlp.Parser, v *fastrlp.Value) error {
 
 	h.SetNonce(nonce)
 
+	// baseFee
+	// the field is only present in headers produced after EIP-1559 activation
+	if len(elems) >= 16 {
+		if h.BaseFee, err = elems[15].GetUint64(); err != nil {
+			return err
+		}
+	}
+
 	// compute the hash after the decoding
 	h.ComputeHash()
 
@@ -371,6 +379,10 @@ func (t *Transaction) UnmarshalRLP(input []byte) error {
 
 // unmarshalRLPFrom unmarshals a Transaction in RLP format
 func (t *Transaction) unmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
+	if t.Type == AccessListTx || t.Type == DynamicFeeTx {
+		return t.unmarshalTypedRLPFrom(p, v)
+	}
+
 	elems, err := v.GetElems()
 	if err != nil {
 		return err
@@ -454,3 +466,161 @@ func (t *Transaction) unmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) erro
 
 	return nil
 }
+
+// unmarshalTypedRLPFrom unmarshals an EIP-2718 typed transaction payload
+// (access list or dynamic fee transaction) in RLP format
+func (t *Transaction) unmarshalTypedRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+
+	// the dynamic fee transactions replace the gas price with the tip and fee caps
+	num := 11
+	if t.Type == DynamicFeeTx {
+		num = 12
+	}
+
+	if len(elems) < num {
+		return fmt.Errorf("incorrect number of elements to decode %s, expected %d but found %d",
+			t.Type, num, len(elems))
+	}
+
+	// chainID
+	t.ChainID = new(big.Int)
+	if err = elems[0].GetBigInt(t.ChainID); err != nil {
+		return err
+	}
+
+	// nonce
+	if t.Nonce, err = elems[1].GetUint64(); err != nil {
+		return err
+	}
+
+	idx := 2
+
+	if t.Type == DynamicFeeTx {
+		// gasTipCap
+		t.GasTipCap = new(big.Int)
+		if err = elems[idx].GetBigInt(t.GasTipCap); err != nil {
+			return err
+		}
+
+		// gasFeeCap
+		t.GasFeeCap = new(big.Int)
+		if err = elems[idx+1].GetBigInt(t.GasFeeCap); err != nil {
+			return err
+		}
+
+		// the legacy gas price is not part of the payload
+		t.GasPrice = new(big.Int)
+		idx += 2
+	} else {
+		// gasPrice
+		t.GasPrice = new(big.Int)
+		if err = elems[idx].GetBigInt(t.GasPrice); err != nil {
+			return err
+		}
+		idx++
+	}
+
+	// gas
+	if t.Gas, err = elems[idx].GetUint64(); err != nil {
+		return err
+	}
+
+	// to
+	if vv, _ := elems[idx+1].Bytes(); len(vv) == AddressLength {
+		// address
+		addr := BytesToAddress(vv)
+		t.To = &addr
+	} else {
+		// reset To
+		t.To = nil
+	}
+
+	// value
+	t.Value = new(big.Int)
+	if err = elems[idx+2].GetBigInt(t.Value); err != nil {
+		return err
+	}
+
+	// input
+	if t.Input, err = elems[idx+3].GetBytes(t.Input[:0]); err != nil {
+		return err
+	}
+
+	// accessList
+	if err = t.AccessList.unmarshalRLPFrom(p, elems[idx+4]); err != nil {
+		return err
+	}
+
+	// V
+	t.V = new(big.Int)
+	if err = elems[idx+5].GetBigInt(t.V); err != nil {
+		return err
+	}
+
+	// R
+	t.R = new(big.Int)
+	if err = elems[idx+6].GetBigInt(t.R); err != nil {
+		return err
+	}
+
+	// S
+	t.S = new(big.Int)
+	if err = elems[idx+7].GetBigInt(t.S); err != nil {
+		return err
+	}
+
+	t.ComputeHash()
+
+	return nil
+}
+
+// unmarshalRLPFrom unmarshals an access list in RLP format
+func (al *TxAccessList) unmarshalRLPFrom(_ *fastrlp.Parser, v *fastrlp.Value) error {
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+
+	*al = nil
+
+	for _, elem := range elems {
+		tupleElems, err := elem.GetElems()
+		if err != nil {
+			return err
+		}
+
+		if len(tupleElems) != 2 {
+			return fmt.Errorf("incorrect number of elements to decode access tuple, expected 2 but found %d",
+				len(tupleElems))
+		}
+
+		tuple := AccessTuple{}
+
+		// address
+		if err = tupleElems[0].GetAddr(tuple.Address[:]); err != nil {
+			return err
+		}
+
+		// storage keys
+		keys, err := tupleElems[1].GetElems()
+		if err != nil {
+			return err
+		}
+
+		tuple.StorageKeys = make([]Hash, len(keys))
+
+		for i, key := range keys {
+			if err = key.GetHash(tuple.StorageKeys[i][:]); err != nil {
+				return err
+			}
+		}
+
+		*al = append(*al, tuple)
+	}
+
+	return nil
+}
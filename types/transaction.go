@@ -11,8 +11,10 @@ import (
 type TxType byte
 
 const (
-	LegacyTx TxType = 0x0
-	StateTx  TxType = 0x7f
+	LegacyTx     TxType = 0x0
+	AccessListTx TxType = 0x1
+	DynamicFeeTx TxType = 0x2
+	StateTx      TxType = 0x7f
 
 	StateTransactionGasLimit = 1000000 // some arbitrary default gas limit for state transactions
 )
@@ -21,7 +23,7 @@ func txTypeFromByte(b byte) (TxType, error) {
 	tt := TxType(b)
 
 	switch tt {
-	case LegacyTx, StateTx:
+	case LegacyTx, AccessListTx, DynamicFeeTx, StateTx:
 		return tt, nil
 	default:
 		return tt, fmt.Errorf("unknown transaction type: %d", b)
@@ -32,6 +34,10 @@ func (t TxType) String() (s string) {
 	switch t {
 	case LegacyTx:
 		return "LegacyTx"
+	case AccessListTx:
+		return "AccessListTx"
+	case DynamicFeeTx:
+		return "DynamicFeeTx"
 	case StateTx:
 		return "StateTx"
 	}
@@ -40,17 +46,22 @@ func (t TxType) String() (s string) {
 }
 
 type Transaction struct {
-	Nonce    uint64
-	GasPrice *big.Int
-	Gas      uint64
-	To       *Address
-	Value    *big.Int
-	Input    []byte
-	V        *big.Int
-	R        *big.Int
-	S        *big.Int
-	Hash     Hash
-	From     Address
+	Nonce     uint64
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+	Gas       uint64
+	To        *Address
+	Value     *big.Int
+	Input     []byte
+	V         *big.Int
+	R         *big.Int
+	S         *big.Int
+	Hash      Hash
+	From      Address
+	ChainID   *big.Int
+
+	AccessList TxAccessList
 
 	Type TxType
 
@@ -63,11 +74,59 @@ func (t *Transaction) IsContractCreation() bool {
 	return t.To == nil
 }
 
+// AccessTuple is the element type of an EIP-2930 access list
+type AccessTuple struct {
+	Address     Address `json:"address"`
+	StorageKeys []Hash  `json:"storageKeys"`
+}
+
+// TxAccessList is an EIP-2930 access list
+type TxAccessList []AccessTuple
+
+// StorageKeys returns the total number of storage keys in the access list
+func (al TxAccessList) StorageKeys() int {
+	sum := 0
+	for _, tuple := range al {
+		sum += len(tuple.StorageKeys)
+	}
+
+	return sum
+}
+
+// Copy makes a deep copy of the access list
+func (al TxAccessList) Copy() TxAccessList {
+	if al == nil {
+		return nil
+	}
+
+	newAccessList := make(TxAccessList, len(al))
+
+	for i, item := range al {
+		newAccessList[i] = AccessTuple{
+			Address:     item.Address,
+			StorageKeys: append([]Hash{}, item.StorageKeys...),
+		}
+	}
+
+	return newAccessList
+}
+
+// IsDynamicFeeTx checks if tx is an EIP-1559 dynamic fee transaction
+func (t *Transaction) IsDynamicFeeTx() bool {
+	return t.Type == DynamicFeeTx
+}
+
 // ComputeHash computes the hash of the transaction
 func (t *Transaction) ComputeHash() *Transaction {
 	ar := marshalArenaPool.Get()
 	hash := keccak.DefaultKeccakPool.Get()
 
+	// EIP-2718 typed transactions are hashed together with their type prefix.
+	// State transactions are internal and keep the plain RLP hash.
+	if t.Type != LegacyTx && t.Type != StateTx {
+		hash.Write([]byte{byte(t.Type)}) //nolint:errcheck
+	}
+
 	v := t.MarshalRLPWith(ar)
 	hash.WriteRlp(t.Hash[:0], v)
 
@@ -86,6 +145,20 @@ func (t *Transaction) Copy() *Transaction {
 		tt.GasPrice.Set(t.GasPrice)
 	}
 
+	if t.GasTipCap != nil {
+		tt.GasTipCap = new(big.Int).Set(t.GasTipCap)
+	}
+
+	if t.GasFeeCap != nil {
+		tt.GasFeeCap = new(big.Int).Set(t.GasFeeCap)
+	}
+
+	if t.ChainID != nil {
+		tt.ChainID = new(big.Int).Set(t.ChainID)
+	}
+
+	tt.AccessList = t.AccessList.Copy()
+
 	tt.Value = new(big.Int)
 	if t.Value != nil {
 		tt.Value.Set(t.Value)
@@ -107,14 +180,81 @@ func (t *Transaction) Copy() *Transaction {
 	return tt
 }
 
-// Cost returns gas * gasPrice + value
+// Cost returns gas * gasPrice + value.
+// For dynamic fee transactions the gas fee cap is used as the gas price
 func (t *Transaction) Cost() *big.Int {
-	total := new(big.Int).Mul(t.GasPrice, new(big.Int).SetUint64(t.Gas))
+	total := new(big.Int).Mul(t.GetGasFeeCap(), new(big.Int).SetUint64(t.Gas))
 	total.Add(total, t.Value)
 
 	return total
 }
 
+// GetGasFeeCap returns the maximum price per gas the sender is willing to pay.
+// For legacy transactions it is the gas price
+func (t *Transaction) GetGasFeeCap() *big.Int {
+	if t.Type == DynamicFeeTx {
+		if t.GasFeeCap == nil {
+			return new(big.Int)
+		}
+
+		return t.GasFeeCap
+	}
+
+	if t.GasPrice == nil {
+		return new(big.Int)
+	}
+
+	return t.GasPrice
+}
+
+// GetGasTipCap returns the maximum tip per gas paid to the block producer.
+// For legacy transactions it is the gas price
+func (t *Transaction) GetGasTipCap() *big.Int {
+	if t.Type == DynamicFeeTx {
+		if t.GasTipCap == nil {
+			return new(big.Int)
+		}
+
+		return t.GasTipCap
+	}
+
+	if t.GasPrice == nil {
+		return new(big.Int)
+	}
+
+	return t.GasPrice
+}
+
+// EffectiveGasPrice returns the price per gas actually paid by the sender
+// for the given base fee: min(gasFeeCap, baseFee + gasTipCap)
+func (t *Transaction) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	if t.Type != DynamicFeeTx {
+		return new(big.Int).Set(t.GetGasFeeCap())
+	}
+
+	price := new(big.Int).Set(t.GetGasTipCap())
+	if baseFee != nil {
+		price.Add(price, baseFee)
+	}
+
+	if price.Cmp(t.GetGasFeeCap()) > 0 {
+		price.Set(t.GetGasFeeCap())
+	}
+
+	return price
+}
+
+// EffectiveTip returns the price per gas received by the block producer
+// for the given base fee. The result is negative if the fee cap doesn't cover the base fee
+func (t *Transaction) EffectiveTip(baseFee *big.Int) *big.Int {
+	tip := t.EffectiveGasPrice(baseFee)
+	if baseFee != nil {
+		tip.Sub(tip, baseFee)
+	}
+
+	return tip
+}
+
 func (t *Transaction) Size() uint64 {
 	if size := t.size.Load(); size != nil {
 		sizeVal, ok := size.(uint64)
@@ -136,5 +276,5 @@ func (t *Transaction) ExceedsBlockGasLimit(blockGasLimit uint64) bool {
 }
 
 func (t *Transaction) IsUnderpriced(priceLimit uint64) bool {
-	return t.GasPrice.Cmp(big.NewInt(0).SetUint64(priceLimit)) < 0
+	return t.GetGasTipCap().Cmp(big.NewInt(0).SetUint64(priceLimit)) < 0
 }